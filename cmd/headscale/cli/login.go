@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cliLoginFileName       = "login.json"
+	cliLoginFilePermission = 0o600
+	cliLoginDirPermission  = 0o700
+)
+
+var (
+	errCLILoginFailed  = errors.New("login failed")
+	errCLILoginExpired = errors.New("login expired before it was completed")
+)
+
+// cliLoginCache is the API key issued by headscale login, cached for the
+// following commands.
+type cliLoginCache struct {
+	Server     string    `json:"server"`
+	Address    string    `json:"address"`
+	APIKey     string    `json:"api_key"`
+	Expiration time.Time `json:"expiration"`
+}
+
+func init() {
+	loginCmd.Flags().String("server", "", "URL of the headscale server (e.g. https://hs.example.com)")
+	if err := loginCmd.MarkFlagRequired("server"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	loginCmd.Flags().
+		String("address", "", "gRPC address of the server, defaults to the host of --server on port 50443")
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+}
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to a remote headscale server with OIDC",
+	Long: `
+Log in to a remote headscale server with the device login of its OIDC
+provider. The server issues a short-lived API key, which is used by the
+following commands until it expires.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		server, _ := cmd.Flags().GetString("server")
+		address, _ := cmd.Flags().GetString("address")
+
+		cfg, err := types.LoadCLIConfig()
+		if err != nil {
+			ErrorOutput(err, fmt.Sprintf("Error loading configuration: %s", err), output)
+		}
+
+		serverURL, err := url.Parse(strings.TrimSuffix(server, "/"))
+		if err != nil || serverURL.Host == "" {
+			ErrorOutput(err, fmt.Sprintf("Invalid server URL: %q", server), output)
+		}
+
+		if address == "" {
+			port := serverURL.Port()
+			if port == "" {
+				port = "50443"
+			}
+			address = net.JoinHostPort(serverURL.Hostname(), port)
+		}
+
+		client := &http.Client{Timeout: cfg.CLI.Timeout}
+		if cfg.CLI.Insecure {
+			client.Transport = &http.Transport{
+				//nolint:gosec
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			}
+		}
+
+		var device types.CLILoginDevice
+		if _, err := cliLoginPost(client, serverURL.String()+types.CLILoginDevicePath, nil, &device); err != nil {
+			ErrorOutput(err, fmt.Sprintf("Error starting login: %s", err), output)
+		}
+
+		verificationURI := device.VerificationURIComplete
+		if verificationURI == "" {
+			verificationURI = device.VerificationURI
+		}
+		fmt.Fprintf(os.Stderr, "To log in, visit:\n\n\t%s\n\nand enter the code: %s\n\n", verificationURI, device.UserCode)
+
+		token, err := waitForCLILogin(client, serverURL.String(), device)
+		if err != nil {
+			ErrorOutput(err, fmt.Sprintf("Error logging in: %s", err), output)
+		}
+
+		login := cliLoginCache{
+			Server:     serverURL.String(),
+			Address:    address,
+			APIKey:     token.APIKey,
+			Expiration: token.Expiration,
+		}
+		if err := saveCLILogin(login); err != nil {
+			ErrorOutput(err, fmt.Sprintf("Error saving login: %s", err), output)
+		}
+
+		SuccessOutput(
+			login.Expiration,
+			fmt.Sprintf("Logged in to %s until %s", address, login.Expiration.Local().Format(HeadscaleDateTimeFormat)),
+			output,
+		)
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the API key of headscale login",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		path, err := cliLoginPath()
+		if err != nil {
+			ErrorOutput(err, fmt.Sprintf("Error logging out: %s", err), output)
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			ErrorOutput(err, fmt.Sprintf("Error logging out: %s", err), output)
+		}
+
+		SuccessOutput(nil, "Logged out", output)
+	},
+}
+
+// waitForCLILogin polls the server until the admin logged in, the server
+// holds each poll until the login completes or it times out.
+func waitForCLILogin(
+	client *http.Client,
+	server string,
+	device types.CLILoginDevice,
+) (*types.CLILoginToken, error) {
+	// The server holds each poll, give it time to answer.
+	pollClient := *client
+	pollClient.Timeout = types.HTTPTimeout
+
+	interval := time.Duration(max(device.Interval, 1)) * time.Second
+	for device.Expiry.IsZero() || time.Now().Before(device.Expiry) {
+		var token types.CLILoginToken
+		status, err := cliLoginPost(
+			&pollClient,
+			server+types.CLILoginTokenPath,
+			types.CLILoginTokenRequest{LoginID: device.LoginID},
+			&token,
+		)
+		switch {
+		case err != nil:
+			return nil, err
+		case status == http.StatusOK:
+			return &token, nil
+		case token.Error == types.CLILoginPending:
+			time.Sleep(interval)
+		default:
+			return nil, fmt.Errorf("%w: %s %s", errCLILoginFailed, token.Error, token.ErrorDescription)
+		}
+	}
+
+	return nil, errCLILoginExpired
+}
+
+// cliLoginPost posts the request as JSON and decodes the JSON response,
+// it returns the status code of the response.
+func cliLoginPost(client *http.Client, url string, request any, response any) (int, error) {
+	var body bytes.Buffer
+	if request != nil {
+		if err := json.NewEncoder(&body).Encode(request); err != nil {
+			return 0, err
+		}
+	}
+
+	resp, err := client.Post(url, "application/json", &body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "application/json" {
+		return resp.StatusCode, fmt.Errorf("%w: unexpected response from server: %s", errCLILoginFailed, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return resp.StatusCode, err
+	}
+
+	return resp.StatusCode, nil
+}
+
+// cliLoginPath returns the path of the cached login in the cache
+// directory of the user.
+func cliLoginPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "headscale", cliLoginFileName), nil
+}
+
+func saveCLILogin(login cliLoginCache) error {
+	path, err := cliLoginPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), cliLoginDirPermission); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, cliLoginFilePermission)
+}
+
+// loadCLILogin returns the cached login, or nil if there is none.
+func loadCLILogin() (*cliLoginCache, error) {
+	path, err := cliLoginPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var login cliLoginCache
+	if err := json.Unmarshal(data, &login); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return &login, nil
+}
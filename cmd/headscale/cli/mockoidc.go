@@ -6,9 +6,11 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/oauth2-proxy/mockoidc"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -18,10 +20,12 @@ const (
 	errMockOidcClientIDNotDefined     = Error("MOCKOIDC_CLIENT_ID not defined")
 	errMockOidcClientSecretNotDefined = Error("MOCKOIDC_CLIENT_SECRET not defined")
 	errMockOidcPortNotDefined         = Error("MOCKOIDC_PORT not defined")
-	refreshTTL                        = 60 * time.Minute
 )
 
-var accessTTL = 2 * time.Minute
+var (
+	accessTTL  = 2 * time.Minute
+	refreshTTL = 60 * time.Minute
+)
 
 func init() {
 	rootCmd.AddCommand(mockOidcCmd)
@@ -65,6 +69,14 @@ func mockOIDC() error {
 		}
 		accessTTL = newTTL
 	}
+	refreshTTLOverride := os.Getenv("MOCKOIDC_REFRESH_TTL")
+	if refreshTTLOverride != "" {
+		newTTL, err := time.ParseDuration(refreshTTLOverride)
+		if err != nil {
+			return err
+		}
+		refreshTTL = newTTL
+	}
 
 	userStr := os.Getenv("MOCKOIDC_USERS")
 	if userStr == "" {
@@ -80,6 +92,7 @@ func mockOIDC() error {
 	log.Info().Interface("users", users).Msg("loading users from JSON")
 
 	log.Info().Msgf("Access token TTL: %s", accessTTL)
+	log.Info().Msgf("Refresh token TTL: %s", refreshTTL)
 
 	port, err := strconv.Atoi(portStr)
 	if err != nil {
@@ -114,6 +127,11 @@ func getMockOIDC(clientID string, clientSecret string, users []mockoidc.MockUser
 		return nil, err
 	}
 
+	// Allow headscale to ask for refresh tokens.
+	if !slices.Contains(mockoidc.ScopesSupported, oidc.ScopeOfflineAccess) {
+		mockoidc.ScopesSupported = append(mockoidc.ScopesSupported, oidc.ScopeOfflineAccess)
+	}
+
 	userQueue := mockoidc.UserQueue{}
 
 	for _, user := range users {
@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/netip"
+	"os"
 	"slices"
 	"strconv"
 	"strings"
@@ -12,17 +14,38 @@ import (
 	survey "github.com/AlecAivazis/survey/v2"
 	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
 	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/prometheus/common/model"
 	"github.com/pterm/pterm"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"tailscale.com/types/key"
+	"tailscale.com/types/ptr"
 )
 
 func init() {
 	rootCmd.AddCommand(nodeCmd)
 	listNodesCmd.Flags().StringP("user", "u", "", "Filter by user")
 	listNodesCmd.Flags().BoolP("tags", "t", false, "Show tags")
+	listNodesCmd.Flags().Bool("labels", false, "Show labels")
+	listNodesCmd.Flags().StringP("label-selector", "l", "", "Filter by labels, e.g. env=prod,owner,!deprecated")
+	listNodesCmd.Flags().StringSlice("tag", []string{}, "Filter by tags, nodes need all of them")
+	listNodesCmd.Flags().Bool("online", false, "Only show online nodes")
+	listNodesCmd.Flags().Bool("offline", false, "Only show offline nodes")
+	listNodesCmd.Flags().Bool("expired", false, "Only show expired nodes")
+	listNodesCmd.Flags().Bool("not-expired", false, "Only show nodes that are not expired")
+	listNodesCmd.Flags().String("last-seen-after", "", "Only show nodes seen after a time (RFC 3339) or duration ago (e.g. 7d)")
+	listNodesCmd.Flags().String("last-seen-before", "", "Only show nodes last seen before a time (RFC 3339) or duration ago (e.g. 30d)")
+	listNodesCmd.Flags().String("os", "", "Filter by operating system, e.g. linux or windows")
+	listNodesCmd.Flags().String("client-version", "", "Filter by Tailscale version prefix, e.g. 1.80")
+	listNodesCmd.Flags().String("name", "", "Filter by a part of the hostname or given name")
+	listNodesCmd.Flags().String("sort", "id", "Sort by id, name, hostname, user, last_seen, created_at or expiry")
+	listNodesCmd.Flags().Bool("desc", false, "Sort in descending order")
+	listNodesCmd.Flags().Uint32("limit", 0, "Maximum number of nodes to show, all if 0")
+	listNodesCmd.Flags().String("page-token", "", "Token of the page to show, printed with the previous page")
+	listNodesCmd.MarkFlagsMutuallyExclusive("online", "offline")
+	listNodesCmd.MarkFlagsMutuallyExclusive("expired", "not-expired")
 
 	listNodesCmd.Flags().StringP("namespace", "n", "", "User")
 	listNodesNamespaceFlag := listNodesCmd.Flags().Lookup("namespace")
@@ -49,13 +72,12 @@ func init() {
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+	registerNodeCmd.Flags().String("ipv4", "", "IPv4 address to assign instead of allocating one")
+	registerNodeCmd.Flags().String("ipv6", "", "IPv6 address to assign instead of allocating one")
 	nodeCmd.AddCommand(registerNodeCmd)
 
 	expireNodeCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
-	err = expireNodeCmd.MarkFlagRequired("identifier")
-	if err != nil {
-		log.Fatal(err.Error())
-	}
+	addBulkNodeFlags(expireNodeCmd)
 	nodeCmd.AddCommand(expireNodeCmd)
 
 	renameNodeCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
@@ -65,19 +87,19 @@ func init() {
 	}
 	nodeCmd.AddCommand(renameNodeCmd)
 
-	deleteNodeCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
-	err = deleteNodeCmd.MarkFlagRequired("identifier")
+	setNodeAliasesCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
+	err = setNodeAliasesCmd.MarkFlagRequired("identifier")
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+	nodeCmd.AddCommand(setNodeAliasesCmd)
+
+	deleteNodeCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
+	addBulkNodeFlags(deleteNodeCmd)
 	nodeCmd.AddCommand(deleteNodeCmd)
 
 	moveNodeCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
-
-	err = moveNodeCmd.MarkFlagRequired("identifier")
-	if err != nil {
-		log.Fatal(err.Error())
-	}
+	addBulkNodeFlags(moveNodeCmd)
 
 	moveNodeCmd.Flags().Uint64P("user", "u", 0, "New user")
 
@@ -92,17 +114,50 @@ func init() {
 	}
 	nodeCmd.AddCommand(moveNodeCmd)
 
+	setNodeIPsCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
+	err = setNodeIPsCmd.MarkFlagRequired("identifier")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	setNodeIPsCmd.Flags().String("ipv4", "", "New IPv4 address, unchanged if empty")
+	setNodeIPsCmd.Flags().String("ipv6", "", "New IPv6 address, unchanged if empty")
+	nodeCmd.AddCommand(setNodeIPsCmd)
+
 	tagCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
-	tagCmd.MarkFlagRequired("identifier")
+	addBulkNodeFlags(tagCmd)
 	tagCmd.Flags().StringSliceP("tags", "t", []string{}, "List of tags to add to the node")
 	nodeCmd.AddCommand(tagCmd)
 
+	setNodeLabelsCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
+	err = setNodeLabelsCmd.MarkFlagRequired("identifier")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	labelsCmd.AddCommand(setNodeLabelsCmd)
+
+	deleteNodeLabelsCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
+	err = deleteNodeLabelsCmd.MarkFlagRequired("identifier")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	labelsCmd.AddCommand(deleteNodeLabelsCmd)
+	nodeCmd.AddCommand(labelsCmd)
+
 	approveRoutesCmd.Flags().Uint64P("identifier", "i", 0, "Node identifier (ID)")
 	approveRoutesCmd.MarkFlagRequired("identifier")
 	approveRoutesCmd.Flags().StringSliceP("routes", "r", []string{}, `List of routes that will be approved (comma-separated, e.g. "10.0.0.0/8,192.168.0.0/24" or empty string to remove all approved routes)`)
 	nodeCmd.AddCommand(approveRoutesCmd)
 
 	nodeCmd.AddCommand(backfillNodeIPsCmd)
+
+	renumberNodesCmd.Flags().String("v4", "", "New IPv4 prefix, the configured one if empty")
+	renumberNodesCmd.Flags().String("v6", "", "New IPv6 prefix, the configured one if empty")
+	renumberNodesCmd.Flags().String("mapping", "", "File with lines of current and new address of nodes, separated by whitespace")
+	renumberNodesCmd.Flags().Bool("dry-run", false, "Only show the changes")
+	nodeCmd.AddCommand(renumberNodesCmd)
+
+	nodeCmd.AddCommand(listStaleNodesCmd)
+	nodeCmd.AddCommand(listEphemeralDeletionsCmd)
 }
 
 var nodeCmd = &cobra.Command{
@@ -138,6 +193,8 @@ var registerNodeCmd = &cobra.Command{
 			Key:  registrationID,
 			User: user,
 		}
+		request.Ipv4, _ = cmd.Flags().GetString("ipv4")
+		request.Ipv6, _ = cmd.Flags().GetString("ipv6")
 
 		response, err := client.RegisterNode(ctx, request)
 		if err != nil {
@@ -163,6 +220,11 @@ var listNodesCmd = &cobra.Command{
 	Aliases: []string{"ls", "show"},
 	Run: func(cmd *cobra.Command, args []string) {
 		output, _ := cmd.Flags().GetString("output")
+		// wide is the table with the device details of the nodes.
+		wide := output == "wide"
+		if wide {
+			output = ""
+		}
 		user, err := cmd.Flags().GetString("user")
 		if err != nil {
 			ErrorOutput(err, fmt.Sprintf("Error getting user: %s", err), output)
@@ -171,15 +233,20 @@ var listNodesCmd = &cobra.Command{
 		if err != nil {
 			ErrorOutput(err, fmt.Sprintf("Error getting tags flag: %s", err), output)
 		}
+		showLabels, err := cmd.Flags().GetBool("labels")
+		if err != nil {
+			ErrorOutput(err, fmt.Sprintf("Error getting labels flag: %s", err), output)
+		}
+		request, err := listNodesRequestFromFlags(cmd)
+		if err != nil {
+			ErrorOutput(err, fmt.Sprintf("Error getting filters: %s", err), output)
+		}
+		request.User = user
 
 		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
 		defer cancel()
 		defer conn.Close()
 
-		request := &v1.ListNodesRequest{
-			User: user,
-		}
-
 		response, err := client.ListNodes(ctx, request)
 		if err != nil {
 			ErrorOutput(
@@ -190,10 +257,13 @@ var listNodesCmd = &cobra.Command{
 		}
 
 		if output != "" {
+			if request.GetPageSize() > 0 {
+				SuccessOutput(response, "", output)
+			}
 			SuccessOutput(response.GetNodes(), "", output)
 		}
 
-		tableData, err := nodesToPtables(user, showTags, response.GetNodes())
+		tableData, err := nodesToPtables(user, showTags, showLabels, wide, response.GetNodes())
 		if err != nil {
 			ErrorOutput(err, fmt.Sprintf("Error converting to table: %s", err), output)
 		}
@@ -206,9 +276,76 @@ var listNodesCmd = &cobra.Command{
 				output,
 			)
 		}
+
+		if response.GetNextPageToken() != "" {
+			fmt.Printf("More nodes with --page-token %s\n", response.GetNextPageToken())
+		}
 	},
 }
 
+// listNodesRequestFromFlags returns the filters, sorting and paging of
+// the list flags as request.
+func listNodesRequestFromFlags(cmd *cobra.Command) (*v1.ListNodesRequest, error) {
+	flags := cmd.Flags()
+	request := &v1.ListNodesRequest{}
+
+	request.LabelSelector, _ = flags.GetString("label-selector")
+	request.Tags, _ = flags.GetStringSlice("tag")
+	request.Os, _ = flags.GetString("os")
+	request.ClientVersion, _ = flags.GetString("client-version")
+	request.Name, _ = flags.GetString("name")
+	request.SortBy, _ = flags.GetString("sort")
+	request.Descending, _ = flags.GetBool("desc")
+	request.PageSize, _ = flags.GetUint32("limit")
+	request.PageToken, _ = flags.GetString("page-token")
+
+	if online, _ := flags.GetBool("online"); online {
+		request.Online = ptr.To(true)
+	}
+	if offline, _ := flags.GetBool("offline"); offline {
+		request.Online = ptr.To(false)
+	}
+	if expired, _ := flags.GetBool("expired"); expired {
+		request.Expired = ptr.To(true)
+	}
+	if notExpired, _ := flags.GetBool("not-expired"); notExpired {
+		request.Expired = ptr.To(false)
+	}
+
+	for flag, field := range map[string]**timestamppb.Timestamp{
+		"last-seen-after":  &request.LastSeenAfter,
+		"last-seen-before": &request.LastSeenBefore,
+	} {
+		value, _ := flags.GetString(flag)
+		if value == "" {
+			continue
+		}
+
+		t, err := parseTimeOrAgo(value)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", flag, err)
+		}
+		*field = timestamppb.New(t)
+	}
+
+	return request, nil
+}
+
+// parseTimeOrAgo parses a RFC 3339 time, or a duration like 30d which is
+// subtracted from now.
+func parseTimeOrAgo(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	duration, err := model.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a time nor a duration", value)
+	}
+
+	return time.Now().Add(-time.Duration(duration)), nil
+}
+
 var listNodeRoutesCmd = &cobra.Command{
 	Use:     "list-routes",
 	Short:   "List routes available on nodes",
@@ -283,6 +420,14 @@ var expireNodeCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		output, _ := cmd.Flags().GetString("output")
 
+		if selector, _ := cmd.Flags().GetString("selector"); selector != "" {
+			runBulkNodes(cmd, "expire", "expired", func(ctx context.Context, client v1.HeadscaleServiceClient, dryRun bool) ([]*v1.Node, error) {
+				response, err := client.ExpireNodes(ctx, &v1.ExpireNodesRequest{Selector: selector, DryRun: dryRun})
+
+				return response.GetNodes(), err
+			})
+		}
+
 		identifier, err := cmd.Flags().GetUint64("identifier")
 		if err != nil {
 			ErrorOutput(
@@ -368,6 +513,45 @@ var renameNodeCmd = &cobra.Command{
 	},
 }
 
+var setNodeAliasesCmd = &cobra.Command{
+	Use:   "aliases [ALIAS...]",
+	Short: "Set the extra DNS names of a node, no aliases removes them",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		identifier, err := cmd.Flags().GetUint64("identifier")
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Error converting ID to integer: %s", err),
+				output,
+			)
+
+			return
+		}
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		response, err := client.SetNodeAliases(ctx, &v1.SetNodeAliasesRequest{
+			NodeId:  identifier,
+			Aliases: args,
+		})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Cannot set node aliases: %s\n", status.Convert(err).Message()),
+				output,
+			)
+
+			return
+		}
+
+		SuccessOutput(response.GetNode(), "Node aliases set", output)
+	},
+}
+
 var deleteNodeCmd = &cobra.Command{
 	Use:     "delete",
 	Short:   "Delete a node",
@@ -375,6 +559,14 @@ var deleteNodeCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		output, _ := cmd.Flags().GetString("output")
 
+		if selector, _ := cmd.Flags().GetString("selector"); selector != "" {
+			runBulkNodes(cmd, "delete", "deleted", func(ctx context.Context, client v1.HeadscaleServiceClient, dryRun bool) ([]*v1.Node, error) {
+				response, err := client.DeleteNodes(ctx, &v1.DeleteNodesRequest{Selector: selector, DryRun: dryRun})
+
+				return response.GetNodes(), err
+			})
+		}
+
 		identifier, err := cmd.Flags().GetUint64("identifier")
 		if err != nil {
 			ErrorOutput(
@@ -486,6 +678,14 @@ var moveNodeCmd = &cobra.Command{
 			return
 		}
 
+		if selector, _ := cmd.Flags().GetString("selector"); selector != "" {
+			runBulkNodes(cmd, "move", "moved", func(ctx context.Context, client v1.HeadscaleServiceClient, dryRun bool) ([]*v1.Node, error) {
+				response, err := client.MoveNodes(ctx, &v1.MoveNodesRequest{Selector: selector, User: user, DryRun: dryRun})
+
+				return response.GetNodes(), err
+			})
+		}
+
 		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
 		defer cancel()
 		defer conn.Close()
@@ -531,6 +731,63 @@ var moveNodeCmd = &cobra.Command{
 	},
 }
 
+var setNodeIPsCmd = &cobra.Command{
+	Use:   "set-ips",
+	Short: "Set the IP addresses of a node",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		identifier, err := cmd.Flags().GetUint64("identifier")
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Error converting ID to integer: %s", err),
+				output,
+			)
+
+			return
+		}
+
+		ipv4, _ := cmd.Flags().GetString("ipv4")
+		ipv6, _ := cmd.Flags().GetString("ipv6")
+		if ipv4 == "" && ipv6 == "" {
+			ErrorOutput(
+				errMissingParameter,
+				"At least one of --ipv4 and --ipv6 must be set",
+				output,
+			)
+
+			return
+		}
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		request := &v1.SetNodeIPsRequest{
+			NodeId: identifier,
+			Ipv4:   ipv4,
+			Ipv6:   ipv6,
+		}
+
+		response, err := client.SetNodeIPs(ctx, request)
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf(
+					"Error setting node IPs: %s",
+					status.Convert(err).Message(),
+				),
+				output,
+			)
+
+			return
+		}
+
+		SuccessOutput(response.GetNode(), "Node IPs updated", output)
+	},
+}
+
 var backfillNodeIPsCmd = &cobra.Command{
 	Use:   "backfillips",
 	Short: "Backfill IPs missing from nodes",
@@ -582,9 +839,126 @@ be assigned to nodes.`,
 	},
 }
 
+var renumberNodesCmd = &cobra.Command{
+	Use:   "renumber",
+	Short: "Move the IPs of all nodes to new prefixes",
+	Long: `
+Renumber moves the IPs of all nodes into new prefixes, without
+re-registering them. Nodes keep the offset of their IP in the
+prefix if possible, IPs in the mapping file are used as they are.
+
+Hosts of the policy with IPs of renumbered nodes are rewritten
+if the policy is stored in the database, and reported otherwise.
+
+Change the prefixes in the configuration afterwards, or change
+them first and renumber without --v4 and --v6 to move all nodes
+into the configured prefixes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		request := &v1.RenumberNodesRequest{}
+		request.PrefixV4, _ = cmd.Flags().GetString("v4")
+		request.PrefixV6, _ = cmd.Flags().GetString("v6")
+		request.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+		if mappingPath, _ := cmd.Flags().GetString("mapping"); mappingPath != "" {
+			mapping, err := readRenumberMapping(mappingPath)
+			if err != nil {
+				ErrorOutput(
+					err,
+					fmt.Sprintf("Error reading mapping: %s", err),
+					output,
+				)
+
+				return
+			}
+			request.Mapping = mapping
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !request.DryRun && !force {
+			confirm := false
+			prompt := &survey.Confirm{
+				Message: "Are you sure that you want to change the IPs of all nodes?",
+			}
+			err := survey.AskOne(prompt, &confirm)
+			if err != nil || !confirm {
+				return
+			}
+		}
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		response, err := client.RenumberNodes(ctx, request)
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf(
+					"Error renumbering nodes: %s",
+					status.Convert(err).Message(),
+				),
+				output,
+			)
+
+			return
+		}
+
+		if output != "" {
+			SuccessOutput(response, "", output)
+
+			return
+		}
+
+		for _, change := range response.GetChanges() {
+			fmt.Println(change)
+		}
+		for _, change := range response.GetPolicyChanges() {
+			fmt.Println("policy:", change)
+		}
+
+		switch {
+		case request.DryRun:
+			fmt.Println("Dry run, nothing was changed")
+		case len(response.GetPolicyChanges()) > 0 && !response.GetPolicyUpdated():
+			fmt.Println("Nodes renumbered, update the hosts of the policy file and the prefixes in the configuration")
+		default:
+			fmt.Println("Nodes renumbered, update the prefixes in the configuration")
+		}
+	},
+}
+
+// readRenumberMapping reads lines of the current and the new address of
+// nodes, empty lines and lines starting with # are skipped.
+func readRenumberMapping(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := make(map[string]string)
+	for number, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected the current and the new address", number+1)
+		}
+		mapping[fields[0]] = fields[1]
+	}
+
+	return mapping, nil
+}
+
 func nodesToPtables(
 	currentUser string,
 	showTags bool,
+	showLabels bool,
+	wide bool,
 	nodes []*v1.Node,
 ) (pterm.TableData, error) {
 	tableHeader := []string{
@@ -608,6 +982,23 @@ func nodesToPtables(
 			"ValidTags",
 		}...)
 	}
+	if showLabels {
+		tableHeader = append(tableHeader, "Labels")
+	}
+	if wide {
+		tableHeader = append(tableHeader, []string{
+			"OS",
+			"Version",
+			"Capver",
+			"Distro",
+			"Model",
+			"DERP",
+			"UDP",
+			"Mapping varies",
+			"Endpoints",
+			"Services",
+		}...)
+	}
 	tableData := pterm.TableData{tableHeader}
 
 	for _, node := range nodes {
@@ -717,6 +1108,12 @@ func nodesToPtables(
 		if showTags {
 			nodeData = append(nodeData, []string{forcedTags, invalidTags, validTags}...)
 		}
+		if showLabels {
+			nodeData = append(nodeData, formatLabels(node.GetLabels()))
+		}
+		if wide {
+			nodeData = append(nodeData, nodeDeviceColumns(node.GetDevice())...)
+		}
 		tableData = append(
 			tableData,
 			nodeData,
@@ -726,6 +1123,65 @@ func nodesToPtables(
 	return tableData, nil
 }
 
+// formatLabels returns the labels as comma separated key=value pairs,
+// sorted by key.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// nodeDeviceColumns returns the columns of the wide node table.
+func nodeDeviceColumns(device *v1.NodeDevice) []string {
+	osVersion := strings.TrimSpace(device.GetOs() + " " + device.GetOsVersion())
+	distro := strings.TrimSpace(device.GetDistro() + " " + device.GetDistroVersion())
+
+	var capVer string
+	if device.GetCapabilityVersion() != 0 {
+		capVer = strconv.Itoa(int(device.GetCapabilityVersion()))
+	}
+
+	var derp, udp, mappingVaries string
+	if ni := device.GetNetInfo(); ni != nil {
+		if ni.GetPreferredDerp() != 0 {
+			derp = strconv.Itoa(int(ni.GetPreferredDerp()))
+		}
+		udp = formatOptionalBool(ni.WorkingUdp)
+		mappingVaries = formatOptionalBool(ni.MappingVariesByDestIp)
+	}
+
+	services := make([]string, 0, len(device.GetServices()))
+	for _, service := range device.GetServices() {
+		services = append(services, fmt.Sprintf("%s:%d", service.GetProto(), service.GetPort()))
+	}
+
+	return []string{
+		osVersion,
+		device.GetClientVersion(),
+		capVer,
+		distro,
+		device.GetDeviceModel(),
+		derp,
+		udp,
+		mappingVaries,
+		strings.Join(device.GetEndpoints(), ", "),
+		strings.Join(services, ", "),
+	}
+}
+
+// formatOptionalBool returns an empty string if the value is unknown.
+func formatOptionalBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+
+	return strconv.FormatBool(*b)
+}
+
 func nodeRoutesToPtables(
 	nodes []*v1.Node,
 ) (pterm.TableData, error) {
@@ -787,6 +1243,14 @@ var tagCmd = &cobra.Command{
 			return
 		}
 
+		if selector, _ := cmd.Flags().GetString("selector"); selector != "" {
+			runBulkNodes(cmd, "tag", "tagged", func(ctx context.Context, client v1.HeadscaleServiceClient, dryRun bool) ([]*v1.Node, error) {
+				response, err := client.SetNodesTags(ctx, &v1.SetNodesTagsRequest{Selector: selector, Tags: tagsToSet, DryRun: dryRun})
+
+				return response.GetNodes(), err
+			})
+		}
+
 		// Sending tags to node
 		request := &v1.SetTagsRequest{
 			NodeId: identifier,
@@ -813,6 +1277,311 @@ var tagCmd = &cobra.Command{
 	},
 }
 
+// addBulkNodeFlags adds the flags to act on all nodes matching a selector
+// instead of the node given by its identifier.
+func addBulkNodeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("selector", "", "Act on all nodes matching a selector like user=alice,os=windows,offline>30d")
+	cmd.Flags().Bool("dry-run", false, "Only show the nodes matching the selector")
+	cmd.MarkFlagsOneRequired("identifier", "selector")
+	cmd.MarkFlagsMutuallyExclusive("identifier", "selector")
+}
+
+// runBulkNodes calls a bulk operation on the nodes matching the selector.
+// It first lists the nodes with a dry run and asks for confirmation,
+// unless --force is given.
+func runBulkNodes(
+	cmd *cobra.Command,
+	action string,
+	done string,
+	call func(ctx context.Context, client v1.HeadscaleServiceClient, dryRun bool) ([]*v1.Node, error),
+) {
+	output, _ := cmd.Flags().GetString("output")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+
+	ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+	defer cancel()
+	defer conn.Close()
+
+	nodes, err := call(ctx, client, true)
+	if err != nil {
+		ErrorOutput(
+			err,
+			fmt.Sprintf("Cannot select nodes: %s", status.Convert(err).Message()),
+			output,
+		)
+	}
+
+	if dryRun || len(nodes) == 0 {
+		if output != "" {
+			SuccessOutput(nodes, "", output)
+		}
+		renderBulkNodes(nodes, output)
+		SuccessOutput(nodes, fmt.Sprintf("%d nodes would be %s", len(nodes), done), output)
+	}
+
+	if !force {
+		renderBulkNodes(nodes, output)
+
+		confirm := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Do you want to %s these %d nodes?", action, len(nodes)),
+		}
+		err = survey.AskOne(prompt, &confirm)
+		if err != nil || !confirm {
+			SuccessOutput(map[string]string{"Result": "Nodes not changed"}, "Nodes not changed", output)
+		}
+	}
+
+	nodes, err = call(ctx, client, false)
+	if err != nil {
+		ErrorOutput(
+			err,
+			fmt.Sprintf("Cannot %s nodes: %s", action, status.Convert(err).Message()),
+			output,
+		)
+	}
+
+	SuccessOutput(nodes, fmt.Sprintf("%d nodes %s", len(nodes), done), output)
+}
+
+func renderBulkNodes(nodes []*v1.Node, output string) {
+	if output != "" || len(nodes) == 0 {
+		return
+	}
+
+	tableData, err := nodesToPtables("", true, false, false, nodes)
+	if err != nil {
+		ErrorOutput(err, fmt.Sprintf("Error converting to table: %s", err), output)
+	}
+
+	err = pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+	if err != nil {
+		ErrorOutput(
+			err,
+			fmt.Sprintf("Failed to render pterm table: %s", err),
+			output,
+		)
+	}
+}
+
+var listStaleNodesCmd = &cobra.Command{
+	Use:   "stale",
+	Short: "List nodes the node cleanup expires or deletes, now or within the grace period",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		response, err := client.ListStaleNodes(ctx, &v1.ListStaleNodesRequest{})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Cannot get stale nodes: %s", status.Convert(err).Message()),
+				output,
+			)
+		}
+
+		if output != "" {
+			SuccessOutput(response, "", output)
+		}
+
+		if !response.GetEnabled() {
+			fmt.Println("The node cleanup is disabled, this is what it would do:")
+		}
+
+		tableData := pterm.TableData{{"ID", "Hostname", "User", "Last seen", "Action", "Due"}}
+		for _, stale := range response.GetNodes() {
+			node := stale.GetNode()
+
+			var lastSeen string
+			if node.GetLastSeen() != nil {
+				lastSeen = node.GetLastSeen().AsTime().Format("2006-01-02 15:04:05")
+			}
+
+			due := stale.GetDue().AsTime()
+			dueTime := due.Format("2006-01-02 15:04:05")
+			if due.Before(time.Now()) {
+				dueTime = pterm.LightRed("next run")
+			}
+
+			tableData = append(tableData, []string{
+				strconv.FormatUint(node.GetId(), util.Base10),
+				node.GetGivenName(),
+				node.GetUser().GetName(),
+				lastSeen,
+				stale.GetAction(),
+				dueTime,
+			})
+		}
+
+		err = pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Failed to render pterm table: %s", err),
+				output,
+			)
+		}
+	},
+}
+
+var listEphemeralDeletionsCmd = &cobra.Command{
+	Use:   "ephemeral",
+	Short: "List disconnected ephemeral nodes and when they are deleted",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		response, err := client.ListEphemeralDeletions(ctx, &v1.ListEphemeralDeletionsRequest{})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Cannot get ephemeral node deletions: %s", status.Convert(err).Message()),
+				output,
+			)
+		}
+
+		if output != "" {
+			SuccessOutput(response, "", output)
+		}
+
+		tableData := pterm.TableData{{"ID", "Hostname", "User", "Last seen", "Due"}}
+		for _, deletion := range response.GetDeletions() {
+			node := deletion.GetNode()
+
+			var lastSeen string
+			if node.GetLastSeen() != nil {
+				lastSeen = node.GetLastSeen().AsTime().Format("2006-01-02 15:04:05")
+			}
+
+			tableData = append(tableData, []string{
+				strconv.FormatUint(node.GetId(), util.Base10),
+				node.GetGivenName(),
+				node.GetUser().GetName(),
+				lastSeen,
+				deletion.GetDue().AsTime().Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		err = pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Failed to render pterm table: %s", err),
+				output,
+			)
+		}
+	},
+}
+
+var labelsCmd = &cobra.Command{
+	Use:     "labels",
+	Short:   "Manage the labels of a node",
+	Aliases: []string{"label"},
+}
+
+var setNodeLabelsCmd = &cobra.Command{
+	Use:   "set key=value...",
+	Short: "Add or change labels of a node",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errMissingParameter
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		identifier, err := cmd.Flags().GetUint64("identifier")
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Error converting ID to integer: %s", err),
+				output,
+			)
+		}
+
+		labels := make(map[string]string, len(args))
+		for _, arg := range args {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				ErrorOutput(
+					errMissingParameter,
+					fmt.Sprintf("Label %q is not of the form key=value", arg),
+					output,
+				)
+			}
+			labels[key] = value
+		}
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		response, err := client.SetNodeLabels(ctx, &v1.SetNodeLabelsRequest{
+			NodeId: identifier,
+			Labels: labels,
+		})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Cannot set node labels: %s", status.Convert(err).Message()),
+				output,
+			)
+		}
+
+		SuccessOutput(response.GetNode(), "Node labels updated", output)
+	},
+}
+
+var deleteNodeLabelsCmd = &cobra.Command{
+	Use:     "delete key...",
+	Short:   "Remove labels from a node",
+	Aliases: []string{"del", "rm"},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errMissingParameter
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		identifier, err := cmd.Flags().GetUint64("identifier")
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Error converting ID to integer: %s", err),
+				output,
+			)
+		}
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		response, err := client.DeleteNodeLabels(ctx, &v1.DeleteNodeLabelsRequest{
+			NodeId: identifier,
+			Keys:   args,
+		})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf("Cannot delete node labels: %s", status.Convert(err).Message()),
+				output,
+			)
+		}
+
+		SuccessOutput(response.GetNode(), "Node labels deleted", output)
+	},
+}
+
 var approveRoutesCmd = &cobra.Command{
 	Use:   "approve-routes",
 	Short: "Manage the approved routes of a node",
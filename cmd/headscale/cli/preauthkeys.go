@@ -11,6 +11,7 @@ import (
 	"github.com/pterm/pterm"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -42,6 +43,20 @@ func init() {
 		StringP("expiration", "e", DefaultPreAuthKeyExpiry, "Human-readable expiration of the key (e.g. 30m, 24h)")
 	createPreAuthKeyCmd.Flags().
 		StringSlice("tags", []string{}, "Tags to automatically assign to node")
+	createPreAuthKeyCmd.Flags().
+		StringSlice("approve-routes", []string{}, "Routes to approve on nodes registering with the key (e.g. 10.0.0.0/8,0.0.0.0/0)")
+	createPreAuthKeyCmd.Flags().
+		String("node-expiry", "", "Human-readable expiry of nodes registering with the key (e.g. 30d), overrides the client")
+	createPreAuthKeyCmd.Flags().
+		String("given-name", "", "Name to give the node registering with the single-use key")
+	createPreAuthKeyCmd.Flags().
+		String("ephemeral-timeout", "", "Human-readable inactivity timeout for ephemeral nodes registering with the key (e.g. 5m)")
+	createPreAuthKeyCmd.Flags().
+		String("ipv4", "", "IPv4 address to assign to the node registering with the single-use key")
+	createPreAuthKeyCmd.Flags().
+		String("ipv6", "", "IPv6 address to assign to the node registering with the single-use key")
+	createPreAuthKeyCmd.Flags().
+		String("name-collision", "", "What happens if the hostname of a node registering with the key is already used: suffix, reject or takeover (default: server setting)")
 }
 
 var preauthkeysCmd = &cobra.Command{
@@ -176,6 +191,38 @@ var createPreAuthKeyCmd = &cobra.Command{
 
 		request.Expiration = timestamppb.New(expiration)
 
+		request.ApprovedRoutes, _ = cmd.Flags().GetStringSlice("approve-routes")
+		request.GivenName, _ = cmd.Flags().GetString("given-name")
+		request.Ipv4, _ = cmd.Flags().GetString("ipv4")
+		request.Ipv6, _ = cmd.Flags().GetString("ipv6")
+		request.NameCollision, _ = cmd.Flags().GetString("name-collision")
+
+		if nodeExpiryStr, _ := cmd.Flags().GetString("node-expiry"); nodeExpiryStr != "" {
+			nodeExpiry, err := model.ParseDuration(nodeExpiryStr)
+			if err != nil {
+				ErrorOutput(
+					err,
+					fmt.Sprintf("Could not parse node expiry: %s\n", err),
+					output,
+				)
+			}
+
+			request.NodeExpiry = durationpb.New(time.Duration(nodeExpiry))
+		}
+
+		if timeoutStr, _ := cmd.Flags().GetString("ephemeral-timeout"); timeoutStr != "" {
+			timeout, err := model.ParseDuration(timeoutStr)
+			if err != nil {
+				ErrorOutput(
+					err,
+					fmt.Sprintf("Could not parse ephemeral timeout: %s\n", err),
+					output,
+				)
+			}
+
+			request.EphemeralInactivityTimeout = durationpb.New(time.Duration(timeout))
+		}
+
 		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
 		defer cancel()
 		defer conn.Close()
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -53,6 +54,17 @@ func init() {
 	usernameAndIDFlag(renameUserCmd)
 	renameUserCmd.Flags().StringP("new-name", "r", "", "New username")
 	renameNodeCmd.MarkFlagRequired("new-name")
+	userCmd.AddCommand(suspendUserCmd)
+	usernameAndIDFlag(suspendUserCmd)
+	userCmd.AddCommand(unsuspendUserCmd)
+	usernameAndIDFlag(unsuspendUserCmd)
+	userCmd.AddCommand(setPasswordUserCmd)
+	usernameAndIDFlag(setPasswordUserCmd)
+	setPasswordUserCmd.Flags().StringP("password", "p", "", "New password, prompted for if not set")
+	setPasswordUserCmd.Flags().Bool("remove", false, "Remove the password and TOTP secret of the user")
+	userCmd.AddCommand(totpUserCmd)
+	usernameAndIDFlag(totpUserCmd)
+	totpUserCmd.Flags().Bool("disable", false, "Disable TOTP for the user")
 }
 
 var errMissingParameter = errors.New("missing parameters")
@@ -323,3 +335,200 @@ var renameUserCmd = &cobra.Command{
 		SuccessOutput(response.GetUser(), "User renamed", output)
 	},
 }
+
+// userFromFlag returns the single user matching the name or identifier
+// flag of the command, it exits the program if there is none or multiple.
+func userFromFlag(
+	ctx context.Context,
+	client v1.HeadscaleServiceClient,
+	cmd *cobra.Command,
+	output string,
+) *v1.User {
+	id, username := usernameAndIDFromFlag(cmd)
+
+	users, err := client.ListUsers(ctx, &v1.ListUsersRequest{
+		Name: username,
+		Id:   id,
+	})
+	if err != nil {
+		ErrorOutput(
+			err,
+			fmt.Sprintf("Error: %s", status.Convert(err).Message()),
+			output,
+		)
+	}
+
+	if len(users.GetUsers()) != 1 {
+		err := fmt.Errorf("Unable to determine user, query returned %d users, use ID", len(users.GetUsers()))
+		ErrorOutput(
+			err,
+			fmt.Sprintf("Error: %s", status.Convert(err).Message()),
+			output,
+		)
+	}
+
+	return users.GetUsers()[0]
+}
+
+var suspendUserCmd = &cobra.Command{
+	Use:   "suspend --identifier ID or --name NAME",
+	Short: "Suspends a user",
+	Long: `
+Suspends a user without deleting it. The nodes of a suspended user are
+unauthorized and removed from all peer lists and filters, its pre auth
+keys are rejected and it cannot log in with OIDC.
+Nodes, IPs and names are kept, so the user can be reinstated with
+"headscale users unsuspend".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		user := userFromFlag(ctx, client, cmd, output)
+
+		response, err := client.SuspendUser(ctx, &v1.SuspendUserRequest{Id: user.GetId()})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf(
+					"Cannot suspend user: %s",
+					status.Convert(err).Message(),
+				),
+				output,
+			)
+		}
+
+		SuccessOutput(response.GetUser(), "User suspended", output)
+	},
+}
+
+var unsuspendUserCmd = &cobra.Command{
+	Use:     "unsuspend --identifier ID or --name NAME",
+	Short:   "Reinstates a suspended user",
+	Aliases: []string{"reinstate"},
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		user := userFromFlag(ctx, client, cmd, output)
+
+		response, err := client.UnsuspendUser(ctx, &v1.UnsuspendUserRequest{Id: user.GetId()})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf(
+					"Cannot unsuspend user: %s",
+					status.Convert(err).Message(),
+				),
+				output,
+			)
+		}
+
+		SuccessOutput(response.GetUser(), "User unsuspended", output)
+	},
+}
+
+var setPasswordUserCmd = &cobra.Command{
+	Use:   "set-password --identifier ID or --name NAME",
+	Short: "Sets the password of a user for local authentication",
+	Long: `
+Sets the password a user logs in with when local_auth is enabled, and
+lifts a lockout after too many failed logins. With --remove the password
+and TOTP secret are removed and the user can no longer log in with a
+password.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		password, _ := cmd.Flags().GetString("password")
+		remove, _ := cmd.Flags().GetBool("remove")
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		user := userFromFlag(ctx, client, cmd, output)
+
+		if !remove && password == "" {
+			prompt := &survey.Password{
+				Message: fmt.Sprintf("New password for user %q:", user.GetName()),
+			}
+			if err := survey.AskOne(prompt, &password, survey.WithValidator(survey.Required)); err != nil {
+				return
+			}
+		}
+
+		if remove {
+			password = ""
+		}
+
+		response, err := client.SetUserPassword(ctx, &v1.SetUserPasswordRequest{
+			Id:       user.GetId(),
+			Password: password,
+		})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf(
+					"Cannot set password: %s",
+					status.Convert(err).Message(),
+				),
+				output,
+			)
+		}
+
+		if remove {
+			SuccessOutput(response.GetUser(), "Password removed", output)
+		}
+
+		SuccessOutput(response.GetUser(), "Password set", output)
+	},
+}
+
+var totpUserCmd = &cobra.Command{
+	Use:   "totp --identifier ID or --name NAME",
+	Short: "Enables TOTP as second factor for local authentication",
+	Long: `
+Generates a new TOTP secret for a user with a password, the user has to
+enter a code of their authenticator app on every login. Import the
+printed otpauth URL or secret into the authenticator app. Running the
+command again replaces the secret, --disable removes it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		disable, _ := cmd.Flags().GetBool("disable")
+
+		ctx, client, conn, cancel := newHeadscaleCLIWithConfig()
+		defer cancel()
+		defer conn.Close()
+
+		user := userFromFlag(ctx, client, cmd, output)
+
+		response, err := client.SetUserTOTP(ctx, &v1.SetUserTOTPRequest{
+			Id:      user.GetId(),
+			Enabled: !disable,
+		})
+		if err != nil {
+			ErrorOutput(
+				err,
+				fmt.Sprintf(
+					"Cannot set TOTP: %s",
+					status.Convert(err).Message(),
+				),
+				output,
+			)
+		}
+
+		if disable {
+			SuccessOutput(response.GetUser(), "TOTP disabled", output)
+		}
+
+		SuccessOutput(
+			response,
+			fmt.Sprintf("TOTP secret: %s\nURL: %s", response.GetSecret(), response.GetUrl()),
+			output,
+		)
+	},
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
 	"github.com/juanfont/headscale/hscontrol"
@@ -61,6 +62,28 @@ func newHeadscaleCLIWithConfig() (context.Context, v1.HeadscaleServiceClient, *g
 	}
 
 	address := cfg.CLI.Address
+	apiKey := cfg.CLI.APIKey
+
+	// Without an API key, use the one of headscale login if it is for
+	// the configured server, or if no server is configured.
+	if apiKey == "" {
+		login, err := loadCLILogin()
+		if err != nil {
+			log.Fatal().Caller().Err(err).Msgf("Failed to load the login of headscale login")
+		}
+
+		if login != nil && (address == "" || address == login.Address) {
+			if login.Expiration.Before(time.Now()) {
+				log.Fatal().Caller().Msgf(
+					"The API key of headscale login expired at %s, run headscale login again.",
+					login.Expiration.Local().Format(HeadscaleDateTimeFormat),
+				)
+			}
+
+			address = login.Address
+			apiKey = login.APIKey
+		}
+	}
 
 	// If the address is not set, we assume that we are on the server hosting hscontrol.
 	if address == "" {
@@ -89,33 +112,39 @@ func newHeadscaleCLIWithConfig() (context.Context, v1.HeadscaleServiceClient, *g
 			grpc.WithContextDialer(util.GrpcSocketDialer),
 		)
 	} else {
-		// If we are not connecting to a local server, require an API key for authentication
-		apiKey := cfg.CLI.APIKey
-		if apiKey == "" {
-			log.Fatal().Caller().Msgf("HEADSCALE_CLI_API_KEY environment variable needs to be set.")
+		// If we are not connecting to a local server, require an API key
+		// or a client certificate for authentication
+		if apiKey == "" && cfg.CLI.ClientCertPath == "" {
+			log.Fatal().Caller().Msgf("HEADSCALE_CLI_API_KEY environment variable needs to be set, or run headscale login.")
+		}
+		if apiKey != "" {
+			grpcOptions = append(grpcOptions,
+				grpc.WithPerRPCCredentials(tokenAuth{
+					token: apiKey,
+				}),
+			)
 		}
-		grpcOptions = append(grpcOptions,
-			grpc.WithPerRPCCredentials(tokenAuth{
-				token: apiKey,
-			}),
-		)
 
+		tlsConfig := &tls.Config{}
 		if cfg.CLI.Insecure {
-			tlsConfig := &tls.Config{
-				// turn of gosec as we are intentionally setting
-				// insecure.
-				//nolint:gosec
-				InsecureSkipVerify: true,
+			// turn of gosec as we are intentionally setting
+			// insecure.
+			//nolint:gosec
+			tlsConfig.InsecureSkipVerify = true
+		}
+
+		if cfg.CLI.ClientCertPath != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.CLI.ClientCertPath, cfg.CLI.ClientKeyPath)
+			if err != nil {
+				log.Fatal().Caller().Err(err).Msgf("Failed to load client certificate")
 			}
 
-			grpcOptions = append(grpcOptions,
-				grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
-			)
-		} else {
-			grpcOptions = append(grpcOptions,
-				grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, "")),
-			)
+			tlsConfig.Certificates = []tls.Certificate{cert}
 		}
+
+		grpcOptions = append(grpcOptions,
+			grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		)
 	}
 
 	log.Trace().Caller().Str("address", address).Msg("Connecting via gRPC")
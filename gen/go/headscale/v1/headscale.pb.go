@@ -25,7 +25,7 @@ var File_headscale_v1_headscale_proto protoreflect.FileDescriptor
 
 const file_headscale_v1_headscale_proto_rawDesc = "" +
 	"\n" +
-	"\x1cheadscale/v1/headscale.proto\x12\fheadscale.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x17headscale/v1/user.proto\x1a\x1dheadscale/v1/preauthkey.proto\x1a\x17headscale/v1/node.proto\x1a\x19headscale/v1/apikey.proto\x1a\x19headscale/v1/policy.proto2\xa3\x16\n" +
+	"\x1cheadscale/v1/headscale.proto\x12\fheadscale.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x17headscale/v1/user.proto\x1a\x1dheadscale/v1/preauthkey.proto\x1a\x17headscale/v1/node.proto\x1a\x19headscale/v1/apikey.proto\x1a\x1eheadscale/v1/oauthclient.proto\x1a\x19headscale/v1/policy.proto2\x9a(\n" +
 	"\x10HeadscaleService\x12h\n" +
 	"\n" +
 	"CreateUser\x12\x1f.headscale.v1.CreateUserRequest\x1a .headscale.v1.CreateUserResponse\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/api/v1/user\x12\x80\x01\n" +
@@ -33,13 +33,20 @@ const file_headscale_v1_headscale_proto_rawDesc = "" +
 	"RenameUser\x12\x1f.headscale.v1.RenameUserRequest\x1a .headscale.v1.RenameUserResponse\"/\x82\xd3\xe4\x93\x02)\"'/api/v1/user/{old_id}/rename/{new_name}\x12j\n" +
 	"\n" +
 	"DeleteUser\x12\x1f.headscale.v1.DeleteUserRequest\x1a .headscale.v1.DeleteUserResponse\"\x19\x82\xd3\xe4\x93\x02\x13*\x11/api/v1/user/{id}\x12b\n" +
-	"\tListUsers\x12\x1e.headscale.v1.ListUsersRequest\x1a\x1f.headscale.v1.ListUsersResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/api/v1/user\x12\x80\x01\n" +
+	"\tListUsers\x12\x1e.headscale.v1.ListUsersRequest\x1a\x1f.headscale.v1.ListUsersResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/api/v1/user\x12u\n" +
+	"\vSuspendUser\x12 .headscale.v1.SuspendUserRequest\x1a!.headscale.v1.SuspendUserResponse\"!\x82\xd3\xe4\x93\x02\x1b\"\x19/api/v1/user/{id}/suspend\x12}\n" +
+	"\rUnsuspendUser\x12\".headscale.v1.UnsuspendUserRequest\x1a#.headscale.v1.UnsuspendUserResponse\"#\x82\xd3\xe4\x93\x02\x1d\"\x1b/api/v1/user/{id}/unsuspend\x12\x85\x01\n" +
+	"\x0fSetUserPassword\x12$.headscale.v1.SetUserPasswordRequest\x1a%.headscale.v1.SetUserPasswordResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/api/v1/user/{id}/password\x12u\n" +
+	"\vSetUserTOTP\x12 .headscale.v1.SetUserTOTPRequest\x1a!.headscale.v1.SetUserTOTPResponse\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/api/v1/user/{id}/totp\x12\x80\x01\n" +
 	"\x10CreatePreAuthKey\x12%.headscale.v1.CreatePreAuthKeyRequest\x1a&.headscale.v1.CreatePreAuthKeyResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/preauthkey\x12\x87\x01\n" +
 	"\x10ExpirePreAuthKey\x12%.headscale.v1.ExpirePreAuthKeyRequest\x1a&.headscale.v1.ExpirePreAuthKeyResponse\"$\x82\xd3\xe4\x93\x02\x1e:\x01*\"\x19/api/v1/preauthkey/expire\x12z\n" +
 	"\x0fListPreAuthKeys\x12$.headscale.v1.ListPreAuthKeysRequest\x1a%.headscale.v1.ListPreAuthKeysResponse\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/api/v1/preauthkey\x12}\n" +
 	"\x0fDebugCreateNode\x12$.headscale.v1.DebugCreateNodeRequest\x1a%.headscale.v1.DebugCreateNodeResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/debug/node\x12f\n" +
 	"\aGetNode\x12\x1c.headscale.v1.GetNodeRequest\x1a\x1d.headscale.v1.GetNodeResponse\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/api/v1/node/{node_id}\x12n\n" +
-	"\aSetTags\x12\x1c.headscale.v1.SetTagsRequest\x1a\x1d.headscale.v1.SetTagsResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/api/v1/node/{node_id}/tags\x12\x96\x01\n" +
+	"\aSetTags\x12\x1c.headscale.v1.SetTagsRequest\x1a\x1d.headscale.v1.SetTagsResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/api/v1/node/{node_id}/tags\x12\x82\x01\n" +
+	"\rSetNodeLabels\x12\".headscale.v1.SetNodeLabelsRequest\x1a#.headscale.v1.SetNodeLabelsResponse\"(\x82\xd3\xe4\x93\x02\":\x01*\"\x1d/api/v1/node/{node_id}/labels\x12\x86\x01\n" +
+	"\x0eSetNodeAliases\x12#.headscale.v1.SetNodeAliasesRequest\x1a$.headscale.v1.SetNodeAliasesResponse\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/api/v1/node/{node_id}/aliases\x12\x88\x01\n" +
+	"\x10DeleteNodeLabels\x12%.headscale.v1.DeleteNodeLabelsRequest\x1a&.headscale.v1.DeleteNodeLabelsResponse\"%\x82\xd3\xe4\x93\x02\x1f*\x1d/api/v1/node/{node_id}/labels\x12\x96\x01\n" +
 	"\x11SetApprovedRoutes\x12&.headscale.v1.SetApprovedRoutesRequest\x1a'.headscale.v1.SetApprovedRoutesResponse\"0\x82\xd3\xe4\x93\x02*:\x01*\"%/api/v1/node/{node_id}/approve_routes\x12t\n" +
 	"\fRegisterNode\x12!.headscale.v1.RegisterNodeRequest\x1a\".headscale.v1.RegisterNodeResponse\"\x1d\x82\xd3\xe4\x93\x02\x17\"\x15/api/v1/node/register\x12o\n" +
 	"\n" +
@@ -49,116 +56,200 @@ const file_headscale_v1_headscale_proto_rawDesc = "" +
 	"\n" +
 	"RenameNode\x12\x1f.headscale.v1.RenameNodeRequest\x1a .headscale.v1.RenameNodeResponse\"0\x82\xd3\xe4\x93\x02*\"(/api/v1/node/{node_id}/rename/{new_name}\x12b\n" +
 	"\tListNodes\x12\x1e.headscale.v1.ListNodesRequest\x1a\x1f.headscale.v1.ListNodesResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/api/v1/node\x12q\n" +
-	"\bMoveNode\x12\x1d.headscale.v1.MoveNodeRequest\x1a\x1e.headscale.v1.MoveNodeResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/api/v1/node/{node_id}/user\x12\x80\x01\n" +
-	"\x0fBackfillNodeIPs\x12$.headscale.v1.BackfillNodeIPsRequest\x1a%.headscale.v1.BackfillNodeIPsResponse\" \x82\xd3\xe4\x93\x02\x1a\"\x18/api/v1/node/backfillips\x12p\n" +
+	"\bMoveNode\x12\x1d.headscale.v1.MoveNodeRequest\x1a\x1e.headscale.v1.MoveNodeResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/api/v1/node/{node_id}/user\x12v\n" +
+	"\n" +
+	"SetNodeIPs\x12\x1f.headscale.v1.SetNodeIPsRequest\x1a .headscale.v1.SetNodeIPsResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/api/v1/node/{node_id}/ips\x12\x80\x01\n" +
+	"\x0fBackfillNodeIPs\x12$.headscale.v1.BackfillNodeIPsRequest\x1a%.headscale.v1.BackfillNodeIPsResponse\" \x82\xd3\xe4\x93\x02\x1a\"\x18/api/v1/node/backfillips\x12z\n" +
+	"\rRenumberNodes\x12\".headscale.v1.RenumberNodesRequest\x1a#.headscale.v1.RenumberNodesResponse\" \x82\xd3\xe4\x93\x02\x1a:\x01*\"\x15/api/v1/node/renumber\x12s\n" +
+	"\vExpireNodes\x12 .headscale.v1.ExpireNodesRequest\x1a!.headscale.v1.ExpireNodesResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/v1/nodes/expire\x12s\n" +
+	"\vDeleteNodes\x12 .headscale.v1.DeleteNodesRequest\x1a!.headscale.v1.DeleteNodesResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/v1/nodes/delete\x12t\n" +
+	"\fSetNodesTags\x12!.headscale.v1.SetNodesTagsRequest\x1a\".headscale.v1.SetNodesTagsResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/nodes/tags\x12k\n" +
+	"\tMoveNodes\x12\x1e.headscale.v1.MoveNodesRequest\x1a\x1f.headscale.v1.MoveNodesResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/nodes/user\x12x\n" +
+	"\x0eListStaleNodes\x12#.headscale.v1.ListStaleNodesRequest\x1a$.headscale.v1.ListStaleNodesResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/api/v1/nodes/stale\x12\x94\x01\n" +
+	"\x16ListEphemeralDeletions\x12+.headscale.v1.ListEphemeralDeletionsRequest\x1a,.headscale.v1.ListEphemeralDeletionsResponse\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/api/v1/nodes/ephemeral\x12p\n" +
 	"\fCreateApiKey\x12!.headscale.v1.CreateApiKeyRequest\x1a\".headscale.v1.CreateApiKeyResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\"\x0e/api/v1/apikey\x12w\n" +
 	"\fExpireApiKey\x12!.headscale.v1.ExpireApiKeyRequest\x1a\".headscale.v1.ExpireApiKeyResponse\" \x82\xd3\xe4\x93\x02\x1a:\x01*\"\x15/api/v1/apikey/expire\x12j\n" +
 	"\vListApiKeys\x12 .headscale.v1.ListApiKeysRequest\x1a!.headscale.v1.ListApiKeysResponse\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/api/v1/apikey\x12v\n" +
-	"\fDeleteApiKey\x12!.headscale.v1.DeleteApiKeyRequest\x1a\".headscale.v1.DeleteApiKeyResponse\"\x1f\x82\xd3\xe4\x93\x02\x19*\x17/api/v1/apikey/{prefix}\x12d\n" +
+	"\fDeleteApiKey\x12!.headscale.v1.DeleteApiKeyRequest\x1a\".headscale.v1.DeleteApiKeyResponse\"\x1f\x82\xd3\xe4\x93\x02\x19*\x17/api/v1/apikey/{prefix}\x12\x84\x01\n" +
+	"\x11CreateOAuthClient\x12&.headscale.v1.CreateOAuthClientRequest\x1a'.headscale.v1.CreateOAuthClientResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/v1/oauthclient\x12~\n" +
+	"\x10ListOAuthClients\x12%.headscale.v1.ListOAuthClientsRequest\x1a&.headscale.v1.ListOAuthClientsResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/api/v1/oauthclient\x12\x8d\x01\n" +
+	"\x11DeleteOAuthClient\x12&.headscale.v1.DeleteOAuthClientRequest\x1a'.headscale.v1.DeleteOAuthClientResponse\"'\x82\xd3\xe4\x93\x02!*\x1f/api/v1/oauthclient/{client_id}\x12d\n" +
 	"\tGetPolicy\x12\x1e.headscale.v1.GetPolicyRequest\x1a\x1f.headscale.v1.GetPolicyResponse\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/api/v1/policy\x12g\n" +
 	"\tSetPolicy\x12\x1e.headscale.v1.SetPolicyRequest\x1a\x1f.headscale.v1.SetPolicyResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\x1a\x0e/api/v1/policyB)Z'github.com/juanfont/headscale/gen/go/v1b\x06proto3"
 
 var file_headscale_v1_headscale_proto_goTypes = []any{
-	(*CreateUserRequest)(nil),         // 0: headscale.v1.CreateUserRequest
-	(*RenameUserRequest)(nil),         // 1: headscale.v1.RenameUserRequest
-	(*DeleteUserRequest)(nil),         // 2: headscale.v1.DeleteUserRequest
-	(*ListUsersRequest)(nil),          // 3: headscale.v1.ListUsersRequest
-	(*CreatePreAuthKeyRequest)(nil),   // 4: headscale.v1.CreatePreAuthKeyRequest
-	(*ExpirePreAuthKeyRequest)(nil),   // 5: headscale.v1.ExpirePreAuthKeyRequest
-	(*ListPreAuthKeysRequest)(nil),    // 6: headscale.v1.ListPreAuthKeysRequest
-	(*DebugCreateNodeRequest)(nil),    // 7: headscale.v1.DebugCreateNodeRequest
-	(*GetNodeRequest)(nil),            // 8: headscale.v1.GetNodeRequest
-	(*SetTagsRequest)(nil),            // 9: headscale.v1.SetTagsRequest
-	(*SetApprovedRoutesRequest)(nil),  // 10: headscale.v1.SetApprovedRoutesRequest
-	(*RegisterNodeRequest)(nil),       // 11: headscale.v1.RegisterNodeRequest
-	(*DeleteNodeRequest)(nil),         // 12: headscale.v1.DeleteNodeRequest
-	(*ExpireNodeRequest)(nil),         // 13: headscale.v1.ExpireNodeRequest
-	(*RenameNodeRequest)(nil),         // 14: headscale.v1.RenameNodeRequest
-	(*ListNodesRequest)(nil),          // 15: headscale.v1.ListNodesRequest
-	(*MoveNodeRequest)(nil),           // 16: headscale.v1.MoveNodeRequest
-	(*BackfillNodeIPsRequest)(nil),    // 17: headscale.v1.BackfillNodeIPsRequest
-	(*CreateApiKeyRequest)(nil),       // 18: headscale.v1.CreateApiKeyRequest
-	(*ExpireApiKeyRequest)(nil),       // 19: headscale.v1.ExpireApiKeyRequest
-	(*ListApiKeysRequest)(nil),        // 20: headscale.v1.ListApiKeysRequest
-	(*DeleteApiKeyRequest)(nil),       // 21: headscale.v1.DeleteApiKeyRequest
-	(*GetPolicyRequest)(nil),          // 22: headscale.v1.GetPolicyRequest
-	(*SetPolicyRequest)(nil),          // 23: headscale.v1.SetPolicyRequest
-	(*CreateUserResponse)(nil),        // 24: headscale.v1.CreateUserResponse
-	(*RenameUserResponse)(nil),        // 25: headscale.v1.RenameUserResponse
-	(*DeleteUserResponse)(nil),        // 26: headscale.v1.DeleteUserResponse
-	(*ListUsersResponse)(nil),         // 27: headscale.v1.ListUsersResponse
-	(*CreatePreAuthKeyResponse)(nil),  // 28: headscale.v1.CreatePreAuthKeyResponse
-	(*ExpirePreAuthKeyResponse)(nil),  // 29: headscale.v1.ExpirePreAuthKeyResponse
-	(*ListPreAuthKeysResponse)(nil),   // 30: headscale.v1.ListPreAuthKeysResponse
-	(*DebugCreateNodeResponse)(nil),   // 31: headscale.v1.DebugCreateNodeResponse
-	(*GetNodeResponse)(nil),           // 32: headscale.v1.GetNodeResponse
-	(*SetTagsResponse)(nil),           // 33: headscale.v1.SetTagsResponse
-	(*SetApprovedRoutesResponse)(nil), // 34: headscale.v1.SetApprovedRoutesResponse
-	(*RegisterNodeResponse)(nil),      // 35: headscale.v1.RegisterNodeResponse
-	(*DeleteNodeResponse)(nil),        // 36: headscale.v1.DeleteNodeResponse
-	(*ExpireNodeResponse)(nil),        // 37: headscale.v1.ExpireNodeResponse
-	(*RenameNodeResponse)(nil),        // 38: headscale.v1.RenameNodeResponse
-	(*ListNodesResponse)(nil),         // 39: headscale.v1.ListNodesResponse
-	(*MoveNodeResponse)(nil),          // 40: headscale.v1.MoveNodeResponse
-	(*BackfillNodeIPsResponse)(nil),   // 41: headscale.v1.BackfillNodeIPsResponse
-	(*CreateApiKeyResponse)(nil),      // 42: headscale.v1.CreateApiKeyResponse
-	(*ExpireApiKeyResponse)(nil),      // 43: headscale.v1.ExpireApiKeyResponse
-	(*ListApiKeysResponse)(nil),       // 44: headscale.v1.ListApiKeysResponse
-	(*DeleteApiKeyResponse)(nil),      // 45: headscale.v1.DeleteApiKeyResponse
-	(*GetPolicyResponse)(nil),         // 46: headscale.v1.GetPolicyResponse
-	(*SetPolicyResponse)(nil),         // 47: headscale.v1.SetPolicyResponse
+	(*CreateUserRequest)(nil),              // 0: headscale.v1.CreateUserRequest
+	(*RenameUserRequest)(nil),              // 1: headscale.v1.RenameUserRequest
+	(*DeleteUserRequest)(nil),              // 2: headscale.v1.DeleteUserRequest
+	(*ListUsersRequest)(nil),               // 3: headscale.v1.ListUsersRequest
+	(*SuspendUserRequest)(nil),             // 4: headscale.v1.SuspendUserRequest
+	(*UnsuspendUserRequest)(nil),           // 5: headscale.v1.UnsuspendUserRequest
+	(*SetUserPasswordRequest)(nil),         // 6: headscale.v1.SetUserPasswordRequest
+	(*SetUserTOTPRequest)(nil),             // 7: headscale.v1.SetUserTOTPRequest
+	(*CreatePreAuthKeyRequest)(nil),        // 8: headscale.v1.CreatePreAuthKeyRequest
+	(*ExpirePreAuthKeyRequest)(nil),        // 9: headscale.v1.ExpirePreAuthKeyRequest
+	(*ListPreAuthKeysRequest)(nil),         // 10: headscale.v1.ListPreAuthKeysRequest
+	(*DebugCreateNodeRequest)(nil),         // 11: headscale.v1.DebugCreateNodeRequest
+	(*GetNodeRequest)(nil),                 // 12: headscale.v1.GetNodeRequest
+	(*SetTagsRequest)(nil),                 // 13: headscale.v1.SetTagsRequest
+	(*SetNodeLabelsRequest)(nil),           // 14: headscale.v1.SetNodeLabelsRequest
+	(*SetNodeAliasesRequest)(nil),          // 15: headscale.v1.SetNodeAliasesRequest
+	(*DeleteNodeLabelsRequest)(nil),        // 16: headscale.v1.DeleteNodeLabelsRequest
+	(*SetApprovedRoutesRequest)(nil),       // 17: headscale.v1.SetApprovedRoutesRequest
+	(*RegisterNodeRequest)(nil),            // 18: headscale.v1.RegisterNodeRequest
+	(*DeleteNodeRequest)(nil),              // 19: headscale.v1.DeleteNodeRequest
+	(*ExpireNodeRequest)(nil),              // 20: headscale.v1.ExpireNodeRequest
+	(*RenameNodeRequest)(nil),              // 21: headscale.v1.RenameNodeRequest
+	(*ListNodesRequest)(nil),               // 22: headscale.v1.ListNodesRequest
+	(*MoveNodeRequest)(nil),                // 23: headscale.v1.MoveNodeRequest
+	(*SetNodeIPsRequest)(nil),              // 24: headscale.v1.SetNodeIPsRequest
+	(*BackfillNodeIPsRequest)(nil),         // 25: headscale.v1.BackfillNodeIPsRequest
+	(*RenumberNodesRequest)(nil),           // 26: headscale.v1.RenumberNodesRequest
+	(*ExpireNodesRequest)(nil),             // 27: headscale.v1.ExpireNodesRequest
+	(*DeleteNodesRequest)(nil),             // 28: headscale.v1.DeleteNodesRequest
+	(*SetNodesTagsRequest)(nil),            // 29: headscale.v1.SetNodesTagsRequest
+	(*MoveNodesRequest)(nil),               // 30: headscale.v1.MoveNodesRequest
+	(*ListStaleNodesRequest)(nil),          // 31: headscale.v1.ListStaleNodesRequest
+	(*ListEphemeralDeletionsRequest)(nil),  // 32: headscale.v1.ListEphemeralDeletionsRequest
+	(*CreateApiKeyRequest)(nil),            // 33: headscale.v1.CreateApiKeyRequest
+	(*ExpireApiKeyRequest)(nil),            // 34: headscale.v1.ExpireApiKeyRequest
+	(*ListApiKeysRequest)(nil),             // 35: headscale.v1.ListApiKeysRequest
+	(*DeleteApiKeyRequest)(nil),            // 36: headscale.v1.DeleteApiKeyRequest
+	(*CreateOAuthClientRequest)(nil),       // 37: headscale.v1.CreateOAuthClientRequest
+	(*ListOAuthClientsRequest)(nil),        // 38: headscale.v1.ListOAuthClientsRequest
+	(*DeleteOAuthClientRequest)(nil),       // 39: headscale.v1.DeleteOAuthClientRequest
+	(*GetPolicyRequest)(nil),               // 40: headscale.v1.GetPolicyRequest
+	(*SetPolicyRequest)(nil),               // 41: headscale.v1.SetPolicyRequest
+	(*CreateUserResponse)(nil),             // 42: headscale.v1.CreateUserResponse
+	(*RenameUserResponse)(nil),             // 43: headscale.v1.RenameUserResponse
+	(*DeleteUserResponse)(nil),             // 44: headscale.v1.DeleteUserResponse
+	(*ListUsersResponse)(nil),              // 45: headscale.v1.ListUsersResponse
+	(*SuspendUserResponse)(nil),            // 46: headscale.v1.SuspendUserResponse
+	(*UnsuspendUserResponse)(nil),          // 47: headscale.v1.UnsuspendUserResponse
+	(*SetUserPasswordResponse)(nil),        // 48: headscale.v1.SetUserPasswordResponse
+	(*SetUserTOTPResponse)(nil),            // 49: headscale.v1.SetUserTOTPResponse
+	(*CreatePreAuthKeyResponse)(nil),       // 50: headscale.v1.CreatePreAuthKeyResponse
+	(*ExpirePreAuthKeyResponse)(nil),       // 51: headscale.v1.ExpirePreAuthKeyResponse
+	(*ListPreAuthKeysResponse)(nil),        // 52: headscale.v1.ListPreAuthKeysResponse
+	(*DebugCreateNodeResponse)(nil),        // 53: headscale.v1.DebugCreateNodeResponse
+	(*GetNodeResponse)(nil),                // 54: headscale.v1.GetNodeResponse
+	(*SetTagsResponse)(nil),                // 55: headscale.v1.SetTagsResponse
+	(*SetNodeLabelsResponse)(nil),          // 56: headscale.v1.SetNodeLabelsResponse
+	(*SetNodeAliasesResponse)(nil),         // 57: headscale.v1.SetNodeAliasesResponse
+	(*DeleteNodeLabelsResponse)(nil),       // 58: headscale.v1.DeleteNodeLabelsResponse
+	(*SetApprovedRoutesResponse)(nil),      // 59: headscale.v1.SetApprovedRoutesResponse
+	(*RegisterNodeResponse)(nil),           // 60: headscale.v1.RegisterNodeResponse
+	(*DeleteNodeResponse)(nil),             // 61: headscale.v1.DeleteNodeResponse
+	(*ExpireNodeResponse)(nil),             // 62: headscale.v1.ExpireNodeResponse
+	(*RenameNodeResponse)(nil),             // 63: headscale.v1.RenameNodeResponse
+	(*ListNodesResponse)(nil),              // 64: headscale.v1.ListNodesResponse
+	(*MoveNodeResponse)(nil),               // 65: headscale.v1.MoveNodeResponse
+	(*SetNodeIPsResponse)(nil),             // 66: headscale.v1.SetNodeIPsResponse
+	(*BackfillNodeIPsResponse)(nil),        // 67: headscale.v1.BackfillNodeIPsResponse
+	(*RenumberNodesResponse)(nil),          // 68: headscale.v1.RenumberNodesResponse
+	(*ExpireNodesResponse)(nil),            // 69: headscale.v1.ExpireNodesResponse
+	(*DeleteNodesResponse)(nil),            // 70: headscale.v1.DeleteNodesResponse
+	(*SetNodesTagsResponse)(nil),           // 71: headscale.v1.SetNodesTagsResponse
+	(*MoveNodesResponse)(nil),              // 72: headscale.v1.MoveNodesResponse
+	(*ListStaleNodesResponse)(nil),         // 73: headscale.v1.ListStaleNodesResponse
+	(*ListEphemeralDeletionsResponse)(nil), // 74: headscale.v1.ListEphemeralDeletionsResponse
+	(*CreateApiKeyResponse)(nil),           // 75: headscale.v1.CreateApiKeyResponse
+	(*ExpireApiKeyResponse)(nil),           // 76: headscale.v1.ExpireApiKeyResponse
+	(*ListApiKeysResponse)(nil),            // 77: headscale.v1.ListApiKeysResponse
+	(*DeleteApiKeyResponse)(nil),           // 78: headscale.v1.DeleteApiKeyResponse
+	(*CreateOAuthClientResponse)(nil),      // 79: headscale.v1.CreateOAuthClientResponse
+	(*ListOAuthClientsResponse)(nil),       // 80: headscale.v1.ListOAuthClientsResponse
+	(*DeleteOAuthClientResponse)(nil),      // 81: headscale.v1.DeleteOAuthClientResponse
+	(*GetPolicyResponse)(nil),              // 82: headscale.v1.GetPolicyResponse
+	(*SetPolicyResponse)(nil),              // 83: headscale.v1.SetPolicyResponse
 }
 var file_headscale_v1_headscale_proto_depIdxs = []int32{
 	0,  // 0: headscale.v1.HeadscaleService.CreateUser:input_type -> headscale.v1.CreateUserRequest
 	1,  // 1: headscale.v1.HeadscaleService.RenameUser:input_type -> headscale.v1.RenameUserRequest
 	2,  // 2: headscale.v1.HeadscaleService.DeleteUser:input_type -> headscale.v1.DeleteUserRequest
 	3,  // 3: headscale.v1.HeadscaleService.ListUsers:input_type -> headscale.v1.ListUsersRequest
-	4,  // 4: headscale.v1.HeadscaleService.CreatePreAuthKey:input_type -> headscale.v1.CreatePreAuthKeyRequest
-	5,  // 5: headscale.v1.HeadscaleService.ExpirePreAuthKey:input_type -> headscale.v1.ExpirePreAuthKeyRequest
-	6,  // 6: headscale.v1.HeadscaleService.ListPreAuthKeys:input_type -> headscale.v1.ListPreAuthKeysRequest
-	7,  // 7: headscale.v1.HeadscaleService.DebugCreateNode:input_type -> headscale.v1.DebugCreateNodeRequest
-	8,  // 8: headscale.v1.HeadscaleService.GetNode:input_type -> headscale.v1.GetNodeRequest
-	9,  // 9: headscale.v1.HeadscaleService.SetTags:input_type -> headscale.v1.SetTagsRequest
-	10, // 10: headscale.v1.HeadscaleService.SetApprovedRoutes:input_type -> headscale.v1.SetApprovedRoutesRequest
-	11, // 11: headscale.v1.HeadscaleService.RegisterNode:input_type -> headscale.v1.RegisterNodeRequest
-	12, // 12: headscale.v1.HeadscaleService.DeleteNode:input_type -> headscale.v1.DeleteNodeRequest
-	13, // 13: headscale.v1.HeadscaleService.ExpireNode:input_type -> headscale.v1.ExpireNodeRequest
-	14, // 14: headscale.v1.HeadscaleService.RenameNode:input_type -> headscale.v1.RenameNodeRequest
-	15, // 15: headscale.v1.HeadscaleService.ListNodes:input_type -> headscale.v1.ListNodesRequest
-	16, // 16: headscale.v1.HeadscaleService.MoveNode:input_type -> headscale.v1.MoveNodeRequest
-	17, // 17: headscale.v1.HeadscaleService.BackfillNodeIPs:input_type -> headscale.v1.BackfillNodeIPsRequest
-	18, // 18: headscale.v1.HeadscaleService.CreateApiKey:input_type -> headscale.v1.CreateApiKeyRequest
-	19, // 19: headscale.v1.HeadscaleService.ExpireApiKey:input_type -> headscale.v1.ExpireApiKeyRequest
-	20, // 20: headscale.v1.HeadscaleService.ListApiKeys:input_type -> headscale.v1.ListApiKeysRequest
-	21, // 21: headscale.v1.HeadscaleService.DeleteApiKey:input_type -> headscale.v1.DeleteApiKeyRequest
-	22, // 22: headscale.v1.HeadscaleService.GetPolicy:input_type -> headscale.v1.GetPolicyRequest
-	23, // 23: headscale.v1.HeadscaleService.SetPolicy:input_type -> headscale.v1.SetPolicyRequest
-	24, // 24: headscale.v1.HeadscaleService.CreateUser:output_type -> headscale.v1.CreateUserResponse
-	25, // 25: headscale.v1.HeadscaleService.RenameUser:output_type -> headscale.v1.RenameUserResponse
-	26, // 26: headscale.v1.HeadscaleService.DeleteUser:output_type -> headscale.v1.DeleteUserResponse
-	27, // 27: headscale.v1.HeadscaleService.ListUsers:output_type -> headscale.v1.ListUsersResponse
-	28, // 28: headscale.v1.HeadscaleService.CreatePreAuthKey:output_type -> headscale.v1.CreatePreAuthKeyResponse
-	29, // 29: headscale.v1.HeadscaleService.ExpirePreAuthKey:output_type -> headscale.v1.ExpirePreAuthKeyResponse
-	30, // 30: headscale.v1.HeadscaleService.ListPreAuthKeys:output_type -> headscale.v1.ListPreAuthKeysResponse
-	31, // 31: headscale.v1.HeadscaleService.DebugCreateNode:output_type -> headscale.v1.DebugCreateNodeResponse
-	32, // 32: headscale.v1.HeadscaleService.GetNode:output_type -> headscale.v1.GetNodeResponse
-	33, // 33: headscale.v1.HeadscaleService.SetTags:output_type -> headscale.v1.SetTagsResponse
-	34, // 34: headscale.v1.HeadscaleService.SetApprovedRoutes:output_type -> headscale.v1.SetApprovedRoutesResponse
-	35, // 35: headscale.v1.HeadscaleService.RegisterNode:output_type -> headscale.v1.RegisterNodeResponse
-	36, // 36: headscale.v1.HeadscaleService.DeleteNode:output_type -> headscale.v1.DeleteNodeResponse
-	37, // 37: headscale.v1.HeadscaleService.ExpireNode:output_type -> headscale.v1.ExpireNodeResponse
-	38, // 38: headscale.v1.HeadscaleService.RenameNode:output_type -> headscale.v1.RenameNodeResponse
-	39, // 39: headscale.v1.HeadscaleService.ListNodes:output_type -> headscale.v1.ListNodesResponse
-	40, // 40: headscale.v1.HeadscaleService.MoveNode:output_type -> headscale.v1.MoveNodeResponse
-	41, // 41: headscale.v1.HeadscaleService.BackfillNodeIPs:output_type -> headscale.v1.BackfillNodeIPsResponse
-	42, // 42: headscale.v1.HeadscaleService.CreateApiKey:output_type -> headscale.v1.CreateApiKeyResponse
-	43, // 43: headscale.v1.HeadscaleService.ExpireApiKey:output_type -> headscale.v1.ExpireApiKeyResponse
-	44, // 44: headscale.v1.HeadscaleService.ListApiKeys:output_type -> headscale.v1.ListApiKeysResponse
-	45, // 45: headscale.v1.HeadscaleService.DeleteApiKey:output_type -> headscale.v1.DeleteApiKeyResponse
-	46, // 46: headscale.v1.HeadscaleService.GetPolicy:output_type -> headscale.v1.GetPolicyResponse
-	47, // 47: headscale.v1.HeadscaleService.SetPolicy:output_type -> headscale.v1.SetPolicyResponse
-	24, // [24:48] is the sub-list for method output_type
-	0,  // [0:24] is the sub-list for method input_type
+	4,  // 4: headscale.v1.HeadscaleService.SuspendUser:input_type -> headscale.v1.SuspendUserRequest
+	5,  // 5: headscale.v1.HeadscaleService.UnsuspendUser:input_type -> headscale.v1.UnsuspendUserRequest
+	6,  // 6: headscale.v1.HeadscaleService.SetUserPassword:input_type -> headscale.v1.SetUserPasswordRequest
+	7,  // 7: headscale.v1.HeadscaleService.SetUserTOTP:input_type -> headscale.v1.SetUserTOTPRequest
+	8,  // 8: headscale.v1.HeadscaleService.CreatePreAuthKey:input_type -> headscale.v1.CreatePreAuthKeyRequest
+	9,  // 9: headscale.v1.HeadscaleService.ExpirePreAuthKey:input_type -> headscale.v1.ExpirePreAuthKeyRequest
+	10, // 10: headscale.v1.HeadscaleService.ListPreAuthKeys:input_type -> headscale.v1.ListPreAuthKeysRequest
+	11, // 11: headscale.v1.HeadscaleService.DebugCreateNode:input_type -> headscale.v1.DebugCreateNodeRequest
+	12, // 12: headscale.v1.HeadscaleService.GetNode:input_type -> headscale.v1.GetNodeRequest
+	13, // 13: headscale.v1.HeadscaleService.SetTags:input_type -> headscale.v1.SetTagsRequest
+	14, // 14: headscale.v1.HeadscaleService.SetNodeLabels:input_type -> headscale.v1.SetNodeLabelsRequest
+	15, // 15: headscale.v1.HeadscaleService.SetNodeAliases:input_type -> headscale.v1.SetNodeAliasesRequest
+	16, // 16: headscale.v1.HeadscaleService.DeleteNodeLabels:input_type -> headscale.v1.DeleteNodeLabelsRequest
+	17, // 17: headscale.v1.HeadscaleService.SetApprovedRoutes:input_type -> headscale.v1.SetApprovedRoutesRequest
+	18, // 18: headscale.v1.HeadscaleService.RegisterNode:input_type -> headscale.v1.RegisterNodeRequest
+	19, // 19: headscale.v1.HeadscaleService.DeleteNode:input_type -> headscale.v1.DeleteNodeRequest
+	20, // 20: headscale.v1.HeadscaleService.ExpireNode:input_type -> headscale.v1.ExpireNodeRequest
+	21, // 21: headscale.v1.HeadscaleService.RenameNode:input_type -> headscale.v1.RenameNodeRequest
+	22, // 22: headscale.v1.HeadscaleService.ListNodes:input_type -> headscale.v1.ListNodesRequest
+	23, // 23: headscale.v1.HeadscaleService.MoveNode:input_type -> headscale.v1.MoveNodeRequest
+	24, // 24: headscale.v1.HeadscaleService.SetNodeIPs:input_type -> headscale.v1.SetNodeIPsRequest
+	25, // 25: headscale.v1.HeadscaleService.BackfillNodeIPs:input_type -> headscale.v1.BackfillNodeIPsRequest
+	26, // 26: headscale.v1.HeadscaleService.RenumberNodes:input_type -> headscale.v1.RenumberNodesRequest
+	27, // 27: headscale.v1.HeadscaleService.ExpireNodes:input_type -> headscale.v1.ExpireNodesRequest
+	28, // 28: headscale.v1.HeadscaleService.DeleteNodes:input_type -> headscale.v1.DeleteNodesRequest
+	29, // 29: headscale.v1.HeadscaleService.SetNodesTags:input_type -> headscale.v1.SetNodesTagsRequest
+	30, // 30: headscale.v1.HeadscaleService.MoveNodes:input_type -> headscale.v1.MoveNodesRequest
+	31, // 31: headscale.v1.HeadscaleService.ListStaleNodes:input_type -> headscale.v1.ListStaleNodesRequest
+	32, // 32: headscale.v1.HeadscaleService.ListEphemeralDeletions:input_type -> headscale.v1.ListEphemeralDeletionsRequest
+	33, // 33: headscale.v1.HeadscaleService.CreateApiKey:input_type -> headscale.v1.CreateApiKeyRequest
+	34, // 34: headscale.v1.HeadscaleService.ExpireApiKey:input_type -> headscale.v1.ExpireApiKeyRequest
+	35, // 35: headscale.v1.HeadscaleService.ListApiKeys:input_type -> headscale.v1.ListApiKeysRequest
+	36, // 36: headscale.v1.HeadscaleService.DeleteApiKey:input_type -> headscale.v1.DeleteApiKeyRequest
+	37, // 37: headscale.v1.HeadscaleService.CreateOAuthClient:input_type -> headscale.v1.CreateOAuthClientRequest
+	38, // 38: headscale.v1.HeadscaleService.ListOAuthClients:input_type -> headscale.v1.ListOAuthClientsRequest
+	39, // 39: headscale.v1.HeadscaleService.DeleteOAuthClient:input_type -> headscale.v1.DeleteOAuthClientRequest
+	40, // 40: headscale.v1.HeadscaleService.GetPolicy:input_type -> headscale.v1.GetPolicyRequest
+	41, // 41: headscale.v1.HeadscaleService.SetPolicy:input_type -> headscale.v1.SetPolicyRequest
+	42, // 42: headscale.v1.HeadscaleService.CreateUser:output_type -> headscale.v1.CreateUserResponse
+	43, // 43: headscale.v1.HeadscaleService.RenameUser:output_type -> headscale.v1.RenameUserResponse
+	44, // 44: headscale.v1.HeadscaleService.DeleteUser:output_type -> headscale.v1.DeleteUserResponse
+	45, // 45: headscale.v1.HeadscaleService.ListUsers:output_type -> headscale.v1.ListUsersResponse
+	46, // 46: headscale.v1.HeadscaleService.SuspendUser:output_type -> headscale.v1.SuspendUserResponse
+	47, // 47: headscale.v1.HeadscaleService.UnsuspendUser:output_type -> headscale.v1.UnsuspendUserResponse
+	48, // 48: headscale.v1.HeadscaleService.SetUserPassword:output_type -> headscale.v1.SetUserPasswordResponse
+	49, // 49: headscale.v1.HeadscaleService.SetUserTOTP:output_type -> headscale.v1.SetUserTOTPResponse
+	50, // 50: headscale.v1.HeadscaleService.CreatePreAuthKey:output_type -> headscale.v1.CreatePreAuthKeyResponse
+	51, // 51: headscale.v1.HeadscaleService.ExpirePreAuthKey:output_type -> headscale.v1.ExpirePreAuthKeyResponse
+	52, // 52: headscale.v1.HeadscaleService.ListPreAuthKeys:output_type -> headscale.v1.ListPreAuthKeysResponse
+	53, // 53: headscale.v1.HeadscaleService.DebugCreateNode:output_type -> headscale.v1.DebugCreateNodeResponse
+	54, // 54: headscale.v1.HeadscaleService.GetNode:output_type -> headscale.v1.GetNodeResponse
+	55, // 55: headscale.v1.HeadscaleService.SetTags:output_type -> headscale.v1.SetTagsResponse
+	56, // 56: headscale.v1.HeadscaleService.SetNodeLabels:output_type -> headscale.v1.SetNodeLabelsResponse
+	57, // 57: headscale.v1.HeadscaleService.SetNodeAliases:output_type -> headscale.v1.SetNodeAliasesResponse
+	58, // 58: headscale.v1.HeadscaleService.DeleteNodeLabels:output_type -> headscale.v1.DeleteNodeLabelsResponse
+	59, // 59: headscale.v1.HeadscaleService.SetApprovedRoutes:output_type -> headscale.v1.SetApprovedRoutesResponse
+	60, // 60: headscale.v1.HeadscaleService.RegisterNode:output_type -> headscale.v1.RegisterNodeResponse
+	61, // 61: headscale.v1.HeadscaleService.DeleteNode:output_type -> headscale.v1.DeleteNodeResponse
+	62, // 62: headscale.v1.HeadscaleService.ExpireNode:output_type -> headscale.v1.ExpireNodeResponse
+	63, // 63: headscale.v1.HeadscaleService.RenameNode:output_type -> headscale.v1.RenameNodeResponse
+	64, // 64: headscale.v1.HeadscaleService.ListNodes:output_type -> headscale.v1.ListNodesResponse
+	65, // 65: headscale.v1.HeadscaleService.MoveNode:output_type -> headscale.v1.MoveNodeResponse
+	66, // 66: headscale.v1.HeadscaleService.SetNodeIPs:output_type -> headscale.v1.SetNodeIPsResponse
+	67, // 67: headscale.v1.HeadscaleService.BackfillNodeIPs:output_type -> headscale.v1.BackfillNodeIPsResponse
+	68, // 68: headscale.v1.HeadscaleService.RenumberNodes:output_type -> headscale.v1.RenumberNodesResponse
+	69, // 69: headscale.v1.HeadscaleService.ExpireNodes:output_type -> headscale.v1.ExpireNodesResponse
+	70, // 70: headscale.v1.HeadscaleService.DeleteNodes:output_type -> headscale.v1.DeleteNodesResponse
+	71, // 71: headscale.v1.HeadscaleService.SetNodesTags:output_type -> headscale.v1.SetNodesTagsResponse
+	72, // 72: headscale.v1.HeadscaleService.MoveNodes:output_type -> headscale.v1.MoveNodesResponse
+	73, // 73: headscale.v1.HeadscaleService.ListStaleNodes:output_type -> headscale.v1.ListStaleNodesResponse
+	74, // 74: headscale.v1.HeadscaleService.ListEphemeralDeletions:output_type -> headscale.v1.ListEphemeralDeletionsResponse
+	75, // 75: headscale.v1.HeadscaleService.CreateApiKey:output_type -> headscale.v1.CreateApiKeyResponse
+	76, // 76: headscale.v1.HeadscaleService.ExpireApiKey:output_type -> headscale.v1.ExpireApiKeyResponse
+	77, // 77: headscale.v1.HeadscaleService.ListApiKeys:output_type -> headscale.v1.ListApiKeysResponse
+	78, // 78: headscale.v1.HeadscaleService.DeleteApiKey:output_type -> headscale.v1.DeleteApiKeyResponse
+	79, // 79: headscale.v1.HeadscaleService.CreateOAuthClient:output_type -> headscale.v1.CreateOAuthClientResponse
+	80, // 80: headscale.v1.HeadscaleService.ListOAuthClients:output_type -> headscale.v1.ListOAuthClientsResponse
+	81, // 81: headscale.v1.HeadscaleService.DeleteOAuthClient:output_type -> headscale.v1.DeleteOAuthClientResponse
+	82, // 82: headscale.v1.HeadscaleService.GetPolicy:output_type -> headscale.v1.GetPolicyResponse
+	83, // 83: headscale.v1.HeadscaleService.SetPolicy:output_type -> headscale.v1.SetPolicyResponse
+	42, // [42:84] is the sub-list for method output_type
+	0,  // [0:42] is the sub-list for method input_type
 	0,  // [0:0] is the sub-list for extension type_name
 	0,  // [0:0] is the sub-list for extension extendee
 	0,  // [0:0] is the sub-list for field type_name
@@ -173,6 +264,7 @@ func file_headscale_v1_headscale_proto_init() {
 	file_headscale_v1_preauthkey_proto_init()
 	file_headscale_v1_node_proto_init()
 	file_headscale_v1_apikey_proto_init()
+	file_headscale_v1_oauthclient_proto_init()
 	file_headscale_v1_policy_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
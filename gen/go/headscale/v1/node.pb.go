@@ -25,10 +25,13 @@ const (
 type RegisterMethod int32
 
 const (
-	RegisterMethod_REGISTER_METHOD_UNSPECIFIED RegisterMethod = 0
-	RegisterMethod_REGISTER_METHOD_AUTH_KEY    RegisterMethod = 1
-	RegisterMethod_REGISTER_METHOD_CLI         RegisterMethod = 2
-	RegisterMethod_REGISTER_METHOD_OIDC        RegisterMethod = 3
+	RegisterMethod_REGISTER_METHOD_UNSPECIFIED  RegisterMethod = 0
+	RegisterMethod_REGISTER_METHOD_AUTH_KEY     RegisterMethod = 1
+	RegisterMethod_REGISTER_METHOD_CLI          RegisterMethod = 2
+	RegisterMethod_REGISTER_METHOD_OIDC         RegisterMethod = 3
+	RegisterMethod_REGISTER_METHOD_LOCAL        RegisterMethod = 4
+	RegisterMethod_REGISTER_METHOD_SAML         RegisterMethod = 5
+	RegisterMethod_REGISTER_METHOD_FORWARD_AUTH RegisterMethod = 6
 )
 
 // Enum value maps for RegisterMethod.
@@ -38,12 +41,18 @@ var (
 		1: "REGISTER_METHOD_AUTH_KEY",
 		2: "REGISTER_METHOD_CLI",
 		3: "REGISTER_METHOD_OIDC",
+		4: "REGISTER_METHOD_LOCAL",
+		5: "REGISTER_METHOD_SAML",
+		6: "REGISTER_METHOD_FORWARD_AUTH",
 	}
 	RegisterMethod_value = map[string]int32{
-		"REGISTER_METHOD_UNSPECIFIED": 0,
-		"REGISTER_METHOD_AUTH_KEY":    1,
-		"REGISTER_METHOD_CLI":         2,
-		"REGISTER_METHOD_OIDC":        3,
+		"REGISTER_METHOD_UNSPECIFIED":  0,
+		"REGISTER_METHOD_AUTH_KEY":     1,
+		"REGISTER_METHOD_CLI":          2,
+		"REGISTER_METHOD_OIDC":         3,
+		"REGISTER_METHOD_LOCAL":        4,
+		"REGISTER_METHOD_SAML":         5,
+		"REGISTER_METHOD_FORWARD_AUTH": 6,
 	}
 )
 
@@ -96,8 +105,13 @@ type Node struct {
 	ApprovedRoutes  []string               `protobuf:"bytes,23,rep,name=approved_routes,json=approvedRoutes,proto3" json:"approved_routes,omitempty"`
 	AvailableRoutes []string               `protobuf:"bytes,24,rep,name=available_routes,json=availableRoutes,proto3" json:"available_routes,omitempty"`
 	SubnetRoutes    []string               `protobuf:"bytes,25,rep,name=subnet_routes,json=subnetRoutes,proto3" json:"subnet_routes,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	Labels          map[string]string      `protobuf:"bytes,26,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Extra DNS names of the node, resolved by MagicDNS.
+	Aliases []string `protobuf:"bytes,27,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	// Details the client reported about its device.
+	Device        *NodeDevice `protobuf:"bytes,28,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Node) Reset() {
@@ -270,28 +284,51 @@ func (x *Node) GetSubnetRoutes() []string {
 	return nil
 }
 
-type RegisterNodeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
-	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+func (x *Node) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Node) GetAliases() []string {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+func (x *Node) GetDevice() *NodeDevice {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+type NodeService struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// tcp, udp, peerapi4, peerapi6 or peerapi-dns-proxy.
+	Proto         string `protobuf:"bytes,1,opt,name=proto,proto3" json:"proto,omitempty"`
+	Port          uint32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	Description   string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RegisterNodeRequest) Reset() {
-	*x = RegisterNodeRequest{}
+func (x *NodeService) Reset() {
+	*x = NodeService{}
 	mi := &file_headscale_v1_node_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RegisterNodeRequest) String() string {
+func (x *NodeService) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RegisterNodeRequest) ProtoMessage() {}
+func (*NodeService) ProtoMessage() {}
 
-func (x *RegisterNodeRequest) ProtoReflect() protoreflect.Message {
+func (x *NodeService) ProtoReflect() protoreflect.Message {
 	mi := &file_headscale_v1_node_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -303,46 +340,60 @@ func (x *RegisterNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RegisterNodeRequest.ProtoReflect.Descriptor instead.
-func (*RegisterNodeRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use NodeService.ProtoReflect.Descriptor instead.
+func (*NodeService) Descriptor() ([]byte, []int) {
 	return file_headscale_v1_node_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *RegisterNodeRequest) GetUser() string {
+func (x *NodeService) GetProto() string {
 	if x != nil {
-		return x.User
+		return x.Proto
 	}
 	return ""
 }
 
-func (x *RegisterNodeRequest) GetKey() string {
+func (x *NodeService) GetPort() uint32 {
 	if x != nil {
-		return x.Key
+		return x.Port
+	}
+	return 0
+}
+
+func (x *NodeService) GetDescription() string {
+	if x != nil {
+		return x.Description
 	}
 	return ""
 }
 
-type RegisterNodeResponse struct {
+type NodeNetInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	PreferredDerp int32                  `protobuf:"varint,1,opt,name=preferred_derp,json=preferredDerp,proto3" json:"preferred_derp,omitempty"`
+	// Reachability is unset if the client has not determined it.
+	WorkingUdp            *bool `protobuf:"varint,2,opt,name=working_udp,json=workingUdp,proto3,oneof" json:"working_udp,omitempty"`
+	WorkingIpv6           *bool `protobuf:"varint,3,opt,name=working_ipv6,json=workingIpv6,proto3,oneof" json:"working_ipv6,omitempty"`
+	MappingVariesByDestIp *bool `protobuf:"varint,4,opt,name=mapping_varies_by_dest_ip,json=mappingVariesByDestIp,proto3,oneof" json:"mapping_varies_by_dest_ip,omitempty"`
+	HairPinning           *bool `protobuf:"varint,5,opt,name=hair_pinning,json=hairPinning,proto3,oneof" json:"hair_pinning,omitempty"`
+	// wired, wifi or mobile.
+	LinkType      string `protobuf:"bytes,6,opt,name=link_type,json=linkType,proto3" json:"link_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RegisterNodeResponse) Reset() {
-	*x = RegisterNodeResponse{}
+func (x *NodeNetInfo) Reset() {
+	*x = NodeNetInfo{}
 	mi := &file_headscale_v1_node_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RegisterNodeResponse) String() string {
+func (x *NodeNetInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RegisterNodeResponse) ProtoMessage() {}
+func (*NodeNetInfo) ProtoMessage() {}
 
-func (x *RegisterNodeResponse) ProtoReflect() protoreflect.Message {
+func (x *NodeNetInfo) ProtoReflect() protoreflect.Message {
 	mi := &file_headscale_v1_node_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -354,39 +405,86 @@ func (x *RegisterNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RegisterNodeResponse.ProtoReflect.Descriptor instead.
-func (*RegisterNodeResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use NodeNetInfo.ProtoReflect.Descriptor instead.
+func (*NodeNetInfo) Descriptor() ([]byte, []int) {
 	return file_headscale_v1_node_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *RegisterNodeResponse) GetNode() *Node {
+func (x *NodeNetInfo) GetPreferredDerp() int32 {
 	if x != nil {
-		return x.Node
+		return x.PreferredDerp
 	}
-	return nil
+	return 0
 }
 
-type GetNodeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *NodeNetInfo) GetWorkingUdp() bool {
+	if x != nil && x.WorkingUdp != nil {
+		return *x.WorkingUdp
+	}
+	return false
 }
 
-func (x *GetNodeRequest) Reset() {
-	*x = GetNodeRequest{}
+func (x *NodeNetInfo) GetWorkingIpv6() bool {
+	if x != nil && x.WorkingIpv6 != nil {
+		return *x.WorkingIpv6
+	}
+	return false
+}
+
+func (x *NodeNetInfo) GetMappingVariesByDestIp() bool {
+	if x != nil && x.MappingVariesByDestIp != nil {
+		return *x.MappingVariesByDestIp
+	}
+	return false
+}
+
+func (x *NodeNetInfo) GetHairPinning() bool {
+	if x != nil && x.HairPinning != nil {
+		return *x.HairPinning
+	}
+	return false
+}
+
+func (x *NodeNetInfo) GetLinkType() string {
+	if x != nil {
+		return x.LinkType
+	}
+	return ""
+}
+
+type NodeDevice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Os            string                 `protobuf:"bytes,1,opt,name=os,proto3" json:"os,omitempty"`
+	OsVersion     string                 `protobuf:"bytes,2,opt,name=os_version,json=osVersion,proto3" json:"os_version,omitempty"`
+	ClientVersion string                 `protobuf:"bytes,3,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	Distro        string                 `protobuf:"bytes,4,opt,name=distro,proto3" json:"distro,omitempty"`
+	DistroVersion string                 `protobuf:"bytes,5,opt,name=distro_version,json=distroVersion,proto3" json:"distro_version,omitempty"`
+	DeviceModel   string                 `protobuf:"bytes,6,opt,name=device_model,json=deviceModel,proto3" json:"device_model,omitempty"`
+	Machine       string                 `protobuf:"bytes,7,opt,name=machine,proto3" json:"machine,omitempty"`
+	Package       string                 `protobuf:"bytes,8,opt,name=package,proto3" json:"package,omitempty"`
+	NetInfo       *NodeNetInfo           `protobuf:"bytes,9,opt,name=net_info,json=netInfo,proto3" json:"net_info,omitempty"`
+	Services      []*NodeService         `protobuf:"bytes,10,rep,name=services,proto3" json:"services,omitempty"`
+	// Capability version of the client.
+	CapabilityVersion int32    `protobuf:"varint,11,opt,name=capability_version,json=capabilityVersion,proto3" json:"capability_version,omitempty"`
+	Endpoints         []string `protobuf:"bytes,12,rep,name=endpoints,proto3" json:"endpoints,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *NodeDevice) Reset() {
+	*x = NodeDevice{}
 	mi := &file_headscale_v1_node_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetNodeRequest) String() string {
+func (x *NodeDevice) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetNodeRequest) ProtoMessage() {}
+func (*NodeDevice) ProtoMessage() {}
 
-func (x *GetNodeRequest) ProtoReflect() protoreflect.Message {
+func (x *NodeDevice) ProtoReflect() protoreflect.Message {
 	mi := &file_headscale_v1_node_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -398,39 +496,120 @@ func (x *GetNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetNodeRequest.ProtoReflect.Descriptor instead.
-func (*GetNodeRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use NodeDevice.ProtoReflect.Descriptor instead.
+func (*NodeDevice) Descriptor() ([]byte, []int) {
 	return file_headscale_v1_node_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *GetNodeRequest) GetNodeId() uint64 {
+func (x *NodeDevice) GetOs() string {
 	if x != nil {
-		return x.NodeId
+		return x.Os
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetOsVersion() string {
+	if x != nil {
+		return x.OsVersion
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetClientVersion() string {
+	if x != nil {
+		return x.ClientVersion
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetDistroVersion() string {
+	if x != nil {
+		return x.DistroVersion
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetDeviceModel() string {
+	if x != nil {
+		return x.DeviceModel
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetMachine() string {
+	if x != nil {
+		return x.Machine
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetPackage() string {
+	if x != nil {
+		return x.Package
+	}
+	return ""
+}
+
+func (x *NodeDevice) GetNetInfo() *NodeNetInfo {
+	if x != nil {
+		return x.NetInfo
+	}
+	return nil
+}
+
+func (x *NodeDevice) GetServices() []*NodeService {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *NodeDevice) GetCapabilityVersion() int32 {
+	if x != nil {
+		return x.CapabilityVersion
 	}
 	return 0
 }
 
-type GetNodeResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+func (x *NodeDevice) GetEndpoints() []string {
+	if x != nil {
+		return x.Endpoints
+	}
+	return nil
+}
+
+type RegisterNodeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	User  string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Key   string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// Optional addresses to give the node instead of allocating them.
+	Ipv4          string `protobuf:"bytes,3,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Ipv6          string `protobuf:"bytes,4,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetNodeResponse) Reset() {
-	*x = GetNodeResponse{}
+func (x *RegisterNodeRequest) Reset() {
+	*x = RegisterNodeRequest{}
 	mi := &file_headscale_v1_node_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetNodeResponse) String() string {
+func (x *RegisterNodeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetNodeResponse) ProtoMessage() {}
+func (*RegisterNodeRequest) ProtoMessage() {}
 
-func (x *GetNodeResponse) ProtoReflect() protoreflect.Message {
+func (x *RegisterNodeRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_headscale_v1_node_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -442,40 +621,60 @@ func (x *GetNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetNodeResponse.ProtoReflect.Descriptor instead.
-func (*GetNodeResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use RegisterNodeRequest.ProtoReflect.Descriptor instead.
+func (*RegisterNodeRequest) Descriptor() ([]byte, []int) {
 	return file_headscale_v1_node_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *GetNodeResponse) GetNode() *Node {
+func (x *RegisterNodeRequest) GetUser() string {
 	if x != nil {
-		return x.Node
+		return x.User
 	}
-	return nil
+	return ""
 }
 
-type SetTagsRequest struct {
+func (x *RegisterNodeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetIpv4() string {
+	if x != nil {
+		return x.Ipv4
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetIpv6() string {
+	if x != nil {
+		return x.Ipv6
+	}
+	return ""
+}
+
+type RegisterNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetTagsRequest) Reset() {
-	*x = SetTagsRequest{}
+func (x *RegisterNodeResponse) Reset() {
+	*x = RegisterNodeResponse{}
 	mi := &file_headscale_v1_node_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetTagsRequest) String() string {
+func (x *RegisterNodeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetTagsRequest) ProtoMessage() {}
+func (*RegisterNodeResponse) ProtoMessage() {}
 
-func (x *SetTagsRequest) ProtoReflect() protoreflect.Message {
+func (x *RegisterNodeResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_headscale_v1_node_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -487,46 +686,39 @@ func (x *SetTagsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetTagsRequest.ProtoReflect.Descriptor instead.
-func (*SetTagsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use RegisterNodeResponse.ProtoReflect.Descriptor instead.
+func (*RegisterNodeResponse) Descriptor() ([]byte, []int) {
 	return file_headscale_v1_node_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *SetTagsRequest) GetNodeId() uint64 {
-	if x != nil {
-		return x.NodeId
-	}
-	return 0
-}
-
-func (x *SetTagsRequest) GetTags() []string {
+func (x *RegisterNodeResponse) GetNode() *Node {
 	if x != nil {
-		return x.Tags
+		return x.Node
 	}
 	return nil
 }
 
-type SetTagsResponse struct {
+type GetNodeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetTagsResponse) Reset() {
-	*x = SetTagsResponse{}
+func (x *GetNodeRequest) Reset() {
+	*x = GetNodeRequest{}
 	mi := &file_headscale_v1_node_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetTagsResponse) String() string {
+func (x *GetNodeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetTagsResponse) ProtoMessage() {}
+func (*GetNodeRequest) ProtoMessage() {}
 
-func (x *SetTagsResponse) ProtoReflect() protoreflect.Message {
+func (x *GetNodeRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_headscale_v1_node_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -538,40 +730,39 @@ func (x *SetTagsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetTagsResponse.ProtoReflect.Descriptor instead.
-func (*SetTagsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetNodeRequest.ProtoReflect.Descriptor instead.
+func (*GetNodeRequest) Descriptor() ([]byte, []int) {
 	return file_headscale_v1_node_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *SetTagsResponse) GetNode() *Node {
+func (x *GetNodeRequest) GetNodeId() uint64 {
 	if x != nil {
-		return x.Node
+		return x.NodeId
 	}
-	return nil
+	return 0
 }
 
-type SetApprovedRoutesRequest struct {
+type GetNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Routes        []string               `protobuf:"bytes,2,rep,name=routes,proto3" json:"routes,omitempty"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetApprovedRoutesRequest) Reset() {
-	*x = SetApprovedRoutesRequest{}
+func (x *GetNodeResponse) Reset() {
+	*x = GetNodeResponse{}
 	mi := &file_headscale_v1_node_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetApprovedRoutesRequest) String() string {
+func (x *GetNodeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetApprovedRoutesRequest) ProtoMessage() {}
+func (*GetNodeResponse) ProtoMessage() {}
 
-func (x *SetApprovedRoutesRequest) ProtoReflect() protoreflect.Message {
+func (x *GetNodeResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_headscale_v1_node_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -583,47 +774,93 @@ func (x *SetApprovedRoutesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetApprovedRoutesRequest.ProtoReflect.Descriptor instead.
-func (*SetApprovedRoutesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetNodeResponse.ProtoReflect.Descriptor instead.
+func (*GetNodeResponse) Descriptor() ([]byte, []int) {
 	return file_headscale_v1_node_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *SetApprovedRoutesRequest) GetNodeId() uint64 {
+func (x *GetNodeResponse) GetNode() *Node {
 	if x != nil {
-		return x.NodeId
+		return x.Node
+	}
+	return nil
+}
+
+type SetNodeLabelsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	NodeId uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	// Labels to add or change, other labels are kept.
+	Labels        map[string]string `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNodeLabelsRequest) Reset() {
+	*x = SetNodeLabelsRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNodeLabelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNodeLabelsRequest) ProtoMessage() {}
+
+func (x *SetNodeLabelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNodeLabelsRequest.ProtoReflect.Descriptor instead.
+func (*SetNodeLabelsRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SetNodeLabelsRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
 	}
 	return 0
 }
 
-func (x *SetApprovedRoutesRequest) GetRoutes() []string {
+func (x *SetNodeLabelsRequest) GetLabels() map[string]string {
 	if x != nil {
-		return x.Routes
+		return x.Labels
 	}
 	return nil
 }
 
-type SetApprovedRoutesResponse struct {
+type SetNodeLabelsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetApprovedRoutesResponse) Reset() {
-	*x = SetApprovedRoutesResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[8]
+func (x *SetNodeLabelsResponse) Reset() {
+	*x = SetNodeLabelsResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetApprovedRoutesResponse) String() string {
+func (x *SetNodeLabelsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetApprovedRoutesResponse) ProtoMessage() {}
+func (*SetNodeLabelsResponse) ProtoMessage() {}
 
-func (x *SetApprovedRoutesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[8]
+func (x *SetNodeLabelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -634,40 +871,138 @@ func (x *SetApprovedRoutesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetApprovedRoutesResponse.ProtoReflect.Descriptor instead.
-func (*SetApprovedRoutesResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use SetNodeLabelsResponse.ProtoReflect.Descriptor instead.
+func (*SetNodeLabelsResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *SetApprovedRoutesResponse) GetNode() *Node {
+func (x *SetNodeLabelsResponse) GetNode() *Node {
 	if x != nil {
 		return x.Node
 	}
 	return nil
 }
 
-type DeleteNodeRequest struct {
+type SetNodeAliasesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	NodeId uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	// Aliases replacing the current ones, none removes them.
+	Aliases       []string `protobuf:"bytes,2,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNodeAliasesRequest) Reset() {
+	*x = SetNodeAliasesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNodeAliasesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNodeAliasesRequest) ProtoMessage() {}
+
+func (x *SetNodeAliasesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNodeAliasesRequest.ProtoReflect.Descriptor instead.
+func (*SetNodeAliasesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SetNodeAliasesRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *SetNodeAliasesRequest) GetAliases() []string {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+type SetNodeAliasesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNodeAliasesResponse) Reset() {
+	*x = SetNodeAliasesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNodeAliasesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNodeAliasesResponse) ProtoMessage() {}
+
+func (x *SetNodeAliasesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNodeAliasesResponse.ProtoReflect.Descriptor instead.
+func (*SetNodeAliasesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SetNodeAliasesResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type DeleteNodeLabelsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Keys          []string               `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteNodeRequest) Reset() {
-	*x = DeleteNodeRequest{}
-	mi := &file_headscale_v1_node_proto_msgTypes[9]
+func (x *DeleteNodeLabelsRequest) Reset() {
+	*x = DeleteNodeLabelsRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteNodeRequest) String() string {
+func (x *DeleteNodeLabelsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteNodeRequest) ProtoMessage() {}
+func (*DeleteNodeLabelsRequest) ProtoMessage() {}
 
-func (x *DeleteNodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[9]
+func (x *DeleteNodeLabelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -678,39 +1013,47 @@ func (x *DeleteNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteNodeRequest.ProtoReflect.Descriptor instead.
-func (*DeleteNodeRequest) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use DeleteNodeLabelsRequest.ProtoReflect.Descriptor instead.
+func (*DeleteNodeLabelsRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *DeleteNodeRequest) GetNodeId() uint64 {
+func (x *DeleteNodeLabelsRequest) GetNodeId() uint64 {
 	if x != nil {
 		return x.NodeId
 	}
 	return 0
 }
 
-type DeleteNodeResponse struct {
+func (x *DeleteNodeLabelsRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type DeleteNodeLabelsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteNodeResponse) Reset() {
-	*x = DeleteNodeResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[10]
+func (x *DeleteNodeLabelsResponse) Reset() {
+	*x = DeleteNodeLabelsResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteNodeResponse) String() string {
+func (x *DeleteNodeLabelsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteNodeResponse) ProtoMessage() {}
+func (*DeleteNodeLabelsResponse) ProtoMessage() {}
 
-func (x *DeleteNodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[10]
+func (x *DeleteNodeLabelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -721,33 +1064,1337 @@ func (x *DeleteNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteNodeResponse.ProtoReflect.Descriptor instead.
-func (*DeleteNodeResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use DeleteNodeLabelsResponse.ProtoReflect.Descriptor instead.
+func (*DeleteNodeLabelsResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{13}
 }
 
-type ExpireNodeRequest struct {
+func (x *DeleteNodeLabelsResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type SetTagsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ExpireNodeRequest) Reset() {
-	*x = ExpireNodeRequest{}
-	mi := &file_headscale_v1_node_proto_msgTypes[11]
+func (x *SetTagsRequest) Reset() {
+	*x = SetTagsRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ExpireNodeRequest) String() string {
+func (x *SetTagsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExpireNodeRequest) ProtoMessage() {}
+func (*SetTagsRequest) ProtoMessage() {}
 
-func (x *ExpireNodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[11]
+func (x *SetTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTagsRequest.ProtoReflect.Descriptor instead.
+func (*SetTagsRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SetTagsRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *SetTagsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type SetTagsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetTagsResponse) Reset() {
+	*x = SetTagsResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetTagsResponse) ProtoMessage() {}
+
+func (x *SetTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTagsResponse.ProtoReflect.Descriptor instead.
+func (*SetTagsResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SetTagsResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type SetApprovedRoutesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Routes        []string               `protobuf:"bytes,2,rep,name=routes,proto3" json:"routes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetApprovedRoutesRequest) Reset() {
+	*x = SetApprovedRoutesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetApprovedRoutesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetApprovedRoutesRequest) ProtoMessage() {}
+
+func (x *SetApprovedRoutesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetApprovedRoutesRequest.ProtoReflect.Descriptor instead.
+func (*SetApprovedRoutesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetApprovedRoutesRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *SetApprovedRoutesRequest) GetRoutes() []string {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+type SetApprovedRoutesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetApprovedRoutesResponse) Reset() {
+	*x = SetApprovedRoutesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetApprovedRoutesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetApprovedRoutesResponse) ProtoMessage() {}
+
+func (x *SetApprovedRoutesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetApprovedRoutesResponse.ProtoReflect.Descriptor instead.
+func (*SetApprovedRoutesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SetApprovedRoutesResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type DeleteNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteNodeRequest) Reset() {
+	*x = DeleteNodeRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteNodeRequest) ProtoMessage() {}
+
+func (x *DeleteNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteNodeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteNodeRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DeleteNodeRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+type DeleteNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteNodeResponse) Reset() {
+	*x = DeleteNodeResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteNodeResponse) ProtoMessage() {}
+
+func (x *DeleteNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteNodeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteNodeResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{19}
+}
+
+type ExpireNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireNodeRequest) Reset() {
+	*x = ExpireNodeRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireNodeRequest) ProtoMessage() {}
+
+func (x *ExpireNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireNodeRequest.ProtoReflect.Descriptor instead.
+func (*ExpireNodeRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ExpireNodeRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+type ExpireNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireNodeResponse) Reset() {
+	*x = ExpireNodeResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireNodeResponse) ProtoMessage() {}
+
+func (x *ExpireNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireNodeResponse.ProtoReflect.Descriptor instead.
+func (*ExpireNodeResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ExpireNodeResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type RenameNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	NewName       string                 `protobuf:"bytes,2,opt,name=new_name,json=newName,proto3" json:"new_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameNodeRequest) Reset() {
+	*x = RenameNodeRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameNodeRequest) ProtoMessage() {}
+
+func (x *RenameNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameNodeRequest.ProtoReflect.Descriptor instead.
+func (*RenameNodeRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RenameNodeRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *RenameNodeRequest) GetNewName() string {
+	if x != nil {
+		return x.NewName
+	}
+	return ""
+}
+
+type RenameNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameNodeResponse) Reset() {
+	*x = RenameNodeResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameNodeResponse) ProtoMessage() {}
+
+func (x *RenameNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameNodeResponse.ProtoReflect.Descriptor instead.
+func (*RenameNodeResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RenameNodeResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type ListNodesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	User  string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	// Comma separated requirements like env=prod, env!=prod, owner or
+	// !owner, all of which have to match.
+	LabelSelector string `protobuf:"bytes,2,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	// Nodes having all of these tags, forced or valid.
+	Tags           []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	Online         *bool                  `protobuf:"varint,4,opt,name=online,proto3,oneof" json:"online,omitempty"`
+	LastSeenAfter  *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_seen_after,json=lastSeenAfter,proto3" json:"last_seen_after,omitempty"`
+	LastSeenBefore *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_seen_before,json=lastSeenBefore,proto3" json:"last_seen_before,omitempty"`
+	// Operating system reported by the client, like linux or windows.
+	Os string `protobuf:"bytes,7,opt,name=os,proto3" json:"os,omitempty"`
+	// Prefix of the Tailscale version of the client, like 1.80.
+	ClientVersion string `protobuf:"bytes,8,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`
+	Expired       *bool  `protobuf:"varint,9,opt,name=expired,proto3,oneof" json:"expired,omitempty"`
+	// Substring of the hostname or the given name, ignoring case.
+	Name string `protobuf:"bytes,10,opt,name=name,proto3" json:"name,omitempty"`
+	// One of id (default), name, hostname, user, last_seen, created_at
+	// and expiry.
+	SortBy     string `protobuf:"bytes,11,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	Descending bool   `protobuf:"varint,12,opt,name=descending,proto3" json:"descending,omitempty"`
+	// Maximum number of nodes to return, all if zero.
+	PageSize uint32 `protobuf:"varint,13,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response, with the same filters and
+	// sorting.
+	PageToken     string `protobuf:"bytes,14,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodesRequest) Reset() {
+	*x = ListNodesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodesRequest) ProtoMessage() {}
+
+func (x *ListNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodesRequest.ProtoReflect.Descriptor instead.
+func (*ListNodesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListNodesRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ListNodesRequest) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *ListNodesRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListNodesRequest) GetOnline() bool {
+	if x != nil && x.Online != nil {
+		return *x.Online
+	}
+	return false
+}
+
+func (x *ListNodesRequest) GetLastSeenAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeenAfter
+	}
+	return nil
+}
+
+func (x *ListNodesRequest) GetLastSeenBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeenBefore
+	}
+	return nil
+}
+
+func (x *ListNodesRequest) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *ListNodesRequest) GetClientVersion() string {
+	if x != nil {
+		return x.ClientVersion
+	}
+	return ""
+}
+
+func (x *ListNodesRequest) GetExpired() bool {
+	if x != nil && x.Expired != nil {
+		return *x.Expired
+	}
+	return false
+}
+
+func (x *ListNodesRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListNodesRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListNodesRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *ListNodesRequest) GetPageSize() uint32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListNodesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListNodesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Nodes []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// Token for the next page, empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNodesResponse) Reset() {
+	*x = ListNodesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNodesResponse) ProtoMessage() {}
+
+func (x *ListNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNodesResponse.ProtoReflect.Descriptor instead.
+func (*ListNodesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListNodesResponse) GetNodes() []*Node {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *ListNodesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type MoveNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	User          uint64                 `protobuf:"varint,2,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveNodeRequest) Reset() {
+	*x = MoveNodeRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveNodeRequest) ProtoMessage() {}
+
+func (x *MoveNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveNodeRequest.ProtoReflect.Descriptor instead.
+func (*MoveNodeRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *MoveNodeRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *MoveNodeRequest) GetUser() uint64 {
+	if x != nil {
+		return x.User
+	}
+	return 0
+}
+
+type MoveNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveNodeResponse) Reset() {
+	*x = MoveNodeResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveNodeResponse) ProtoMessage() {}
+
+func (x *MoveNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveNodeResponse.ProtoReflect.Descriptor instead.
+func (*MoveNodeResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *MoveNodeResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type DebugCreateNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Routes        []string               `protobuf:"bytes,4,rep,name=routes,proto3" json:"routes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DebugCreateNodeRequest) Reset() {
+	*x = DebugCreateNodeRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DebugCreateNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DebugCreateNodeRequest) ProtoMessage() {}
+
+func (x *DebugCreateNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DebugCreateNodeRequest.ProtoReflect.Descriptor instead.
+func (*DebugCreateNodeRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DebugCreateNodeRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *DebugCreateNodeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *DebugCreateNodeRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DebugCreateNodeRequest) GetRoutes() []string {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+type DebugCreateNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DebugCreateNodeResponse) Reset() {
+	*x = DebugCreateNodeResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DebugCreateNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DebugCreateNodeResponse) ProtoMessage() {}
+
+func (x *DebugCreateNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DebugCreateNodeResponse.ProtoReflect.Descriptor instead.
+func (*DebugCreateNodeResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *DebugCreateNodeResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type SetNodeIPsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	NodeId uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	// Empty addresses are left unchanged.
+	Ipv4          string `protobuf:"bytes,2,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Ipv6          string `protobuf:"bytes,3,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNodeIPsRequest) Reset() {
+	*x = SetNodeIPsRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNodeIPsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNodeIPsRequest) ProtoMessage() {}
+
+func (x *SetNodeIPsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNodeIPsRequest.ProtoReflect.Descriptor instead.
+func (*SetNodeIPsRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *SetNodeIPsRequest) GetNodeId() uint64 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *SetNodeIPsRequest) GetIpv4() string {
+	if x != nil {
+		return x.Ipv4
+	}
+	return ""
+}
+
+func (x *SetNodeIPsRequest) GetIpv6() string {
+	if x != nil {
+		return x.Ipv6
+	}
+	return ""
+}
+
+type SetNodeIPsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNodeIPsResponse) Reset() {
+	*x = SetNodeIPsResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNodeIPsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNodeIPsResponse) ProtoMessage() {}
+
+func (x *SetNodeIPsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNodeIPsResponse.ProtoReflect.Descriptor instead.
+func (*SetNodeIPsResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *SetNodeIPsResponse) GetNode() *Node {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+type BackfillNodeIPsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Confirmed     bool                   `protobuf:"varint,1,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackfillNodeIPsRequest) Reset() {
+	*x = BackfillNodeIPsRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackfillNodeIPsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackfillNodeIPsRequest) ProtoMessage() {}
+
+func (x *BackfillNodeIPsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackfillNodeIPsRequest.ProtoReflect.Descriptor instead.
+func (*BackfillNodeIPsRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *BackfillNodeIPsRequest) GetConfirmed() bool {
+	if x != nil {
+		return x.Confirmed
+	}
+	return false
+}
+
+type BackfillNodeIPsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Changes       []string               `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackfillNodeIPsResponse) Reset() {
+	*x = BackfillNodeIPsResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackfillNodeIPsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackfillNodeIPsResponse) ProtoMessage() {}
+
+func (x *BackfillNodeIPsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackfillNodeIPsResponse.ProtoReflect.Descriptor instead.
+func (*BackfillNodeIPsResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *BackfillNodeIPsResponse) GetChanges() []string {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type RenumberNodesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty prefixes keep the configured prefix, nodes outside of it are
+	// moved into it.
+	PrefixV4 string `protobuf:"bytes,1,opt,name=prefix_v4,json=prefixV4,proto3" json:"prefix_v4,omitempty"`
+	PrefixV6 string `protobuf:"bytes,2,opt,name=prefix_v6,json=prefixV6,proto3" json:"prefix_v6,omitempty"`
+	// New addresses of nodes by their current address.
+	Mapping       map[string]string `protobuf:"bytes,3,rep,name=mapping,proto3" json:"mapping,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DryRun        bool              `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenumberNodesRequest) Reset() {
+	*x = RenumberNodesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenumberNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenumberNodesRequest) ProtoMessage() {}
+
+func (x *RenumberNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenumberNodesRequest.ProtoReflect.Descriptor instead.
+func (*RenumberNodesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RenumberNodesRequest) GetPrefixV4() string {
+	if x != nil {
+		return x.PrefixV4
+	}
+	return ""
+}
+
+func (x *RenumberNodesRequest) GetPrefixV6() string {
+	if x != nil {
+		return x.PrefixV6
+	}
+	return ""
+}
+
+func (x *RenumberNodesRequest) GetMapping() map[string]string {
+	if x != nil {
+		return x.Mapping
+	}
+	return nil
+}
+
+func (x *RenumberNodesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type RenumberNodesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Changes       []string               `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	PolicyChanges []string               `protobuf:"bytes,2,rep,name=policy_changes,json=policyChanges,proto3" json:"policy_changes,omitempty"`
+	PolicyUpdated bool                   `protobuf:"varint,3,opt,name=policy_updated,json=policyUpdated,proto3" json:"policy_updated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenumberNodesResponse) Reset() {
+	*x = RenumberNodesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenumberNodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenumberNodesResponse) ProtoMessage() {}
+
+func (x *RenumberNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenumberNodesResponse.ProtoReflect.Descriptor instead.
+func (*RenumberNodesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RenumberNodesResponse) GetChanges() []string {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *RenumberNodesResponse) GetPolicyChanges() []string {
+	if x != nil {
+		return x.PolicyChanges
+	}
+	return nil
+}
+
+func (x *RenumberNodesResponse) GetPolicyUpdated() bool {
+	if x != nil {
+		return x.PolicyUpdated
+	}
+	return false
+}
+
+type ExpireNodesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Selector      string                 `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	DryRun        bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireNodesRequest) Reset() {
+	*x = ExpireNodesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireNodesRequest) ProtoMessage() {}
+
+func (x *ExpireNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireNodesRequest.ProtoReflect.Descriptor instead.
+func (*ExpireNodesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ExpireNodesRequest) GetSelector() string {
+	if x != nil {
+		return x.Selector
+	}
+	return ""
+}
+
+func (x *ExpireNodesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ExpireNodesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireNodesResponse) Reset() {
+	*x = ExpireNodesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireNodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireNodesResponse) ProtoMessage() {}
+
+func (x *ExpireNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireNodesResponse.ProtoReflect.Descriptor instead.
+func (*ExpireNodesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ExpireNodesResponse) GetNodes() []*Node {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type DeleteNodesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Selector      string                 `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	DryRun        bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteNodesRequest) Reset() {
+	*x = DeleteNodesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteNodesRequest) ProtoMessage() {}
+
+func (x *DeleteNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -758,40 +2405,47 @@ func (x *ExpireNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExpireNodeRequest.ProtoReflect.Descriptor instead.
-func (*ExpireNodeRequest) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use DeleteNodesRequest.ProtoReflect.Descriptor instead.
+func (*DeleteNodesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *ExpireNodeRequest) GetNodeId() uint64 {
+func (x *DeleteNodesRequest) GetSelector() string {
 	if x != nil {
-		return x.NodeId
+		return x.Selector
 	}
-	return 0
+	return ""
 }
 
-type ExpireNodeResponse struct {
+func (x *DeleteNodesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type DeleteNodesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Nodes         []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ExpireNodeResponse) Reset() {
-	*x = ExpireNodeResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[12]
+func (x *DeleteNodesResponse) Reset() {
+	*x = DeleteNodesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ExpireNodeResponse) String() string {
+func (x *DeleteNodesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExpireNodeResponse) ProtoMessage() {}
+func (*DeleteNodesResponse) ProtoMessage() {}
 
-func (x *ExpireNodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[12]
+func (x *DeleteNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -802,41 +2456,42 @@ func (x *ExpireNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExpireNodeResponse.ProtoReflect.Descriptor instead.
-func (*ExpireNodeResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use DeleteNodesResponse.ProtoReflect.Descriptor instead.
+func (*DeleteNodesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{39}
 }
 
-func (x *ExpireNodeResponse) GetNode() *Node {
+func (x *DeleteNodesResponse) GetNodes() []*Node {
 	if x != nil {
-		return x.Node
+		return x.Nodes
 	}
 	return nil
 }
 
-type RenameNodeRequest struct {
+type SetNodesTagsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	NewName       string                 `protobuf:"bytes,2,opt,name=new_name,json=newName,proto3" json:"new_name,omitempty"`
+	Selector      string                 `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	DryRun        bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RenameNodeRequest) Reset() {
-	*x = RenameNodeRequest{}
-	mi := &file_headscale_v1_node_proto_msgTypes[13]
+func (x *SetNodesTagsRequest) Reset() {
+	*x = SetNodesTagsRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RenameNodeRequest) String() string {
+func (x *SetNodesTagsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RenameNodeRequest) ProtoMessage() {}
+func (*SetNodesTagsRequest) ProtoMessage() {}
 
-func (x *RenameNodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[13]
+func (x *SetNodesTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -847,47 +2502,54 @@ func (x *RenameNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RenameNodeRequest.ProtoReflect.Descriptor instead.
-func (*RenameNodeRequest) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use SetNodesTagsRequest.ProtoReflect.Descriptor instead.
+func (*SetNodesTagsRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{40}
 }
 
-func (x *RenameNodeRequest) GetNodeId() uint64 {
+func (x *SetNodesTagsRequest) GetSelector() string {
 	if x != nil {
-		return x.NodeId
+		return x.Selector
 	}
-	return 0
+	return ""
 }
 
-func (x *RenameNodeRequest) GetNewName() string {
+func (x *SetNodesTagsRequest) GetTags() []string {
 	if x != nil {
-		return x.NewName
+		return x.Tags
 	}
-	return ""
+	return nil
 }
 
-type RenameNodeResponse struct {
+func (x *SetNodesTagsRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type SetNodesTagsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Nodes         []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RenameNodeResponse) Reset() {
-	*x = RenameNodeResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[14]
+func (x *SetNodesTagsResponse) Reset() {
+	*x = SetNodesTagsResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RenameNodeResponse) String() string {
+func (x *SetNodesTagsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RenameNodeResponse) ProtoMessage() {}
+func (*SetNodesTagsResponse) ProtoMessage() {}
 
-func (x *RenameNodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[14]
+func (x *SetNodesTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -898,40 +2560,42 @@ func (x *RenameNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RenameNodeResponse.ProtoReflect.Descriptor instead.
-func (*RenameNodeResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use SetNodesTagsResponse.ProtoReflect.Descriptor instead.
+func (*SetNodesTagsResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{41}
 }
 
-func (x *RenameNodeResponse) GetNode() *Node {
+func (x *SetNodesTagsResponse) GetNodes() []*Node {
 	if x != nil {
-		return x.Node
+		return x.Nodes
 	}
 	return nil
 }
 
-type ListNodesRequest struct {
+type MoveNodesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Selector      string                 `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	User          uint64                 `protobuf:"varint,2,opt,name=user,proto3" json:"user,omitempty"`
+	DryRun        bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListNodesRequest) Reset() {
-	*x = ListNodesRequest{}
-	mi := &file_headscale_v1_node_proto_msgTypes[15]
+func (x *MoveNodesRequest) Reset() {
+	*x = MoveNodesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListNodesRequest) String() string {
+func (x *MoveNodesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListNodesRequest) ProtoMessage() {}
+func (*MoveNodesRequest) ProtoMessage() {}
 
-func (x *ListNodesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[15]
+func (x *MoveNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -942,40 +2606,54 @@ func (x *ListNodesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListNodesRequest.ProtoReflect.Descriptor instead.
-func (*ListNodesRequest) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use MoveNodesRequest.ProtoReflect.Descriptor instead.
+func (*MoveNodesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{42}
 }
 
-func (x *ListNodesRequest) GetUser() string {
+func (x *MoveNodesRequest) GetSelector() string {
 	if x != nil {
-		return x.User
+		return x.Selector
 	}
 	return ""
 }
 
-type ListNodesResponse struct {
+func (x *MoveNodesRequest) GetUser() uint64 {
+	if x != nil {
+		return x.User
+	}
+	return 0
+}
+
+func (x *MoveNodesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type MoveNodesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Nodes         []*Node                `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListNodesResponse) Reset() {
-	*x = ListNodesResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[16]
+func (x *MoveNodesResponse) Reset() {
+	*x = MoveNodesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListNodesResponse) String() string {
+func (x *MoveNodesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListNodesResponse) ProtoMessage() {}
+func (*MoveNodesResponse) ProtoMessage() {}
 
-func (x *ListNodesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[16]
+func (x *MoveNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -986,41 +2664,45 @@ func (x *ListNodesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListNodesResponse.ProtoReflect.Descriptor instead.
-func (*ListNodesResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use MoveNodesResponse.ProtoReflect.Descriptor instead.
+func (*MoveNodesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{43}
 }
 
-func (x *ListNodesResponse) GetNodes() []*Node {
+func (x *MoveNodesResponse) GetNodes() []*Node {
 	if x != nil {
 		return x.Nodes
 	}
 	return nil
 }
 
-type MoveNodeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        uint64                 `protobuf:"varint,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	User          uint64                 `protobuf:"varint,2,opt,name=user,proto3" json:"user,omitempty"`
+type StaleNode struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Node  *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// expire or delete
+	Action string `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	// When the node is cleaned up, in the past if it is done on the next
+	// run.
+	Due           *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=due,proto3" json:"due,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MoveNodeRequest) Reset() {
-	*x = MoveNodeRequest{}
-	mi := &file_headscale_v1_node_proto_msgTypes[17]
+func (x *StaleNode) Reset() {
+	*x = StaleNode{}
+	mi := &file_headscale_v1_node_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MoveNodeRequest) String() string {
+func (x *StaleNode) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MoveNodeRequest) ProtoMessage() {}
+func (*StaleNode) ProtoMessage() {}
 
-func (x *MoveNodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[17]
+func (x *StaleNode) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1031,47 +2713,53 @@ func (x *MoveNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MoveNodeRequest.ProtoReflect.Descriptor instead.
-func (*MoveNodeRequest) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use StaleNode.ProtoReflect.Descriptor instead.
+func (*StaleNode) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{44}
 }
 
-func (x *MoveNodeRequest) GetNodeId() uint64 {
+func (x *StaleNode) GetNode() *Node {
 	if x != nil {
-		return x.NodeId
+		return x.Node
 	}
-	return 0
+	return nil
 }
 
-func (x *MoveNodeRequest) GetUser() uint64 {
+func (x *StaleNode) GetAction() string {
 	if x != nil {
-		return x.User
+		return x.Action
 	}
-	return 0
+	return ""
 }
 
-type MoveNodeResponse struct {
+func (x *StaleNode) GetDue() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Due
+	}
+	return nil
+}
+
+type ListStaleNodesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MoveNodeResponse) Reset() {
-	*x = MoveNodeResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[18]
+func (x *ListStaleNodesRequest) Reset() {
+	*x = ListStaleNodesRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MoveNodeResponse) String() string {
+func (x *ListStaleNodesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MoveNodeResponse) ProtoMessage() {}
+func (*ListStaleNodesRequest) ProtoMessage() {}
 
-func (x *MoveNodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[18]
+func (x *ListStaleNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1082,43 +2770,35 @@ func (x *MoveNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MoveNodeResponse.ProtoReflect.Descriptor instead.
-func (*MoveNodeResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{18}
-}
-
-func (x *MoveNodeResponse) GetNode() *Node {
-	if x != nil {
-		return x.Node
-	}
-	return nil
+// Deprecated: Use ListStaleNodesRequest.ProtoReflect.Descriptor instead.
+func (*ListStaleNodesRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{45}
 }
 
-type DebugCreateNodeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
-	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	Routes        []string               `protobuf:"bytes,4,rep,name=routes,proto3" json:"routes,omitempty"`
+type ListStaleNodesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Nodes []*StaleNode           `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// If the cleanup runs, otherwise the report shows what it would do.
+	Enabled       bool `protobuf:"varint,2,opt,name=enabled,proto3" json:"enabled,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DebugCreateNodeRequest) Reset() {
-	*x = DebugCreateNodeRequest{}
-	mi := &file_headscale_v1_node_proto_msgTypes[19]
+func (x *ListStaleNodesResponse) Reset() {
+	*x = ListStaleNodesResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DebugCreateNodeRequest) String() string {
+func (x *ListStaleNodesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DebugCreateNodeRequest) ProtoMessage() {}
+func (*ListStaleNodesResponse) ProtoMessage() {}
 
-func (x *DebugCreateNodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[19]
+func (x *ListStaleNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1129,61 +2809,49 @@ func (x *DebugCreateNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DebugCreateNodeRequest.ProtoReflect.Descriptor instead.
-func (*DebugCreateNodeRequest) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{19}
-}
-
-func (x *DebugCreateNodeRequest) GetUser() string {
-	if x != nil {
-		return x.User
-	}
-	return ""
-}
-
-func (x *DebugCreateNodeRequest) GetKey() string {
-	if x != nil {
-		return x.Key
-	}
-	return ""
+// Deprecated: Use ListStaleNodesResponse.ProtoReflect.Descriptor instead.
+func (*ListStaleNodesResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{46}
 }
 
-func (x *DebugCreateNodeRequest) GetName() string {
+func (x *ListStaleNodesResponse) GetNodes() []*StaleNode {
 	if x != nil {
-		return x.Name
+		return x.Nodes
 	}
-	return ""
+	return nil
 }
 
-func (x *DebugCreateNodeRequest) GetRoutes() []string {
+func (x *ListStaleNodesResponse) GetEnabled() bool {
 	if x != nil {
-		return x.Routes
+		return x.Enabled
 	}
-	return nil
+	return false
 }
 
-type DebugCreateNodeResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Node          *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+type EphemeralDeletion struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Node  *Node                  `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// When the disconnected ephemeral node is deleted.
+	Due           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=due,proto3" json:"due,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DebugCreateNodeResponse) Reset() {
-	*x = DebugCreateNodeResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[20]
+func (x *EphemeralDeletion) Reset() {
+	*x = EphemeralDeletion{}
+	mi := &file_headscale_v1_node_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DebugCreateNodeResponse) String() string {
+func (x *EphemeralDeletion) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DebugCreateNodeResponse) ProtoMessage() {}
+func (*EphemeralDeletion) ProtoMessage() {}
 
-func (x *DebugCreateNodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[20]
+func (x *EphemeralDeletion) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1194,40 +2862,46 @@ func (x *DebugCreateNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DebugCreateNodeResponse.ProtoReflect.Descriptor instead.
-func (*DebugCreateNodeResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use EphemeralDeletion.ProtoReflect.Descriptor instead.
+func (*EphemeralDeletion) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *DebugCreateNodeResponse) GetNode() *Node {
+func (x *EphemeralDeletion) GetNode() *Node {
 	if x != nil {
 		return x.Node
 	}
 	return nil
 }
 
-type BackfillNodeIPsRequest struct {
+func (x *EphemeralDeletion) GetDue() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Due
+	}
+	return nil
+}
+
+type ListEphemeralDeletionsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Confirmed     bool                   `protobuf:"varint,1,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BackfillNodeIPsRequest) Reset() {
-	*x = BackfillNodeIPsRequest{}
-	mi := &file_headscale_v1_node_proto_msgTypes[21]
+func (x *ListEphemeralDeletionsRequest) Reset() {
+	*x = ListEphemeralDeletionsRequest{}
+	mi := &file_headscale_v1_node_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BackfillNodeIPsRequest) String() string {
+func (x *ListEphemeralDeletionsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BackfillNodeIPsRequest) ProtoMessage() {}
+func (*ListEphemeralDeletionsRequest) ProtoMessage() {}
 
-func (x *BackfillNodeIPsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[21]
+func (x *ListEphemeralDeletionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1238,40 +2912,33 @@ func (x *BackfillNodeIPsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BackfillNodeIPsRequest.ProtoReflect.Descriptor instead.
-func (*BackfillNodeIPsRequest) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{21}
-}
-
-func (x *BackfillNodeIPsRequest) GetConfirmed() bool {
-	if x != nil {
-		return x.Confirmed
-	}
-	return false
+// Deprecated: Use ListEphemeralDeletionsRequest.ProtoReflect.Descriptor instead.
+func (*ListEphemeralDeletionsRequest) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{48}
 }
 
-type BackfillNodeIPsResponse struct {
+type ListEphemeralDeletionsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Changes       []string               `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	Deletions     []*EphemeralDeletion   `protobuf:"bytes,1,rep,name=deletions,proto3" json:"deletions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BackfillNodeIPsResponse) Reset() {
-	*x = BackfillNodeIPsResponse{}
-	mi := &file_headscale_v1_node_proto_msgTypes[22]
+func (x *ListEphemeralDeletionsResponse) Reset() {
+	*x = ListEphemeralDeletionsResponse{}
+	mi := &file_headscale_v1_node_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BackfillNodeIPsResponse) String() string {
+func (x *ListEphemeralDeletionsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BackfillNodeIPsResponse) ProtoMessage() {}
+func (*ListEphemeralDeletionsResponse) ProtoMessage() {}
 
-func (x *BackfillNodeIPsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_headscale_v1_node_proto_msgTypes[22]
+func (x *ListEphemeralDeletionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_headscale_v1_node_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1282,14 +2949,14 @@ func (x *BackfillNodeIPsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BackfillNodeIPsResponse.ProtoReflect.Descriptor instead.
-func (*BackfillNodeIPsResponse) Descriptor() ([]byte, []int) {
-	return file_headscale_v1_node_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use ListEphemeralDeletionsResponse.ProtoReflect.Descriptor instead.
+func (*ListEphemeralDeletionsResponse) Descriptor() ([]byte, []int) {
+	return file_headscale_v1_node_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *BackfillNodeIPsResponse) GetChanges() []string {
+func (x *ListEphemeralDeletionsResponse) GetDeletions() []*EphemeralDeletion {
 	if x != nil {
-		return x.Changes
+		return x.Deletions
 	}
 	return nil
 }
@@ -1298,7 +2965,7 @@ var File_headscale_v1_node_proto protoreflect.FileDescriptor
 
 const file_headscale_v1_node_proto_rawDesc = "" +
 	"\n" +
-	"\x17headscale/v1/node.proto\x12\fheadscale.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1dheadscale/v1/preauthkey.proto\x1a\x17headscale/v1/user.proto\"\x98\x06\n" +
+	"\x17headscale/v1/node.proto\x12\fheadscale.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1dheadscale/v1/preauthkey.proto\x1a\x17headscale/v1/user.proto\"\xd7\a\n" +
 	"\x04Node\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1f\n" +
 	"\vmachine_key\x18\x02 \x01(\tR\n" +
@@ -1326,16 +2993,74 @@ const file_headscale_v1_node_proto_rawDesc = "" +
 	"\x06online\x18\x16 \x01(\bR\x06online\x12'\n" +
 	"\x0fapproved_routes\x18\x17 \x03(\tR\x0eapprovedRoutes\x12)\n" +
 	"\x10available_routes\x18\x18 \x03(\tR\x0favailableRoutes\x12#\n" +
-	"\rsubnet_routes\x18\x19 \x03(\tR\fsubnetRoutesJ\x04\b\t\x10\n" +
-	"J\x04\b\x0e\x10\x12\";\n" +
+	"\rsubnet_routes\x18\x19 \x03(\tR\fsubnetRoutes\x126\n" +
+	"\x06labels\x18\x1a \x03(\v2\x1e.headscale.v1.Node.LabelsEntryR\x06labels\x12\x18\n" +
+	"\aaliases\x18\x1b \x03(\tR\aaliases\x120\n" +
+	"\x06device\x18\x1c \x01(\v2\x18.headscale.v1.NodeDeviceR\x06device\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01J\x04\b\t\x10\n" +
+	"J\x04\b\x0e\x10\x12\"Y\n" +
+	"\vNodeService\x12\x14\n" +
+	"\x05proto\x18\x01 \x01(\tR\x05proto\x12\x12\n" +
+	"\x04port\x18\x02 \x01(\rR\x04port\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"\xd6\x02\n" +
+	"\vNodeNetInfo\x12%\n" +
+	"\x0epreferred_derp\x18\x01 \x01(\x05R\rpreferredDerp\x12$\n" +
+	"\vworking_udp\x18\x02 \x01(\bH\x00R\n" +
+	"workingUdp\x88\x01\x01\x12&\n" +
+	"\fworking_ipv6\x18\x03 \x01(\bH\x01R\vworkingIpv6\x88\x01\x01\x12=\n" +
+	"\x19mapping_varies_by_dest_ip\x18\x04 \x01(\bH\x02R\x15mappingVariesByDestIp\x88\x01\x01\x12&\n" +
+	"\fhair_pinning\x18\x05 \x01(\bH\x03R\vhairPinning\x88\x01\x01\x12\x1b\n" +
+	"\tlink_type\x18\x06 \x01(\tR\blinkTypeB\x0e\n" +
+	"\f_working_udpB\x0f\n" +
+	"\r_working_ipv6B\x1c\n" +
+	"\x1a_mapping_varies_by_dest_ipB\x0f\n" +
+	"\r_hair_pinning\"\xb2\x03\n" +
+	"\n" +
+	"NodeDevice\x12\x0e\n" +
+	"\x02os\x18\x01 \x01(\tR\x02os\x12\x1d\n" +
+	"\n" +
+	"os_version\x18\x02 \x01(\tR\tosVersion\x12%\n" +
+	"\x0eclient_version\x18\x03 \x01(\tR\rclientVersion\x12\x16\n" +
+	"\x06distro\x18\x04 \x01(\tR\x06distro\x12%\n" +
+	"\x0edistro_version\x18\x05 \x01(\tR\rdistroVersion\x12!\n" +
+	"\fdevice_model\x18\x06 \x01(\tR\vdeviceModel\x12\x18\n" +
+	"\amachine\x18\a \x01(\tR\amachine\x12\x18\n" +
+	"\apackage\x18\b \x01(\tR\apackage\x124\n" +
+	"\bnet_info\x18\t \x01(\v2\x19.headscale.v1.NodeNetInfoR\anetInfo\x125\n" +
+	"\bservices\x18\n" +
+	" \x03(\v2\x19.headscale.v1.NodeServiceR\bservices\x12-\n" +
+	"\x12capability_version\x18\v \x01(\x05R\x11capabilityVersion\x12\x1c\n" +
+	"\tendpoints\x18\f \x03(\tR\tendpoints\"c\n" +
 	"\x13RegisterNodeRequest\x12\x12\n" +
 	"\x04user\x18\x01 \x01(\tR\x04user\x12\x10\n" +
-	"\x03key\x18\x02 \x01(\tR\x03key\">\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x12\n" +
+	"\x04ipv4\x18\x03 \x01(\tR\x04ipv4\x12\x12\n" +
+	"\x04ipv6\x18\x04 \x01(\tR\x04ipv6\">\n" +
 	"\x14RegisterNodeResponse\x12&\n" +
 	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\")\n" +
 	"\x0eGetNodeRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\"9\n" +
 	"\x0fGetNodeResponse\x12&\n" +
+	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"\xb2\x01\n" +
+	"\x14SetNodeLabelsRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\x12F\n" +
+	"\x06labels\x18\x02 \x03(\v2..headscale.v1.SetNodeLabelsRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"?\n" +
+	"\x15SetNodeLabelsResponse\x12&\n" +
+	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"J\n" +
+	"\x15SetNodeAliasesRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\x12\x18\n" +
+	"\aaliases\x18\x02 \x03(\tR\aaliases\"@\n" +
+	"\x16SetNodeAliasesResponse\x12&\n" +
+	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"F\n" +
+	"\x17DeleteNodeLabelsRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\"B\n" +
+	"\x18DeleteNodeLabelsResponse\x12&\n" +
 	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"=\n" +
 	"\x0eSetTagsRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\x12\x12\n" +
@@ -1358,11 +3083,32 @@ const file_headscale_v1_node_proto_rawDesc = "" +
 	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\x12\x19\n" +
 	"\bnew_name\x18\x02 \x01(\tR\anewName\"<\n" +
 	"\x12RenameNodeResponse\x12&\n" +
-	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"&\n" +
+	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"\xfe\x03\n" +
 	"\x10ListNodesRequest\x12\x12\n" +
-	"\x04user\x18\x01 \x01(\tR\x04user\"=\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12%\n" +
+	"\x0elabel_selector\x18\x02 \x01(\tR\rlabelSelector\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\x1b\n" +
+	"\x06online\x18\x04 \x01(\bH\x00R\x06online\x88\x01\x01\x12B\n" +
+	"\x0flast_seen_after\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\rlastSeenAfter\x12D\n" +
+	"\x10last_seen_before\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x0elastSeenBefore\x12\x0e\n" +
+	"\x02os\x18\a \x01(\tR\x02os\x12%\n" +
+	"\x0eclient_version\x18\b \x01(\tR\rclientVersion\x12\x1d\n" +
+	"\aexpired\x18\t \x01(\bH\x01R\aexpired\x88\x01\x01\x12\x12\n" +
+	"\x04name\x18\n" +
+	" \x01(\tR\x04name\x12\x17\n" +
+	"\asort_by\x18\v \x01(\tR\x06sortBy\x12\x1e\n" +
+	"\n" +
+	"descending\x18\f \x01(\bR\n" +
+	"descending\x12\x1b\n" +
+	"\tpage_size\x18\r \x01(\rR\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x0e \x01(\tR\tpageTokenB\t\n" +
+	"\a_onlineB\n" +
+	"\n" +
+	"\b_expired\"e\n" +
 	"\x11ListNodesResponse\x12(\n" +
-	"\x05nodes\x18\x01 \x03(\v2\x12.headscale.v1.NodeR\x05nodes\">\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x12.headscale.v1.NodeR\x05nodes\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\">\n" +
 	"\x0fMoveNodeRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\x12\x12\n" +
 	"\x04user\x18\x02 \x01(\x04R\x04user\":\n" +
@@ -1374,16 +3120,73 @@ const file_headscale_v1_node_proto_rawDesc = "" +
 	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
 	"\x06routes\x18\x04 \x03(\tR\x06routes\"A\n" +
 	"\x17DebugCreateNodeResponse\x12&\n" +
+	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"T\n" +
+	"\x11SetNodeIPsRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\x04R\x06nodeId\x12\x12\n" +
+	"\x04ipv4\x18\x02 \x01(\tR\x04ipv4\x12\x12\n" +
+	"\x04ipv6\x18\x03 \x01(\tR\x04ipv6\"<\n" +
+	"\x12SetNodeIPsResponse\x12&\n" +
 	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\"6\n" +
 	"\x16BackfillNodeIPsRequest\x12\x1c\n" +
 	"\tconfirmed\x18\x01 \x01(\bR\tconfirmed\"3\n" +
 	"\x17BackfillNodeIPsResponse\x12\x18\n" +
-	"\achanges\x18\x01 \x03(\tR\achanges*\x82\x01\n" +
+	"\achanges\x18\x01 \x03(\tR\achanges\"\xf0\x01\n" +
+	"\x14RenumberNodesRequest\x12\x1b\n" +
+	"\tprefix_v4\x18\x01 \x01(\tR\bprefixV4\x12\x1b\n" +
+	"\tprefix_v6\x18\x02 \x01(\tR\bprefixV6\x12I\n" +
+	"\amapping\x18\x03 \x03(\v2/.headscale.v1.RenumberNodesRequest.MappingEntryR\amapping\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\x1a:\n" +
+	"\fMappingEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x7f\n" +
+	"\x15RenumberNodesResponse\x12\x18\n" +
+	"\achanges\x18\x01 \x03(\tR\achanges\x12%\n" +
+	"\x0epolicy_changes\x18\x02 \x03(\tR\rpolicyChanges\x12%\n" +
+	"\x0epolicy_updated\x18\x03 \x01(\bR\rpolicyUpdated\"I\n" +
+	"\x12ExpireNodesRequest\x12\x1a\n" +
+	"\bselector\x18\x01 \x01(\tR\bselector\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\"?\n" +
+	"\x13ExpireNodesResponse\x12(\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x12.headscale.v1.NodeR\x05nodes\"I\n" +
+	"\x12DeleteNodesRequest\x12\x1a\n" +
+	"\bselector\x18\x01 \x01(\tR\bselector\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\"?\n" +
+	"\x13DeleteNodesResponse\x12(\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x12.headscale.v1.NodeR\x05nodes\"^\n" +
+	"\x13SetNodesTagsRequest\x12\x1a\n" +
+	"\bselector\x18\x01 \x01(\tR\bselector\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"@\n" +
+	"\x14SetNodesTagsResponse\x12(\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x12.headscale.v1.NodeR\x05nodes\"[\n" +
+	"\x10MoveNodesRequest\x12\x1a\n" +
+	"\bselector\x18\x01 \x01(\tR\bselector\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\x04R\x04user\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"=\n" +
+	"\x11MoveNodesResponse\x12(\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x12.headscale.v1.NodeR\x05nodes\"y\n" +
+	"\tStaleNode\x12&\n" +
+	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12,\n" +
+	"\x03due\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x03due\"\x17\n" +
+	"\x15ListStaleNodesRequest\"a\n" +
+	"\x16ListStaleNodesResponse\x12-\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x17.headscale.v1.StaleNodeR\x05nodes\x12\x18\n" +
+	"\aenabled\x18\x02 \x01(\bR\aenabled\"i\n" +
+	"\x11EphemeralDeletion\x12&\n" +
+	"\x04node\x18\x01 \x01(\v2\x12.headscale.v1.NodeR\x04node\x12,\n" +
+	"\x03due\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03due\"\x1f\n" +
+	"\x1dListEphemeralDeletionsRequest\"_\n" +
+	"\x1eListEphemeralDeletionsResponse\x12=\n" +
+	"\tdeletions\x18\x01 \x03(\v2\x1f.headscale.v1.EphemeralDeletionR\tdeletions*\xd9\x01\n" +
 	"\x0eRegisterMethod\x12\x1f\n" +
 	"\x1bREGISTER_METHOD_UNSPECIFIED\x10\x00\x12\x1c\n" +
 	"\x18REGISTER_METHOD_AUTH_KEY\x10\x01\x12\x17\n" +
 	"\x13REGISTER_METHOD_CLI\x10\x02\x12\x18\n" +
-	"\x14REGISTER_METHOD_OIDC\x10\x03B)Z'github.com/juanfont/headscale/gen/go/v1b\x06proto3"
+	"\x14REGISTER_METHOD_OIDC\x10\x03\x12\x19\n" +
+	"\x15REGISTER_METHOD_LOCAL\x10\x04\x12\x18\n" +
+	"\x14REGISTER_METHOD_SAML\x10\x05\x12 \n" +
+	"\x1cREGISTER_METHOD_FORWARD_AUTH\x10\x06B)Z'github.com/juanfont/headscale/gen/go/v1b\x06proto3"
 
 var (
 	file_headscale_v1_node_proto_rawDescOnce sync.Once
@@ -1398,57 +3201,109 @@ func file_headscale_v1_node_proto_rawDescGZIP() []byte {
 }
 
 var file_headscale_v1_node_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_headscale_v1_node_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_headscale_v1_node_proto_msgTypes = make([]protoimpl.MessageInfo, 53)
 var file_headscale_v1_node_proto_goTypes = []any{
-	(RegisterMethod)(0),               // 0: headscale.v1.RegisterMethod
-	(*Node)(nil),                      // 1: headscale.v1.Node
-	(*RegisterNodeRequest)(nil),       // 2: headscale.v1.RegisterNodeRequest
-	(*RegisterNodeResponse)(nil),      // 3: headscale.v1.RegisterNodeResponse
-	(*GetNodeRequest)(nil),            // 4: headscale.v1.GetNodeRequest
-	(*GetNodeResponse)(nil),           // 5: headscale.v1.GetNodeResponse
-	(*SetTagsRequest)(nil),            // 6: headscale.v1.SetTagsRequest
-	(*SetTagsResponse)(nil),           // 7: headscale.v1.SetTagsResponse
-	(*SetApprovedRoutesRequest)(nil),  // 8: headscale.v1.SetApprovedRoutesRequest
-	(*SetApprovedRoutesResponse)(nil), // 9: headscale.v1.SetApprovedRoutesResponse
-	(*DeleteNodeRequest)(nil),         // 10: headscale.v1.DeleteNodeRequest
-	(*DeleteNodeResponse)(nil),        // 11: headscale.v1.DeleteNodeResponse
-	(*ExpireNodeRequest)(nil),         // 12: headscale.v1.ExpireNodeRequest
-	(*ExpireNodeResponse)(nil),        // 13: headscale.v1.ExpireNodeResponse
-	(*RenameNodeRequest)(nil),         // 14: headscale.v1.RenameNodeRequest
-	(*RenameNodeResponse)(nil),        // 15: headscale.v1.RenameNodeResponse
-	(*ListNodesRequest)(nil),          // 16: headscale.v1.ListNodesRequest
-	(*ListNodesResponse)(nil),         // 17: headscale.v1.ListNodesResponse
-	(*MoveNodeRequest)(nil),           // 18: headscale.v1.MoveNodeRequest
-	(*MoveNodeResponse)(nil),          // 19: headscale.v1.MoveNodeResponse
-	(*DebugCreateNodeRequest)(nil),    // 20: headscale.v1.DebugCreateNodeRequest
-	(*DebugCreateNodeResponse)(nil),   // 21: headscale.v1.DebugCreateNodeResponse
-	(*BackfillNodeIPsRequest)(nil),    // 22: headscale.v1.BackfillNodeIPsRequest
-	(*BackfillNodeIPsResponse)(nil),   // 23: headscale.v1.BackfillNodeIPsResponse
-	(*User)(nil),                      // 24: headscale.v1.User
-	(*timestamppb.Timestamp)(nil),     // 25: google.protobuf.Timestamp
-	(*PreAuthKey)(nil),                // 26: headscale.v1.PreAuthKey
+	(RegisterMethod)(0),                    // 0: headscale.v1.RegisterMethod
+	(*Node)(nil),                           // 1: headscale.v1.Node
+	(*NodeService)(nil),                    // 2: headscale.v1.NodeService
+	(*NodeNetInfo)(nil),                    // 3: headscale.v1.NodeNetInfo
+	(*NodeDevice)(nil),                     // 4: headscale.v1.NodeDevice
+	(*RegisterNodeRequest)(nil),            // 5: headscale.v1.RegisterNodeRequest
+	(*RegisterNodeResponse)(nil),           // 6: headscale.v1.RegisterNodeResponse
+	(*GetNodeRequest)(nil),                 // 7: headscale.v1.GetNodeRequest
+	(*GetNodeResponse)(nil),                // 8: headscale.v1.GetNodeResponse
+	(*SetNodeLabelsRequest)(nil),           // 9: headscale.v1.SetNodeLabelsRequest
+	(*SetNodeLabelsResponse)(nil),          // 10: headscale.v1.SetNodeLabelsResponse
+	(*SetNodeAliasesRequest)(nil),          // 11: headscale.v1.SetNodeAliasesRequest
+	(*SetNodeAliasesResponse)(nil),         // 12: headscale.v1.SetNodeAliasesResponse
+	(*DeleteNodeLabelsRequest)(nil),        // 13: headscale.v1.DeleteNodeLabelsRequest
+	(*DeleteNodeLabelsResponse)(nil),       // 14: headscale.v1.DeleteNodeLabelsResponse
+	(*SetTagsRequest)(nil),                 // 15: headscale.v1.SetTagsRequest
+	(*SetTagsResponse)(nil),                // 16: headscale.v1.SetTagsResponse
+	(*SetApprovedRoutesRequest)(nil),       // 17: headscale.v1.SetApprovedRoutesRequest
+	(*SetApprovedRoutesResponse)(nil),      // 18: headscale.v1.SetApprovedRoutesResponse
+	(*DeleteNodeRequest)(nil),              // 19: headscale.v1.DeleteNodeRequest
+	(*DeleteNodeResponse)(nil),             // 20: headscale.v1.DeleteNodeResponse
+	(*ExpireNodeRequest)(nil),              // 21: headscale.v1.ExpireNodeRequest
+	(*ExpireNodeResponse)(nil),             // 22: headscale.v1.ExpireNodeResponse
+	(*RenameNodeRequest)(nil),              // 23: headscale.v1.RenameNodeRequest
+	(*RenameNodeResponse)(nil),             // 24: headscale.v1.RenameNodeResponse
+	(*ListNodesRequest)(nil),               // 25: headscale.v1.ListNodesRequest
+	(*ListNodesResponse)(nil),              // 26: headscale.v1.ListNodesResponse
+	(*MoveNodeRequest)(nil),                // 27: headscale.v1.MoveNodeRequest
+	(*MoveNodeResponse)(nil),               // 28: headscale.v1.MoveNodeResponse
+	(*DebugCreateNodeRequest)(nil),         // 29: headscale.v1.DebugCreateNodeRequest
+	(*DebugCreateNodeResponse)(nil),        // 30: headscale.v1.DebugCreateNodeResponse
+	(*SetNodeIPsRequest)(nil),              // 31: headscale.v1.SetNodeIPsRequest
+	(*SetNodeIPsResponse)(nil),             // 32: headscale.v1.SetNodeIPsResponse
+	(*BackfillNodeIPsRequest)(nil),         // 33: headscale.v1.BackfillNodeIPsRequest
+	(*BackfillNodeIPsResponse)(nil),        // 34: headscale.v1.BackfillNodeIPsResponse
+	(*RenumberNodesRequest)(nil),           // 35: headscale.v1.RenumberNodesRequest
+	(*RenumberNodesResponse)(nil),          // 36: headscale.v1.RenumberNodesResponse
+	(*ExpireNodesRequest)(nil),             // 37: headscale.v1.ExpireNodesRequest
+	(*ExpireNodesResponse)(nil),            // 38: headscale.v1.ExpireNodesResponse
+	(*DeleteNodesRequest)(nil),             // 39: headscale.v1.DeleteNodesRequest
+	(*DeleteNodesResponse)(nil),            // 40: headscale.v1.DeleteNodesResponse
+	(*SetNodesTagsRequest)(nil),            // 41: headscale.v1.SetNodesTagsRequest
+	(*SetNodesTagsResponse)(nil),           // 42: headscale.v1.SetNodesTagsResponse
+	(*MoveNodesRequest)(nil),               // 43: headscale.v1.MoveNodesRequest
+	(*MoveNodesResponse)(nil),              // 44: headscale.v1.MoveNodesResponse
+	(*StaleNode)(nil),                      // 45: headscale.v1.StaleNode
+	(*ListStaleNodesRequest)(nil),          // 46: headscale.v1.ListStaleNodesRequest
+	(*ListStaleNodesResponse)(nil),         // 47: headscale.v1.ListStaleNodesResponse
+	(*EphemeralDeletion)(nil),              // 48: headscale.v1.EphemeralDeletion
+	(*ListEphemeralDeletionsRequest)(nil),  // 49: headscale.v1.ListEphemeralDeletionsRequest
+	(*ListEphemeralDeletionsResponse)(nil), // 50: headscale.v1.ListEphemeralDeletionsResponse
+	nil,                                    // 51: headscale.v1.Node.LabelsEntry
+	nil,                                    // 52: headscale.v1.SetNodeLabelsRequest.LabelsEntry
+	nil,                                    // 53: headscale.v1.RenumberNodesRequest.MappingEntry
+	(*User)(nil),                           // 54: headscale.v1.User
+	(*timestamppb.Timestamp)(nil),          // 55: google.protobuf.Timestamp
+	(*PreAuthKey)(nil),                     // 56: headscale.v1.PreAuthKey
 }
 var file_headscale_v1_node_proto_depIdxs = []int32{
-	24, // 0: headscale.v1.Node.user:type_name -> headscale.v1.User
-	25, // 1: headscale.v1.Node.last_seen:type_name -> google.protobuf.Timestamp
-	25, // 2: headscale.v1.Node.expiry:type_name -> google.protobuf.Timestamp
-	26, // 3: headscale.v1.Node.pre_auth_key:type_name -> headscale.v1.PreAuthKey
-	25, // 4: headscale.v1.Node.created_at:type_name -> google.protobuf.Timestamp
+	54, // 0: headscale.v1.Node.user:type_name -> headscale.v1.User
+	55, // 1: headscale.v1.Node.last_seen:type_name -> google.protobuf.Timestamp
+	55, // 2: headscale.v1.Node.expiry:type_name -> google.protobuf.Timestamp
+	56, // 3: headscale.v1.Node.pre_auth_key:type_name -> headscale.v1.PreAuthKey
+	55, // 4: headscale.v1.Node.created_at:type_name -> google.protobuf.Timestamp
 	0,  // 5: headscale.v1.Node.register_method:type_name -> headscale.v1.RegisterMethod
-	1,  // 6: headscale.v1.RegisterNodeResponse.node:type_name -> headscale.v1.Node
-	1,  // 7: headscale.v1.GetNodeResponse.node:type_name -> headscale.v1.Node
-	1,  // 8: headscale.v1.SetTagsResponse.node:type_name -> headscale.v1.Node
-	1,  // 9: headscale.v1.SetApprovedRoutesResponse.node:type_name -> headscale.v1.Node
-	1,  // 10: headscale.v1.ExpireNodeResponse.node:type_name -> headscale.v1.Node
-	1,  // 11: headscale.v1.RenameNodeResponse.node:type_name -> headscale.v1.Node
-	1,  // 12: headscale.v1.ListNodesResponse.nodes:type_name -> headscale.v1.Node
-	1,  // 13: headscale.v1.MoveNodeResponse.node:type_name -> headscale.v1.Node
-	1,  // 14: headscale.v1.DebugCreateNodeResponse.node:type_name -> headscale.v1.Node
-	15, // [15:15] is the sub-list for method output_type
-	15, // [15:15] is the sub-list for method input_type
-	15, // [15:15] is the sub-list for extension type_name
-	15, // [15:15] is the sub-list for extension extendee
-	0,  // [0:15] is the sub-list for field type_name
+	51, // 6: headscale.v1.Node.labels:type_name -> headscale.v1.Node.LabelsEntry
+	4,  // 7: headscale.v1.Node.device:type_name -> headscale.v1.NodeDevice
+	3,  // 8: headscale.v1.NodeDevice.net_info:type_name -> headscale.v1.NodeNetInfo
+	2,  // 9: headscale.v1.NodeDevice.services:type_name -> headscale.v1.NodeService
+	1,  // 10: headscale.v1.RegisterNodeResponse.node:type_name -> headscale.v1.Node
+	1,  // 11: headscale.v1.GetNodeResponse.node:type_name -> headscale.v1.Node
+	52, // 12: headscale.v1.SetNodeLabelsRequest.labels:type_name -> headscale.v1.SetNodeLabelsRequest.LabelsEntry
+	1,  // 13: headscale.v1.SetNodeLabelsResponse.node:type_name -> headscale.v1.Node
+	1,  // 14: headscale.v1.SetNodeAliasesResponse.node:type_name -> headscale.v1.Node
+	1,  // 15: headscale.v1.DeleteNodeLabelsResponse.node:type_name -> headscale.v1.Node
+	1,  // 16: headscale.v1.SetTagsResponse.node:type_name -> headscale.v1.Node
+	1,  // 17: headscale.v1.SetApprovedRoutesResponse.node:type_name -> headscale.v1.Node
+	1,  // 18: headscale.v1.ExpireNodeResponse.node:type_name -> headscale.v1.Node
+	1,  // 19: headscale.v1.RenameNodeResponse.node:type_name -> headscale.v1.Node
+	55, // 20: headscale.v1.ListNodesRequest.last_seen_after:type_name -> google.protobuf.Timestamp
+	55, // 21: headscale.v1.ListNodesRequest.last_seen_before:type_name -> google.protobuf.Timestamp
+	1,  // 22: headscale.v1.ListNodesResponse.nodes:type_name -> headscale.v1.Node
+	1,  // 23: headscale.v1.MoveNodeResponse.node:type_name -> headscale.v1.Node
+	1,  // 24: headscale.v1.DebugCreateNodeResponse.node:type_name -> headscale.v1.Node
+	1,  // 25: headscale.v1.SetNodeIPsResponse.node:type_name -> headscale.v1.Node
+	53, // 26: headscale.v1.RenumberNodesRequest.mapping:type_name -> headscale.v1.RenumberNodesRequest.MappingEntry
+	1,  // 27: headscale.v1.ExpireNodesResponse.nodes:type_name -> headscale.v1.Node
+	1,  // 28: headscale.v1.DeleteNodesResponse.nodes:type_name -> headscale.v1.Node
+	1,  // 29: headscale.v1.SetNodesTagsResponse.nodes:type_name -> headscale.v1.Node
+	1,  // 30: headscale.v1.MoveNodesResponse.nodes:type_name -> headscale.v1.Node
+	1,  // 31: headscale.v1.StaleNode.node:type_name -> headscale.v1.Node
+	55, // 32: headscale.v1.StaleNode.due:type_name -> google.protobuf.Timestamp
+	45, // 33: headscale.v1.ListStaleNodesResponse.nodes:type_name -> headscale.v1.StaleNode
+	1,  // 34: headscale.v1.EphemeralDeletion.node:type_name -> headscale.v1.Node
+	55, // 35: headscale.v1.EphemeralDeletion.due:type_name -> google.protobuf.Timestamp
+	48, // 36: headscale.v1.ListEphemeralDeletionsResponse.deletions:type_name -> headscale.v1.EphemeralDeletion
+	37, // [37:37] is the sub-list for method output_type
+	37, // [37:37] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
 }
 
 func init() { file_headscale_v1_node_proto_init() }
@@ -1458,13 +3313,15 @@ func file_headscale_v1_node_proto_init() {
 	}
 	file_headscale_v1_preauthkey_proto_init()
 	file_headscale_v1_user_proto_init()
+	file_headscale_v1_node_proto_msgTypes[2].OneofWrappers = []any{}
+	file_headscale_v1_node_proto_msgTypes[24].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_headscale_v1_node_proto_rawDesc), len(file_headscale_v1_node_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   23,
+			NumMessages:   53,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
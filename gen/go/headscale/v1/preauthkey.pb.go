@@ -9,6 +9,7 @@ package v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -23,18 +24,25 @@ const (
 )
 
 type PreAuthKey struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
-	Id            uint64                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
-	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
-	Reusable      bool                   `protobuf:"varint,4,opt,name=reusable,proto3" json:"reusable,omitempty"`
-	Ephemeral     bool                   `protobuf:"varint,5,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
-	Used          bool                   `protobuf:"varint,6,opt,name=used,proto3" json:"used,omitempty"`
-	Expiration    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expiration,proto3" json:"expiration,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	AclTags       []string               `protobuf:"bytes,9,rep,name=acl_tags,json=aclTags,proto3" json:"acl_tags,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                      protoimpl.MessageState `protogen:"open.v1"`
+	User                       *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Id                         uint64                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Key                        string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Reusable                   bool                   `protobuf:"varint,4,opt,name=reusable,proto3" json:"reusable,omitempty"`
+	Ephemeral                  bool                   `protobuf:"varint,5,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	Used                       bool                   `protobuf:"varint,6,opt,name=used,proto3" json:"used,omitempty"`
+	Expiration                 *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	CreatedAt                  *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	AclTags                    []string               `protobuf:"bytes,9,rep,name=acl_tags,json=aclTags,proto3" json:"acl_tags,omitempty"`
+	ApprovedRoutes             []string               `protobuf:"bytes,10,rep,name=approved_routes,json=approvedRoutes,proto3" json:"approved_routes,omitempty"`
+	NodeExpiry                 *durationpb.Duration   `protobuf:"bytes,11,opt,name=node_expiry,json=nodeExpiry,proto3" json:"node_expiry,omitempty"`
+	GivenName                  string                 `protobuf:"bytes,12,opt,name=given_name,json=givenName,proto3" json:"given_name,omitempty"`
+	EphemeralInactivityTimeout *durationpb.Duration   `protobuf:"bytes,13,opt,name=ephemeral_inactivity_timeout,json=ephemeralInactivityTimeout,proto3" json:"ephemeral_inactivity_timeout,omitempty"`
+	Ipv4                       string                 `protobuf:"bytes,14,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Ipv6                       string                 `protobuf:"bytes,15,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
+	NameCollision              string                 `protobuf:"bytes,16,opt,name=name_collision,json=nameCollision,proto3" json:"name_collision,omitempty"`
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
 }
 
 func (x *PreAuthKey) Reset() {
@@ -130,13 +138,72 @@ func (x *PreAuthKey) GetAclTags() []string {
 	return nil
 }
 
+func (x *PreAuthKey) GetApprovedRoutes() []string {
+	if x != nil {
+		return x.ApprovedRoutes
+	}
+	return nil
+}
+
+func (x *PreAuthKey) GetNodeExpiry() *durationpb.Duration {
+	if x != nil {
+		return x.NodeExpiry
+	}
+	return nil
+}
+
+func (x *PreAuthKey) GetGivenName() string {
+	if x != nil {
+		return x.GivenName
+	}
+	return ""
+}
+
+func (x *PreAuthKey) GetEphemeralInactivityTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.EphemeralInactivityTimeout
+	}
+	return nil
+}
+
+func (x *PreAuthKey) GetIpv4() string {
+	if x != nil {
+		return x.Ipv4
+	}
+	return ""
+}
+
+func (x *PreAuthKey) GetIpv6() string {
+	if x != nil {
+		return x.Ipv6
+	}
+	return ""
+}
+
+func (x *PreAuthKey) GetNameCollision() string {
+	if x != nil {
+		return x.NameCollision
+	}
+	return ""
+}
+
 type CreatePreAuthKeyRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	User          uint64                 `protobuf:"varint,1,opt,name=user,proto3" json:"user,omitempty"`
-	Reusable      bool                   `protobuf:"varint,2,opt,name=reusable,proto3" json:"reusable,omitempty"`
-	Ephemeral     bool                   `protobuf:"varint,3,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
-	Expiration    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expiration,proto3" json:"expiration,omitempty"`
-	AclTags       []string               `protobuf:"bytes,5,rep,name=acl_tags,json=aclTags,proto3" json:"acl_tags,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	User       uint64                 `protobuf:"varint,1,opt,name=user,proto3" json:"user,omitempty"`
+	Reusable   bool                   `protobuf:"varint,2,opt,name=reusable,proto3" json:"reusable,omitempty"`
+	Ephemeral  bool                   `protobuf:"varint,3,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	Expiration *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	AclTags    []string               `protobuf:"bytes,5,rep,name=acl_tags,json=aclTags,proto3" json:"acl_tags,omitempty"`
+	// Settings applied to nodes registering with the key.
+	ApprovedRoutes             []string             `protobuf:"bytes,6,rep,name=approved_routes,json=approvedRoutes,proto3" json:"approved_routes,omitempty"`
+	NodeExpiry                 *durationpb.Duration `protobuf:"bytes,7,opt,name=node_expiry,json=nodeExpiry,proto3" json:"node_expiry,omitempty"`
+	GivenName                  string               `protobuf:"bytes,8,opt,name=given_name,json=givenName,proto3" json:"given_name,omitempty"`
+	EphemeralInactivityTimeout *durationpb.Duration `protobuf:"bytes,9,opt,name=ephemeral_inactivity_timeout,json=ephemeralInactivityTimeout,proto3" json:"ephemeral_inactivity_timeout,omitempty"`
+	Ipv4                       string               `protobuf:"bytes,10,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Ipv6                       string               `protobuf:"bytes,11,opt,name=ipv6,proto3" json:"ipv6,omitempty"`
+	// What happens if the hostname of a node is already used: suffix,
+	// reject or takeover. Empty uses the server default.
+	NameCollision string `protobuf:"bytes,12,opt,name=name_collision,json=nameCollision,proto3" json:"name_collision,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -206,6 +273,55 @@ func (x *CreatePreAuthKeyRequest) GetAclTags() []string {
 	return nil
 }
 
+func (x *CreatePreAuthKeyRequest) GetApprovedRoutes() []string {
+	if x != nil {
+		return x.ApprovedRoutes
+	}
+	return nil
+}
+
+func (x *CreatePreAuthKeyRequest) GetNodeExpiry() *durationpb.Duration {
+	if x != nil {
+		return x.NodeExpiry
+	}
+	return nil
+}
+
+func (x *CreatePreAuthKeyRequest) GetGivenName() string {
+	if x != nil {
+		return x.GivenName
+	}
+	return ""
+}
+
+func (x *CreatePreAuthKeyRequest) GetEphemeralInactivityTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.EphemeralInactivityTimeout
+	}
+	return nil
+}
+
+func (x *CreatePreAuthKeyRequest) GetIpv4() string {
+	if x != nil {
+		return x.Ipv4
+	}
+	return ""
+}
+
+func (x *CreatePreAuthKeyRequest) GetIpv6() string {
+	if x != nil {
+		return x.Ipv6
+	}
+	return ""
+}
+
+func (x *CreatePreAuthKeyRequest) GetNameCollision() string {
+	if x != nil {
+		return x.NameCollision
+	}
+	return ""
+}
+
 type CreatePreAuthKeyResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	PreAuthKey    *PreAuthKey            `protobuf:"bytes,1,opt,name=pre_auth_key,json=preAuthKey,proto3" json:"pre_auth_key,omitempty"`
@@ -430,7 +546,7 @@ var File_headscale_v1_preauthkey_proto protoreflect.FileDescriptor
 
 const file_headscale_v1_preauthkey_proto_rawDesc = "" +
 	"\n" +
-	"\x1dheadscale/v1/preauthkey.proto\x12\fheadscale.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x17headscale/v1/user.proto\"\xb6\x02\n" +
+	"\x1dheadscale/v1/preauthkey.proto\x12\fheadscale.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x17headscale/v1/user.proto\"\xe6\x04\n" +
 	"\n" +
 	"PreAuthKey\x12&\n" +
 	"\x04user\x18\x01 \x01(\v2\x12.headscale.v1.UserR\x04user\x12\x0e\n" +
@@ -444,7 +560,17 @@ const file_headscale_v1_preauthkey_proto_rawDesc = "" +
 	"expiration\x129\n" +
 	"\n" +
 	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x19\n" +
-	"\bacl_tags\x18\t \x03(\tR\aaclTags\"\xbe\x01\n" +
+	"\bacl_tags\x18\t \x03(\tR\aaclTags\x12'\n" +
+	"\x0fapproved_routes\x18\n" +
+	" \x03(\tR\x0eapprovedRoutes\x12:\n" +
+	"\vnode_expiry\x18\v \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"nodeExpiry\x12\x1d\n" +
+	"\n" +
+	"given_name\x18\f \x01(\tR\tgivenName\x12[\n" +
+	"\x1cephemeral_inactivity_timeout\x18\r \x01(\v2\x19.google.protobuf.DurationR\x1aephemeralInactivityTimeout\x12\x12\n" +
+	"\x04ipv4\x18\x0e \x01(\tR\x04ipv4\x12\x12\n" +
+	"\x04ipv6\x18\x0f \x01(\tR\x04ipv6\x12%\n" +
+	"\x0ename_collision\x18\x10 \x01(\tR\rnameCollision\"\xee\x03\n" +
 	"\x17CreatePreAuthKeyRequest\x12\x12\n" +
 	"\x04user\x18\x01 \x01(\x04R\x04user\x12\x1a\n" +
 	"\breusable\x18\x02 \x01(\bR\breusable\x12\x1c\n" +
@@ -452,7 +578,17 @@ const file_headscale_v1_preauthkey_proto_rawDesc = "" +
 	"\n" +
 	"expiration\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
 	"expiration\x12\x19\n" +
-	"\bacl_tags\x18\x05 \x03(\tR\aaclTags\"V\n" +
+	"\bacl_tags\x18\x05 \x03(\tR\aaclTags\x12'\n" +
+	"\x0fapproved_routes\x18\x06 \x03(\tR\x0eapprovedRoutes\x12:\n" +
+	"\vnode_expiry\x18\a \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"nodeExpiry\x12\x1d\n" +
+	"\n" +
+	"given_name\x18\b \x01(\tR\tgivenName\x12[\n" +
+	"\x1cephemeral_inactivity_timeout\x18\t \x01(\v2\x19.google.protobuf.DurationR\x1aephemeralInactivityTimeout\x12\x12\n" +
+	"\x04ipv4\x18\n" +
+	" \x01(\tR\x04ipv4\x12\x12\n" +
+	"\x04ipv6\x18\v \x01(\tR\x04ipv6\x12%\n" +
+	"\x0ename_collision\x18\f \x01(\tR\rnameCollision\"V\n" +
 	"\x18CreatePreAuthKeyResponse\x12:\n" +
 	"\fpre_auth_key\x18\x01 \x01(\v2\x18.headscale.v1.PreAuthKeyR\n" +
 	"preAuthKey\"?\n" +
@@ -488,19 +624,24 @@ var file_headscale_v1_preauthkey_proto_goTypes = []any{
 	(*ListPreAuthKeysResponse)(nil),  // 6: headscale.v1.ListPreAuthKeysResponse
 	(*User)(nil),                     // 7: headscale.v1.User
 	(*timestamppb.Timestamp)(nil),    // 8: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),      // 9: google.protobuf.Duration
 }
 var file_headscale_v1_preauthkey_proto_depIdxs = []int32{
-	7, // 0: headscale.v1.PreAuthKey.user:type_name -> headscale.v1.User
-	8, // 1: headscale.v1.PreAuthKey.expiration:type_name -> google.protobuf.Timestamp
-	8, // 2: headscale.v1.PreAuthKey.created_at:type_name -> google.protobuf.Timestamp
-	8, // 3: headscale.v1.CreatePreAuthKeyRequest.expiration:type_name -> google.protobuf.Timestamp
-	0, // 4: headscale.v1.CreatePreAuthKeyResponse.pre_auth_key:type_name -> headscale.v1.PreAuthKey
-	0, // 5: headscale.v1.ListPreAuthKeysResponse.pre_auth_keys:type_name -> headscale.v1.PreAuthKey
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	7,  // 0: headscale.v1.PreAuthKey.user:type_name -> headscale.v1.User
+	8,  // 1: headscale.v1.PreAuthKey.expiration:type_name -> google.protobuf.Timestamp
+	8,  // 2: headscale.v1.PreAuthKey.created_at:type_name -> google.protobuf.Timestamp
+	9,  // 3: headscale.v1.PreAuthKey.node_expiry:type_name -> google.protobuf.Duration
+	9,  // 4: headscale.v1.PreAuthKey.ephemeral_inactivity_timeout:type_name -> google.protobuf.Duration
+	8,  // 5: headscale.v1.CreatePreAuthKeyRequest.expiration:type_name -> google.protobuf.Timestamp
+	9,  // 6: headscale.v1.CreatePreAuthKeyRequest.node_expiry:type_name -> google.protobuf.Duration
+	9,  // 7: headscale.v1.CreatePreAuthKeyRequest.ephemeral_inactivity_timeout:type_name -> google.protobuf.Duration
+	0,  // 8: headscale.v1.CreatePreAuthKeyResponse.pre_auth_key:type_name -> headscale.v1.PreAuthKey
+	0,  // 9: headscale.v1.ListPreAuthKeysResponse.pre_auth_keys:type_name -> headscale.v1.PreAuthKey
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_headscale_v1_preauthkey_proto_init() }
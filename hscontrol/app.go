@@ -101,6 +101,10 @@ type Headscale struct {
 	registrationCache *zcache.Cache[types.RegistrationID, types.RegisterNode]
 
 	authProvider AuthProvider
+	cliLogin     *CLILogin
+	socketPeers  *socketPeerAuthorizer
+
+	grpcClientAuth *grpcClientAuth
 
 	pollNetMapStreamWG sync.WaitGroup
 }
@@ -148,7 +152,7 @@ func NewHeadscale(cfg *types.Config) (*Headscale, error) {
 		return nil, fmt.Errorf("new database: %w", err)
 	}
 
-	app.ipAlloc, err = db.NewIPAllocator(app.db, cfg.PrefixV4, cfg.PrefixV6, cfg.IPAllocation)
+	app.ipAlloc, err = db.NewIPAllocator(app.db, cfg.PrefixV4, cfg.PrefixV6, cfg.IPAllocation, cfg.IPPools)
 	if err != nil {
 		return nil, err
 	}
@@ -165,30 +169,90 @@ func NewHeadscale(cfg *types.Config) (*Headscale, error) {
 
 	var authProvider AuthProvider
 	authProvider = NewAuthProviderWeb(cfg.ServerURL)
-	if cfg.OIDC.Issuer != "" {
+	if oidcConfigs := cfg.OIDCConfigs(); len(oidcConfigs) > 0 {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		oidcProvider, err := NewAuthProviderOIDC(
 			ctx,
 			cfg.ServerURL,
-			&cfg.OIDC,
+			oidcConfigs,
 			app.db,
 			app.nodeNotifier,
 			app.ipAlloc,
 			app.polMan,
 		)
-		if err != nil {
-			if cfg.OIDC.OnlyStartIfOIDCIsAvailable {
+		switch {
+		case errors.Is(err, errOIDCNoProviderAvailable):
+			log.Warn().Err(err).Msg("failed to set up OIDC provider, falling back to CLI based authentication")
+		case err != nil:
+			return nil, err
+		default:
+			authProvider = oidcProvider
+		}
+
+		if cfg.CLILogin.Enabled && oidcProvider != nil {
+			app.cliLogin, err = NewCLILogin(cfg.CLILogin, oidcProvider, app.db)
+			switch {
+			case errors.Is(err, errCLILoginProviderUnavailable):
+				log.Warn().Err(err).Msg("failed to set up headscale login, it is disabled")
+			case err != nil:
 				return nil, err
-			} else {
-				log.Warn().Err(err).Msg("failed to set up OIDC provider, falling back to CLI based authentication")
 			}
-		} else {
-			authProvider = oidcProvider
 		}
 	}
+	if cfg.LocalAuth.Enabled {
+		authProvider = NewAuthProviderLocal(
+			cfg.ServerURL,
+			cfg.LocalAuth,
+			app.db,
+			app.nodeNotifier,
+			app.ipAlloc,
+			app.polMan,
+		)
+	}
+	if cfg.SAML.Enabled {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		samlProvider, err := NewAuthProviderSAML(
+			ctx,
+			cfg.ServerURL,
+			cfg.SAML,
+			app.db,
+			app.nodeNotifier,
+			app.ipAlloc,
+			app.polMan,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("setting up SAML provider: %w", err)
+		}
+		authProvider = samlProvider
+	}
+	if cfg.ForwardAuth.Enabled {
+		authProvider = NewAuthProviderForwardAuth(
+			cfg.ServerURL,
+			cfg.ForwardAuth,
+			app.db,
+			app.nodeNotifier,
+			app.ipAlloc,
+			app.polMan,
+		)
+	}
 	app.authProvider = authProvider
 
+	if cfg.UnixSocketPeers.Enabled {
+		app.socketPeers, err = newSocketPeerAuthorizer(cfg.UnixSocketPeers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.GRPCClientAuth.Enabled {
+		app.grpcClientAuth, err = newGRPCClientAuth(cfg.GRPCClientAuth)
+		if err != nil {
+			return nil, fmt.Errorf("setting up gRPC client certificates: %w", err)
+		}
+	}
+
 	if app.cfg.TailcfgDNSConfig != nil && app.cfg.TailcfgDNSConfig.Proxied { // if MagicDNS
 		// TODO(kradalby): revisit why this takes a list.
 
@@ -259,6 +323,22 @@ func (h *Headscale) scheduledTasks(ctx context.Context) {
 		derpTickerChan = derpTicker.C
 	}
 
+	oidcRefreshTickerChan := make(<-chan time.Time)
+	if oidcProvider, ok := h.authProvider.(*AuthProviderOIDC); ok {
+		if interval := oidcProvider.refreshCheckInterval(); interval > 0 {
+			oidcRefreshTicker := time.NewTicker(interval)
+			defer oidcRefreshTicker.Stop()
+			oidcRefreshTickerChan = oidcRefreshTicker.C
+		}
+	}
+
+	nodeCleanupTickerChan := make(<-chan time.Time)
+	if h.cfg.NodeCleanup.Enabled {
+		nodeCleanupTicker := time.NewTicker(h.cfg.NodeCleanup.Interval)
+		defer nodeCleanupTicker.Stop()
+		nodeCleanupTickerChan = nodeCleanupTicker.C
+	}
+
 	var extraRecordsUpdate <-chan []tailcfg.DNSRecord
 	if h.extraRecordMan != nil {
 		extraRecordsUpdate = h.extraRecordMan.UpdateCh()
@@ -306,6 +386,14 @@ func (h *Headscale) scheduledTasks(ctx context.Context) {
 				DERPMap: h.DERPMap,
 			})
 
+		case <-oidcRefreshTickerChan:
+			// Refreshing calls the identity provider for every
+			// session, it must not hold up the other tasks.
+			go h.authProvider.(*AuthProviderOIDC).RefreshNodeExpiries(ctx)
+
+		case <-nodeCleanupTickerChan:
+			h.cleanupStaleNodes()
+
 		case records, ok := <-extraRecordsUpdate:
 			if !ok {
 				continue
@@ -337,6 +425,17 @@ func (h *Headscale) grpcAuthenticationInterceptor(ctx context.Context,
 		Str("client_address", client.Addr.String()).
 		Msg("Client is trying to authenticate")
 
+	if h.grpcClientAuth != nil {
+		authenticated, err := h.grpcClientAuth.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return ctx, err
+		}
+
+		if authenticated {
+			return handler(ctx, req)
+		}
+	}
+
 	meta, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return ctx, status.Errorf(
@@ -362,12 +461,27 @@ func (h *Headscale) grpcAuthenticationInterceptor(ctx context.Context,
 		)
 	}
 
-	valid, err := h.db.ValidateAPIKey(strings.TrimPrefix(token, AuthPrefix))
+	token = strings.TrimPrefix(token, AuthPrefix)
+
+	if strings.HasPrefix(token, types.OAuthAccessTokenPrefix) {
+		if err := h.authorizeOAuthAccessToken(token, info.FullMethod, req); err != nil {
+			log.Info().
+				Str("client_address", client.Addr.String()).
+				Err(err).
+				Msg("OAuth access token rejected")
+
+			return ctx, err
+		}
+
+		return handler(ctx, req)
+	}
+
+	apiKey, err := h.db.AuthenticateAPIKey(token, h.cfg.CLILogin.IsAdmin)
 	if err != nil {
 		return ctx, status.Error(codes.Internal, "failed to validate token")
 	}
 
-	if !valid {
+	if apiKey == nil {
 		log.Info().
 			Str("client_address", client.Addr.String()).
 			Msg("invalid token")
@@ -375,6 +489,56 @@ func (h *Headscale) grpcAuthenticationInterceptor(ctx context.Context,
 		return ctx, status.Error(codes.Unauthenticated, "invalid token")
 	}
 
+	if err := authorizeAPIKeyScopes(apiKey, info.FullMethod); err != nil {
+		log.Info().
+			Str("client_address", client.Addr.String()).
+			Err(err).
+			Msg("API key rejected")
+
+		return ctx, err
+	}
+
+	return handler(ctx, req)
+}
+
+// grpcSocketAuthorizationInterceptor limits requests carrying an OAuth
+// access token or a scoped API key to the scopes of the token. Requests
+// over the unix socket are otherwise trusted, unless unix_socket_peers maps
+// the local users of callers to roles, but the gRPC gateway forwards the
+// Authorization header of HTTP API requests over it.
+func (h *Headscale) grpcSocketAuthorizationInterceptor(ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if h.socketPeers != nil {
+		if err := h.socketPeers.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+	}
+
+	meta, _ := metadata.FromIncomingContext(ctx)
+	for _, authHeader := range meta["authorization"] {
+		token := strings.TrimPrefix(authHeader, AuthPrefix)
+		if !strings.HasPrefix(token, types.OAuthAccessTokenPrefix) {
+			// The HTTP API has already rejected invalid keys.
+			apiKey, err := h.db.AuthenticateAPIKey(token, h.cfg.CLILogin.IsAdmin)
+			if err != nil || apiKey == nil {
+				continue
+			}
+
+			if err := authorizeAPIKeyScopes(apiKey, info.FullMethod); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err := h.authorizeOAuthAccessToken(token, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+	}
+
 	return handler(ctx, req)
 }
 
@@ -407,7 +571,7 @@ func (h *Headscale) httpAuthenticationMiddleware(next http.Handler) http.Handler
 			return
 		}
 
-		valid, err := h.db.ValidateAPIKey(strings.TrimPrefix(authHeader, AuthPrefix))
+		valid, err := h.validateAPIToken(strings.TrimPrefix(authHeader, AuthPrefix))
 		if err != nil {
 			log.Error().
 				Caller().
@@ -473,6 +637,25 @@ func (h *Headscale) createRouter(grpcMux *grpcRuntime.ServeMux) *mux.Router {
 
 	if provider, ok := h.authProvider.(*AuthProviderOIDC); ok {
 		router.HandleFunc("/oidc/callback", provider.OIDCCallbackHandler).Methods(http.MethodGet)
+		router.HandleFunc("/oidc/callback/{provider}", provider.OIDCCallbackHandler).Methods(http.MethodGet)
+		router.HandleFunc("/oidc/logout", h.OIDCBackChannelLogoutHandler).Methods(http.MethodPost)
+		router.HandleFunc("/oidc/logout/{provider}", h.OIDCBackChannelLogoutHandler).Methods(http.MethodPost)
+	}
+	if provider, ok := h.authProvider.(*AuthProviderLocal); ok {
+		router.HandleFunc("/register/{registration_id}", provider.RegisterHandler).Methods(http.MethodPost)
+		router.HandleFunc("/login/password", provider.PasswordHandler).
+			Methods(http.MethodGet, http.MethodPost)
+	}
+	if provider, ok := h.authProvider.(*AuthProviderSAML); ok {
+		router.HandleFunc(samlMetadataPath, provider.MetadataHandler).Methods(http.MethodGet)
+		router.HandleFunc(samlACSPath, provider.ACSHandler).Methods(http.MethodPost)
+	}
+	if h.cliLogin != nil {
+		router.HandleFunc(types.CLILoginDevicePath, h.cliLogin.DeviceHandler).Methods(http.MethodPost)
+		router.HandleFunc(types.CLILoginTokenPath, h.cliLogin.TokenHandler).Methods(http.MethodPost)
+	}
+	if provider, ok := h.authProvider.(*AuthProviderForwardAuth); ok {
+		router.HandleFunc("/register/{registration_id}", provider.RegisterHandler).Methods(http.MethodPost)
 	}
 	router.HandleFunc("/apple", h.AppleConfigMessage).Methods(http.MethodGet)
 	router.HandleFunc("/apple/{platform}", h.ApplePlatformConfig).
@@ -493,6 +676,12 @@ func (h *Headscale) createRouter(grpcMux *grpcRuntime.ServeMux) *mux.Router {
 		router.HandleFunc("/bootstrap-dns", derpServer.DERPBootstrapDNSHandler(h.DERPMap))
 	}
 
+	router.HandleFunc(oauthTokenPath, h.OAuthTokenHandler).Methods(http.MethodPost)
+
+	if h.cfg.SCIM.Enabled {
+		h.registerSCIMRoutes(router)
+	}
+
 	apiRouter := router.PathPrefix("/api").Subrouter()
 	apiRouter.Use(h.httpAuthenticationMiddleware)
 	apiRouter.PathPrefix("/v1/").HandlerFunc(grpcMux.ServeHTTP)
@@ -539,7 +728,7 @@ func nodesChangedHook(
 		return false, err
 	}
 
-	filterChanged, err := polMan.SetNodes(nodes)
+	filterChanged, err := polMan.SetNodes(nodes.WithoutSuspended())
 	if err != nil {
 		return false, err
 	}
@@ -611,12 +800,9 @@ func (h *Headscale) Serve() error {
 	// around between restarts, they will reconnect and the GC will
 	// be cancelled.
 	go h.ephemeralGC.Start()
-	ephmNodes, err := h.db.ListEphemeralNodes()
+	err := h.scheduleEphemeralNodes()
 	if err != nil {
-		return fmt.Errorf("failed to list ephemeral nodes: %w", err)
-	}
-	for _, node := range ephmNodes {
-		h.ephemeralGC.Schedule(node.ID, h.cfg.EphemeralNodeInactivityTimeout)
+		return fmt.Errorf("failed to schedule ephemeral nodes: %w", err)
 	}
 
 	if h.cfg.DNSConfig.ExtraRecordsPath != "" {
@@ -696,10 +882,15 @@ func (h *Headscale) Serve() error {
 	}
 
 	// Start the local gRPC server without TLS and without authentication
-	grpcSocket := grpc.NewServer(
-	// Uncomment to debug grpc communication.
-	// zerolog.UnaryInterceptor(),
-	)
+	socketOptions := []grpc.ServerOption{
+		grpc.UnaryInterceptor(h.grpcSocketAuthorizationInterceptor),
+		// Uncomment to debug grpc communication.
+		// zerolog.UnaryInterceptor(),
+	}
+	if h.socketPeers != nil {
+		socketOptions = append(socketOptions, grpc.Creds(socketPeerCredentials{}))
+	}
+	grpcSocket := grpc.NewServer(socketOptions...)
 
 	v1.RegisterHeadscaleServiceServer(grpcSocket, newHeadscaleV1APIServer(h))
 	reflection.Register(grpcSocket)
@@ -728,6 +919,10 @@ func (h *Headscale) Serve() error {
 	// https://github.com/soheilhy/cmux/issues/68
 	// https://github.com/soheilhy/cmux/issues/91
 
+	if h.grpcClientAuth != nil && tlsConfig == nil {
+		return errGRPCClientAuthWithoutTLS
+	}
+
 	var grpcServer *grpc.Server
 	var grpcListener net.Listener
 	if tlsConfig != nil || h.cfg.GRPCAllowInsecure {
@@ -743,7 +938,11 @@ func (h *Headscale) Serve() error {
 			),
 		}
 
-		if tlsConfig != nil {
+		if h.grpcClientAuth != nil {
+			grpcOptions = append(grpcOptions,
+				grpc.Creds(credentials.NewTLS(h.grpcClientAuth.tlsConfig(tlsConfig))),
+			)
+		} else if tlsConfig != nil {
 			grpcOptions = append(grpcOptions,
 				grpc.Creds(credentials.NewTLS(tlsConfig)),
 			)
@@ -1150,7 +1349,7 @@ func (h *Headscale) loadPolicyManager() error {
 			return
 		}
 
-		h.polMan, err = policy.NewPolicyManager(pol, users, nodes)
+		h.polMan, err = policy.NewPolicyManager(pol, users, nodes.WithoutSuspended())
 		if err != nil {
 			errOut = fmt.Errorf("creating policy manager: %w", err)
 			return
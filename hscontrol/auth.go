@@ -1,6 +1,7 @@
 package hscontrol
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/notifier"
 	"github.com/juanfont/headscale/hscontrol/policy"
 	"github.com/juanfont/headscale/hscontrol/types"
 	"github.com/juanfont/headscale/hscontrol/util"
@@ -24,6 +26,92 @@ type AuthProvider interface {
 	AuthURL(types.RegistrationID) string
 }
 
+// authRegistrar registers the nodes of users who logged in interactively
+// with one of the auth providers.
+type authRegistrar struct {
+	db       *db.HSDatabase
+	notifier *notifier.Notifier
+	ipAlloc  *db.IPAllocator
+	polMan   policy.PolicyManager
+}
+
+// handleRegistration registers the node waiting for the registration ID to
+// the user. Unless tags is nil, the tags replace the forced tags of the
+// node.
+func (r *authRegistrar) handleRegistration(
+	user *types.User,
+	registrationID types.RegistrationID,
+	expiry *time.Time,
+	registrationMethod string,
+	tags []string,
+) (*types.Node, bool, error) {
+	ipv4, ipv6, err := r.ipAlloc.NextFor(user, tags, nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	node, newNode, renamedID, err := r.db.HandleNodeFromAuthPath(
+		registrationID,
+		types.UserID(user.ID),
+		expiry,
+		registrationMethod,
+		ipv4, ipv6,
+		nil,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not register node: %w", err)
+	}
+
+	// The tags mapped from the identity of the user replace the forced
+	// tags on every login, so tags are removed once they no longer apply.
+	if tags != nil {
+		if err := r.db.SetTags(node.ID, tags); err != nil {
+			return nil, false, fmt.Errorf("setting tags from identity: %w", err)
+		}
+		node.ForcedTags = tags
+	}
+
+	// Send an update to all nodes if this is a new node that they need to know
+	// about.
+	// If this is a refresh, just send new expiry updates.
+	updateSent, err := nodesChangedHook(r.db, r.polMan, r.notifier)
+	if err != nil {
+		return nil, false, fmt.Errorf("updating resources using node: %w", err)
+	}
+
+	// This is a bit of a back and forth, but we have a bit of a chicken and egg
+	// dependency here.
+	// Because the way the policy manager works, we need to have the node
+	// in the database, then add it to the policy manager and then we can
+	// approve the route. This means we get this dance where the node is
+	// first added to the database, then we add it to the policy manager via
+	// nodesChangedHook and then we can auto approve the routes.
+	// As that only approves the struct object, we need to save it again and
+	// ensure we send an update.
+	// This works, but might be another good candidate for doing some sort of
+	// eventbus.
+	routesChanged := policy.AutoApproveRoutes(r.polMan, node)
+	if err := r.db.DB.Save(node).Error; err != nil {
+		return nil, false, fmt.Errorf("saving auto approved routes to node: %w", err)
+	}
+
+	if !updateSent || routesChanged {
+		ctx := types.NotifyCtx(context.Background(), registrationMethod+"-expiry-self", node.Hostname)
+		r.notifier.NotifyByNodeID(
+			ctx,
+			types.UpdateSelf(node.ID),
+			node.ID,
+		)
+
+		ctx = types.NotifyCtx(context.Background(), registrationMethod+"-expiry-peers", node.Hostname)
+		r.notifier.NotifyWithIgnore(ctx, types.UpdatePeerChanged(node.ID), node.ID)
+	}
+
+	notifyNameTakenOver(r.notifier, renamedID, node.Hostname)
+
+	return node, newNode, nil
+}
+
 func (h *Headscale) handleRegister(
 	ctx context.Context,
 	regReq tailcfg.RegisterRequest,
@@ -161,6 +249,12 @@ func canUsePreAuthKey(pak *types.PreAuthKey) error {
 	if pak.Expiration != nil && pak.Expiration.Before(time.Now()) {
 		return NewHTTPError(http.StatusUnauthorized, "authkey expired", nil)
 	}
+	if pak.User.Deactivated {
+		return NewHTTPError(http.StatusUnauthorized, "authkey belongs to a deactivated user", nil)
+	}
+	if pak.User.Suspended {
+		return NewHTTPError(http.StatusUnauthorized, "authkey belongs to a suspended user", nil)
+	}
 
 	// we don't need to check if has been used before
 	if pak.Reusable {
@@ -213,12 +307,33 @@ func (h *Headscale) handleRegisterWithAuthKey(
 		nodeToRegister.Expiry = &regReq.Expiry
 	}
 
-	ipv4, ipv6, err := h.ipAlloc.Next()
+	// Apply the settings of the key, they take precedence over
+	// what the client requested.
+	if len(pak.NodeSettings.ApprovedRoutes) > 0 {
+		nodeToRegister.ApprovedRoutes = pak.NodeSettings.ApprovedRoutes
+	}
+
+	if pak.NodeSettings.NodeExpiry > 0 {
+		nodeToRegister.Expiry = ptr.To(time.Now().Add(pak.NodeSettings.NodeExpiry))
+	}
+
+	if pak.NodeSettings.GivenName != "" {
+		nodeToRegister.GivenName = pak.NodeSettings.GivenName
+	}
+
+	ipv4, ipv6, err := h.ipAlloc.NextFor(&pak.User, pak.Tags, pak.NodeSettings.IPv4, pak.NodeSettings.IPv6)
 	if err != nil {
 		return nil, fmt.Errorf("allocating IPs: %w", err)
 	}
 
+	var renamedID types.NodeID
 	node, err := db.Write(h.db.DB, func(tx *gorm.DB) (*types.Node, error) {
+		var err error
+		renamedID, err = db.AssignGivenName(tx, &nodeToRegister, h.nodeNaming(pak.NodeSettings.NameCollision))
+		if err != nil {
+			return nil, err
+		}
+
 		node, err := db.RegisterNode(tx,
 			nodeToRegister,
 			ipv4, ipv6,
@@ -227,6 +342,16 @@ func (h *Headscale) handleRegisterWithAuthKey(
 			return nil, fmt.Errorf("registering node: %w", err)
 		}
 
+		if pak.NodeSettings.IPv4 != nil || pak.NodeSettings.IPv6 != nil {
+			err = db.SetNodeIPs(tx, h.ipAlloc, node.ID, pak.NodeSettings.IPv4, pak.NodeSettings.IPv6)
+			if err != nil {
+				return nil, fmt.Errorf("assigning IPs of pre auth key: %w", err)
+			}
+
+			node.IPv4 = cmp.Or(pak.NodeSettings.IPv4, node.IPv4)
+			node.IPv6 = cmp.Or(pak.NodeSettings.IPv6, node.IPv6)
+		}
+
 		if !pak.Reusable {
 			err = db.UsePreAuthKey(tx, pak)
 			if err != nil {
@@ -236,6 +361,12 @@ func (h *Headscale) handleRegisterWithAuthKey(
 
 		return node, nil
 	})
+	if errors.Is(err, db.ErrNodeGivenNameNotUnique) {
+		return nil, NewHTTPError(http.StatusConflict, "node name is already in use", err)
+	}
+	if errors.Is(err, db.ErrIPInUse) || errors.Is(err, db.ErrIPNotInPrefix) || errors.Is(err, db.ErrIPReserved) {
+		return nil, NewHTTPError(http.StatusConflict, "IP address of pre auth key cannot be assigned", err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -266,6 +397,8 @@ func (h *Headscale) handleRegisterWithAuthKey(
 		h.nodeNotifier.NotifyAll(ctx, types.UpdatePeerChanged(node.ID))
 	}
 
+	notifyNameTakenOver(h.nodeNotifier, renamedID, node.Hostname)
+
 	return &tailcfg.RegisterResponse{
 		MachineAuthorized: true,
 		NodeKeyExpired:    node.IsExpired(),
@@ -274,6 +407,27 @@ func (h *Headscale) handleRegisterWithAuthKey(
 	}, nil
 }
 
+// nodeNaming returns how new nodes are named if their hostname is already
+// used, the collision strategy of a pre auth key overrides the server
+// default.
+func (h *Headscale) nodeNaming(collision types.NodeNameCollision) types.NodeNaming {
+	return types.NodeNaming{
+		Collision: cmp.Or(collision, h.cfg.NodeNameCollision),
+		IsOnline:  h.nodeNotifier.IsLikelyConnected,
+	}
+}
+
+// notifyNameTakenOver tells all nodes about the new name of the node
+// whose name a new node with the hostname took over, if any.
+func notifyNameTakenOver(notif *notifier.Notifier, renamedID types.NodeID, hostname string) {
+	if renamedID == 0 {
+		return
+	}
+
+	ctx := types.NotifyCtx(context.Background(), "node-name-taken-over", hostname)
+	notif.NotifyAll(ctx, types.UpdatePeerChanged(renamedID))
+}
+
 func (h *Headscale) handleRegisterInteractive(
 	regReq tailcfg.RegisterRequest,
 	machineKey key.MachinePublic,
@@ -292,6 +446,7 @@ func (h *Headscale) handleRegisterInteractive(
 			LastSeen:   ptr.To(time.Now()),
 		},
 		Registered: make(chan *types.Node),
+		Naming:     h.nodeNaming(""),
 	}
 
 	if !regReq.Expiry.IsZero() {
@@ -1,12 +1,18 @@
 package hscontrol
 
 import (
+	"database/sql"
 	"net/http"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
 )
 
 func TestCanUsePreAuthKey(t *testing.T) {
@@ -76,6 +82,28 @@ func TestCanUsePreAuthKey(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "key of deactivated user",
+			pak: &types.PreAuthKey{
+				Reusable:   true,
+				Used:       false,
+				Expiration: &future,
+				User:       types.User{Deactivated: true},
+			},
+			wantErr: true,
+			err:     NewHTTPError(http.StatusUnauthorized, "authkey belongs to a deactivated user", nil),
+		},
+		{
+			name: "key of suspended user",
+			pak: &types.PreAuthKey{
+				Reusable:   true,
+				Used:       false,
+				Expiration: &future,
+				User:       types.User{Suspended: true},
+			},
+			wantErr: true,
+			err:     NewHTTPError(http.StatusUnauthorized, "authkey belongs to a suspended user", nil),
+		},
 		{
 			name:    "nil preauth key",
 			pak:     nil,
@@ -128,3 +156,109 @@ func TestCanUsePreAuthKey(t *testing.T) {
 		})
 	}
 }
+
+func TestSuspendedUserCannotAuthenticate(t *testing.T) {
+	h := newTestHeadscale(t, nil)
+
+	user, err := h.db.CreateUser(types.User{
+		Name:               "alice",
+		ProviderIdentifier: sql.NullString{String: "https://sso.example.com/alice", Valid: true},
+	})
+	require.NoError(t, err)
+
+	expiration := time.Now().Add(time.Hour)
+	pak, err := h.db.CreatePreAuthKey(types.UserID(user.ID), true, false, &expiration, nil, nil)
+	require.NoError(t, err)
+	apiKeyStr, _, err := h.db.CreateScopedAPIKey(
+		[]string{types.OAuthScopeAll},
+		"https://sso.example.com/alice",
+		&expiration,
+	)
+	require.NoError(t, err)
+
+	usePreAuthKey := func() error {
+		loaded, err := h.db.GetPreAuthKey(pak.Key)
+		require.NoError(t, err)
+
+		return canUsePreAuthKey(loaded)
+	}
+	authenticateAPIKey := func() *types.APIKey {
+		apiKey, err := h.db.AuthenticateAPIKey(apiKeyStr, func(*types.User) bool { return true })
+		require.NoError(t, err)
+
+		return apiKey
+	}
+
+	require.NoError(t, usePreAuthKey())
+	require.NotNil(t, authenticateAPIKey())
+
+	_, err = h.db.SuspendUser(types.UserID(user.ID))
+	require.NoError(t, err)
+
+	assert.Equal(t, NewHTTPError(http.StatusUnauthorized, "authkey belongs to a suspended user", nil), usePreAuthKey())
+	assert.Nil(t, authenticateAPIKey())
+
+	_, err = h.db.UnsuspendUser(types.UserID(user.ID))
+	require.NoError(t, err)
+
+	require.NoError(t, usePreAuthKey())
+	require.NotNil(t, authenticateAPIKey())
+}
+
+func TestPeersSeeNodeWithNameTakenOver(t *testing.T) {
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.NodeNameCollision = types.NodeNameCollisionTakeover
+	})
+
+	user, err := h.db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+	pak, err := h.db.CreatePreAuthKey(types.UserID(user.ID), true, false, nil, nil, nil)
+	require.NoError(t, err)
+
+	register := func(hostname string) *types.Node {
+		regReq := tailcfg.RegisterRequest{
+			NodeKey:  key.NewNode().Public(),
+			Hostinfo: &tailcfg.Hostinfo{Hostname: hostname},
+			Auth:     &tailcfg.RegisterResponseAuth{AuthKey: pak.Key},
+		}
+		_, err := h.handleRegisterWithAuthKey(regReq, key.NewMachine().Public())
+		require.NoError(t, err)
+
+		node, err := h.db.GetNodeByNodeKey(regReq.NodeKey)
+		require.NoError(t, err)
+
+		return node
+	}
+
+	web := register("web")
+	peer := register("peer")
+
+	updates := make(chan types.StateUpdate, 16)
+	h.nodeNotifier.AddNode(peer.ID, updates)
+
+	waitForPeerChanged := func(nodeID types.NodeID) {
+		t.Helper()
+
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case update := <-updates:
+				if update.Type == types.StatePeerChanged && slices.Contains(update.ChangeNodes, nodeID) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("peer was not told about node %d", nodeID)
+			}
+		}
+	}
+
+	// The changes of the registrations are batched, they have to be
+	// sent before the renamed node is looked for.
+	waitForPeerChanged(peer.ID)
+
+	// The offline node loses its name to the reinstalled one.
+	reinstalled := register("web")
+	assert.Equal(t, "web", reinstalled.GivenName)
+
+	waitForPeerChanged(web.ID)
+}
@@ -0,0 +1,329 @@
+package hscontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+	"zgo.at/zcache/v2"
+)
+
+const (
+	// cliLoginPollTimeout is how long a poll of the token endpoint waits
+	// for the login to complete, it has to stay below the HTTP timeout.
+	cliLoginPollTimeout = 20 * time.Second
+
+	cliLoginIDLength = 32
+
+	// Every pending login polls the OIDC provider until the admin logged
+	// in or the login expired, so they are limited in total and per
+	// source address.
+	cliLoginMaxPending          = 32
+	cliLoginMaxPendingPerSource = 3
+)
+
+var (
+	errCLILoginProviderUnavailable = errors.New("OIDC provider of oidc.cli_login is not available")
+	errCLILoginNoDeviceAuth        = errors.New("OIDC provider does not support the device authorization flow")
+	errCLILoginNotAdmin            = errors.New("authenticated principal is not an admin")
+	errCLILoginUserDisabled        = errors.New("authenticated principal belongs to a suspended or deactivated user")
+	errCLILoginTooManyPending      = errors.New("too many pending logins")
+)
+
+// cliLogin is a headscale login waiting for the admin to log in at the
+// OIDC provider. Once done is closed, token holds the API key or error.
+// cancel stops polling the OIDC provider.
+type cliLogin struct {
+	done   chan struct{}
+	token  types.CLILoginToken
+	cancel context.CancelFunc
+}
+
+// CLILogin issues short-lived API keys to admins logging in to the CLI with
+// the OAuth 2.0 device authorization flow of an OIDC provider.
+type CLILogin struct {
+	cfg      types.CLILoginConfig
+	oidc     *AuthProviderOIDC
+	provider *oidcProvider
+	db       *db.HSDatabase
+	logins   *zcache.Cache[string, *cliLogin]
+
+	mu      sync.Mutex
+	pending map[string]int
+	total   int
+}
+
+func NewCLILogin(
+	cfg types.CLILoginConfig,
+	oidc *AuthProviderOIDC,
+	db *db.HSDatabase,
+) (*CLILogin, error) {
+	provider, ok := oidc.providerByName(cfg.Provider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errCLILoginProviderUnavailable, cfg.Provider)
+	}
+
+	if provider.oauth2Config.Endpoint.DeviceAuthURL == "" {
+		return nil, fmt.Errorf("%w: %q", errCLILoginNoDeviceAuth, cfg.Provider)
+	}
+
+	c := &CLILogin{
+		cfg:      cfg,
+		oidc:     oidc,
+		provider: provider,
+		db:       db,
+		logins: zcache.New[string, *cliLogin](
+			registerCacheExpiration,
+			registerCacheCleanup,
+		),
+		pending: make(map[string]int),
+	}
+
+	// Stop polling the OIDC provider once the login expired.
+	c.logins.OnEvicted(func(_ string, login *cliLogin) {
+		login.cancel()
+	})
+
+	return c, nil
+}
+
+// reserve counts a pending login of the source address, it reports false
+// if there are too many pending logins.
+func (c *CLILogin) reserve(source string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.total >= cliLoginMaxPending || c.pending[source] >= cliLoginMaxPendingPerSource {
+		return false
+	}
+
+	c.pending[source]++
+	c.total++
+
+	return true
+}
+
+// release removes a pending login of the source address.
+func (c *CLILogin) release(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending[source]--
+	if c.pending[source] <= 0 {
+		delete(c.pending, source)
+	}
+	c.total--
+}
+
+// cliLoginSource returns the address logins are limited by.
+func cliLoginSource(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// DeviceHandler starts a login with the device authorization flow of the
+// OIDC provider and tells the CLI where the admin has to log in.
+// Listens in /cli/login/device.
+func (c *CLILogin) DeviceHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	source := cliLoginSource(req)
+	if !c.reserve(source) {
+		log.Warn().Str("client_address", req.RemoteAddr).Msg("too many pending headscale logins")
+		httpError(writer, NewHTTPError(http.StatusTooManyRequests, "too many pending logins, try again later", errCLILoginTooManyPending))
+
+		return
+	}
+
+	// Unlike the token endpoint, the oauth2 package does not
+	// authenticate the client at the device authorization endpoint.
+	var opts []oauth2.AuthCodeOption
+	if c.provider.cfg.ClientSecret != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("client_secret", c.provider.cfg.ClientSecret))
+	}
+
+	deviceAuth, err := c.provider.oauth2Config.DeviceAuth(req.Context(), opts...)
+	if err != nil {
+		c.release(source)
+		httpError(writer, NewHTTPError(http.StatusBadGateway, "OIDC provider did not start the device login", err))
+
+		return
+	}
+
+	loginID, err := util.GenerateRandomStringURLSafe(cliLoginIDLength)
+	if err != nil {
+		c.release(source)
+		httpError(writer, err)
+
+		return
+	}
+
+	// The login ends when the device code expires, or at the latest
+	// when the login ID expires.
+	expiry := time.Now().Add(registerCacheExpiration)
+	if !deviceAuth.Expiry.IsZero() && deviceAuth.Expiry.Before(expiry) {
+		expiry = deviceAuth.Expiry
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), expiry)
+
+	login := &cliLogin{done: make(chan struct{}), cancel: cancel}
+	c.logins.Set(loginID, login)
+
+	go func() {
+		defer c.release(source)
+		defer cancel()
+
+		login.token = c.waitForLogin(ctx, deviceAuth)
+		close(login.done)
+	}()
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(writer).Encode(types.CLILoginDevice{
+		LoginID:                 loginID,
+		UserCode:                deviceAuth.UserCode,
+		VerificationURI:         deviceAuth.VerificationURI,
+		VerificationURIComplete: deviceAuth.VerificationURIComplete,
+		Expiry:                  deviceAuth.Expiry,
+		Interval:                deviceAuth.Interval,
+	})
+}
+
+// TokenHandler returns the API key once the admin logged in, it waits for
+// a while before telling the CLI to poll again.
+// Listens in /cli/login/token.
+func (c *CLILogin) TokenHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	respond := func(code int, token types.CLILoginToken) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set("Cache-Control", "no-store")
+		writer.WriteHeader(code)
+		json.NewEncoder(writer).Encode(token)
+	}
+
+	var tokenReq types.CLILoginTokenRequest
+	if err := json.NewDecoder(req.Body).Decode(&tokenReq); err != nil {
+		respond(http.StatusBadRequest, types.CLILoginToken{Error: "invalid_request", ErrorDescription: "malformed request body"})
+		return
+	}
+
+	login, ok := c.logins.Get(tokenReq.LoginID)
+	if !ok {
+		respond(http.StatusBadRequest, types.CLILoginToken{Error: "expired_token", ErrorDescription: "login not found or expired"})
+		return
+	}
+
+	select {
+	case <-login.done:
+		c.logins.Delete(tokenReq.LoginID)
+	case <-time.After(cliLoginPollTimeout):
+		respond(http.StatusBadRequest, types.CLILoginToken{Error: types.CLILoginPending})
+		return
+	case <-req.Context().Done():
+		return
+	}
+
+	if login.token.Error != "" {
+		respond(http.StatusForbidden, login.token)
+		return
+	}
+
+	respond(http.StatusOK, login.token)
+}
+
+// waitForLogin polls the OIDC provider until the admin logged in, and
+// issues the API key if the admin is allowed to use the CLI.
+func (c *CLILogin) waitForLogin(ctx context.Context, deviceAuth *oauth2.DeviceAuthResponse) types.CLILoginToken {
+	denied := func(err error) types.CLILoginToken {
+		log.Info().Err(err).Msg("headscale login denied")
+
+		return types.CLILoginToken{Error: "access_denied", ErrorDescription: err.Error()}
+	}
+
+	oauth2Token, err := c.provider.oauth2Config.DeviceAccessToken(ctx, deviceAuth)
+	if err != nil {
+		return denied(fmt.Errorf("device login at the OIDC provider failed: %w", err))
+	}
+
+	idToken, err := extractIDToken(ctx, c.provider, oauth2Token)
+	if err != nil {
+		return denied(err)
+	}
+
+	var claims types.OIDCClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return denied(fmt.Errorf("decoding ID token claims: %w", err))
+	}
+
+	if !c.isAdmin(&claims) {
+		return denied(fmt.Errorf("%w: %q", errCLILoginNotAdmin, claims.Email))
+	}
+
+	rawClaims, err := rawOIDCClaims(idToken, nil)
+	if err != nil {
+		return denied(err)
+	}
+
+	// The user of the admin is stored like on a login in the browser, the
+	// API key is only valid while it exists and is still an admin.
+	user, err := c.oidc.createOrUpdateUserFromClaim(c.provider.cfg.Groups, &claims, mapOIDCClaims(c.provider.cfg.Claims, rawClaims))
+	if errors.Is(err, errOIDCUserSuspended) || errors.Is(err, errOIDCUserDeactivated) {
+		return denied(errCLILoginUserDisabled)
+	}
+	if err != nil {
+		return denied(err)
+	}
+	if !c.cfg.IsAdmin(user) {
+		return denied(fmt.Errorf("%w: %q", errCLILoginNotAdmin, claims.Email))
+	}
+
+	expiration := time.Now().Add(c.cfg.Expiry)
+	apiKey, key, err := c.db.CreateScopedAPIKey([]string{c.cfg.Scope}, claims.Identifier(), &expiration)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to issue API key for headscale login")
+
+		return types.CLILoginToken{Error: "server_error"}
+	}
+
+	log.Info().
+		Str("admin", claims.Identifier()).
+		Str("prefix", key.Prefix).
+		Str("scope", c.cfg.Scope).
+		Msg("issued API key with headscale login")
+
+	return types.CLILoginToken{
+		APIKey:     apiKey,
+		Expiration: expiration,
+		Scope:      c.cfg.Scope,
+	}
+}
+
+// isAdmin reports if the verified email or one of the groups of the claims
+// is in the admin lists.
+func (c *CLILogin) isAdmin(claims *types.OIDCClaims) bool {
+	if claims.Email != "" && bool(claims.EmailVerified) && slices.Contains(c.cfg.AdminUsers, claims.Email) {
+		return true
+	}
+
+	return slices.ContainsFunc(claims.Groups, func(group string) bool {
+		return slices.Contains(c.cfg.AdminGroups, group)
+	})
+}
@@ -0,0 +1,198 @@
+package hscontrol
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/oauth2-proxy/mockoidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLILogin(t *testing.T) {
+	mock, err := mockoidc.Run()
+	require.NoError(t, err)
+	defer mock.Shutdown()
+
+	h := newTestHeadscale(t, nil)
+
+	oidcProvider, err := NewAuthProviderOIDC(
+		context.Background(),
+		h.cfg.ServerURL,
+		[]*types.OIDCConfig{{
+			Name:         "default",
+			CallbackPath: "/oidc/callback",
+			Issuer:       mock.Issuer(),
+			ClientID:     mock.ClientID,
+			ClientSecret: mock.ClientSecret,
+			Scope:        []string{"openid", "profile", "email"},
+		}},
+		h.db,
+		h.nodeNotifier,
+		h.ipAlloc,
+		h.polMan,
+	)
+	require.NoError(t, err)
+
+	// The mock provider has no device authorization flow, the device
+	// login is answered by this server with ID tokens signed by the
+	// mock provider. The first poll of each login is pending.
+	var email atomic.Value
+	var emailVerified atomic.Bool
+	var polls atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			polls.Store(0)
+			json.NewEncoder(w).Encode(map[string]any{
+				"device_code":      "device-code",
+				"user_code":        "ABCD-EFGH",
+				"verification_uri": "https://sso.example.com/device",
+				"expires_in":       60,
+				"interval":         1,
+			})
+		case "/token":
+			if polls.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{"error": "authorization_pending"})
+
+				return
+			}
+
+			now := time.Now()
+			idToken, err := mock.Keypair.SignJWT(jwt.MapClaims{
+				"iss":            mock.Issuer(),
+				"aud":            mock.ClientID,
+				"sub":            email.Load().(string),
+				"email":          email.Load().(string),
+				"email_verified": emailVerified.Load(),
+				"iat":            now.Unix(),
+				"exp":            now.Add(time.Minute).Unix(),
+			})
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "access-token",
+				"token_type":   "Bearer",
+				"expires_in":   60,
+				"id_token":     idToken,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer idp.Close()
+
+	provider, ok := oidcProvider.providerByName("default")
+	require.True(t, ok)
+	provider.oauth2Config.Endpoint.DeviceAuthURL = idp.URL + "/device"
+	provider.oauth2Config.Endpoint.TokenURL = idp.URL + "/token"
+
+	cliLogin, err := NewCLILogin(types.CLILoginConfig{
+		Enabled:    true,
+		Provider:   "default",
+		AdminUsers: []string{"admin@example.com"},
+		Expiry:     time.Hour,
+		Scope:      types.OAuthScopeAllRead,
+	}, oidcProvider, h.db)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc(types.CLILoginDevicePath, cliLogin.DeviceHandler)
+	router.HandleFunc(types.CLILoginTokenPath, cliLogin.TokenHandler)
+
+	login := func(as string, verified bool) (int, types.CLILoginToken) {
+		email.Store(as)
+		emailVerified.Store(verified)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, types.CLILoginDevicePath, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var device types.CLILoginDevice
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&device))
+		assert.Equal(t, "ABCD-EFGH", device.UserCode)
+		assert.Equal(t, "https://sso.example.com/device", device.VerificationURI)
+
+		body, err := json.Marshal(types.CLILoginTokenRequest{LoginID: device.LoginID})
+		require.NoError(t, err)
+
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, types.CLILoginTokenPath, strings.NewReader(string(body))))
+
+		var token types.CLILoginToken
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&token))
+
+		return rec.Code, token
+	}
+
+	code, token := login("admin@example.com", true)
+	require.Equal(t, http.StatusOK, code, token.ErrorDescription)
+	assert.Equal(t, types.OAuthScopeAllRead, token.Scope)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiration, time.Minute)
+
+	key, err := h.db.AuthenticateAPIKey(token.APIKey, cliLogin.cfg.IsAdmin)
+	require.NoError(t, err)
+	require.NotNil(t, key)
+
+	// The login stored the user of the admin, the key is rechecked
+	// against it.
+	admin, err := h.db.GetUserByOIDCIdentifier(key.ProviderIdentifier)
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", admin.Email)
+	assert.Equal(t, []string{types.OAuthScopeAllRead}, key.Scopes)
+	assert.Equal(t, mock.Issuer()+"/admin@example.com", key.ProviderIdentifier)
+	require.NoError(t, authorizeAPIKeyScopes(key, "/headscale.v1.HeadscaleService/ListNodes"))
+	require.Error(t, authorizeAPIKeyScopes(key, "/headscale.v1.HeadscaleService/DeleteNode"))
+
+	code, token = login("user@example.com", true)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "access_denied", token.Error)
+	assert.Empty(t, token.APIKey)
+
+	// The email of admins has to be verified by the OIDC provider.
+	code, token = login("admin@example.com", false)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "access_denied", token.Error)
+	assert.Empty(t, token.APIKey)
+
+	// Pending logins are limited per source address, test requests
+	// come from 192.0.2.1.
+	for range cliLoginMaxPendingPerSource {
+		require.True(t, cliLogin.reserve("192.0.2.1"))
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, types.CLILoginDevicePath, nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.True(t, cliLogin.reserve("198.51.100.1"))
+
+	for range cliLoginMaxPendingPerSource {
+		cliLogin.release("192.0.2.1")
+	}
+	cliLogin.release("198.51.100.1")
+
+	code, token = login("admin@example.com", true)
+	require.Equal(t, http.StatusOK, code, token.ErrorDescription)
+
+	// The pending logins are released once they completed.
+	assert.Eventually(t, func() bool {
+		cliLogin.mu.Lock()
+		defer cliLogin.mu.Unlock()
+
+		return cliLogin.total == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -21,6 +21,16 @@ var ErrAPIKeyFailedToParse = errors.New("failed to parse ApiKey")
 // CreateAPIKey creates a new ApiKey in a user, and returns it.
 func (hsdb *HSDatabase) CreateAPIKey(
 	expiration *time.Time,
+) (string, *types.APIKey, error) {
+	return hsdb.CreateScopedAPIKey(nil, "", expiration)
+}
+
+// CreateScopedAPIKey creates a new ApiKey limited to the given scopes and
+// issued to the admin with the given provider identifier, and returns it.
+func (hsdb *HSDatabase) CreateScopedAPIKey(
+	scopes []string,
+	providerIdentifier string,
+	expiration *time.Time,
 ) (string, *types.APIKey, error) {
 	prefix, err := util.GenerateRandomStringURLSafe(apiPrefixLength)
 	if err != nil {
@@ -41,9 +51,11 @@ func (hsdb *HSDatabase) CreateAPIKey(
 	}
 
 	key := types.APIKey{
-		Prefix:     prefix,
-		Hash:       hash,
-		Expiration: expiration,
+		Prefix:             prefix,
+		Hash:               hash,
+		Scopes:             scopes,
+		ProviderIdentifier: providerIdentifier,
+		Expiration:         expiration,
 	}
 
 	if err := hsdb.DB.Save(&key).Error; err != nil {
@@ -102,24 +114,51 @@ func (hsdb *HSDatabase) ExpireAPIKey(key *types.APIKey) error {
 	return nil
 }
 
-func (hsdb *HSDatabase) ValidateAPIKey(keyStr string) (bool, error) {
+func (hsdb *HSDatabase) ValidateAPIKey(keyStr string, isAdmin func(*types.User) bool) (bool, error) {
+	key, err := hsdb.AuthenticateAPIKey(keyStr, isAdmin)
+	if err != nil || key == nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AuthenticateAPIKey returns the ApiKey of the given key, or nil if the
+// key is expired or was issued to an admin who is no longer active. Keys
+// issued to admins require their user to exist, to be neither suspended
+// nor deactivated and to still be an admin according to isAdmin.
+func (hsdb *HSDatabase) AuthenticateAPIKey(keyStr string, isAdmin func(*types.User) bool) (*types.APIKey, error) {
 	prefix, hash, found := strings.Cut(keyStr, ".")
 	if !found {
-		return false, ErrAPIKeyFailedToParse
+		return nil, ErrAPIKeyFailedToParse
 	}
 
 	key, err := hsdb.GetAPIKey(prefix)
 	if err != nil {
-		return false, fmt.Errorf("failed to validate api key: %w", err)
+		return nil, fmt.Errorf("failed to validate api key: %w", err)
 	}
 
 	if key.Expiration.Before(time.Now()) {
-		return false, nil
+		return nil, nil
 	}
 
 	if err := bcrypt.CompareHashAndPassword(key.Hash, []byte(hash)); err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return true, nil
+	if key.ProviderIdentifier != "" {
+		user, err := hsdb.GetUserByOIDCIdentifier(key.ProviderIdentifier)
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate api key: %w", err)
+		}
+
+		if user.Suspended || user.Deactivated || isAdmin == nil || !isAdmin(user) {
+			return nil, nil
+		}
+	}
+
+	return key, nil
 }
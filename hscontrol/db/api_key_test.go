@@ -1,8 +1,11 @@
 package db
 
 import (
+	"database/sql"
+	"slices"
 	"time"
 
+	"github.com/juanfont/headscale/hscontrol/types"
 	"gopkg.in/check.v1"
 )
 
@@ -36,7 +39,7 @@ func (*Suite) TestValidateAPIKeyOk(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(apiKey, check.NotNil)
 
-	valid, err := db.ValidateAPIKey(apiKeyStr)
+	valid, err := db.ValidateAPIKey(apiKeyStr, nil)
 	c.Assert(err, check.IsNil)
 	c.Assert(valid, check.Equals, true)
 }
@@ -47,7 +50,7 @@ func (*Suite) TestValidateAPIKeyNotOk(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(apiKey, check.NotNil)
 
-	valid, err := db.ValidateAPIKey(apiKeyStr)
+	valid, err := db.ValidateAPIKey(apiKeyStr, nil)
 	c.Assert(err, check.IsNil)
 	c.Assert(valid, check.Equals, false)
 
@@ -56,15 +59,15 @@ func (*Suite) TestValidateAPIKeyNotOk(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(apiKey, check.NotNil)
 
-	validNow, err := db.ValidateAPIKey(apiKeyStrNow)
+	validNow, err := db.ValidateAPIKey(apiKeyStrNow, nil)
 	c.Assert(err, check.IsNil)
 	c.Assert(validNow, check.Equals, false)
 
-	validSilly, err := db.ValidateAPIKey("nota.validkey")
+	validSilly, err := db.ValidateAPIKey("nota.validkey", nil)
 	c.Assert(err, check.NotNil)
 	c.Assert(validSilly, check.Equals, false)
 
-	validWithErr, err := db.ValidateAPIKey("produceerrorkey")
+	validWithErr, err := db.ValidateAPIKey("produceerrorkey", nil)
 	c.Assert(err, check.NotNil)
 	c.Assert(validWithErr, check.Equals, false)
 }
@@ -75,7 +78,7 @@ func (*Suite) TestExpireAPIKey(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(apiKey, check.NotNil)
 
-	valid, err := db.ValidateAPIKey(apiKeyStr)
+	valid, err := db.ValidateAPIKey(apiKeyStr, nil)
 	c.Assert(err, check.IsNil)
 	c.Assert(valid, check.Equals, true)
 
@@ -83,7 +86,80 @@ func (*Suite) TestExpireAPIKey(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(apiKey.Expiration, check.NotNil)
 
-	notValid, err := db.ValidateAPIKey(apiKeyStr)
+	notValid, err := db.ValidateAPIKey(apiKeyStr, nil)
 	c.Assert(err, check.IsNil)
 	c.Assert(notValid, check.Equals, false)
 }
+
+func (*Suite) TestScopedAPIKeyOfSuspendedUser(c *check.C) {
+	user, err := db.CreateUser(types.User{
+		Name:               "admin",
+		ProviderIdentifier: sql.NullString{String: "https://sso.example.com/admin", Valid: true},
+	})
+	c.Assert(err, check.IsNil)
+
+	nowPlus2 := time.Now().Add(2 * time.Hour)
+	apiKeyStr, _, err := db.CreateScopedAPIKey(
+		[]string{types.OAuthScopeAllRead},
+		"https://sso.example.com/admin",
+		&nowPlus2,
+	)
+	c.Assert(err, check.IsNil)
+
+	isAdmin := func(*types.User) bool { return true }
+	key, err := db.AuthenticateAPIKey(apiKeyStr, isAdmin)
+	c.Assert(err, check.IsNil)
+	c.Assert(key, check.NotNil)
+	c.Assert(key.HasScope(types.OAuthScopeAllRead), check.Equals, true)
+	c.Assert(key.HasScope(types.OAuthScopeAll), check.Equals, false)
+
+	_, err = db.SuspendUser(types.UserID(user.ID))
+	c.Assert(err, check.IsNil)
+
+	valid, err := db.ValidateAPIKey(apiKeyStr, isAdmin)
+	c.Assert(err, check.IsNil)
+	c.Assert(valid, check.Equals, false)
+}
+
+func (*Suite) TestScopedAPIKeyOfFormerAdmin(c *check.C) {
+	user, err := db.CreateUser(types.User{
+		Name:               "admin",
+		Email:              "admin@example.com",
+		ProviderIdentifier: sql.NullString{String: "https://sso.example.com/admin", Valid: true},
+	})
+	c.Assert(err, check.IsNil)
+
+	nowPlus2 := time.Now().Add(2 * time.Hour)
+	apiKeyStr, _, err := db.CreateScopedAPIKey(
+		[]string{types.OAuthScopeAll},
+		"https://sso.example.com/admin",
+		&nowPlus2,
+	)
+	c.Assert(err, check.IsNil)
+
+	admins := []string{"admin@example.com"}
+	isAdmin := func(user *types.User) bool { return slices.Contains(admins, user.Email) }
+
+	valid, err := db.ValidateAPIKey(apiKeyStr, isAdmin)
+	c.Assert(err, check.IsNil)
+	c.Assert(valid, check.Equals, true)
+
+	// Keys of admins are rejected without a check of the admins.
+	valid, err = db.ValidateAPIKey(apiKeyStr, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(valid, check.Equals, false)
+
+	// Once the admin is no longer listed.
+	admins = nil
+	valid, err = db.ValidateAPIKey(apiKeyStr, isAdmin)
+	c.Assert(err, check.IsNil)
+	c.Assert(valid, check.Equals, false)
+
+	// Or the user of the admin was deleted.
+	admins = []string{"admin@example.com"}
+	c.Assert(db.DestroyUser(types.UserID(user.ID)), check.IsNil)
+
+	key, err := db.AuthenticateAPIKey(apiKeyStr, isAdmin)
+	c.Assert(err, check.IsNil)
+	c.Assert(key, check.IsNil)
+}
@@ -708,7 +708,9 @@ AND auth_key_id NOT IN (
 					for _, user := range users {
 						user.ProviderIdentifier.String = types.CleanIdentifier(user.ProviderIdentifier.String)
 
-						err := tx.Save(user).Error
+						// Only update the identifier, columns added
+						// by later migrations do not exist yet.
+						err := tx.Model(&user).Update("provider_identifier", user.ProviderIdentifier).Error
 						if err != nil {
 							return fmt.Errorf("saving user: %w", err)
 						}
@@ -718,6 +720,237 @@ AND auth_key_id NOT IN (
 				},
 				Rollback: func(db *gorm.DB) error { return nil },
 			},
+			// Add the node settings applied at registration to the
+			// preauth key table.
+			{
+				ID: "202610181200",
+				Migrate: func(tx *gorm.DB) error {
+					err := tx.AutoMigrate(&types.PreAuthKey{})
+					if err != nil {
+						return fmt.Errorf("automigrating types.PreAuthKey: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add tables for OAuth clients and their access tokens.
+			{
+				ID: "202610181300",
+				Migrate: func(tx *gorm.DB) error {
+					err := tx.AutoMigrate(&types.OAuthClient{}, &types.OAuthAccessToken{})
+					if err != nil {
+						return fmt.Errorf("automigrating OAuth clients: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add the groups of the user, synced from OIDC.
+			{
+				ID: "202610181400",
+				Migrate: func(tx *gorm.DB) error {
+					// Only add the column, the indexes of the user
+					// table are managed by hand.
+					if !tx.Migrator().HasColumn(&types.User{}, "groups") {
+						err := tx.Migrator().AddColumn(&types.User{}, "groups")
+						if err != nil {
+							return fmt.Errorf("adding column types.User: %w", err)
+						}
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add users and groups provisioned via SCIM.
+			{
+				ID: "202610181500",
+				Migrate: func(tx *gorm.DB) error {
+					// Only add the columns, the indexes of the user
+					// table are managed by hand.
+					for _, column := range []string{"scim_external_id", "scim_groups", "deactivated"} {
+						if !tx.Migrator().HasColumn(&types.User{}, column) {
+							err := tx.Migrator().AddColumn(&types.User{}, column)
+							if err != nil {
+								return fmt.Errorf("adding column types.User: %w", err)
+							}
+						}
+					}
+
+					err := tx.AutoMigrate(&types.SCIMGroup{})
+					if err != nil {
+						return fmt.Errorf("automigrating types.SCIMGroup: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add suspension of users.
+			{
+				ID: "202610181600",
+				Migrate: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasColumn(&types.User{}, "suspended") {
+						err := tx.Migrator().AddColumn(&types.User{}, "suspended")
+						if err != nil {
+							return fmt.Errorf("adding column types.User: %w", err)
+						}
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add OIDC sessions to extend node expiry with refresh tokens.
+			{
+				ID: "202610181700",
+				Migrate: func(tx *gorm.DB) error {
+					err := tx.AutoMigrate(&types.OIDCSession{})
+					if err != nil {
+						return fmt.Errorf("automigrating types.OIDCSession: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add the sid of OIDC sessions for back-channel logout.
+			{
+				ID: "202610181800",
+				Migrate: func(tx *gorm.DB) error {
+					err := tx.AutoMigrate(&types.OIDCSession{})
+					if err != nil {
+						return fmt.Errorf("automigrating types.OIDCSession: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add local credentials to log in with a password and TOTP code.
+			{
+				ID: "202610181900",
+				Migrate: func(tx *gorm.DB) error {
+					err := tx.AutoMigrate(&types.LocalCredential{})
+					if err != nil {
+						return fmt.Errorf("automigrating types.LocalCredential: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add scopes and the identity of the admin to API keys
+			// issued by headscale login.
+			{
+				ID: "202610182000",
+				Migrate: func(tx *gorm.DB) error {
+					err := tx.AutoMigrate(&types.APIKey{})
+					if err != nil {
+						return fmt.Errorf("automigrating types.APIKey: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			// Add the IP addresses given to nodes registering with
+			// a preauth key.
+			{
+				ID: "202610182100",
+				Migrate: func(tx *gorm.DB) error {
+					err := tx.AutoMigrate(&types.PreAuthKey{})
+					if err != nil {
+						return fmt.Errorf("automigrating types.PreAuthKey: %w", err)
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			{
+				// Add free-form labels to nodes.
+				ID: "202610182200",
+				Migrate: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasColumn(&types.Node{}, "labels") {
+						if err := tx.Migrator().AddColumn(&types.Node{}, "labels"); err != nil {
+							return fmt.Errorf("adding labels column: %w", err)
+						}
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			{
+				// Persist when disconnected ephemeral nodes are
+				// deleted, so that restarts do not postpone it.
+				ID: "202610182300",
+				Migrate: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasColumn(&types.Node{}, "ephemeral_deletion_due") {
+						if err := tx.Migrator().AddColumn(&types.Node{}, "ephemeral_deletion_due"); err != nil {
+							return fmt.Errorf("adding ephemeral_deletion_due column: %w", err)
+						}
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			{
+				// Add DNS aliases to nodes, and the name collision
+				// strategy to preauth keys.
+				ID: "202610182330",
+				Migrate: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasColumn(&types.Node{}, "aliases") {
+						if err := tx.Migrator().AddColumn(&types.Node{}, "aliases"); err != nil {
+							return fmt.Errorf("adding aliases column: %w", err)
+						}
+					}
+
+					if !tx.Migrator().HasColumn(&types.PreAuthKey{}, "name_collision") {
+						if err := tx.Migrator().AddColumn(&types.PreAuthKey{}, "name_collision"); err != nil {
+							return fmt.Errorf("adding name_collision column: %w", err)
+						}
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			{
+				// Store the capability version reported by the
+				// client, the rest of the device details are
+				// in the Hostinfo.
+				ID: "202610190000",
+				Migrate: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasColumn(&types.Node{}, "capver") {
+						if err := tx.Migrator().AddColumn(&types.Node{}, "capver"); err != nil {
+							return fmt.Errorf("adding capver column: %w", err)
+						}
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
+			{
+				// OAuth clients only create ephemeral pre auth
+				// keys unless they are allowed otherwise.
+				ID: "202610190100",
+				Migrate: func(tx *gorm.DB) error {
+					if !tx.Migrator().HasColumn(&types.OAuthClient{}, "allow_non_ephemeral") {
+						if err := tx.Migrator().AddColumn(&types.OAuthClient{}, "allow_non_ephemeral"); err != nil {
+							return fmt.Errorf("adding allow_non_ephemeral column: %w", err)
+						}
+					}
+
+					return nil
+				},
+				Rollback: func(db *gorm.DB) error { return nil },
+			},
 		},
 	)
 
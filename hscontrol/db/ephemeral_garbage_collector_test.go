@@ -387,3 +387,30 @@ func TestEphemeralGarbageCollectorConcurrentScheduleAndClose(t *testing.T) {
 	assert.LessOrEqual(t, finalGoroutines, initialGoroutines+5,
 		"There should be no significant goroutine leaks during concurrent Schedule and Close operations")
 }
+
+// TestEphemeralGarbageCollectorScheduleAt is a test for scheduling nodes at a due time in EphemeralGarbageCollector().
+// It schedules a node in the future and a node in the past, verifies that the node in the past is deleted right away,
+// and that only the node in the future is still scheduled, with its due time.
+func TestEphemeralGarbageCollectorScheduleAt(t *testing.T) {
+	deletionNotifier := make(chan types.NodeID, 1)
+	deleteFunc := func(nodeID types.NodeID) {
+		deletionNotifier <- nodeID
+	}
+
+	gc := NewEphemeralGarbageCollector(deleteFunc)
+	go gc.Start()
+	defer gc.Close()
+
+	due := time.Now().Add(time.Hour)
+	gc.ScheduleAt(types.NodeID(1), due)
+	gc.ScheduleAt(types.NodeID(2), time.Now().Add(-time.Hour))
+
+	select {
+	case deletedNodeID := <-deletionNotifier:
+		assert.Equal(t, types.NodeID(2), deletedNodeID, "The overdue node should be deleted")
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for node deletion")
+	}
+
+	assert.Equal(t, map[types.NodeID]time.Time{1: due}, gc.Scheduled())
+}
@@ -1,12 +1,14 @@
 package db
 
 import (
+	"cmp"
 	"crypto/rand"
 	"database/sql"
 	"errors"
 	"fmt"
 	"math/big"
 	"net/netip"
+	"slices"
 	"sync"
 
 	"github.com/juanfont/headscale/hscontrol/types"
@@ -15,8 +17,13 @@ import (
 	"go4.org/netipx"
 	"gorm.io/gorm"
 	"tailscale.com/net/tsaddr"
+	"tailscale.com/util/set"
 )
 
+// outsideAddrExamples is the number of addresses outside of the prefixes
+// logged at startup.
+const outsideAddrExamples = 5
+
 // IPAllocator is a singleton responsible for allocating
 // IP addresses for nodes and making sure the same
 // address is not handed out twice. There can only be one
@@ -35,6 +42,10 @@ type IPAllocator struct {
 	// strategy used for handing out IP addresses.
 	strategy types.IPAllocationStrategy
 
+	// pools are the parts of the prefixes reserved for the nodes
+	// of some users or tags.
+	pools []*ipPool
+
 	// Set of all IPs handed out.
 	// This might not be in sync with the database,
 	// but it is more conservative. If saves to the
@@ -43,6 +54,38 @@ type IPAllocator struct {
 	usedIPs netipx.IPSetBuilder
 }
 
+// ipPool is a pool of the allocator, it keeps track of the previous
+// addresses handed out from the pool.
+type ipPool struct {
+	types.IPPool
+
+	prev4 netip.Addr
+	prev6 netip.Addr
+}
+
+// family returns the previous address and the prefix of the pool for
+// the IPv4 or IPv6 family.
+func (p *ipPool) family(is4 bool) (*netip.Addr, *netip.Prefix) {
+	if is4 {
+		return &p.prev4, p.PrefixV4
+	}
+
+	return &p.prev6, p.PrefixV6
+}
+
+// matches reports if the pool is for one of the tags, or for the user.
+func (p *ipPool) matches(user *types.User, tags []string) (byTag bool, byUser bool) {
+	byTag = slices.ContainsFunc(p.Tags, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+
+	byUser = user != nil && slices.ContainsFunc(p.Users, func(name string) bool {
+		return name == "*" || name == user.Name || name == user.Email || name == user.Username()
+	})
+
+	return byTag, byUser
+}
+
 // NewIPAllocator returns a new IPAllocator singleton which
 // can be used to hand out unique IP addresses within the
 // provided IPv4 and IPv6 prefix. It needs to be created
@@ -52,6 +95,7 @@ func NewIPAllocator(
 	db *HSDatabase,
 	prefix4, prefix6 *netip.Prefix,
 	strategy types.IPAllocationStrategy,
+	pools []types.IPPool,
 ) (*IPAllocator, error) {
 	ret := IPAllocator{
 		prefix4: prefix4,
@@ -102,8 +146,29 @@ func NewIPAllocator(
 		ret.prev6 = network6
 	}
 
+	// Pools are handled like subnets of the prefixes, their network
+	// and broadcast addrs are not handed out either.
+	for _, pool := range pools {
+		p := &ipPool{IPPool: pool}
+		if pool.PrefixV4 != nil {
+			network4, broadcast4 := util.GetIPPrefixEndpoints(*pool.PrefixV4)
+			ips.Add(network4)
+			ips.Add(broadcast4)
+			p.prev4 = network4
+		}
+		if pool.PrefixV6 != nil {
+			network6, broadcast6 := util.GetIPPrefixEndpoints(*pool.PrefixV6)
+			ips.Add(network6)
+			ips.Add(broadcast6)
+			p.prev6 = network6
+		}
+
+		ret.pools = append(ret.pools, p)
+	}
+
 	// Fetch all the IP Addresses currently handed out from the Database
 	// and add them to the used IP set.
+	var outside []string
 	for _, addrStr := range append(v4s, v6s...) {
 		if addrStr.Valid {
 			addr, err := netip.ParseAddr(addrStr.String)
@@ -112,9 +177,28 @@ func NewIPAllocator(
 			}
 
 			ips.Add(addr)
+
+			if (addr.Is4() && prefix4 != nil && !prefix4.Contains(addr)) ||
+				(addr.Is6() && prefix6 != nil && !prefix6.Contains(addr)) {
+				outside = append(outside, addr.String())
+			}
 		}
 	}
 
+	// After a renumbering, the configuration has to be changed to the
+	// new prefixes, otherwise new nodes get addresses of the old ones and
+	// reverse DNS only covers the old ones.
+	if len(outside) > 0 {
+		log.Error().
+			Int("addresses", len(outside)).
+			Strs("examples", outside[:min(len(outside), outsideAddrExamples)]).
+			Str("prefix_v4", fmt.Sprint(prefix4)).
+			Str("prefix_v6", fmt.Sprint(prefix6)).
+			Msg("nodes have addresses outside of the configured prefixes, " +
+				"change prefixes in the configuration if the nodes were renumbered, " +
+				"or move them into the configured prefixes with 'headscale nodes renumber'")
+	}
+
 	// Build the initial IPSet to validate that we can use it.
 	_, err := ips.IPSet()
 	if err != nil {
@@ -130,42 +214,197 @@ func NewIPAllocator(
 }
 
 func (i *IPAllocator) Next() (*netip.Addr, *netip.Addr, error) {
+	return i.NextFor(nil, nil, nil, nil)
+}
+
+// NextFor returns the given addresses as they are, and allocates the
+// next address of the families without one from the pool of the user or
+// the tags. The given addresses still have to be assigned with
+// SetNodeIPs, which checks them.
+func (i *IPAllocator) NextFor(
+	user *types.User,
+	tags []string,
+	ipv4, ipv6 *netip.Addr,
+) (*netip.Addr, *netip.Addr, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
 	var err error
-	var ret4 *netip.Addr
-	var ret6 *netip.Addr
+	ret4 := ipv4
+	ret6 := ipv6
 
-	if i.prefix4 != nil {
-		ret4, err = i.next(i.prev4, i.prefix4)
+	if i.prefix4 != nil && ret4 == nil {
+		ret4, err = i.allocate(true, user, tags)
 		if err != nil {
 			return nil, nil, fmt.Errorf("allocating IPv4 address: %w", err)
 		}
-		i.prev4 = *ret4
 	}
 
-	if i.prefix6 != nil {
-		ret6, err = i.next(i.prev6, i.prefix6)
+	if i.prefix6 != nil && ret6 == nil {
+		ret6, err = i.allocate(false, user, tags)
 		if err != nil {
 			return nil, nil, fmt.Errorf("allocating IPv6 address: %w", err)
 		}
-		i.prev6 = *ret6
 	}
 
 	return ret4, ret6, nil
 }
 
-var ErrCouldNotAllocateIP = errors.New("failed to allocate IP")
+// pool returns the first pool of one of the tags, or else the first pool
+// of the user.
+func (i *IPAllocator) pool(user *types.User, tags []string) *ipPool {
+	var userPool *ipPool
+	for _, pool := range i.pools {
+		byTag, byUser := pool.matches(user, tags)
+		if byTag {
+			return pool
+		}
+		if byUser && userPool == nil {
+			userPool = pool
+		}
+	}
+
+	return userPool
+}
+
+// allocate hands out the next address of the family from the pool of the
+// user or the tags. Without a pool, or if the pool has no prefix of the
+// family, the address is outside of all pools.
+func (i *IPAllocator) allocate(is4 bool, user *types.User, tags []string) (*netip.Addr, error) {
+	if pool := i.pool(user, tags); pool != nil {
+		if prev, prefix := pool.family(is4); prefix != nil {
+			ip, err := i.next(*prev, prefix, nil)
+			if err != nil {
+				return nil, fmt.Errorf("pool %q: %w", pool.Name, err)
+			}
+			*prev = *ip
+
+			return ip, nil
+		}
+	}
+
+	prev, prefix := &i.prev6, i.prefix6
+	if is4 {
+		prev, prefix = &i.prev4, i.prefix4
+	}
+
+	var exclude []netip.Prefix
+	for _, pool := range i.pools {
+		if _, poolPrefix := pool.family(is4); poolPrefix != nil {
+			exclude = append(exclude, *poolPrefix)
+		}
+	}
+
+	ip, err := i.next(*prev, prefix, exclude)
+	if err != nil {
+		return nil, err
+	}
+	*prev = *ip
+
+	return ip, nil
+}
+
+var (
+	ErrCouldNotAllocateIP = errors.New("failed to allocate IP")
+	ErrIPNotInPrefix      = errors.New("IP address is not in the prefix of the tailnet")
+	ErrIPReserved         = errors.New("IP address is reserved")
+	ErrIPInUse            = errors.New("IP address is in use by another node")
+)
+
+// reserve checks that addr can be assigned to the node and marks it as
+// used. Addresses in the used set which no node holds anymore, e.g. of
+// deleted nodes, can be reserved again.
+func (i *IPAllocator) reserve(tx *gorm.DB, nodeID types.NodeID, addr netip.Addr) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	prefix := i.prefix6
+	if addr.Is4() {
+		prefix = i.prefix4
+	}
+
+	if prefix == nil || !prefix.Contains(addr) {
+		return fmt.Errorf("%w: %s", ErrIPNotInPrefix, addr)
+	}
+
+	network, broadcast := util.GetIPPrefixEndpoints(*prefix)
+	if addr == network || addr == broadcast || isTailscaleReservedIP(addr) {
+		return fmt.Errorf("%w: %s", ErrIPReserved, addr)
+	}
+
+	var count int64
+	if err := tx.Model(&types.Node{}).
+		Where("(ipv4 = ? OR ipv6 = ?) AND id <> ?", addr.String(), addr.String(), nodeID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("checking if IP address is in use: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrIPInUse, addr)
+	}
+
+	i.usedIPs.Add(addr)
+
+	return nil
+}
+
+// SetNodeIPs assigns the given addresses to the node, a nil address leaves
+// the address of its family unchanged. The addresses must be in the
+// prefixes of the tailnet and not be used by another node.
+func SetNodeIPs(
+	tx *gorm.DB,
+	i *IPAllocator,
+	nodeID types.NodeID,
+	ipv4, ipv6 *netip.Addr,
+) error {
+	if ipv4 != nil && !ipv4.Is4() {
+		return fmt.Errorf("%w: %s is not an IPv4 address", ErrIPNotInPrefix, ipv4)
+	}
+
+	if ipv6 != nil && !ipv6.Is6() {
+		return fmt.Errorf("%w: %s is not an IPv6 address", ErrIPNotInPrefix, ipv6)
+	}
+
+	updates := map[string]any{}
+	for _, ip := range []struct {
+		column string
+		addr   *netip.Addr
+	}{
+		{"ipv4", ipv4},
+		{"ipv6", ipv6},
+	} {
+		if ip.addr == nil {
+			continue
+		}
+
+		if err := i.reserve(tx, nodeID, *ip.addr); err != nil {
+			return err
+		}
 
-func (i *IPAllocator) nextLocked(prev netip.Addr, prefix *netip.Prefix) (*netip.Addr, error) {
+		updates[ip.column] = ip.addr.String()
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&types.Node{}).Where("id = ?", nodeID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("updating node IPs: %w", err)
+	}
+
+	return nil
+}
+
+func (i *IPAllocator) allocateLocked(is4 bool, user *types.User, tags []string) (*netip.Addr, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	return i.next(prev, prefix)
+	return i.allocate(is4, user, tags)
 }
 
-func (i *IPAllocator) next(prev netip.Addr, prefix *netip.Prefix) (*netip.Addr, error) {
+// next returns the next free address of the prefix, skipping the
+// addresses of the excluded prefixes.
+func (i *IPAllocator) next(prev netip.Addr, prefix *netip.Prefix, exclude []netip.Prefix) (*netip.Addr, error) {
 	var err error
 	var ip netip.Addr
 
@@ -186,17 +425,44 @@ func (i *IPAllocator) next(prev netip.Addr, prefix *netip.Prefix) (*netip.Addr,
 		return nil, err
 	}
 
+	// Random addresses are drawn from the whole prefix until a free
+	// one is found, make sure there is one.
+	if i.strategy == types.IPAllocationStrategyRandom {
+		var free netipx.IPSetBuilder
+		free.AddPrefix(*prefix)
+		for _, excluded := range exclude {
+			free.RemovePrefix(excluded)
+		}
+		free.RemoveSet(set)
+
+		freeSet, err := free.IPSet()
+		if err != nil {
+			return nil, err
+		}
+		if len(freeSet.Ranges()) == 0 {
+			return nil, ErrCouldNotAllocateIP
+		}
+	}
+
 	for {
 		if !prefix.Contains(ip) {
 			return nil, ErrCouldNotAllocateIP
 		}
 
-		// Check if the IP has already been allocated
-		// or if it is a IP reserved by Tailscale.
-		if set.Contains(ip) || isTailscaleReservedIP(ip) {
+		excluded := slices.IndexFunc(exclude, func(p netip.Prefix) bool {
+			return p.Contains(ip)
+		})
+
+		// Check if the IP has already been allocated, is in a
+		// pool or if it is a IP reserved by Tailscale.
+		if excluded >= 0 || set.Contains(ip) || isTailscaleReservedIP(ip) {
 			switch i.strategy {
 			case types.IPAllocationStrategySequential:
-				ip = ip.Next()
+				if excluded >= 0 {
+					ip = netipx.PrefixLastIP(exclude[excluded]).Next()
+				} else {
+					ip = ip.Next()
+				}
 			case types.IPAllocationStrategyRandom:
 				ip, err = randomNext(*prefix)
 				if err != nil {
@@ -286,7 +552,7 @@ func (db *HSDatabase) BackfillNodeIPs(i *IPAllocator) ([]string, error) {
 			changed := false
 			// IPv4 prefix is set, but node ip is missing, alloc
 			if i.prefix4 != nil && node.IPv4 == nil {
-				ret4, err := i.nextLocked(i.prev4, i.prefix4)
+				ret4, err := i.allocateLocked(true, &node.User, node.ForcedTags)
 				if err != nil {
 					return fmt.Errorf("failed to allocate ipv4 for node(%d): %w", node.ID, err)
 				}
@@ -298,7 +564,7 @@ func (db *HSDatabase) BackfillNodeIPs(i *IPAllocator) ([]string, error) {
 
 			// IPv6 prefix is set, but node ip is missing, alloc
 			if i.prefix6 != nil && node.IPv6 == nil {
-				ret6, err := i.nextLocked(i.prev6, i.prefix6)
+				ret6, err := i.allocateLocked(false, &node.User, node.ForcedTags)
 				if err != nil {
 					return fmt.Errorf("failed to allocate ipv6 for node(%d): %w", node.ID, err)
 				}
@@ -335,3 +601,268 @@ func (db *HSDatabase) BackfillNodeIPs(i *IPAllocator) ([]string, error) {
 
 	return ret, err
 }
+
+var (
+	ErrRenumberFamilyDisabled = errors.New("cannot renumber into a prefix of an IP family without a configured prefix")
+	ErrRenumberWithPools      = errors.New("cannot renumber into other prefixes with prefixes.pools, change the prefixes and pools in the configuration first")
+	ErrRenumberMapping        = errors.New("invalid renumber mapping")
+)
+
+// RenumberNodes moves the addresses of all nodes into the given prefixes
+// in one transaction, without re-registering them. A nil prefix keeps the
+// prefix of the allocator, nodes outside of it are moved into it.
+//
+// Addresses in mapping are given as they are. Otherwise, addresses
+// already in the prefix are kept, the others keep their offset in the
+// prefix if it fits and is free, or get the next free address.
+//
+// It returns the new addresses by the old ones and the changes, nothing
+// is changed on a dry run. The allocator hands out addresses of the new
+// prefixes afterwards, the configuration still has to be changed.
+func (hsdb *HSDatabase) RenumberNodes(
+	i *IPAllocator,
+	prefix4, prefix6 *netip.Prefix,
+	mapping map[netip.Addr]netip.Addr,
+	dryRun bool,
+) (map[netip.Addr]netip.Addr, []string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if (prefix4 != nil && i.prefix4 == nil) || (prefix6 != nil && i.prefix6 == nil) {
+		return nil, nil, ErrRenumberFamilyDisabled
+	}
+
+	prefix4 = cmp.Or(prefix4, i.prefix4)
+	prefix6 = cmp.Or(prefix6, i.prefix6)
+
+	var poolEndpoints []netip.Addr
+	for _, pool := range i.pools {
+		if (pool.PrefixV4 != nil && *prefix4 != *i.prefix4) ||
+			(pool.PrefixV6 != nil && *prefix6 != *i.prefix6) {
+			return nil, nil, ErrRenumberWithPools
+		}
+
+		for _, prefix := range []*netip.Prefix{pool.PrefixV4, pool.PrefixV6} {
+			if prefix != nil {
+				network, broadcast := util.GetIPPrefixEndpoints(*prefix)
+				poolEndpoints = append(poolEndpoints, network, broadcast)
+			}
+		}
+	}
+
+	renumbered := make(map[netip.Addr]netip.Addr)
+	var changes []string
+	var nodes types.Nodes
+
+	err := hsdb.Write(func(tx *gorm.DB) error {
+		var err error
+		nodes, err = ListNodes(tx)
+		if err != nil {
+			return fmt.Errorf("listing nodes to renumber: %w", err)
+		}
+
+		newAddrs := make(map[netip.Addr]netip.Addr)
+		for _, family := range []struct {
+			is4      bool
+			from, to *netip.Prefix
+		}{
+			{true, i.prefix4, prefix4},
+			{false, i.prefix6, prefix6},
+		} {
+			if family.to == nil {
+				continue
+			}
+
+			var addrs []netip.Addr
+			for _, node := range nodes {
+				if addr := nodeAddr(node, family.is4); addr != nil {
+					addrs = append(addrs, *addr)
+				}
+			}
+			slices.SortFunc(addrs, netip.Addr.Compare)
+
+			m, err := renumberPrefix(addrs, *family.from, *family.to, mapping, poolEndpoints)
+			if err != nil {
+				return err
+			}
+
+			for old, addr := range m {
+				newAddrs[old] = addr
+			}
+		}
+
+		for old := range mapping {
+			if _, ok := newAddrs[old]; !ok {
+				return fmt.Errorf("%w: no node has the address %s", ErrRenumberMapping, old)
+			}
+		}
+
+		for _, node := range nodes {
+			for _, family := range []struct {
+				name   string
+				column string
+				addr   **netip.Addr
+			}{
+				{"IPv4", "ipv4", &node.IPv4},
+				{"IPv6", "ipv6", &node.IPv6},
+			} {
+				old := *family.addr
+				if old == nil {
+					continue
+				}
+
+				addr, ok := newAddrs[*old]
+				if !ok || addr == *old {
+					continue
+				}
+
+				renumbered[*old] = addr
+				changes = append(changes, fmt.Sprintf("renumbered %s %q to %q on Node(%d) %q", family.name, old.String(), addr.String(), node.ID, node.Hostname))
+				*family.addr = &addr
+
+				if dryRun {
+					continue
+				}
+
+				err := tx.Model(&types.Node{}).Where("id = ?", node.ID).Update(family.column, addr.String()).Error
+				if err != nil {
+					return fmt.Errorf("renumbering node(%d): %w", node.ID, err)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil || dryRun {
+		return renumbered, changes, err
+	}
+
+	// Start over in the new prefixes.
+	var ips netipx.IPSetBuilder
+	for _, prefix := range []*netip.Prefix{prefix4, prefix6} {
+		if prefix != nil {
+			network, broadcast := util.GetIPPrefixEndpoints(*prefix)
+			ips.Add(network)
+			ips.Add(broadcast)
+		}
+	}
+	for _, addr := range poolEndpoints {
+		ips.Add(addr)
+	}
+	for _, node := range nodes {
+		for _, addr := range node.IPs() {
+			ips.Add(addr)
+		}
+	}
+
+	i.usedIPs = ips
+	i.prefix4 = prefix4
+	i.prefix6 = prefix6
+	if prefix4 != nil {
+		i.prev4, _ = util.GetIPPrefixEndpoints(*prefix4)
+	}
+	if prefix6 != nil {
+		i.prev6, _ = util.GetIPPrefixEndpoints(*prefix6)
+	}
+
+	return renumbered, changes, nil
+}
+
+func nodeAddr(node *types.Node, is4 bool) *netip.Addr {
+	if is4 {
+		return node.IPv4
+	}
+
+	return node.IPv6
+}
+
+// renumberPrefix returns the new addresses of the sorted addresses in the
+// to prefix, by the old ones.
+func renumberPrefix(
+	addrs []netip.Addr,
+	from, to netip.Prefix,
+	mapping map[netip.Addr]netip.Addr,
+	reserved []netip.Addr,
+) (map[netip.Addr]netip.Addr, error) {
+	network, broadcast := util.GetIPPrefixEndpoints(to)
+	taken := set.SetOf(append([]netip.Addr{network, broadcast}, reserved...))
+	free := func(addr netip.Addr) bool {
+		return to.Contains(addr) && !taken.Contains(addr) && !isTailscaleReservedIP(addr)
+	}
+
+	ret := make(map[netip.Addr]netip.Addr, len(addrs))
+	assign := func(old, addr netip.Addr) {
+		ret[old] = addr
+		taken.Add(addr)
+	}
+
+	for _, old := range addrs {
+		if addr, ok := mapping[old]; ok {
+			if !free(addr) {
+				return nil, fmt.Errorf("%w: %s for %s is outside of %s, reserved or given twice", ErrRenumberMapping, addr, old, to)
+			}
+
+			assign(old, addr)
+		}
+	}
+
+	for _, old := range addrs {
+		if _, ok := ret[old]; !ok && free(old) {
+			assign(old, old)
+		}
+	}
+
+	for _, old := range addrs {
+		if _, ok := ret[old]; ok {
+			continue
+		}
+
+		if addr, ok := offsetAddr(from, to, old); ok && free(addr) {
+			assign(old, addr)
+		}
+	}
+
+	next := network
+	for _, old := range addrs {
+		if _, ok := ret[old]; ok {
+			continue
+		}
+
+		for next = next.Next(); !free(next); next = next.Next() {
+			if !to.Contains(next) {
+				return nil, fmt.Errorf("renumbering into %s: %w", to, ErrCouldNotAllocateIP)
+			}
+		}
+
+		assign(old, next)
+	}
+
+	return ret, nil
+}
+
+// offsetAddr returns the address at the offset of addr in from, in the to
+// prefix. It reports false if the offset does not fit into to.
+func offsetAddr(from, to netip.Prefix, addr netip.Addr) (netip.Addr, bool) {
+	if !from.Contains(addr) || from.Addr().BitLen() != to.Addr().BitLen() {
+		return netip.Addr{}, false
+	}
+
+	bytes := addr.AsSlice()
+	ret := to.Masked().Addr().AsSlice()
+	for bit := from.Bits(); bit < len(bytes)*8; bit++ {
+		mask := byte(0x80) >> (bit % 8)
+		if bytes[bit/8]&mask == 0 {
+			continue
+		}
+
+		if bit < to.Bits() {
+			return netip.Addr{}, false
+		}
+
+		ret[bit/8] |= mask
+	}
+
+	renumbered, _ := netip.AddrFromSlice(ret)
+
+	return renumbered, true
+}
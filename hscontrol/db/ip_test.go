@@ -13,7 +13,9 @@ import (
 	"github.com/juanfont/headscale/hscontrol/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 	"tailscale.com/net/tsaddr"
+	"tailscale.com/types/key"
 	"tailscale.com/types/ptr"
 )
 
@@ -157,6 +159,7 @@ func TestIPAllocatorSequential(t *testing.T) {
 				tt.prefix4,
 				tt.prefix6,
 				types.IPAllocationStrategySequential,
+				nil,
 			)
 
 			spew.Dump(alloc)
@@ -261,7 +264,7 @@ func TestIPAllocatorRandom(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db := tt.dbFunc()
 
-			alloc, _ := NewIPAllocator(db, tt.prefix4, tt.prefix6, types.IPAllocationStrategyRandom)
+			alloc, _ := NewIPAllocator(db, tt.prefix4, tt.prefix6, types.IPAllocationStrategyRandom, nil)
 
 			spew.Dump(alloc)
 
@@ -462,6 +465,7 @@ func TestBackfillIPAddresses(t *testing.T) {
 				tt.prefix4,
 				tt.prefix6,
 				types.IPAllocationStrategySequential,
+				nil,
 			)
 			if err != nil {
 				t.Fatalf("failed to set up ip alloc: %s", err)
@@ -496,24 +500,275 @@ func TestIPAllocatorNextNoReservedIPs(t *testing.T) {
 		ptr.To(tsaddr.CGNATRange()),
 		ptr.To(tsaddr.TailscaleULARange()),
 		types.IPAllocationStrategySequential,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("failed to set up ip alloc: %s", err)
 	}
 
 	// Validate that we do not give out 100.100.100.100
-	nextQuad100, err := alloc.next(na("100.100.100.99"), ptr.To(tsaddr.CGNATRange()))
+	nextQuad100, err := alloc.next(na("100.100.100.99"), ptr.To(tsaddr.CGNATRange()), nil)
 	require.NoError(t, err)
 	assert.Equal(t, na("100.100.100.101"), *nextQuad100)
 
 	// Validate that we do not give out fd7a:115c:a1e0::53
-	nextQuad100v6, err := alloc.next(na("fd7a:115c:a1e0::52"), ptr.To(tsaddr.TailscaleULARange()))
+	nextQuad100v6, err := alloc.next(na("fd7a:115c:a1e0::52"), ptr.To(tsaddr.TailscaleULARange()), nil)
 	require.NoError(t, err)
 	assert.Equal(t, na("fd7a:115c:a1e0::54"), *nextQuad100v6)
 
 	// Validate that we do not give out fd7a:115c:a1e0::53
-	nextChrome, err := alloc.next(na("100.115.91.255"), ptr.To(tsaddr.CGNATRange()))
+	nextChrome, err := alloc.next(na("100.115.91.255"), ptr.To(tsaddr.CGNATRange()), nil)
 	t.Logf("chrome: %s", nextChrome.String())
 	require.NoError(t, err)
 	assert.Equal(t, na("100.115.94.0"), *nextChrome)
 }
+
+func TestSetNodeIPs(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	alloc, err := NewIPAllocator(
+		db,
+		ptr.To(tsaddr.CGNATRange()),
+		ptr.To(tsaddr.TailscaleULARange()),
+		types.IPAllocationStrategySequential,
+		nil,
+	)
+	require.NoError(t, err)
+
+	user, err := db.CreateUser(types.User{Name: "test"})
+	require.NoError(t, err)
+
+	newNode := func(hostname string) *types.Node {
+		ipv4, ipv6, err := alloc.Next()
+		require.NoError(t, err)
+
+		node := &types.Node{
+			MachineKey:     key.NewMachine().Public(),
+			NodeKey:        key.NewNode().Public(),
+			Hostname:       hostname,
+			UserID:         user.ID,
+			RegisterMethod: util.RegisterMethodCLI,
+			IPv4:           ipv4,
+			IPv6:           ipv6,
+		}
+		require.NoError(t, db.DB.Save(node).Error)
+
+		return node
+	}
+
+	node1 := newNode("node1")
+	node2 := newNode("node2")
+
+	setIPs := func(nodeID types.NodeID, ipv4, ipv6 *netip.Addr) error {
+		return db.Write(func(tx *gorm.DB) error {
+			return SetNodeIPs(tx, alloc, nodeID, ipv4, ipv6)
+		})
+	}
+
+	require.ErrorIs(t, setIPs(node1.ID, nap("10.0.0.1"), nil), ErrIPNotInPrefix)
+	require.ErrorIs(t, setIPs(node1.ID, nap("fd7a:115c:a1e0::1"), nil), ErrIPNotInPrefix)
+	require.ErrorIs(t, setIPs(node1.ID, nap("100.100.100.100"), nil), ErrIPReserved)
+	require.ErrorIs(t, setIPs(node1.ID, nil, nap("fd7a:115c:a1e0::53")), ErrIPReserved)
+	require.ErrorIs(t, setIPs(node1.ID, node2.IPv4, nil), ErrIPInUse)
+
+	// Keeping its own address is fine.
+	require.NoError(t, setIPs(node1.ID, node1.IPv4, nil))
+
+	require.NoError(t, setIPs(node1.ID, nap("100.64.10.10"), nil))
+	got, err := db.GetNodeByID(node1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, na("100.64.10.10"), *got.IPv4)
+	assert.Equal(t, *node1.IPv6, *got.IPv6)
+
+	// The address of a deleted node can be assigned again.
+	oldIPv4 := *node2.IPv4
+	require.NoError(t, db.DB.Delete(&types.Node{}, node2.ID).Error)
+	node3 := newNode("node3")
+	assert.NotEqual(t, oldIPv4, *node3.IPv4)
+	require.NoError(t, setIPs(node3.ID, &oldIPv4, nil))
+
+	// Fixed addresses are returned as is, the others are allocated.
+	ipv4, ipv6, err := alloc.NextFor(nil, nil, nap("100.64.20.20"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, na("100.64.20.20"), *ipv4)
+	assert.NotNil(t, ipv6)
+}
+
+func TestIPAllocatorPools(t *testing.T) {
+	pools := []types.IPPool{
+		{
+			Name:     "servers",
+			PrefixV4: mpp("100.64.10.0/30"),
+			Tags:     []string{"tag:server"},
+		},
+		{
+			Name:     "users",
+			PrefixV4: mpp("100.64.128.0/17"),
+			PrefixV6: mpp("fd7a:115c:a1e0:8000::/64"),
+			Users:    []string{"*"},
+		},
+		{
+			Name:     "admins",
+			PrefixV4: mpp("100.64.0.0/31"),
+			Users:    []string{"admin"},
+		},
+	}
+
+	for _, strategy := range []types.IPAllocationStrategy{
+		types.IPAllocationStrategySequential,
+		types.IPAllocationStrategyRandom,
+	} {
+		t.Run(string(strategy), func(t *testing.T) {
+			alloc, err := NewIPAllocator(nil, mpp("100.64.0.0/10"), mpp("fd7a:115c:a1e0::/48"), strategy, pools)
+			require.NoError(t, err)
+
+			user := &types.User{Name: "alice"}
+			admin := &types.User{Name: "admin"}
+
+			// Tags take precedence over users.
+			ipv4, ipv6, err := alloc.NextFor(user, []string{"tag:server"}, nil, nil)
+			require.NoError(t, err)
+			assert.True(t, mpp("100.64.10.0/30").Contains(*ipv4), ipv4)
+			assert.False(t, mpp("fd7a:115c:a1e0:8000::/64").Contains(*ipv6), "pool without IPv6 falls back to global pool: %s", ipv6)
+
+			ipv4, ipv6, err = alloc.NextFor(user, nil, nil, nil)
+			require.NoError(t, err)
+			assert.True(t, mpp("100.64.128.0/17").Contains(*ipv4), ipv4)
+			assert.True(t, mpp("fd7a:115c:a1e0:8000::/64").Contains(*ipv6), ipv6)
+
+			// The first matching pool wins, even if a later pool
+			// lists the user.
+			ipv4, _, err = alloc.NextFor(admin, nil, nil, nil)
+			require.NoError(t, err)
+			assert.True(t, mpp("100.64.128.0/17").Contains(*ipv4), ipv4)
+
+			// Nodes matching no pool get addresses outside of all pools.
+			for range 10 {
+				ipv4, ipv6, err = alloc.Next()
+				require.NoError(t, err)
+				for _, pool := range pools {
+					assert.False(t, pool.PrefixV4.Contains(*ipv4), "%s in pool %s", ipv4, pool.Name)
+					if pool.PrefixV6 != nil {
+						assert.False(t, pool.PrefixV6.Contains(*ipv6), "%s in pool %s", ipv6, pool.Name)
+					}
+				}
+			}
+
+			// Exhausted pools are reported by name, the network and
+			// broadcast addresses of pools are not handed out.
+			_, _, err = alloc.NextFor(nil, []string{"tag:server"}, nil, nil)
+			require.NoError(t, err)
+			_, _, err = alloc.NextFor(nil, []string{"tag:server"}, nil, nil)
+			require.ErrorIs(t, err, ErrCouldNotAllocateIP)
+			assert.ErrorContains(t, err, `pool "servers"`)
+		})
+	}
+}
+
+func TestRenumberNodes(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	alloc, err := NewIPAllocator(db, mpp("100.64.0.0/10"), mpp("fd7a:115c:a1e0::/48"), types.IPAllocationStrategySequential, nil)
+	require.NoError(t, err)
+
+	user, err := db.CreateUser(types.User{Name: "test"})
+	require.NoError(t, err)
+
+	addrs := []string{"100.64.0.1", "100.64.0.2", "100.80.0.1", "100.100.0.7", "100.64.0.9"}
+	var nodes []*types.Node
+	for index, addr := range addrs {
+		node := &types.Node{
+			MachineKey:     key.NewMachine().Public(),
+			NodeKey:        key.NewNode().Public(),
+			Hostname:       fmt.Sprintf("node%d", index),
+			UserID:         user.ID,
+			RegisterMethod: util.RegisterMethodCLI,
+			IPv4:           nap(addr),
+			IPv6:           nap(fmt.Sprintf("fd7a:115c:a1e0::%d", index+1)),
+		}
+		require.NoError(t, db.DB.Save(node).Error)
+		nodes = append(nodes, node)
+	}
+
+	mapping := map[netip.Addr]netip.Addr{
+		na("100.64.0.9"): na("100.80.0.100"),
+	}
+
+	_, _, err = db.RenumberNodes(alloc, mpp("100.80.0.0/16"), nil, map[netip.Addr]netip.Addr{
+		na("100.64.0.3"): na("100.80.0.3"),
+	}, true)
+	require.ErrorIs(t, err, ErrRenumberMapping)
+
+	renumbered, changes, err := db.RenumberNodes(alloc, mpp("100.80.0.0/16"), nil, mapping, true)
+	require.NoError(t, err)
+	assert.Len(t, changes, 4)
+
+	// Nothing is changed on a dry run.
+	got, err := db.GetNodeByID(nodes[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, na("100.64.0.1"), *got.IPv4)
+
+	dryRun := renumbered
+	renumbered, _, err = db.RenumberNodes(alloc, mpp("100.80.0.0/16"), nil, mapping, false)
+	require.NoError(t, err)
+	assert.Equal(t, dryRun, renumbered)
+
+	want := map[netip.Addr]netip.Addr{
+		// 100.80.0.1 is kept, so 100.64.0.1 cannot keep its offset.
+		na("100.64.0.1"): na("100.80.0.3"),
+		na("100.64.0.2"): na("100.80.0.2"),
+		// The offset of 100.100.0.7 does not fit into a /16.
+		na("100.100.0.7"): na("100.80.0.4"),
+		na("100.64.0.9"):  na("100.80.0.100"),
+	}
+	assert.Equal(t, want, renumbered)
+
+	for _, node := range nodes {
+		got, err := db.GetNodeByID(node.ID)
+		require.NoError(t, err)
+		wantIPv4, ok := want[*node.IPv4]
+		if !ok {
+			wantIPv4 = *node.IPv4
+		}
+		assert.Equal(t, wantIPv4, *got.IPv4)
+		assert.Equal(t, *node.IPv6, *got.IPv6)
+	}
+
+	// New nodes get addresses of the new prefix.
+	ipv4, _, err := alloc.Next()
+	require.NoError(t, err)
+	assert.True(t, mpp("100.80.0.0/16").Contains(*ipv4), ipv4)
+	assert.NotContains(t, want, *ipv4)
+}
+
+func TestOffsetAddr(t *testing.T) {
+	tests := []struct {
+		from, to string
+		addr     string
+		want     string
+	}{
+		{"100.64.0.0/10", "100.80.0.0/16", "100.64.1.2", "100.80.1.2"},
+		{"100.64.0.0/10", "100.80.0.0/16", "100.65.0.1", ""},
+		{"100.64.0.0/10", "10.0.0.0/8", "100.100.0.7", "10.36.0.7"},
+		{"fd7a:115c:a1e0::/48", "fd00:1::/64", "fd7a:115c:a1e0::1:2", "fd00:1::1:2"},
+		{"fd7a:115c:a1e0::/48", "fd00:1::/64", "fd7a:115c:a1e0:1::1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr+"->"+tt.to, func(t *testing.T) {
+			got, ok := offsetAddr(netip.MustParsePrefix(tt.from), netip.MustParsePrefix(tt.to), na(tt.addr))
+			if tt.want == "" {
+				assert.False(t, ok, got)
+
+				return
+			}
+
+			assert.True(t, ok)
+			assert.Equal(t, na(tt.want), got)
+		})
+	}
+}
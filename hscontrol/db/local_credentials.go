@@ -0,0 +1,227 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLocalCredentialNotFound = errors.New("user has no password")
+	ErrLocalLoginInvalid       = errors.New("invalid username, password or code")
+	ErrLocalLoginLocked        = errors.New("too many failed logins, try again later")
+)
+
+// unknownUserHash is compared against the password of logins for unknown
+// users, so they take as long as logins with a wrong password.
+var unknownUserHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("headscale"), bcrypt.DefaultCost)
+
+	return hash
+})
+
+func (hsdb *HSDatabase) SetUserPassword(uid types.UserID, password string) error {
+	return hsdb.Write(func(tx *gorm.DB) error {
+		return SetUserPassword(tx, uid, password)
+	})
+}
+
+// SetUserPassword sets the password of a local user and lifts a lockout.
+// An empty password removes the local credential, including the TOTP
+// secret, so the user can no longer log in with a password.
+func SetUserPassword(tx *gorm.DB, uid types.UserID, password string) error {
+	if _, err := GetUserByID(tx, uid); err != nil {
+		return err
+	}
+
+	if password == "" {
+		if err := tx.Where("user_id = ?", uid).Delete(&types.LocalCredential{}).Error; err != nil {
+			return fmt.Errorf("deleting local credential of user %d: %w", uid, err)
+		}
+
+		return nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	cred := types.LocalCredential{
+		UserID:       uint(uid),
+		PasswordHash: hash,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"password_hash":   hash,
+			"failed_attempts": 0,
+			"locked_until":    nil,
+			"updated_at":      time.Now(),
+		}),
+	}).Omit("User").Create(&cred).Error; err != nil {
+		return fmt.Errorf("storing local credential of user %d: %w", uid, err)
+	}
+
+	return nil
+}
+
+func (hsdb *HSDatabase) SetUserTOTPSecret(uid types.UserID, secret string) error {
+	return hsdb.Write(func(tx *gorm.DB) error {
+		return SetUserTOTPSecret(tx, uid, secret)
+	})
+}
+
+// SetUserTOTPSecret sets the TOTP secret of a local user, an empty secret
+// disables the second factor. The user must have a password.
+func SetUserTOTPSecret(tx *gorm.DB, uid types.UserID, secret string) error {
+	res := tx.Model(&types.LocalCredential{}).
+		Where("user_id = ?", uid).
+		Updates(map[string]any{
+			"totp_secret":    secret,
+			"last_totp_step": 0,
+		})
+	if res.Error != nil {
+		return fmt.Errorf("storing TOTP secret of user %d: %w", uid, res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrLocalCredentialNotFound
+	}
+
+	return nil
+}
+
+func (hsdb *HSDatabase) GetLocalCredential(uid types.UserID) (*types.LocalCredential, error) {
+	return Read(hsdb.DB, func(rx *gorm.DB) (*types.LocalCredential, error) {
+		return GetLocalCredential(rx, uid)
+	})
+}
+
+// GetLocalCredential returns the local credential of a user.
+func GetLocalCredential(tx *gorm.DB, uid types.UserID) (*types.LocalCredential, error) {
+	var cred types.LocalCredential
+	if err := tx.Where("user_id = ?", uid).Take(&cred).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLocalCredentialNotFound
+		}
+
+		return nil, err
+	}
+
+	return &cred, nil
+}
+
+// LocalLogin checks the password and, if the user has a TOTP secret, the
+// TOTP code of a local user. After maxAttempts failed logins the user is
+// locked out for lockout, a maxAttempts of zero disables the lockout.
+// Unknown users, wrong passwords and wrong codes all return
+// ErrLocalLoginInvalid, so they cannot be told apart.
+func (hsdb *HSDatabase) LocalLogin(
+	name string,
+	password string,
+	code string,
+	maxAttempts int,
+	lockout time.Duration,
+) (*types.User, error) {
+	user, err := hsdb.GetUserByName(name)
+	if errors.Is(err, ErrUserNotFound) {
+		_ = bcrypt.CompareHashAndPassword(unknownUserHash(), []byte(password))
+		return nil, ErrLocalLoginInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := hsdb.GetLocalCredential(types.UserID(user.ID))
+	if errors.Is(err, ErrLocalCredentialNotFound) {
+		_ = bcrypt.CompareHashAndPassword(unknownUserHash(), []byte(password))
+		return nil, ErrLocalLoginInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if cred.LockedUntil != nil && now.Before(*cred.LockedUntil) {
+		return nil, ErrLocalLoginLocked
+	}
+
+	valid := bcrypt.CompareHashAndPassword(cred.PasswordHash, []byte(password)) == nil
+	step := cred.LastTOTPStep
+	if valid && cred.TOTPSecret != "" {
+		step, valid = util.ValidateTOTP(cred.TOTPSecret, code, now)
+	}
+
+	if !valid {
+		locked, err := recordLocalLoginFailure(hsdb.DB, cred.ID, maxAttempts, now.Add(lockout))
+		if err != nil {
+			return nil, err
+		}
+
+		if locked {
+			return nil, ErrLocalLoginLocked
+		}
+
+		return nil, ErrLocalLoginInvalid
+	}
+
+	// The update only matches if the TOTP code has not been used
+	// before, which also guards against concurrent logins with the
+	// same code.
+	res := hsdb.DB.Model(&types.LocalCredential{}).
+		Where("id = ? AND (totp_secret = '' OR last_totp_step < ?)", cred.ID, step).
+		Updates(map[string]any{
+			"failed_attempts": 0,
+			"locked_until":    nil,
+			"last_totp_step":  step,
+		})
+	if res.Error != nil {
+		return nil, fmt.Errorf("storing login of user %d: %w", user.ID, res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return nil, ErrLocalLoginInvalid
+	}
+
+	return user, nil
+}
+
+// recordLocalLoginFailure counts a failed login and locks the user out
+// once maxAttempts is reached. It reports if the user is locked out.
+func recordLocalLoginFailure(
+	db *gorm.DB,
+	credID uint64,
+	maxAttempts int,
+	lockedUntil time.Time,
+) (bool, error) {
+	return Write(db, func(tx *gorm.DB) (bool, error) {
+		var cred types.LocalCredential
+		if err := tx.Take(&cred, credID).Error; err != nil {
+			return false, err
+		}
+
+		cred.FailedAttempts++
+		locked := maxAttempts > 0 && cred.FailedAttempts >= maxAttempts
+		if locked {
+			cred.FailedAttempts = 0
+			cred.LockedUntil = &lockedUntil
+		}
+
+		if err := tx.Model(&cred).Updates(map[string]any{
+			"failed_attempts": cred.FailedAttempts,
+			"locked_until":    cred.LockedUntil,
+		}).Error; err != nil {
+			return false, fmt.Errorf("storing failed login: %w", err)
+		}
+
+		return locked, nil
+	})
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalLogin(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	user, err := db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+	uid := types.UserID(user.ID)
+
+	_, err = db.LocalLogin("alice", "correct horse", "", 3, time.Minute)
+	require.ErrorIs(t, err, ErrLocalLoginInvalid)
+
+	require.ErrorIs(t, db.SetUserTOTPSecret(uid, "secret"), ErrLocalCredentialNotFound)
+
+	require.NoError(t, db.SetUserPassword(uid, "correct horse"))
+
+	got, err := db.LocalLogin("alice", "correct horse", "", 3, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	_, err = db.LocalLogin("bob", "correct horse", "", 3, time.Minute)
+	require.ErrorIs(t, err, ErrLocalLoginInvalid)
+
+	// The user is locked out after three failed logins, even with the
+	// right password.
+	for range 2 {
+		_, err = db.LocalLogin("alice", "wrong", "", 3, time.Minute)
+		require.ErrorIs(t, err, ErrLocalLoginInvalid)
+	}
+	_, err = db.LocalLogin("alice", "wrong", "", 3, time.Minute)
+	require.ErrorIs(t, err, ErrLocalLoginLocked)
+	_, err = db.LocalLogin("alice", "correct horse", "", 3, time.Minute)
+	require.ErrorIs(t, err, ErrLocalLoginLocked)
+
+	// Setting a new password lifts the lockout.
+	require.NoError(t, db.SetUserPassword(uid, "battery staple"))
+
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+	require.NoError(t, db.SetUserTOTPSecret(uid, secret))
+
+	_, err = db.LocalLogin("alice", "battery staple", "", 3, time.Minute)
+	require.ErrorIs(t, err, ErrLocalLoginInvalid)
+
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	_, err = db.LocalLogin("alice", "battery staple", code, 3, time.Minute)
+	require.NoError(t, err)
+
+	// A code can only be used once.
+	_, err = db.LocalLogin("alice", "battery staple", code, 3, time.Minute)
+	require.ErrorIs(t, err, ErrLocalLoginInvalid)
+
+	// Removing the password removes the TOTP secret as well.
+	require.NoError(t, db.SetUserPassword(uid, ""))
+	_, err = db.GetLocalCredential(uid)
+	require.ErrorIs(t, err, ErrLocalCredentialNotFound)
+}
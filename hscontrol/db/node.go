@@ -1,12 +1,15 @@
 package db
 
 import (
+	"cmp"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/netip"
 	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,6 +36,10 @@ var (
 	ErrDifferentRegisteredUser      = errors.New(
 		"node was previously registered with a different user",
 	)
+	ErrNodeGivenNameNotUnique = errors.New("given name is already in use by another node")
+	ErrNodeAliasNotUnique     = errors.New("alias is already in use")
+	ErrNodeAliasInvalid       = errors.New("invalid alias")
+	ErrInvalidNodeCursor      = errors.New("invalid node cursor")
 )
 
 // ListPeers returns peers of node, regardless of any Policy or if the node is expired.
@@ -86,6 +93,204 @@ func ListNodes(tx *gorm.DB, nodeIDs ...types.NodeID) (types.Nodes, error) {
 	return nodes, nil
 }
 
+// NodeFilter narrows down the nodes returned by ListNodesFiltered, zero
+// fields match all nodes.
+type NodeFilter struct {
+	UserID *types.UserID
+	// Name is a substring of the hostname or the given name, ignoring
+	// case.
+	Name          string
+	LastSeenAfter *time.Time
+	// LastSeenBefore also matches nodes which were never seen and were
+	// created before.
+	LastSeenBefore *time.Time
+
+	// SortBy is one of the keys accepted by IsNodeSortKey, nodes are
+	// sorted by ID if it is empty. Ties are broken by the ID.
+	SortBy     string
+	Descending bool
+	// After skips the nodes up to and including the cursor in the sort
+	// order.
+	After *NodeCursor
+	// Limit is the maximum number of nodes returned, zero for all.
+	Limit int
+}
+
+// NodeCursor is the position of a node in the sort order of
+// ListNodesFiltered, as returned by NodeSortCursor.
+type NodeCursor struct {
+	Key string
+	ID  types.NodeID
+}
+
+type nodeSortColumn struct {
+	column string
+	time   bool
+	key    func(*types.Node) string
+}
+
+// nodeSortColumns are the columns nodes can be sorted by and the cursor
+// key of a node in them. Times are keyed in RFC 3339, missing times sort
+// first and have an empty key.
+var nodeSortColumns = map[string]nodeSortColumn{
+	"id": {
+		key: func(*types.Node) string { return "" },
+	},
+	"name": {
+		column: "COALESCE(nodes.given_name, '')",
+		key:    func(n *types.Node) string { return n.GivenName },
+	},
+	"hostname": {
+		column: "COALESCE(nodes.hostname, '')",
+		key:    func(n *types.Node) string { return n.Hostname },
+	},
+	"user": {
+		column: "COALESCE((SELECT users.name FROM users WHERE users.id = nodes.user_id), '')",
+		key:    func(n *types.Node) string { return n.User.Name },
+	},
+	"last_seen": {
+		column: "nodes.last_seen",
+		time:   true,
+		key:    func(n *types.Node) string { return nodeSortTime(n.LastSeen) },
+	},
+	"created_at": {
+		column: "nodes.created_at",
+		time:   true,
+		key:    func(n *types.Node) string { return nodeSortTime(&n.CreatedAt) },
+	},
+	"expiry": {
+		column: "nodes.expiry",
+		time:   true,
+		key:    func(n *types.Node) string { return nodeSortTime(n.Expiry) },
+	},
+}
+
+func nodeSortTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+// IsNodeSortKey reports if ListNodesFiltered can sort nodes by the key.
+func IsNodeSortKey(sortBy string) bool {
+	_, ok := nodeSortColumns[sortBy]
+
+	return ok
+}
+
+// NodeSortCursor returns the position of the node when sorting by the
+// key.
+func NodeSortCursor(node *types.Node, sortBy string) NodeCursor {
+	return NodeCursor{
+		Key: nodeSortColumns[cmp.Or(sortBy, "id")].key(node),
+		ID:  node.ID,
+	}
+}
+
+// ListNodesFiltered queries the database for the nodes matching the
+// filter, sorted as requested.
+func ListNodesFiltered(tx *gorm.DB, filter NodeFilter) (types.Nodes, error) {
+	query := tx.
+		Preload("AuthKey").
+		Preload("AuthKey.User").
+		Preload("User")
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+
+	if filter.Name != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Name)) + "%"
+		query = query.Where(
+			`LOWER(hostname) LIKE ? ESCAPE '\' OR LOWER(given_name) LIKE ? ESCAPE '\'`,
+			pattern, pattern,
+		)
+	}
+
+	if filter.LastSeenAfter != nil {
+		query = query.Where("last_seen > ?", *filter.LastSeenAfter)
+	}
+
+	if filter.LastSeenBefore != nil {
+		query = query.Where(
+			"last_seen < ? OR (last_seen IS NULL AND created_at < ?)",
+			*filter.LastSeenBefore, *filter.LastSeenBefore,
+		)
+	}
+
+	query, err := sortNodes(query, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	nodes := types.Nodes{}
+	if err := query.Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// sortNodes orders the query by the sort key of the filter and skips the
+// nodes up to its cursor.
+func sortNodes(query *gorm.DB, filter NodeFilter) (*gorm.DB, error) {
+	sortBy, ok := nodeSortColumns[cmp.Or(filter.SortBy, "id")]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort key %q", ErrInvalidNodeCursor, filter.SortBy)
+	}
+
+	direction, op := "ASC", ">"
+	if filter.Descending {
+		direction, op = "DESC", "<"
+	}
+
+	col := sortBy.column
+	if col != "" {
+		if sortBy.time {
+			query = query.Order(fmt.Sprintf("%s IS NOT NULL %s", col, direction))
+		}
+		query = query.Order(col + " " + direction)
+	}
+	query = query.Order("nodes.id " + direction)
+
+	after := filter.After
+	switch {
+	case after == nil:
+	case col == "":
+		query = query.Where("nodes.id "+op+" ?", after.ID)
+	case sortBy.time && after.Key == "" && !filter.Descending:
+		query = query.Where(fmt.Sprintf("%s IS NOT NULL OR nodes.id > ?", col), after.ID)
+	case sortBy.time && after.Key == "":
+		query = query.Where(fmt.Sprintf("%s IS NULL AND nodes.id < ?", col), after.ID)
+	default:
+		var key any = after.Key
+		if sortBy.time {
+			t, err := time.Parse(time.RFC3339Nano, after.Key)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidNodeCursor, err)
+			}
+			key = t
+		}
+
+		cond := fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND nodes.id %[2]s ?)", col, op)
+		if sortBy.time && filter.Descending {
+			// Nodes without a time sort last.
+			cond = col + " IS NULL OR " + cond
+		}
+		query = query.Where(cond, key, key, after.ID)
+	}
+
+	return query, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (hsdb *HSDatabase) ListEphemeralNodes() (types.Nodes, error) {
 	return Read(hsdb.DB, func(rx *gorm.DB) (types.Nodes, error) {
 		nodes := types.Nodes{}
@@ -224,6 +429,133 @@ func SetTags(
 	return nil
 }
 
+// SetNodeLabels adds or changes the given labels of the node, other
+// labels are kept.
+func SetNodeLabels(
+	tx *gorm.DB,
+	nodeID types.NodeID,
+	labels map[string]string,
+) error {
+	for key, value := range labels {
+		if err := types.ValidateLabel(key, value); err != nil {
+			return err
+		}
+	}
+
+	node, err := GetNodeByID(tx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	if node.Labels == nil {
+		node.Labels = make(map[string]string, len(labels))
+	}
+	maps.Copy(node.Labels, labels)
+
+	return updateNodeLabels(tx, nodeID, node.Labels)
+}
+
+// DeleteNodeLabels removes the labels with the given keys from the node,
+// keys the node does not have are ignored.
+func DeleteNodeLabels(
+	tx *gorm.DB,
+	nodeID types.NodeID,
+	keys []string,
+) error {
+	node, err := GetNodeByID(tx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		delete(node.Labels, key)
+	}
+
+	return updateNodeLabels(tx, nodeID, node.Labels)
+}
+
+func updateNodeLabels(tx *gorm.DB, nodeID types.NodeID, labels map[string]string) error {
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+
+	if len(labels) == 0 {
+		b = []byte("{}")
+	}
+
+	if err := tx.Model(&types.Node{}).Where("id = ?", nodeID).Update("labels", string(b)).Error; err != nil {
+		return fmt.Errorf("updating labels: %w", err)
+	}
+
+	return nil
+}
+
+// SetNodeAliases replaces the DNS aliases of the node. Aliases are single
+// DNS labels that are not the name or an alias of another node.
+func SetNodeAliases(tx *gorm.DB, nodeID types.NodeID, aliases []string) error {
+	node, err := GetNodeByID(tx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	aliases = slices.Clone(aliases)
+	slices.Sort(aliases)
+	aliases = slices.Compact(aliases)
+
+	for _, alias := range aliases {
+		if err := util.CheckForFQDNRules(alias); err != nil {
+			return fmt.Errorf("%w: %w", ErrNodeAliasInvalid, err)
+		}
+
+		if strings.Contains(alias, ".") {
+			return fmt.Errorf("%w: %q must be a single DNS label", ErrNodeAliasInvalid, alias)
+		}
+
+		other, err := nodeByDNSName(tx, alias)
+		if err != nil {
+			return err
+		}
+
+		if alias == node.GivenName || (other != nil && other.ID != node.ID) {
+			return fmt.Errorf("%w: %s", ErrNodeAliasNotUnique, alias)
+		}
+	}
+
+	b, err := json.Marshal(aliases)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&types.Node{}).Where("id = ?", nodeID).Update("aliases", string(b)).Error; err != nil {
+		return fmt.Errorf("updating aliases: %w", err)
+	}
+
+	return nil
+}
+
+// ListNodesWithAliases returns the nodes that have DNS aliases.
+func (hsdb *HSDatabase) ListNodesWithAliases() (types.Nodes, error) {
+	return Read(hsdb.DB, func(rx *gorm.DB) (types.Nodes, error) {
+		return ListNodesWithAliases(rx)
+	})
+}
+
+// ListNodesWithAliases returns the nodes that have DNS aliases.
+func ListNodesWithAliases(tx *gorm.DB) (types.Nodes, error) {
+	nodes := types.Nodes{}
+	if err := tx.
+		Preload("AuthKey").
+		Preload("AuthKey.User").
+		Preload("User").
+		Where("aliases IS NOT NULL AND aliases NOT IN ?", []string{"", "null", "[]"}).
+		Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
 // SetTags takes a Node struct pointer and update the forced tags.
 func SetApprovedRoutes(
 	tx *gorm.DB,
@@ -265,6 +597,20 @@ func SetLastSeen(tx *gorm.DB, nodeID types.NodeID, lastSeen time.Time) error {
 	return tx.Model(&types.Node{}).Where("id = ?", nodeID).Update("last_seen", lastSeen).Error
 }
 
+// SetEphemeralDeletionDue records when the ephemeral node is deleted, nil
+// clears it when the node reconnects.
+func (hsdb *HSDatabase) SetEphemeralDeletionDue(nodeID types.NodeID, due *time.Time) error {
+	return hsdb.Write(func(tx *gorm.DB) error {
+		return SetEphemeralDeletionDue(tx, nodeID, due)
+	})
+}
+
+// SetEphemeralDeletionDue records when the ephemeral node is deleted, nil
+// clears it when the node reconnects.
+func SetEphemeralDeletionDue(tx *gorm.DB, nodeID types.NodeID, due *time.Time) error {
+	return tx.Model(&types.Node{}).Where("id = ?", nodeID).Update("ephemeral_deletion_due", due).Error
+}
+
 // RenameNode takes a Node struct and a new GivenName for the nodes
 // and renames it. If the name is not unique, it will return an error.
 func RenameNode(tx *gorm.DB,
@@ -306,6 +652,32 @@ func NodeSetExpiry(tx *gorm.DB,
 	return tx.Model(&types.Node{}).Where("id = ?", nodeID).Update("expiry", expiry).Error
 }
 
+// ExpireUserNodes expires all nodes of the user which are not expired yet
+// and returns them.
+// Caller is responsible for notifying all of change.
+func ExpireUserNodes(tx *gorm.DB, uid types.UserID, expiry time.Time) (types.Nodes, error) {
+	nodes, err := ListNodesByUser(tx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	var expired types.Nodes
+	for _, node := range nodes {
+		if node.IsExpired() {
+			continue
+		}
+
+		if err := NodeSetExpiry(tx, node.ID, expiry); err != nil {
+			return nil, fmt.Errorf("expiring node %d: %w", node.ID, err)
+		}
+		node.Expiry = &expiry
+
+		expired = append(expired, node)
+	}
+
+	return expired, nil
+}
+
 func (hsdb *HSDatabase) DeleteNode(node *types.Node) error {
 	return hsdb.Write(func(tx *gorm.DB) error {
 		return DeleteNode(tx, node)
@@ -344,7 +716,8 @@ func (hsdb *HSDatabase) DeleteEphemeralNode(
 // If the node found in the registration cache is not already registered,
 // it will be registered with the user and the node will be removed from the cache.
 // If the node is already registered, the expiry will be updated.
-// The node, and a boolean indicating if it was a new node or not, will be returned.
+// The node, a boolean indicating if it was a new node or not, and the ID
+// of the node whose name the new node took over, if any, will be returned.
 func (hsdb *HSDatabase) HandleNodeFromAuthPath(
 	registrationID types.RegistrationID,
 	userID types.UserID,
@@ -352,8 +725,10 @@ func (hsdb *HSDatabase) HandleNodeFromAuthPath(
 	registrationMethod string,
 	ipv4 *netip.Addr,
 	ipv6 *netip.Addr,
-) (*types.Node, bool, error) {
+	setIPs func(tx *gorm.DB, node *types.Node) error,
+) (*types.Node, bool, types.NodeID, error) {
 	var newNode bool
+	var renamedID types.NodeID
 	node, err := Write(hsdb.DB, func(tx *gorm.DB) (*types.Node, error) {
 		if reg, ok := hsdb.regCache.Get(registrationID); ok {
 			if node, _ := GetNodeByNodeKey(tx, reg.Node.NodeKey); node == nil {
@@ -388,12 +763,26 @@ func (hsdb *HSDatabase) HandleNodeFromAuthPath(
 					reg.Node.Expiry = nodeExpiry
 				}
 
+				renamedID, err = AssignGivenName(tx, &reg.Node, reg.Naming)
+				if err != nil {
+					return nil, err
+				}
+
 				node, err := RegisterNode(
 					tx,
 					reg.Node,
 					ipv4, ipv6,
 				)
 
+				// Addresses requested for the node are checked
+				// before the client is told it is registered, so a
+				// conflict rolls back the registration.
+				if err == nil && setIPs != nil {
+					if err := setIPs(tx, node); err != nil {
+						return nil, err
+					}
+				}
+
 				if err == nil {
 					hsdb.regCache.Delete(registrationID)
 				}
@@ -420,7 +809,7 @@ func (hsdb *HSDatabase) HandleNodeFromAuthPath(
 		return nil, ErrNodeNotFoundRegistrationCache
 	})
 
-	return node, newNode, err
+	return node, newNode, renamedID, err
 }
 
 func (hsdb *HSDatabase) RegisterNode(node types.Node, ipv4 *netip.Addr, ipv6 *netip.Addr) (*types.Node, error) {
@@ -445,7 +834,7 @@ func RegisterNode(tx *gorm.DB, node types.Node, ipv4 *netip.Addr, ipv6 *netip.Ad
 	oldNode, _ := GetNodeByMachineKey(tx, node.MachineKey)
 	if oldNode != nil && oldNode.UserID == node.UserID {
 		node.ID = oldNode.ID
-		node.GivenName = oldNode.GivenName
+		node.GivenName = cmp.Or(node.GivenName, oldNode.GivenName)
 		ipv4 = oldNode.IPv4
 		ipv6 = oldNode.IPv6
 	}
@@ -479,6 +868,18 @@ func RegisterNode(tx *gorm.DB, node types.Node, ipv4 *netip.Addr, ipv6 *netip.Ad
 		}
 
 		node.GivenName = givenName
+	} else {
+		// A given name was set before registering, e.g. by a
+		// pre auth key, it must be kept as is, so it is not
+		// made unique, but rejected if another node uses it.
+		other, err := nodeByDNSName(tx, node.GivenName)
+		if err != nil {
+			return nil, fmt.Errorf("checking if given name is unique: %w", err)
+		}
+
+		if other != nil && other.ID != node.ID {
+			return nil, fmt.Errorf("%w: %s", ErrNodeGivenNameNotUnique, node.GivenName)
+		}
 	}
 
 	if err := tx.Save(&node).Error; err != nil {
@@ -552,13 +953,39 @@ func generateGivenName(suppliedName string, randomSuffix bool) (string, error) {
 }
 
 func isUniqueName(tx *gorm.DB, name string) (bool, error) {
+	node, err := nodeByDNSName(tx, name)
+	if err != nil {
+		return false, err
+	}
+
+	return node == nil, nil
+}
+
+// nodeByDNSName returns the node using the name as given name or alias,
+// or nil if no node uses it.
+func nodeByDNSName(tx *gorm.DB, name string) (*types.Node, error) {
 	nodes := types.Nodes{}
 	if err := tx.
 		Where("given_name = ?", name).Find(&nodes).Error; err != nil {
-		return false, err
+		return nil, err
+	}
+
+	if len(nodes) > 0 {
+		return nodes[0], nil
+	}
+
+	aliased, err := ListNodesWithAliases(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range aliased {
+		if slices.Contains(node.Aliases, name) {
+			return node, nil
+		}
 	}
 
-	return len(nodes) == 0, nil
+	return nil, nil
 }
 
 func ensureUniqueGivenName(
@@ -587,6 +1014,76 @@ func ensureUniqueGivenName(
 	return givenName, nil
 }
 
+// AssignGivenName sets the given name of a new node from its hostname,
+// and resolves a collision with the name of another node as the naming
+// decides. Nodes with a given name and nodes registering again with
+// the same machine key are left as they are.
+// If the name is taken over, the ID of the renamed node is returned, so
+// its peers can be told about the new name once the transaction is
+// committed.
+func AssignGivenName(tx *gorm.DB, node *types.Node, naming types.NodeNaming) (types.NodeID, error) {
+	if node.GivenName != "" {
+		return 0, nil
+	}
+
+	if old, _ := GetNodeByMachineKey(tx, node.MachineKey); old != nil && old.UserID == node.UserID {
+		return 0, nil
+	}
+
+	givenName, err := generateGivenName(node.Hostname, false)
+	if err != nil {
+		return 0, err
+	}
+
+	other, err := nodeByDNSName(tx, givenName)
+	if err != nil {
+		return 0, err
+	}
+
+	var renamedID types.NodeID
+	switch {
+	case other == nil:
+	case naming.Collision == types.NodeNameCollisionReject:
+		return 0, fmt.Errorf("%w: %s", ErrNodeGivenNameNotUnique, givenName)
+	case naming.Collision == types.NodeNameCollisionTakeover && canTakeOverName(other, node, givenName, naming.IsOnline):
+		renamed, err := ensureUniqueGivenName(tx, other.Hostname)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := tx.Model(&types.Node{}).Where("id = ?", other.ID).Update("given_name", renamed).Error; err != nil {
+			return 0, fmt.Errorf("renaming node: %w", err)
+		}
+		renamedID = other.ID
+
+		log.Info().
+			Uint64("node.id", other.ID.Uint64()).
+			Str("old_name", givenName).
+			Str("new_name", renamed).
+			Msg("Name of node taken over by a new node with the same hostname")
+	default:
+		givenName, err = ensureUniqueGivenName(tx, node.Hostname)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	node.GivenName = givenName
+
+	return renamedID, nil
+}
+
+// canTakeOverName reports if the new node can take the name from the
+// other node: it is the given name of a node with the same hostname and
+// user, which is expired or offline.
+func canTakeOverName(other *types.Node, node *types.Node, name string, isOnline func(types.NodeID) bool) bool {
+	if other.GivenName != name || other.Hostname != node.Hostname || other.UserID != node.UserID {
+		return false
+	}
+
+	return other.IsExpired() || (isOnline != nil && !isOnline(other.ID))
+}
+
 func ExpireExpiredNodes(tx *gorm.DB,
 	lastCheck time.Time,
 ) (time.Time, types.StateUpdate, bool) {
@@ -625,17 +1122,22 @@ type EphemeralGarbageCollector struct {
 	mu sync.Mutex
 
 	deleteFunc  func(types.NodeID)
-	toBeDeleted map[types.NodeID]*time.Timer
+	toBeDeleted map[types.NodeID]scheduledDeletion
 
 	deleteCh chan types.NodeID
 	cancelCh chan struct{}
 }
 
+type scheduledDeletion struct {
+	timer *time.Timer
+	due   time.Time
+}
+
 // NewEphemeralGarbageCollector creates a new EphemeralGarbageCollector, it takes
 // a deleteFunc that will be called when a node is scheduled for deletion.
 func NewEphemeralGarbageCollector(deleteFunc func(types.NodeID)) *EphemeralGarbageCollector {
 	return &EphemeralGarbageCollector{
-		toBeDeleted: make(map[types.NodeID]*time.Timer),
+		toBeDeleted: make(map[types.NodeID]scheduledDeletion),
 		deleteCh:    make(chan types.NodeID, 10),
 		cancelCh:    make(chan struct{}),
 		deleteFunc:  deleteFunc,
@@ -648,8 +1150,8 @@ func (e *EphemeralGarbageCollector) Close() {
 	defer e.mu.Unlock()
 
 	// Stop all timers
-	for _, timer := range e.toBeDeleted {
-		timer.Stop()
+	for _, scheduled := range e.toBeDeleted {
+		scheduled.timer.Stop()
 	}
 
 	// Close the cancel channel to signal all goroutines to exit
@@ -659,6 +1161,13 @@ func (e *EphemeralGarbageCollector) Close() {
 // Schedule schedules a node for deletion after the expiry duration.
 // If the garbage collector is already closed, this is a no-op.
 func (e *EphemeralGarbageCollector) Schedule(nodeID types.NodeID, expiry time.Duration) {
+	e.ScheduleAt(nodeID, time.Now().Add(expiry))
+}
+
+// ScheduleAt schedules a node for deletion at the due time, a due time in
+// the past deletes it right away.
+// If the garbage collector is already closed, this is a no-op.
+func (e *EphemeralGarbageCollector) ScheduleAt(nodeID types.NodeID, due time.Time) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -673,12 +1182,12 @@ func (e *EphemeralGarbageCollector) Schedule(nodeID types.NodeID, expiry time.Du
 	}
 
 	// If a timer already exists for this node, stop it first
-	if oldTimer, exists := e.toBeDeleted[nodeID]; exists {
-		oldTimer.Stop()
+	if old, exists := e.toBeDeleted[nodeID]; exists {
+		old.timer.Stop()
 	}
 
-	timer := time.NewTimer(expiry)
-	e.toBeDeleted[nodeID] = timer
+	timer := time.NewTimer(time.Until(due))
+	e.toBeDeleted[nodeID] = scheduledDeletion{timer: timer, due: due}
 	// Start a goroutine to handle the timer completion
 	go func() {
 		select {
@@ -706,12 +1215,26 @@ func (e *EphemeralGarbageCollector) Cancel(nodeID types.NodeID) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	if timer, ok := e.toBeDeleted[nodeID]; ok {
-		timer.Stop()
+	if scheduled, ok := e.toBeDeleted[nodeID]; ok {
+		scheduled.timer.Stop()
 		delete(e.toBeDeleted, nodeID)
 	}
 }
 
+// Scheduled returns the nodes scheduled for deletion and when they are
+// deleted.
+func (e *EphemeralGarbageCollector) Scheduled() map[types.NodeID]time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	scheduled := make(map[types.NodeID]time.Time, len(e.toBeDeleted))
+	for nodeID, s := range e.toBeDeleted {
+		scheduled[nodeID] = s.due
+	}
+
+	return scheduled
+}
+
 // Start starts the garbage collector.
 func (e *EphemeralGarbageCollector) Start() {
 	for {
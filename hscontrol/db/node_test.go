@@ -6,7 +6,9 @@ import (
 	"math/big"
 	"net/netip"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -29,7 +31,7 @@ func (s *Suite) TestGetNode(c *check.C) {
 	user, err := db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	_, err = db.getNode(types.UserID(user.ID), "testnode")
@@ -58,7 +60,7 @@ func (s *Suite) TestGetNodeByID(c *check.C) {
 	user, err := db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	_, err = db.GetNodeByID(0)
@@ -112,7 +114,7 @@ func (s *Suite) TestListPeers(c *check.C) {
 	user, err := db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	_, err = db.GetNodeByID(0)
@@ -151,7 +153,7 @@ func (s *Suite) TestExpireNode(c *check.C) {
 	user, err := db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	_, err = db.getNode(types.UserID(user.ID), "testnode")
@@ -192,7 +194,7 @@ func (s *Suite) TestSetTags(c *check.C) {
 	user, err := db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	_, err = db.getNode(types.UserID(user.ID), "testnode")
@@ -602,10 +604,10 @@ func TestListEphemeralNodes(t *testing.T) {
 	user, err := db.CreateUser(types.User{Name: "test"})
 	require.NoError(t, err)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	require.NoError(t, err)
 
-	pakEph, err := db.CreatePreAuthKey(types.UserID(user.ID), false, true, nil, nil)
+	pakEph, err := db.CreatePreAuthKey(types.UserID(user.ID), false, true, nil, nil, nil)
 	require.NoError(t, err)
 
 	node := types.Node{
@@ -748,6 +750,199 @@ func TestRenameNode(t *testing.T) {
 	assert.ErrorContains(t, err, "name is not unique")
 }
 
+func TestNodeLabels(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	user, err := db.CreateUser(types.User{Name: "test"})
+	require.NoError(t, err)
+
+	node := types.Node{
+		MachineKey:     key.NewMachine().Public(),
+		NodeKey:        key.NewNode().Public(),
+		Hostname:       "test",
+		UserID:         user.ID,
+		RegisterMethod: util.RegisterMethodAuthKey,
+		Hostinfo:       &tailcfg.Hostinfo{},
+	}
+	require.NoError(t, db.DB.Save(&node).Error)
+
+	err = db.Write(func(tx *gorm.DB) error {
+		return SetNodeLabels(tx, node.ID, map[string]string{"env": "prod", "owner": "alice"})
+	})
+	require.NoError(t, err)
+
+	// Setting labels keeps the other labels.
+	err = db.Write(func(tx *gorm.DB) error {
+		return SetNodeLabels(tx, node.ID, map[string]string{"env": "dev", "rack": "r12"})
+	})
+	require.NoError(t, err)
+
+	got, err := db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "dev", "owner": "alice", "rack": "r12"}, got.Labels)
+
+	err = db.Write(func(tx *gorm.DB) error {
+		return SetNodeLabels(tx, node.ID, map[string]string{"bad key": "x"})
+	})
+	require.ErrorIs(t, err, types.ErrInvalidLabel)
+
+	err = db.Write(func(tx *gorm.DB) error {
+		return DeleteNodeLabels(tx, node.ID, []string{"env", "rack", "missing"})
+	})
+	require.NoError(t, err)
+
+	got, err = db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "alice"}, got.Labels)
+
+	err = db.Write(func(tx *gorm.DB) error {
+		return DeleteNodeLabels(tx, node.ID, []string{"owner"})
+	})
+	require.NoError(t, err)
+
+	got, err = db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Labels)
+}
+
+func TestListNodesFiltered(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	user1, err := db.CreateUser(types.User{Name: "user1"})
+	require.NoError(t, err)
+	user2, err := db.CreateUser(types.User{Name: "user2"})
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, node := range []types.Node{
+		{Hostname: "web-1", GivenName: "web-1", UserID: user1.ID, LastSeen: ptr.To(now.Add(-time.Hour))},
+		{Hostname: "web-2", GivenName: "frontend", UserID: user2.ID, LastSeen: ptr.To(now.Add(-48 * time.Hour))},
+		{Hostname: "db_1", GivenName: "db-1", UserID: user1.ID},
+	} {
+		node.MachineKey = key.NewMachine().Public()
+		node.NodeKey = key.NewNode().Public()
+		node.Hostinfo = &tailcfg.Hostinfo{}
+		require.NoError(t, db.DB.Save(&node).Error)
+	}
+
+	hostnames := func(filter NodeFilter) []string {
+		nodes, err := Read(db.DB, func(rx *gorm.DB) (types.Nodes, error) {
+			return ListNodesFiltered(rx, filter)
+		})
+		require.NoError(t, err)
+
+		var ret []string
+		for _, node := range nodes {
+			ret = append(ret, node.Hostname)
+		}
+		slices.Sort(ret)
+
+		return ret
+	}
+
+	assert.Equal(t, []string{"db_1", "web-1", "web-2"}, hostnames(NodeFilter{}))
+	assert.Equal(t, []string{"db_1", "web-1"}, hostnames(NodeFilter{UserID: ptr.To(types.UserID(user1.ID))}))
+	assert.Equal(t, []string{"web-1", "web-2"}, hostnames(NodeFilter{Name: "WEB"}))
+	assert.Equal(t, []string{"web-2"}, hostnames(NodeFilter{Name: "front"}))
+	assert.Equal(t, []string{"db_1"}, hostnames(NodeFilter{Name: "_"}))
+	assert.Equal(t, []string{"web-1"}, hostnames(NodeFilter{LastSeenAfter: ptr.To(now.Add(-24 * time.Hour))}))
+	assert.Equal(t, []string{"web-2"}, hostnames(NodeFilter{LastSeenBefore: ptr.To(now.Add(-24 * time.Hour))}))
+}
+
+func TestListNodesFilteredPages(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	user, err := db.CreateUser(types.User{Name: "user1"})
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, node := range []types.Node{
+		{Hostname: "web", GivenName: "web-1", LastSeen: ptr.To(now.Add(-time.Hour))},
+		{Hostname: "db", GivenName: "db", LastSeen: ptr.To(now)},
+		{Hostname: "web", GivenName: "web-2"},
+		{Hostname: "app", GivenName: "app", LastSeen: ptr.To(now.Add(-time.Minute))},
+		{Hostname: "cache", GivenName: "cache", LastSeen: ptr.To(now.Add(-time.Minute))},
+		{Hostname: "mail", GivenName: "mail"},
+	} {
+		node.UserID = user.ID
+		node.MachineKey = key.NewMachine().Public()
+		node.NodeKey = key.NewNode().Public()
+		node.Hostinfo = &tailcfg.Hostinfo{}
+		require.NoError(t, db.DB.Save(&node).Error)
+	}
+
+	tests := []struct {
+		name   string
+		filter NodeFilter
+		want   [][]types.NodeID
+	}{
+		{
+			name:   "all-by-id",
+			filter: NodeFilter{},
+			want:   [][]types.NodeID{{1, 2, 3, 4, 5, 6}},
+		},
+		{
+			name:   "pages-by-id-descending",
+			filter: NodeFilter{Descending: true, Limit: 4},
+			want:   [][]types.NodeID{{6, 5, 4, 3}, {2, 1}},
+		},
+		{
+			name:   "pages-by-hostname",
+			filter: NodeFilter{SortBy: "hostname", Limit: 2},
+			want:   [][]types.NodeID{{4, 5}, {2, 6}, {1, 3}, {}},
+		},
+		{
+			name:   "pages-by-last-seen",
+			filter: NodeFilter{SortBy: "last_seen", Limit: 2},
+			want:   [][]types.NodeID{{3, 6}, {1, 4}, {5, 2}, {}},
+		},
+		{
+			name:   "pages-by-last-seen-descending",
+			filter: NodeFilter{SortBy: "last_seen", Descending: true, Limit: 4},
+			want:   [][]types.NodeID{{2, 5, 4, 1}, {6, 3}},
+		},
+		{
+			name:   "pages-by-user",
+			filter: NodeFilter{SortBy: "user", Limit: 5},
+			want:   [][]types.NodeID{{1, 2, 3, 4, 5}, {6}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			got := [][]types.NodeID{}
+			for {
+				nodes, err := Read(db.DB, func(rx *gorm.DB) (types.Nodes, error) {
+					return ListNodesFiltered(rx, filter)
+				})
+				require.NoError(t, err)
+
+				ids := []types.NodeID{}
+				for _, node := range nodes {
+					ids = append(ids, node.ID)
+				}
+				got = append(got, ids)
+
+				if filter.Limit == 0 || len(nodes) < filter.Limit {
+					break
+				}
+				filter.After = ptr.To(NodeSortCursor(nodes[len(nodes)-1], filter.SortBy))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = Read(db.DB, func(rx *gorm.DB) (types.Nodes, error) {
+		return ListNodesFiltered(rx, NodeFilter{SortBy: "expiry", After: &NodeCursor{Key: "yesterday", ID: 1}})
+	})
+	require.ErrorIs(t, err, ErrInvalidNodeCursor)
+}
+
 func TestListPeers(t *testing.T) {
 	// Setup test database
 	db, err := newSQLiteTestDB()
@@ -918,3 +1113,173 @@ func TestListNodes(t *testing.T) {
 	assert.Equal(t, "test1", nodes[0].Hostname)
 	assert.Equal(t, "test2", nodes[1].Hostname)
 }
+
+func TestRegisterNodePresetGivenName(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	user, err := db.CreateUser(types.User{Name: "test"})
+	require.NoError(t, err)
+
+	newNode := func(givenName string) types.Node {
+		return types.Node{
+			MachineKey:     key.NewMachine().Public(),
+			NodeKey:        key.NewNode().Public(),
+			Hostname:       "test",
+			GivenName:      givenName,
+			UserID:         user.ID,
+			RegisterMethod: util.RegisterMethodAuthKey,
+			Hostinfo:       &tailcfg.Hostinfo{},
+		}
+	}
+
+	_, err = db.RegisterNode(newNode(""), nil, nil)
+	require.NoError(t, err)
+
+	// A preset name is kept as is, even if it does not match the hostname.
+	node, err := db.RegisterNode(newNode("ci-runner"), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ci-runner", node.GivenName)
+
+	// A preset name is not made unique, but rejected.
+	_, err = db.RegisterNode(newNode("test"), nil, nil)
+	require.ErrorIs(t, err, ErrNodeGivenNameNotUnique)
+
+	// Re-registering the same node with its own name is fine.
+	node.NodeKey = key.NewNode().Public()
+	_, err = db.RegisterNode(*node, nil, nil)
+	require.NoError(t, err)
+}
+
+func TestAssignGivenName(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	alice, err := db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+	bob, err := db.CreateUser(types.User{Name: "bob"})
+	require.NoError(t, err)
+
+	newNode := func(hostname string, userID uint) types.Node {
+		return types.Node{
+			MachineKey:     key.NewMachine().Public(),
+			NodeKey:        key.NewNode().Public(),
+			Hostname:       hostname,
+			UserID:         userID,
+			RegisterMethod: util.RegisterMethodAuthKey,
+			Hostinfo:       &tailcfg.Hostinfo{},
+		}
+	}
+
+	online := map[types.NodeID]bool{}
+	var renamedID types.NodeID
+	register := func(node types.Node, collision types.NodeNameCollision) (*types.Node, error) {
+		return Write(db.DB, func(tx *gorm.DB) (*types.Node, error) {
+			var err error
+			renamedID, err = AssignGivenName(tx, &node, types.NodeNaming{
+				Collision: collision,
+				IsOnline:  func(id types.NodeID) bool { return online[id] },
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return RegisterNode(tx, node, nil, nil)
+		})
+	}
+
+	web, err := register(newNode("web-1", alice.ID), types.NodeNameCollisionSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, "web-1", web.GivenName)
+	online[web.ID] = true
+
+	suffixed, err := register(newNode("web-1", alice.ID), types.NodeNameCollisionSuffix)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(suffixed.GivenName, "web-1-"))
+
+	_, err = register(newNode("web-1", alice.ID), types.NodeNameCollisionReject)
+	require.ErrorIs(t, err, ErrNodeGivenNameNotUnique)
+
+	// The name of a connected node is not taken over.
+	connected, err := register(newNode("web-1", alice.ID), types.NodeNameCollisionTakeover)
+	require.NoError(t, err)
+	assert.NotEqual(t, "web-1", connected.GivenName)
+	assert.Zero(t, renamedID)
+
+	// Nor the name of a node of another user.
+	online[web.ID] = false
+	other, err := register(newNode("web-1", bob.ID), types.NodeNameCollisionTakeover)
+	require.NoError(t, err)
+	assert.NotEqual(t, "web-1", other.GivenName)
+
+	reinstalled, err := register(newNode("web-1", alice.ID), types.NodeNameCollisionTakeover)
+	require.NoError(t, err)
+	assert.Equal(t, "web-1", reinstalled.GivenName)
+	assert.Equal(t, web.ID, renamedID)
+
+	web, err = db.GetNodeByID(web.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(web.GivenName, "web-1-"))
+
+	// Aliases are names too.
+	require.NoError(t, db.Write(func(tx *gorm.DB) error {
+		return SetNodeAliases(tx, web.ID, []string{"www"})
+	}))
+	_, err = register(newNode("www", alice.ID), types.NodeNameCollisionReject)
+	require.ErrorIs(t, err, ErrNodeGivenNameNotUnique)
+}
+
+func TestSetNodeAliases(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	user, err := db.CreateUser(types.User{Name: "test"})
+	require.NoError(t, err)
+
+	for _, hostname := range []string{"web", "db"} {
+		node := types.Node{
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			Hostname:   hostname,
+			GivenName:  hostname,
+			UserID:     user.ID,
+		}
+		require.NoError(t, db.DB.Save(&node).Error)
+	}
+
+	setAliases := func(nodeID types.NodeID, aliases ...string) error {
+		return db.Write(func(tx *gorm.DB) error {
+			return SetNodeAliases(tx, nodeID, aliases)
+		})
+	}
+
+	require.NoError(t, setAliases(1, "www", "app", "www"))
+	node, err := db.GetNodeByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app", "www"}, node.Aliases)
+
+	// Setting the aliases again keeps the node's own aliases valid.
+	require.NoError(t, setAliases(1, "www"))
+
+	require.ErrorIs(t, setAliases(2, "www"), ErrNodeAliasNotUnique)
+	require.ErrorIs(t, setAliases(2, "web"), ErrNodeAliasNotUnique)
+	require.ErrorIs(t, setAliases(2, "db"), ErrNodeAliasNotUnique)
+	require.ErrorIs(t, setAliases(2, "Www"), ErrNodeAliasInvalid)
+	require.ErrorIs(t, setAliases(2, "www.app"), ErrNodeAliasInvalid)
+
+	// Renaming to an alias of another node fails.
+	err = db.Write(func(tx *gorm.DB) error {
+		return RenameNode(tx, 2, "www")
+	})
+	require.Error(t, err)
+
+	nodes, err := db.ListNodesWithAliases()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "web", nodes[0].Hostname)
+
+	require.NoError(t, setAliases(1))
+	nodes, err = db.ListNodesWithAliases()
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+}
@@ -0,0 +1,260 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	oauthClientIDLength     = 16
+	oauthClientSecretLength = 48
+)
+
+var (
+	ErrOAuthClientScopeInvalid     = errors.New("invalid OAuth client scope")
+	ErrOAuthClientTagInvalid       = errors.New("invalid OAuth client tag")
+	ErrOAuthClientInvalid          = errors.New("invalid OAuth client credentials")
+	ErrOAuthScopeNotGranted        = errors.New("scope is not granted to OAuth client")
+	ErrOAuthAccessTokenInvalid     = errors.New("invalid OAuth access token")
+	ErrOAuthAccessTokenFailedParse = errors.New("failed to parse OAuth access token")
+)
+
+// CreateOAuthClient creates a new OAuth client, and returns it together with
+// its secret. The secret is only visible _once_.
+func (hsdb *HSDatabase) CreateOAuthClient(
+	scopes []string,
+	tags []string,
+	description string,
+	expiration *time.Time,
+	allowNonEphemeral bool,
+) (string, *types.OAuthClient, error) {
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", ErrOAuthClientScopeInvalid)
+	}
+
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+	for _, scope := range scopes {
+		if !slices.Contains(types.OAuthScopes, scope) {
+			return "", nil, fmt.Errorf(
+				"%w: %q, must be one of %s",
+				ErrOAuthClientScopeInvalid,
+				scope,
+				strings.Join(types.OAuthScopes, ", "),
+			)
+		}
+	}
+
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, "tag:") {
+			return "", nil, fmt.Errorf("%w: %q did not begin with 'tag:'", ErrOAuthClientTagInvalid, tag)
+		}
+	}
+
+	// Keys created with the auth_keys scope are always limited to
+	// the tags of the client.
+	if slices.Contains(scopes, types.OAuthScopeAuthKeys) && len(tags) == 0 {
+		return "", nil, fmt.Errorf("%w: scope %q requires at least one tag", ErrOAuthClientTagInvalid, types.OAuthScopeAuthKeys)
+	}
+
+	clientID, err := util.GenerateRandomStringDNSSafe(oauthClientIDLength)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret, err := util.GenerateRandomStringURLSafe(oauthClientSecretLength)
+	if err != nil {
+		return "", nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, err
+	}
+
+	client := types.OAuthClient{
+		ClientID:    clientID,
+		Hash:        hash,
+		Scopes:      scopes,
+		Tags:        tags,
+		Description: description,
+		Expiration:  expiration,
+
+		AllowNonEphemeral: allowNonEphemeral,
+	}
+
+	if err := hsdb.DB.Save(&client).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to save OAuth client to database: %w", err)
+	}
+
+	return secret, &client, nil
+}
+
+// ListOAuthClients returns the list of OAuth clients.
+func (hsdb *HSDatabase) ListOAuthClients() ([]types.OAuthClient, error) {
+	clients := []types.OAuthClient{}
+	if err := hsdb.DB.Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+// GetOAuthClient returns an OAuth client for a given client ID.
+func (hsdb *HSDatabase) GetOAuthClient(clientID string) (*types.OAuthClient, error) {
+	client := types.OAuthClient{}
+	if result := hsdb.DB.First(&client, "client_id = ?", clientID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &client, nil
+}
+
+// DeleteOAuthClient deletes an OAuth client and all the access tokens
+// issued to it.
+func (hsdb *HSDatabase) DeleteOAuthClient(client *types.OAuthClient) error {
+	return hsdb.Write(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("o_auth_client_id = ?", client.ID).Delete(&types.OAuthAccessToken{}).Error; err != nil {
+			return fmt.Errorf("deleting OAuth access tokens: %w", err)
+		}
+
+		if err := tx.Unscoped().Delete(client).Error; err != nil {
+			return fmt.Errorf("deleting OAuth client: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// CreateOAuthAccessToken authenticates an OAuth client with its secret and
+// issues an access token valid for the given duration. The token is granted
+// the requested scopes, or all scopes of the client if none are requested.
+func (hsdb *HSDatabase) CreateOAuthAccessToken(
+	clientID string,
+	clientSecret string,
+	scopes []string,
+	validFor time.Duration,
+) (string, *types.OAuthAccessToken, error) {
+	client, err := hsdb.GetOAuthClient(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, ErrOAuthClientInvalid
+		}
+
+		return "", nil, err
+	}
+
+	if client.IsExpired() {
+		return "", nil, ErrOAuthClientInvalid
+	}
+
+	if err := bcrypt.CompareHashAndPassword(client.Hash, []byte(clientSecret)); err != nil {
+		return "", nil, ErrOAuthClientInvalid
+	}
+
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return "", nil, fmt.Errorf("%w: %q", ErrOAuthScopeNotGranted, scope)
+		}
+	}
+
+	prefix, err := util.GenerateRandomStringURLSafe(apiPrefixLength)
+	if err != nil {
+		return "", nil, err
+	}
+
+	toBeHashed, err := util.GenerateRandomStringURLSafe(apiKeyLength)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Token to return to the client, this will only be visible _once_
+	tokenStr := types.OAuthAccessTokenPrefix + prefix + "." + toBeHashed
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(toBeHashed), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().UTC()
+	expiration := now.Add(validFor)
+	token := types.OAuthAccessToken{
+		Prefix:        prefix,
+		Hash:          hash,
+		OAuthClientID: client.ID,
+		OAuthClient:   *client,
+		Scopes:        slices.Clone(scopes),
+		Expiration:    &expiration,
+	}
+
+	err = hsdb.Write(func(tx *gorm.DB) error {
+		// Tokens are short-lived, clean up the expired ones
+		// while we are here.
+		if err := tx.Where("expiration < ?", now).Delete(&types.OAuthAccessToken{}).Error; err != nil {
+			return fmt.Errorf("deleting expired OAuth access tokens: %w", err)
+		}
+
+		if err := tx.Omit("OAuthClient").Save(&token).Error; err != nil {
+			return fmt.Errorf("failed to save OAuth access token to database: %w", err)
+		}
+
+		if err := tx.Model(client).Update("last_seen", now).Error; err != nil {
+			return fmt.Errorf("updating OAuth client last seen: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tokenStr, &token, nil
+}
+
+// ValidateOAuthAccessToken returns the access token, including its client,
+// if the given token is valid and neither the token nor its client has
+// expired or been deleted.
+func (hsdb *HSDatabase) ValidateOAuthAccessToken(tokenStr string) (*types.OAuthAccessToken, error) {
+	prefix, secret, found := strings.Cut(strings.TrimPrefix(tokenStr, types.OAuthAccessTokenPrefix), ".")
+	if !found {
+		return nil, ErrOAuthAccessTokenFailedParse
+	}
+
+	token := types.OAuthAccessToken{}
+	if err := hsdb.DB.Preload("OAuthClient").First(&token, "prefix = ?", prefix).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOAuthAccessTokenInvalid
+		}
+
+		return nil, fmt.Errorf("failed to validate OAuth access token: %w", err)
+	}
+
+	if token.Expiration == nil || token.Expiration.Before(time.Now()) {
+		return nil, ErrOAuthAccessTokenInvalid
+	}
+
+	// The client is not loaded if it has been deleted.
+	if token.OAuthClient.ID == 0 || token.OAuthClient.IsExpired() {
+		return nil, ErrOAuthAccessTokenInvalid
+	}
+
+	if err := bcrypt.CompareHashAndPassword(token.Hash, []byte(secret)); err != nil {
+		return nil, ErrOAuthAccessTokenInvalid
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOAuthClientValidation(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	_, _, err = db.CreateOAuthClient(nil, nil, "", nil, false)
+	require.ErrorIs(t, err, ErrOAuthClientScopeInvalid)
+
+	_, _, err = db.CreateOAuthClient([]string{"devices"}, nil, "", nil, false)
+	require.ErrorIs(t, err, ErrOAuthClientScopeInvalid)
+
+	_, _, err = db.CreateOAuthClient([]string{types.OAuthScopeAuthKeys}, nil, "", nil, false)
+	require.ErrorIs(t, err, ErrOAuthClientTagInvalid)
+
+	_, _, err = db.CreateOAuthClient([]string{types.OAuthScopeAuthKeys}, []string{"ci"}, "", nil, false)
+	require.ErrorIs(t, err, ErrOAuthClientTagInvalid)
+
+	secret, client, err := db.CreateOAuthClient(
+		[]string{types.OAuthScopeAuthKeys, types.OAuthScopeAllRead},
+		[]string{"tag:ci", "tag:ci"},
+		"github runners",
+		nil,
+		false,
+	)
+	require.NoError(t, err)
+	assert.NotEmpty(t, secret)
+	assert.NotEmpty(t, client.ClientID)
+	assert.Equal(t, []string{"tag:ci"}, client.Tags)
+
+	clients, err := db.ListOAuthClients()
+	require.NoError(t, err)
+	assert.Len(t, clients, 1)
+}
+
+func TestOAuthAccessToken(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+
+	secret, client, err := db.CreateOAuthClient(
+		[]string{types.OAuthScopeAuthKeys, types.OAuthScopeAllRead},
+		[]string{"tag:ci"},
+		"",
+		nil,
+		false,
+	)
+	require.NoError(t, err)
+
+	_, _, err = db.CreateOAuthAccessToken(client.ClientID, "wrong", nil, time.Hour)
+	require.ErrorIs(t, err, ErrOAuthClientInvalid)
+
+	_, _, err = db.CreateOAuthAccessToken("does-not-exist", secret, nil, time.Hour)
+	require.ErrorIs(t, err, ErrOAuthClientInvalid)
+
+	_, _, err = db.CreateOAuthAccessToken(client.ClientID, secret, []string{types.OAuthScopeAll}, time.Hour)
+	require.ErrorIs(t, err, ErrOAuthScopeNotGranted)
+
+	// Without requested scopes, all scopes of the client are granted.
+	_, token, err := db.CreateOAuthAccessToken(client.ClientID, secret, nil, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, client.Scopes, token.Scopes)
+
+	tokenStr, _, err := db.CreateOAuthAccessToken(client.ClientID, secret, []string{types.OAuthScopeAuthKeys}, time.Hour)
+	require.NoError(t, err)
+
+	got, err := db.ValidateOAuthAccessToken(tokenStr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{types.OAuthScopeAuthKeys}, got.Scopes)
+	assert.Equal(t, []string{"tag:ci"}, got.OAuthClient.Tags)
+
+	_, err = db.ValidateOAuthAccessToken(tokenStr + "x")
+	require.ErrorIs(t, err, ErrOAuthAccessTokenInvalid)
+
+	expiredStr, _, err := db.CreateOAuthAccessToken(client.ClientID, secret, nil, -time.Minute)
+	require.NoError(t, err)
+	_, err = db.ValidateOAuthAccessToken(expiredStr)
+	require.ErrorIs(t, err, ErrOAuthAccessTokenInvalid)
+
+	// Tokens stop working when their client expires.
+	err = db.DB.Model(client).Update("expiration", time.Now().Add(-time.Minute)).Error
+	require.NoError(t, err)
+	_, err = db.ValidateOAuthAccessToken(tokenStr)
+	require.ErrorIs(t, err, ErrOAuthAccessTokenInvalid)
+
+	err = db.DB.Model(client).Update("expiration", nil).Error
+	require.NoError(t, err)
+	_, err = db.ValidateOAuthAccessToken(tokenStr)
+	require.NoError(t, err)
+
+	// Deleting the client revokes its tokens.
+	err = db.DeleteOAuthClient(client)
+	require.NoError(t, err)
+	_, err = db.ValidateOAuthAccessToken(tokenStr)
+	require.ErrorIs(t, err, ErrOAuthAccessTokenInvalid)
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (hsdb *HSDatabase) SetOIDCSession(session *types.OIDCSession) error {
+	return hsdb.Write(func(tx *gorm.DB) error {
+		return SetOIDCSession(tx, session)
+	})
+}
+
+// SetOIDCSession stores the OIDC session of a node, replacing the
+// session of a previous login.
+func SetOIDCSession(tx *gorm.DB, session *types.OIDCSession) error {
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "node_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "subject", "session_id", "refresh_token", "last_refreshed", "updated_at"}),
+	}).Omit("Node").Create(session).Error; err != nil {
+		return fmt.Errorf("storing OIDC session of node %d: %w", session.NodeID, err)
+	}
+
+	return nil
+}
+
+func (hsdb *HSDatabase) DeleteOIDCSession(nodeID types.NodeID) error {
+	return hsdb.Write(func(tx *gorm.DB) error {
+		return DeleteOIDCSession(tx, nodeID)
+	})
+}
+
+// DeleteOIDCSession deletes the OIDC session of a node, if any.
+func DeleteOIDCSession(tx *gorm.DB, nodeID types.NodeID) error {
+	if err := tx.Where("node_id = ?", nodeID).Delete(&types.OIDCSession{}).Error; err != nil {
+		return fmt.Errorf("deleting OIDC session of node %d: %w", nodeID, err)
+	}
+
+	return nil
+}
+
+func (hsdb *HSDatabase) ListOIDCSessions() ([]types.OIDCSession, error) {
+	return Read(hsdb.DB, func(rx *gorm.DB) ([]types.OIDCSession, error) {
+		return ListOIDCSessions(rx)
+	})
+}
+
+// ListOIDCSessions returns all OIDC sessions with their nodes and the
+// users of the nodes.
+func ListOIDCSessions(tx *gorm.DB) ([]types.OIDCSession, error) {
+	sessions := []types.OIDCSession{}
+	if err := tx.Preload("Node.User").Order("id").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (hsdb *HSDatabase) ListOIDCSessionsBySessionID(provider string, sessionID string) ([]types.OIDCSession, error) {
+	return Read(hsdb.DB, func(rx *gorm.DB) ([]types.OIDCSession, error) {
+		return ListOIDCSessionsBySessionID(rx, provider, sessionID)
+	})
+}
+
+// ListOIDCSessionsBySessionID returns the OIDC sessions of the given
+// provider with the given sid, with their nodes.
+func ListOIDCSessionsBySessionID(tx *gorm.DB, provider string, sessionID string) ([]types.OIDCSession, error) {
+	sessions := []types.OIDCSession{}
+	if err := tx.Preload("Node").
+		Where("provider = ? AND session_id = ?", provider, sessionID).
+		Order("id").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
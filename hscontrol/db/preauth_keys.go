@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
 	"gorm.io/gorm"
 	"tailscale.com/util/set"
 )
@@ -19,6 +20,7 @@ var (
 	ErrSingleUseAuthKeyHasBeenUsed = errors.New("AuthKey has already been used")
 	ErrUserMismatch                = errors.New("user mismatch")
 	ErrPreAuthKeyACLTagInvalid     = errors.New("AuthKey tag is invalid")
+	ErrPreAuthKeySettingsInvalid   = errors.New("AuthKey node settings are invalid")
 )
 
 func (hsdb *HSDatabase) CreatePreAuthKey(
@@ -27,13 +29,15 @@ func (hsdb *HSDatabase) CreatePreAuthKey(
 	ephemeral bool,
 	expiration *time.Time,
 	aclTags []string,
+	settings *types.PreAuthKeyNodeSettings,
 ) (*types.PreAuthKey, error) {
 	return Write(hsdb.DB, func(tx *gorm.DB) (*types.PreAuthKey, error) {
-		return CreatePreAuthKey(tx, uid, reusable, ephemeral, expiration, aclTags)
+		return CreatePreAuthKey(tx, uid, reusable, ephemeral, expiration, aclTags, settings)
 	})
 }
 
 // CreatePreAuthKey creates a new PreAuthKey in a user, and returns it.
+// The optional settings are applied to nodes registering with the key.
 func CreatePreAuthKey(
 	tx *gorm.DB,
 	uid types.UserID,
@@ -41,6 +45,7 @@ func CreatePreAuthKey(
 	ephemeral bool,
 	expiration *time.Time,
 	aclTags []string,
+	settings *types.PreAuthKeyNodeSettings,
 ) (*types.PreAuthKey, error) {
 	user, err := GetUserByID(tx, uid)
 	if err != nil {
@@ -62,6 +67,16 @@ func CreatePreAuthKey(
 		}
 	}
 
+	var nodeSettings types.PreAuthKeyNodeSettings
+	if settings != nil {
+		err = validatePreAuthKeyNodeSettings(settings, reusable)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeSettings = *settings
+	}
+
 	now := time.Now().UTC()
 	// TODO(kradalby): unify the key generations spread all over the code.
 	kstr, err := generateKey()
@@ -78,6 +93,8 @@ func CreatePreAuthKey(
 		CreatedAt:  &now,
 		Expiration: expiration,
 		Tags:       aclTags,
+
+		NodeSettings: nodeSettings,
 	}
 
 	if err := tx.Save(&key).Error; err != nil {
@@ -87,6 +104,55 @@ func CreatePreAuthKey(
 	return &key, nil
 }
 
+// validatePreAuthKeyNodeSettings checks that the node settings of a
+// PreAuthKey can be applied to a node when it registers.
+func validatePreAuthKeyNodeSettings(settings *types.PreAuthKeyNodeSettings, reusable bool) error {
+	// A reusable key would hand the same name and addresses to every
+	// node registering with it, all but the first would be rejected.
+	if reusable && settings.GivenName != "" {
+		return fmt.Errorf("%w: a given name can only be set on single use keys", ErrPreAuthKeySettingsInvalid)
+	}
+
+	if reusable && (settings.IPv4 != nil || settings.IPv6 != nil) {
+		return fmt.Errorf("%w: IP addresses can only be set on single use keys", ErrPreAuthKeySettingsInvalid)
+	}
+
+	for _, route := range settings.ApprovedRoutes {
+		if !route.IsValid() {
+			return fmt.Errorf("%w: invalid route %q", ErrPreAuthKeySettingsInvalid, route)
+		}
+	}
+
+	if settings.NodeExpiry < 0 {
+		return fmt.Errorf("%w: node expiry cannot be negative", ErrPreAuthKeySettingsInvalid)
+	}
+
+	if settings.EphemeralInactivityTimeout < 0 {
+		return fmt.Errorf("%w: ephemeral inactivity timeout cannot be negative", ErrPreAuthKeySettingsInvalid)
+	}
+
+	if settings.GivenName != "" {
+		err := util.CheckForFQDNRules(settings.GivenName)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrPreAuthKeySettingsInvalid, err)
+		}
+	}
+
+	if _, err := types.ParseNodeNameCollision(string(settings.NameCollision)); err != nil {
+		return fmt.Errorf("%w: %w", ErrPreAuthKeySettingsInvalid, err)
+	}
+
+	if settings.IPv4 != nil && !settings.IPv4.Is4() {
+		return fmt.Errorf("%w: %s is not an IPv4 address", ErrPreAuthKeySettingsInvalid, settings.IPv4)
+	}
+
+	if settings.IPv6 != nil && !settings.IPv6.Is6() {
+		return fmt.Errorf("%w: %s is not an IPv6 address", ErrPreAuthKeySettingsInvalid, settings.IPv6)
+	}
+
+	return nil
+}
+
 func (hsdb *HSDatabase) ListPreAuthKeys(uid types.UserID) ([]types.PreAuthKey, error) {
 	return Read(hsdb.DB, func(rx *gorm.DB) ([]types.PreAuthKey, error) {
 		return ListPreAuthKeysByUser(rx, uid)
@@ -1,8 +1,10 @@
 package db
 
 import (
+	"net/netip"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/juanfont/headscale/hscontrol/types"
 	"github.com/juanfont/headscale/hscontrol/util"
@@ -15,13 +17,13 @@ import (
 
 func (*Suite) TestCreatePreAuthKey(c *check.C) {
 	// ID does not exist
-	_, err := db.CreatePreAuthKey(12345, true, false, nil, nil)
+	_, err := db.CreatePreAuthKey(12345, true, false, nil, nil, nil)
 	c.Assert(err, check.NotNil)
 
 	user, err := db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	key, err := db.CreatePreAuthKey(types.UserID(user.ID), true, false, nil, nil)
+	key, err := db.CreatePreAuthKey(types.UserID(user.ID), true, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	// Did we get a valid key?
@@ -47,12 +49,12 @@ func (*Suite) TestPreAuthKeyACLTags(c *check.C) {
 	user, err := db.CreateUser(types.User{Name: "test8"})
 	c.Assert(err, check.IsNil)
 
-	_, err = db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, []string{"badtag"})
+	_, err = db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, []string{"badtag"}, nil)
 	c.Assert(err, check.NotNil) // Confirm that malformed tags are rejected
 
 	tags := []string{"tag:test1", "tag:test2"}
 	tagsWithDuplicate := []string{"tag:test1", "tag:test2", "tag:test2"}
-	_, err = db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, tagsWithDuplicate)
+	_, err = db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, tagsWithDuplicate, nil)
 	c.Assert(err, check.IsNil)
 
 	listedPaks, err := db.ListPreAuthKeys(types.UserID(user.ID))
@@ -68,7 +70,7 @@ func TestCannotDeleteAssignedPreAuthKey(t *testing.T) {
 	user, err := db.CreateUser(types.User{Name: "test8"})
 	assert.NoError(t, err)
 
-	key, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, []string{"tag:good"})
+	key, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, []string{"tag:good"}, nil)
 	assert.NoError(t, err)
 
 	node := types.Node{
@@ -83,3 +85,101 @@ func TestCannotDeleteAssignedPreAuthKey(t *testing.T) {
 	err = db.DB.Delete(key).Error
 	require.ErrorContains(t, err, "constraint failed: FOREIGN KEY constraint failed")
 }
+
+func TestPreAuthKeyNodeSettings(t *testing.T) {
+	db, err := newSQLiteTestDB()
+	require.NoError(t, err)
+	user, err := db.CreateUser(types.User{Name: "test"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		reusable bool
+		settings types.PreAuthKeyNodeSettings
+		wantErr  bool
+	}{
+		{
+			name: "valid",
+			settings: types.PreAuthKeyNodeSettings{
+				ApprovedRoutes:             []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				NodeExpiry:                 24 * time.Hour,
+				GivenName:                  "ci-runner",
+				EphemeralInactivityTimeout: 5 * time.Minute,
+			},
+		},
+		{
+			name: "invalid-given-name",
+			settings: types.PreAuthKeyNodeSettings{
+				GivenName: "Not A Name",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative-node-expiry",
+			settings: types.PreAuthKeyNodeSettings{
+				NodeExpiry: -time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative-ephemeral-timeout",
+			settings: types.PreAuthKeyNodeSettings{
+				EphemeralInactivityTimeout: -time.Minute,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fixed-ips",
+			settings: types.PreAuthKeyNodeSettings{
+				IPv4: ptr.To(netip.MustParseAddr("100.64.0.42")),
+				IPv6: ptr.To(netip.MustParseAddr("fd7a:115c:a1e0::42")),
+			},
+		},
+		{
+			name: "ipv6-as-ipv4",
+			settings: types.PreAuthKeyNodeSettings{
+				IPv4: ptr.To(netip.MustParseAddr("fd7a:115c:a1e0::42")),
+			},
+			wantErr: true,
+		},
+		{
+			name:     "given-name-on-reusable-key",
+			reusable: true,
+			settings: types.PreAuthKeyNodeSettings{
+				GivenName: "ci-runner",
+			},
+			wantErr: true,
+		},
+		{
+			name:     "reusable-key",
+			reusable: true,
+			settings: types.PreAuthKeyNodeSettings{
+				ApprovedRoutes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				NodeExpiry:     24 * time.Hour,
+			},
+		},
+		{
+			name:     "fixed-ip-on-reusable-key",
+			reusable: true,
+			settings: types.PreAuthKeyNodeSettings{
+				IPv4: ptr.To(netip.MustParseAddr("100.64.0.42")),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := db.CreatePreAuthKey(types.UserID(user.ID), tt.reusable, false, nil, nil, &tt.settings)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrPreAuthKeySettingsInvalid)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := db.GetPreAuthKey(key.Key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.settings, got.NodeSettings)
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSCIMGroupNotFound = errors.New("SCIM group not found")
+	ErrSCIMGroupExists   = errors.New("SCIM group already exists")
+)
+
+// DeactivateUser marks a user as deactivated and expires or deletes its
+// nodes according to the given action. The affected nodes are returned,
+// the caller is responsible for notifying about the change.
+func DeactivateUser(
+	tx *gorm.DB,
+	uid types.UserID,
+	action types.SCIMDeprovisionAction,
+) (types.Nodes, error) {
+	if err := tx.Model(&types.User{}).Where("id = ?", uid).Update("deactivated", true).Error; err != nil {
+		return nil, fmt.Errorf("deactivating user: %w", err)
+	}
+
+	nodes, err := ListNodesByUser(tx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, node := range nodes {
+		switch action {
+		case types.SCIMDeprovisionActionDelete:
+			err = DeleteNode(tx, node)
+		default:
+			// Nodes which are already expired keep their expiry.
+			if node.IsExpired() {
+				continue
+			}
+
+			err = NodeSetExpiry(tx, node.ID, now)
+			node.Expiry = &now
+		}
+		if err != nil {
+			return nil, fmt.Errorf("deprovisioning node %d: %w", node.ID, err)
+		}
+	}
+
+	return nodes, nil
+}
+
+// ReactivateUser reverts DeactivateUser, nodes which have been expired stay
+// expired and have to log in again.
+func ReactivateUser(tx *gorm.DB, uid types.UserID) error {
+	if err := tx.Model(&types.User{}).Where("id = ?", uid).Update("deactivated", false).Error; err != nil {
+		return fmt.Errorf("reactivating user: %w", err)
+	}
+
+	return nil
+}
+
+func (hsdb *HSDatabase) GetUnlinkedSCIMUserByEmail(email string) (*types.User, error) {
+	return Read(hsdb.DB, func(rx *gorm.DB) (*types.User, error) {
+		return GetUnlinkedSCIMUserByEmail(rx, email)
+	})
+}
+
+// GetUnlinkedSCIMUserByEmail returns the user provisioned via SCIM with the
+// given email, which has not logged in with OIDC yet.
+func GetUnlinkedSCIMUserByEmail(tx *gorm.DB, email string) (*types.User, error) {
+	user := types.User{}
+	if err := tx.
+		Where("email = ? AND provider = ? AND provider_identifier IS NULL", email, types.SCIMProvider).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// ListSCIMGroups returns all groups provisioned via SCIM.
+func ListSCIMGroups(tx *gorm.DB) ([]types.SCIMGroup, error) {
+	groups := []types.SCIMGroup{}
+	if err := tx.Order("id").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// GetSCIMGroup returns the SCIM group with the given ID.
+func GetSCIMGroup(tx *gorm.DB, id uint) (*types.SCIMGroup, error) {
+	group := types.SCIMGroup{}
+	if err := tx.First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSCIMGroupNotFound
+		}
+
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+// CreateSCIMGroup creates a SCIM group, its policy name is the display
+// name with the given prefix.
+func CreateSCIMGroup(tx *gorm.DB, group types.SCIMGroup, prefix string) (*types.SCIMGroup, error) {
+	group.PolicyName = prefix + group.DisplayName
+
+	var count int64
+	if err := tx.Model(&types.SCIMGroup{}).Where("display_name = ?", group.DisplayName).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSCIMGroupExists, group.DisplayName)
+	}
+
+	if err := tx.Create(&group).Error; err != nil {
+		return nil, fmt.Errorf("creating SCIM group: %w", err)
+	}
+
+	return &group, nil
+}
+
+// UpdateSCIMGroup saves the group and renames it for all its members if the
+// display name changed.
+func UpdateSCIMGroup(tx *gorm.DB, group *types.SCIMGroup, prefix string) error {
+	oldName := group.PolicyName
+	group.PolicyName = prefix + group.DisplayName
+
+	var count int64
+	if err := tx.Model(&types.SCIMGroup{}).
+		Where("display_name = ? AND id <> ?", group.DisplayName, group.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrSCIMGroupExists, group.DisplayName)
+	}
+
+	if err := tx.Save(group).Error; err != nil {
+		return fmt.Errorf("updating SCIM group: %w", err)
+	}
+
+	if oldName == group.PolicyName {
+		return nil
+	}
+
+	return updateSCIMGroupMembership(tx, func(user *types.User) bool {
+		if !slices.Contains(user.SCIMGroups, oldName) {
+			return false
+		}
+
+		user.SCIMGroups = replaceGroup(user.SCIMGroups, oldName, group.PolicyName)
+
+		return true
+	})
+}
+
+// DeleteSCIMGroup deletes the group and removes all members from it.
+func DeleteSCIMGroup(tx *gorm.DB, group *types.SCIMGroup) error {
+	if err := SetSCIMGroupMembers(tx, group, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Delete(group).Error; err != nil {
+		return fmt.Errorf("deleting SCIM group: %w", err)
+	}
+
+	return nil
+}
+
+// ListSCIMGroupMembers returns the members of the group.
+func ListSCIMGroupMembers(tx *gorm.DB, group *types.SCIMGroup) (types.Users, error) {
+	users, err := ListUsers(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var members types.Users
+	for _, user := range users {
+		if slices.Contains(user.SCIMGroups, group.PolicyName) {
+			members = append(members, user)
+		}
+	}
+
+	return members, nil
+}
+
+// SetSCIMGroupMembers replaces the members of the group.
+func SetSCIMGroupMembers(tx *gorm.DB, group *types.SCIMGroup, uids []types.UserID) error {
+	return updateSCIMGroupMembership(tx, func(user *types.User) bool {
+		isMember := slices.Contains(user.SCIMGroups, group.PolicyName)
+		shouldBeMember := slices.Contains(uids, types.UserID(user.ID))
+
+		switch {
+		case isMember && !shouldBeMember:
+			user.SCIMGroups = slices.DeleteFunc(user.SCIMGroups, func(g string) bool { return g == group.PolicyName })
+		case !isMember && shouldBeMember:
+			user.SCIMGroups = append(user.SCIMGroups, group.PolicyName)
+		default:
+			return false
+		}
+
+		return true
+	})
+}
+
+// AddSCIMGroupMembers adds the given users to the group.
+func AddSCIMGroupMembers(tx *gorm.DB, group *types.SCIMGroup, uids []types.UserID) error {
+	return updateSCIMGroupMembership(tx, func(user *types.User) bool {
+		if !slices.Contains(uids, types.UserID(user.ID)) || slices.Contains(user.SCIMGroups, group.PolicyName) {
+			return false
+		}
+
+		user.SCIMGroups = append(user.SCIMGroups, group.PolicyName)
+
+		return true
+	})
+}
+
+// RemoveSCIMGroupMembers removes the given users from the group.
+func RemoveSCIMGroupMembers(tx *gorm.DB, group *types.SCIMGroup, uids []types.UserID) error {
+	return updateSCIMGroupMembership(tx, func(user *types.User) bool {
+		if !slices.Contains(uids, types.UserID(user.ID)) || !slices.Contains(user.SCIMGroups, group.PolicyName) {
+			return false
+		}
+
+		user.SCIMGroups = slices.DeleteFunc(user.SCIMGroups, func(g string) bool { return g == group.PolicyName })
+
+		return true
+	})
+}
+
+// updateSCIMGroupMembership calls update for every user and saves the
+// SCIM groups of the users for which it reports a change.
+func updateSCIMGroupMembership(tx *gorm.DB, update func(user *types.User) bool) error {
+	users, err := ListUsers(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if !update(&user) {
+			continue
+		}
+
+		slices.Sort(user.SCIMGroups)
+		b, err := json.Marshal(user.SCIMGroups)
+		if err != nil {
+			return err
+		}
+		if len(user.SCIMGroups) == 0 {
+			b = []byte("[]")
+		}
+
+		if err := tx.Model(&types.User{}).Where("id = ?", user.ID).Update("scim_groups", string(b)).Error; err != nil {
+			return fmt.Errorf("updating SCIM groups of user %d: %w", user.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func replaceGroup(groups []string, oldName, newName string) []string {
+	groups = slices.DeleteFunc(groups, func(g string) bool { return g == oldName })
+	if !slices.Contains(groups, newName) {
+		groups = append(groups, newName)
+	}
+
+	return groups
+}
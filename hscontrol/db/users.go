@@ -109,6 +109,34 @@ func RenameUser(tx *gorm.DB, uid types.UserID, newName string) error {
 	return nil
 }
 
+func (hsdb *HSDatabase) SuspendUser(uid types.UserID) (*types.User, error) {
+	return Write(hsdb.DB, func(tx *gorm.DB) (*types.User, error) {
+		return SetUserSuspended(tx, uid, true)
+	})
+}
+
+func (hsdb *HSDatabase) UnsuspendUser(uid types.UserID) (*types.User, error) {
+	return Write(hsdb.DB, func(tx *gorm.DB) (*types.User, error) {
+		return SetUserSuspended(tx, uid, false)
+	})
+}
+
+// SetUserSuspended suspends or reinstates a User. The nodes, pre auth
+// keys and IPs of the User are kept untouched.
+func SetUserSuspended(tx *gorm.DB, uid types.UserID, suspended bool) (*types.User, error) {
+	user, err := GetUserByID(tx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Suspended = suspended
+	if err := tx.Model(user).Update("suspended", suspended).Error; err != nil {
+		return nil, fmt.Errorf("updating suspension of user: %w", err)
+	}
+
+	return user, nil
+}
+
 func (hsdb *HSDatabase) GetUserByID(uid types.UserID) (*types.User, error) {
 	return Read(hsdb.DB, func(rx *gorm.DB) (*types.User, error) {
 		return GetUserByID(rx, uid)
@@ -173,7 +201,13 @@ func ListUsers(tx *gorm.DB, where ...*types.User) ([]types.User, error) {
 // GetUserByName returns a user if the provided username is
 // unique, and otherwise an error.
 func (hsdb *HSDatabase) GetUserByName(name string) (*types.User, error) {
-	users, err := hsdb.ListUsers(&types.User{Name: name})
+	return Read(hsdb.DB, func(rx *gorm.DB) (*types.User, error) {
+		return GetUserByName(rx, name)
+	})
+}
+
+func GetUserByName(tx *gorm.DB, name string) (*types.User, error) {
+	users, err := ListUsers(tx, &types.User{Name: name})
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"net/netip"
 	"strings"
 
 	"github.com/juanfont/headscale/hscontrol/types"
@@ -33,7 +34,7 @@ func (s *Suite) TestDestroyUserErrors(c *check.C) {
 	user, err := db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	err = db.DestroyUser(types.UserID(user.ID))
@@ -46,7 +47,7 @@ func (s *Suite) TestDestroyUserErrors(c *check.C) {
 	user, err = db.CreateUser(types.User{Name: "test"})
 	c.Assert(err, check.IsNil)
 
-	pak, err = db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil)
+	pak, err = db.CreatePreAuthKey(types.UserID(user.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	node := types.Node{
@@ -97,6 +98,40 @@ func (s *Suite) TestRenameUser(c *check.C) {
 	}
 }
 
+func (s *Suite) TestSuspendUser(c *check.C) {
+	user, err := db.CreateUser(types.User{Name: "test"})
+	c.Assert(err, check.IsNil)
+
+	node := types.Node{
+		Hostname:       "testnode",
+		UserID:         user.ID,
+		RegisterMethod: util.RegisterMethodCLI,
+		IPv4:           ptr.To(netip.MustParseAddr("100.64.0.1")),
+	}
+	c.Assert(db.DB.Save(&node).Error, check.IsNil)
+
+	suspended, err := db.SuspendUser(types.UserID(user.ID))
+	c.Assert(err, check.IsNil)
+	c.Assert(suspended.Suspended, check.Equals, true)
+
+	// The nodes of the user are kept with their IPs.
+	dbNode, err := db.GetNodeByID(node.ID)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbNode.IsSuspended(), check.Equals, true)
+	c.Assert(dbNode.IPv4.String(), check.Equals, "100.64.0.1")
+
+	unsuspended, err := db.UnsuspendUser(types.UserID(user.ID))
+	c.Assert(err, check.IsNil)
+	c.Assert(unsuspended.Suspended, check.Equals, false)
+
+	dbNode, err = db.GetNodeByID(node.ID)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbNode.IsSuspended(), check.Equals, false)
+
+	_, err = db.SuspendUser(99988)
+	c.Assert(err, check.Equals, ErrUserNotFound)
+}
+
 func (s *Suite) TestSetMachineUser(c *check.C) {
 	oldUser, err := db.CreateUser(types.User{Name: "old"})
 	c.Assert(err, check.IsNil)
@@ -104,7 +139,7 @@ func (s *Suite) TestSetMachineUser(c *check.C) {
 	newUser, err := db.CreateUser(types.User{Name: "new"})
 	c.Assert(err, check.IsNil)
 
-	pak, err := db.CreatePreAuthKey(types.UserID(oldUser.ID), false, false, nil, nil)
+	pak, err := db.CreatePreAuthKey(types.UserID(oldUser.ID), false, false, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	node := types.Node{
@@ -100,6 +100,16 @@ func (h *Headscale) debugHTTPServer() *http.Server {
 		w.WriteHeader(http.StatusOK)
 		w.Write(registrationsJSON)
 	}))
+	debug.Handle("ephemeral-gc", "Scheduled deletions of ephemeral nodes", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheduledJSON, err := json.MarshalIndent(h.ephemeralGC.Scheduled(), "", "  ")
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(scheduledJSON)
+	}))
 	debug.Handle("routes", "Routes", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain")
 		w.WriteHeader(http.StatusOK)
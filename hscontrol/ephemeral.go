@@ -0,0 +1,54 @@
+package hscontrol
+
+import (
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/rs/zerolog/log"
+)
+
+// scheduleEphemeralDeletion schedules the deletion of the disconnected
+// ephemeral node, and persists when it is due so that restarts do not
+// postpone it.
+func (h *Headscale) scheduleEphemeralDeletion(node *types.Node, due time.Time) {
+	node.EphemeralDeletionDue = &due
+	if err := h.db.SetEphemeralDeletionDue(node.ID, &due); err != nil {
+		log.Error().Err(err).Uint64("node.id", node.ID.Uint64()).Msg("failed to persist ephemeral node deletion")
+	}
+
+	h.ephemeralGC.ScheduleAt(node.ID, due)
+}
+
+// cancelEphemeralDeletion cancels the deletion of the reconnected
+// ephemeral node.
+func (h *Headscale) cancelEphemeralDeletion(node *types.Node) {
+	h.ephemeralGC.Cancel(node.ID)
+
+	node.EphemeralDeletionDue = nil
+	if err := h.db.SetEphemeralDeletionDue(node.ID, nil); err != nil {
+		log.Error().Err(err).Uint64("node.id", node.ID.Uint64()).Msg("failed to clear ephemeral node deletion")
+	}
+}
+
+// scheduleEphemeralNodes schedules the deletion of all ephemeral nodes in
+// the database when headscale starts. Nodes keep the deletion scheduled
+// before the restart. Nodes that were connected when headscale stopped
+// get the full timeout to reconnect, and will be cancelled when they do.
+func (h *Headscale) scheduleEphemeralNodes() error {
+	nodes, err := h.db.ListEphemeralNodes()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, node := range nodes {
+		due := now.Add(node.EphemeralInactivityTimeout(h.cfg.EphemeralNodeInactivityTimeout))
+		if node.EphemeralDeletionDue != nil {
+			due = *node.EphemeralDeletionDue
+		}
+
+		h.scheduleEphemeralDeletion(node, due)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package hscontrol
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"tailscale.com/types/key"
+)
+
+func TestScheduleEphemeralNodes(t *testing.T) {
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.EphemeralNodeInactivityTimeout = 30 * time.Minute
+	})
+	defer h.ephemeralGC.Close()
+	api := newHeadscaleV1APIServer(h)
+
+	user, err := h.db.CreateUser(types.User{Name: "ci"})
+	require.NoError(t, err)
+
+	pak := types.PreAuthKey{Key: "ephemeral", UserID: user.ID, Ephemeral: true}
+	require.NoError(t, h.db.DB.Save(&pak).Error)
+
+	// The deletion scheduled before the restart is kept, even if it is
+	// sooner than the timeout.
+	due := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	for _, node := range []types.Node{
+		{Hostname: "disconnected", UserID: user.ID, AuthKeyID: &pak.ID, EphemeralDeletionDue: &due},
+		{Hostname: "connected", UserID: user.ID, AuthKeyID: &pak.ID},
+		{Hostname: "persistent", UserID: user.ID},
+	} {
+		node.MachineKey = key.NewMachine().Public()
+		node.NodeKey = key.NewNode().Public()
+		node.GivenName = node.Hostname
+		require.NoError(t, h.db.DB.Save(&node).Error)
+	}
+
+	start := time.Now()
+	require.NoError(t, h.scheduleEphemeralNodes())
+
+	resp, err := api.ListEphemeralDeletions(context.Background(), &v1.ListEphemeralDeletionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetDeletions(), 2)
+
+	assert.Equal(t, "disconnected", resp.GetDeletions()[0].GetNode().GetName())
+	assert.True(t, due.Equal(resp.GetDeletions()[0].GetDue().AsTime()))
+
+	// Nodes that were connected get the full timeout to reconnect.
+	assert.Equal(t, "connected", resp.GetDeletions()[1].GetNode().GetName())
+	connectedDue := resp.GetDeletions()[1].GetDue().AsTime()
+	assert.WithinRange(t, connectedDue, start.Add(30*time.Minute), time.Now().Add(30*time.Minute))
+
+	connected, err := db.Read(h.db.DB, func(rx *gorm.DB) (*types.Node, error) {
+		return db.GetNodeByID(rx, types.NodeID(resp.GetDeletions()[1].GetNode().GetId()))
+	})
+	require.NoError(t, err)
+	require.NotNil(t, connected.EphemeralDeletionDue)
+	assert.True(t, connectedDue.Equal(*connected.EphemeralDeletionDue))
+
+	// Reconnecting cancels the deletion.
+	h.cancelEphemeralDeletion(connected)
+
+	connected, err = db.Read(h.db.DB, func(rx *gorm.DB) (*types.Node, error) {
+		return db.GetNodeByID(rx, connected.ID)
+	})
+	require.NoError(t, err)
+	assert.Nil(t, connected.EphemeralDeletionDue)
+
+	resp, err = api.ListEphemeralDeletions(context.Background(), &v1.ListEphemeralDeletionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetDeletions(), 1)
+	assert.Equal(t, "disconnected", resp.GetDeletions()[0].GetNode().GetName())
+}
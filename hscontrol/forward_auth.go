@@ -0,0 +1,234 @@
+package hscontrol
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/chasefleming/elem-go"
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/notifier"
+	"github.com/juanfont/headscale/hscontrol/policy"
+	"github.com/juanfont/headscale/hscontrol/templates"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	errForwardAuthUntrustedSource = errors.New("request is not from a trusted proxy")
+	errForwardAuthNoUser          = errors.New("request has no user header")
+	errForwardAuthUserDeactivated = errors.New("user is deactivated")
+	errForwardAuthUserSuspended   = errors.New("user is suspended")
+)
+
+// AuthProviderForwardAuth registers nodes of the user a trusted reverse
+// proxy, like oauth2-proxy or Authelia, logged in and set in headers.
+type AuthProviderForwardAuth struct {
+	serverURL string
+	cfg       types.ForwardAuthConfig
+
+	authRegistrar
+}
+
+func NewAuthProviderForwardAuth(
+	serverURL string,
+	cfg types.ForwardAuthConfig,
+	db *db.HSDatabase,
+	notif *notifier.Notifier,
+	ipAlloc *db.IPAllocator,
+	polMan policy.PolicyManager,
+) *AuthProviderForwardAuth {
+	return &AuthProviderForwardAuth{
+		serverURL: serverURL,
+		cfg:       cfg,
+		authRegistrar: authRegistrar{
+			db:       db,
+			notifier: notif,
+			ipAlloc:  ipAlloc,
+			polMan:   polMan,
+		},
+	}
+}
+
+func (a *AuthProviderForwardAuth) AuthURL(registrationID types.RegistrationID) string {
+	return fmt.Sprintf(
+		"%s/register/%s",
+		strings.TrimSuffix(a.serverURL, "/"),
+		registrationID.String())
+}
+
+// RegisterHandler asks the user logged in by the proxy to confirm the
+// registration, and registers the node once confirmed. The confirmation
+// keeps links to the page from registering nodes of others to the user.
+// Listens in /register/:registration_id.
+func (a *AuthProviderForwardAuth) RegisterHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	registrationID, err := types.RegistrationIDFromString(mux.Vars(req)["registration_id"])
+	if err != nil {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "invalid registration id", err))
+		return
+	}
+
+	identity, err := a.identityFromRequest(req)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("client_address", req.RemoteAddr).
+			Msg("rejected forward-auth registration")
+		httpError(writer, NewHTTPError(http.StatusForbidden, "not logged in by a trusted proxy", err))
+
+		return
+	}
+
+	if req.Method != http.MethodPost {
+		renderCSRFForm(writer, req, http.StatusOK, func(csrf string) *elem.Element {
+			return templates.ForwardAuthConfirm(
+				"/register/"+registrationID.String(),
+				csrf,
+				cmp.Or(identity.DisplayName, identity.Username),
+			)
+		})
+
+		return
+	}
+
+	if !validCSRF(req) {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, errCSRFMismatch.Error(), errCSRFMismatch))
+		return
+	}
+
+	user, err := a.createOrUpdateUserFromForwardAuth(identity)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	node, newNode, err := a.handleRegistration(
+		user,
+		registrationID,
+		nil,
+		util.RegisterMethodForwardAuth,
+		nil,
+	)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	log.Info().
+		Str("user", user.Name).
+		Uint64("node.id", node.ID.Uint64()).
+		Msg("registered node with forward-auth login")
+
+	verb := "Reauthenticated"
+	if newNode {
+		verb = "Authenticated"
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	if _, err := writer.Write([]byte(templates.RegistrationSuccess(user.Display(), verb).Render())); err != nil {
+		util.LogErr(err, "Failed to write response")
+	}
+}
+
+// identityFromRequest reads the identity of the user from the configured
+// headers, if the request comes from a trusted proxy.
+func (a *AuthProviderForwardAuth) identityFromRequest(req *http.Request) (*types.ForwardAuthIdentity, error) {
+	if !a.trustedSource(req.RemoteAddr) {
+		return nil, errForwardAuthUntrustedSource
+	}
+
+	username := strings.TrimSpace(req.Header.Get(a.cfg.Headers.User))
+	if username == "" {
+		return nil, errForwardAuthNoUser
+	}
+
+	identity := &types.ForwardAuthIdentity{
+		Username: username,
+		Email:    strings.TrimSpace(req.Header.Get(a.cfg.Headers.Email)),
+	}
+
+	if a.cfg.Headers.DisplayName != "" {
+		identity.DisplayName = strings.TrimSpace(req.Header.Get(a.cfg.Headers.DisplayName))
+	}
+
+	if a.cfg.Headers.Groups != "" {
+		if values, ok := req.Header[http.CanonicalHeaderKey(a.cfg.Headers.Groups)]; ok {
+			identity.Groups = []string{}
+			for _, value := range values {
+				for _, group := range strings.Split(value, ",") {
+					if group = strings.TrimSpace(group); group != "" {
+						identity.Groups = append(identity.Groups, a.cfg.GroupsPrefix+group)
+					}
+				}
+			}
+			slices.Sort(identity.Groups)
+			identity.Groups = slices.Compact(identity.Groups)
+		}
+	}
+
+	return identity, nil
+}
+
+// trustedSource reports if the remote address of a request is in one of
+// the trusted proxy prefixes.
+func (a *AuthProviderForwardAuth) trustedSource(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	return slices.ContainsFunc(a.cfg.TrustedProxies, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+func (a *AuthProviderForwardAuth) createOrUpdateUserFromForwardAuth(identity *types.ForwardAuthIdentity) (*types.User, error) {
+	user, err := a.db.GetUserByOIDCIdentifier(identity.Identifier())
+	if err != nil && !errors.Is(err, db.ErrUserNotFound) {
+		return nil, fmt.Errorf("creating or updating user: %w", err)
+	}
+
+	if user != nil && user.Deactivated {
+		return nil, NewHTTPError(http.StatusForbidden, "user is deactivated", errForwardAuthUserDeactivated)
+	}
+
+	if user != nil && user.Suspended {
+		return nil, NewHTTPError(http.StatusForbidden, "user is suspended", errForwardAuthUserSuspended)
+	}
+
+	if user == nil {
+		user = &types.User{}
+	}
+
+	user.FromForwardAuth(identity)
+	if identity.Groups != nil {
+		user.Groups = identity.Groups
+	}
+
+	if err := a.db.DB.Save(user).Error; err != nil {
+		return nil, fmt.Errorf("creating or updating user: %w", err)
+	}
+
+	if err := usersChangedHook(a.db, a.polMan, a.notifier); err != nil {
+		return nil, fmt.Errorf("updating resources using user: %w", err)
+	}
+
+	return user, nil
+}
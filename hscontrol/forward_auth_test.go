@@ -0,0 +1,102 @@
+package hscontrol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tailscale.com/types/key"
+)
+
+func TestAuthProviderForwardAuth(t *testing.T) {
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.ForwardAuth = types.ForwardAuthConfig{
+			Enabled:        true,
+			TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")},
+			Headers: types.ForwardAuthHeadersConfig{
+				User:        "X-Forwarded-User",
+				Email:       "X-Forwarded-Email",
+				DisplayName: "X-Forwarded-Name",
+				Groups:      "X-Forwarded-Groups",
+			},
+			GroupsPrefix: "group:forward-auth-",
+		}
+	})
+
+	provider, ok := h.authProvider.(*AuthProviderForwardAuth)
+	require.True(t, ok)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/register/{registration_id}", provider.RegisterHandler)
+
+	request := func(method, remoteAddr string, regID types.RegistrationID, form url.Values, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/register/"+regID.String(), strings.NewReader(form.Encode()))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Forwarded-User", "alice")
+		req.Header.Set("X-Forwarded-Email", "alice@example.com")
+		req.Header.Set("X-Forwarded-Name", "Alice Example")
+		req.Header.Set("X-Forwarded-Groups", "admins, dev,admins")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	regID, err := types.NewRegistrationID()
+	require.NoError(t, err)
+	h.registrationCache.Set(regID, types.RegisterNode{
+		Node: types.Node{
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			Hostname:   "alice-laptop",
+		},
+		Registered: make(chan *types.Node, 1),
+	})
+
+	// Headers from untrusted sources are ignored.
+	rec := request(http.MethodGet, "192.0.2.1:1234", regID, nil, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = request(http.MethodGet, "10.0.0.5:1234", regID, nil, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Alice Example")
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Contains(t, rec.Body.String(), cookies[0].Value)
+
+	// The registration needs the confirmation form.
+	rec = request(http.MethodPost, "10.0.0.5:1234", regID, url.Values{"csrf": {"forged"}}, cookies)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = request(http.MethodPost, "10.0.0.5:1234", regID, url.Values{"csrf": {cookies[0].Value}}, cookies)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "Authenticated as Alice Example")
+
+	user, err := h.db.GetUserByOIDCIdentifier("forward-auth/alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, "Alice Example", user.DisplayName)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.Equal(t, util.RegisterMethodForwardAuth, user.Provider)
+	assert.Equal(t, []string{"group:forward-auth-admins", "group:forward-auth-dev"}, user.Groups)
+
+	nodes, err := h.db.ListNodes()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, user.ID, nodes[0].UserID)
+	assert.Equal(t, util.RegisterMethodForwardAuth, nodes[0].RegisterMethod)
+}
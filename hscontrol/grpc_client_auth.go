@@ -0,0 +1,294 @@
+package hscontrol
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"tailscale.com/util/set"
+)
+
+var (
+	errGRPCClientAuthNoCerts     = errors.New("grpc_client_auth.ca_path contains no certificates")
+	errGRPCClientAuthWithoutTLS  = errors.New("grpc_client_auth requires TLS for the gRPC listener")
+	errClientCertRevoked         = errors.New("client certificate is revoked")
+	errCRLSignatureUnknownIssuer = errors.New("certificate revocation list is not signed by a client CA")
+	errCRLExpired                = errors.New("certificate revocation list is past its next update")
+)
+
+// grpcClientAuth authenticates remote gRPC clients with TLS client
+// certificates issued by the configured CAs, and maps their identities to
+// roles.
+type grpcClientAuth struct {
+	cfg  types.GRPCClientAuthConfig
+	pool *x509.CertPool
+	cas  []*x509.Certificate
+
+	mu         sync.Mutex
+	crlModTime time.Time
+	crl        *revocationList
+}
+
+// revocationList is the parsed certificate revocation list of one of the
+// client CAs.
+type revocationList struct {
+	issuer     []byte
+	nextUpdate time.Time
+	serials    set.Set[string]
+}
+
+// revokes reports if the certificate was issued by the issuer of the list
+// and is listed in it.
+func (l *revocationList) revokes(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, l.issuer) && l.serials.Contains(cert.SerialNumber.String())
+}
+
+func newGRPCClientAuth(cfg types.GRPCClientAuthConfig) (*grpcClientAuth, error) {
+	data, err := os.ReadFile(cfg.CAPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading grpc_client_auth.ca_path: %w", err)
+	}
+
+	auth := &grpcClientAuth{
+		cfg:  cfg,
+		pool: x509.NewCertPool(),
+	}
+
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing grpc_client_auth.ca_path: %w", err)
+		}
+
+		auth.pool.AddCert(cert)
+		auth.cas = append(auth.cas, cert)
+	}
+
+	if len(auth.cas) == 0 {
+		return nil, errGRPCClientAuthNoCerts
+	}
+
+	// Fail early on a broken revocation list, instead of rejecting
+	// every client.
+	if cfg.CRLPath != "" {
+		if _, err := auth.revocations(); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth, nil
+}
+
+// tlsConfig returns the TLS config of the gRPC listener, asking clients
+// for certificates. Clients without a certificate can still use API keys.
+func (a *grpcClientAuth) tlsConfig(base *tls.Config) *tls.Config {
+	cfg := base.Clone()
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	cfg.ClientCAs = a.pool
+	cfg.VerifyPeerCertificate = a.verifyNotRevoked
+
+	return cfg
+}
+
+// verifyNotRevoked rejects client certificates listed in the revocation
+// list, it runs after the chains have been verified.
+func (a *grpcClientAuth) verifyNotRevoked(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if a.cfg.CRLPath == "" || len(verifiedChains) == 0 {
+		return nil
+	}
+
+	revoked, err := a.revocations()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to read certificate revocation list, rejecting client certificate")
+
+		return err
+	}
+
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			if revoked.revokes(cert) {
+				return fmt.Errorf("%w: serial %s", errClientCertRevoked, cert.SerialNumber)
+			}
+		}
+	}
+
+	return nil
+}
+
+// revocations returns the revocation list, the file is only read again
+// when it changes. A list past its next update is not trusted anymore.
+func (a *grpcClientAuth) revocations() (*revocationList, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.cfg.CRLPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading grpc_client_auth.crl_path: %w", err)
+	}
+
+	if a.crl == nil || !info.ModTime().Equal(a.crlModTime) {
+		crl, err := a.readRevocationList()
+		if err != nil {
+			return nil, err
+		}
+
+		a.crl = crl
+		a.crlModTime = info.ModTime()
+	}
+
+	if !a.crl.nextUpdate.IsZero() && time.Now().After(a.crl.nextUpdate) {
+		return nil, fmt.Errorf("%w: was due at %s", errCRLExpired, a.crl.nextUpdate.Format(time.RFC3339))
+	}
+
+	return a.crl, nil
+}
+
+// readRevocationList reads and verifies the revocation list.
+func (a *grpcClientAuth) readRevocationList() (*revocationList, error) {
+	data, err := os.ReadFile(a.cfg.CRLPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading grpc_client_auth.crl_path: %w", err)
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing grpc_client_auth.crl_path: %w", err)
+	}
+
+	signed := false
+	for _, ca := range a.cas {
+		if crl.CheckSignatureFrom(ca) == nil {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return nil, errCRLSignatureUnknownIssuer
+	}
+
+	revoked := &revocationList{
+		issuer:     crl.RawIssuer,
+		nextUpdate: crl.NextUpdate,
+		serials:    make(set.Set[string]),
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		revoked.serials.Add(entry.SerialNumber.String())
+	}
+
+	return revoked, nil
+}
+
+// role returns the highest role matching one of the identities of the
+// certificate, and the identity it matched.
+func (a *grpcClientAuth) role(cert *x509.Certificate) (apiRole, string) {
+	identities := clientCertIdentities(cert)
+
+	for _, role := range []struct {
+		role     apiRole
+		patterns []string
+	}{
+		{apiRoleAdmin, a.cfg.Admin},
+		{apiRoleReadOnly, a.cfg.ReadOnly},
+	} {
+		for _, identity := range identities {
+			for _, pattern := range role.patterns {
+				if matchClientCertIdentity(pattern, identity) {
+					return role.role, identity
+				}
+			}
+		}
+	}
+
+	return apiRoleNone, cert.Subject.CommonName
+}
+
+// clientCertIdentities returns the subject common name and the SANs of
+// the certificate.
+func clientCertIdentities(cert *x509.Certificate) []string {
+	var identities []string
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	identities = append(identities, cert.EmailAddresses...)
+
+	return identities
+}
+
+// matchClientCertIdentity matches the identity exactly, or by prefix if
+// the pattern ends with *.
+func matchClientCertIdentity(pattern, identity string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(identity, prefix)
+	}
+
+	return pattern == identity
+}
+
+// authorize authorizes the call with the client certificate of the
+// connection. It reports false if the client has no certificate mapped to
+// a role, leaving the authentication to the API key.
+func (a *grpcClientAuth) authorize(ctx context.Context, fullMethod string) (bool, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return false, nil
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	role, identity := a.role(cert)
+	logger := log.With().
+		Str("client_address", p.Addr.String()).
+		Str("client_cert", identity).
+		Str("serial", cert.SerialNumber.String()).
+		Str("method", fullMethod).
+		Logger()
+
+	switch {
+	case role == apiRoleNone:
+		logger.Debug().Msg("client certificate is not mapped to a role, falling back to API key")
+
+		return false, nil
+	case role == apiRoleReadOnly && !isReadOnlyMethod(fullMethod):
+		logger.Info().Msg("gRPC call denied, client certificate is read-only")
+
+		return true, status.Errorf(codes.PermissionDenied, "client certificate %q is only allowed to read", identity)
+	}
+
+	if isReadOnlyMethod(fullMethod) {
+		logger.Debug().Msg("gRPC call with client certificate")
+	} else {
+		logger.Info().Msg("gRPC call with client certificate")
+	}
+
+	return true, nil
+}
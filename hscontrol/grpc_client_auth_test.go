@@ -0,0 +1,209 @@
+package hscontrol
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCClientAuth(t *testing.T) {
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "headscale client CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	// A second CA issuing serials independently of the first one.
+	otherCAKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherCATemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "other client CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	otherCADER, err := x509.CreateCertificate(rand.Reader, otherCATemplate, otherCATemplate, &otherCAKey.PublicKey, otherCAKey)
+	require.NoError(t, err)
+	otherCA, err := x509.ParseCertificate(otherCADER)
+	require.NoError(t, err)
+
+	caPath := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: otherCADER})...,
+	), 0o600))
+
+	issueBy := func(ca *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64, commonName string, uri string) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: commonName},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		if uri != "" {
+			u, err := url.Parse(uri)
+			require.NoError(t, err)
+			template.URIs = []*url.URL{u}
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+
+		return cert
+	}
+
+	issue := func(serial int64, commonName string, uri string) *x509.Certificate {
+		return issueBy(ca, caKey, serial, commonName, uri)
+	}
+
+	automation := issue(10, "automation", "spiffe://mesh.example.com/ns/ops/sa/automation")
+	monitoring := issue(11, "monitoring", "")
+	other := issue(12, "other", "spiffe://mesh.example.com/ns/dev/sa/other")
+	revoked := issue(13, "revoked", "spiffe://mesh.example.com/ns/ops/sa/revoked")
+
+	sameSerial := issueBy(otherCA, otherCAKey, revoked.SerialNumber.Int64(), "same-serial", "")
+
+	crlPath := filepath.Join(dir, "crl.pem")
+	writeCRL := func(number int64, nextUpdate time.Time) {
+		crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(number),
+			ThisUpdate: nextUpdate.Add(-2 * time.Hour),
+			NextUpdate: nextUpdate,
+			RevokedCertificateEntries: []x509.RevocationListEntry{
+				{SerialNumber: revoked.SerialNumber, RevocationTime: time.Now()},
+			},
+		}, ca, caKey)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(crlPath, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlDER}), 0o600))
+	}
+	writeCRL(1, time.Now().Add(time.Hour))
+
+	auth, err := newGRPCClientAuth(types.GRPCClientAuthConfig{
+		Enabled:  true,
+		CAPath:   caPath,
+		CRLPath:  crlPath,
+		Admin:    []string{"spiffe://mesh.example.com/ns/ops/*"},
+		ReadOnly: []string{"monitoring"},
+	})
+	require.NoError(t, err)
+
+	tlsConfig := auth.tlsConfig(&tls.Config{})
+	assert.Equal(t, tls.VerifyClientCertIfGiven, tlsConfig.ClientAuth)
+
+	require.NoError(t, tlsConfig.VerifyPeerCertificate(nil, [][]*x509.Certificate{{automation, ca}}))
+	require.ErrorIs(t, tlsConfig.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revoked, ca}}), errClientCertRevoked)
+
+	// Only certificates of the issuer of the revocation list are revoked.
+	require.NoError(t, tlsConfig.VerifyPeerCertificate(nil, [][]*x509.Certificate{{sameSerial, otherCA}}))
+
+	call := func(cert *x509.Certificate, method string) (bool, codes.Code) {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234},
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{cert, ca}},
+			}},
+		})
+
+		authenticated, err := auth.authorize(ctx, "/headscale.v1.HeadscaleService/"+method)
+
+		return authenticated, status.Code(err)
+	}
+
+	tests := []struct {
+		name              string
+		cert              *x509.Certificate
+		method            string
+		wantAuthenticated bool
+		wantCode          codes.Code
+	}{
+		{
+			name:              "admin-by-uri-prefix",
+			cert:              automation,
+			method:            "DeleteNode",
+			wantAuthenticated: true,
+			wantCode:          codes.OK,
+		},
+		{
+			name:              "read-only-by-common-name-lists",
+			cert:              monitoring,
+			method:            "ListNodes",
+			wantAuthenticated: true,
+			wantCode:          codes.OK,
+		},
+		{
+			name:              "read-only-by-common-name-deletes",
+			cert:              monitoring,
+			method:            "DeleteNode",
+			wantAuthenticated: true,
+			wantCode:          codes.PermissionDenied,
+		},
+		{
+			name:              "unmapped-falls-back-to-api-key",
+			cert:              other,
+			method:            "ListNodes",
+			wantAuthenticated: false,
+			wantCode:          codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticated, code := call(tt.cert, tt.method)
+			assert.Equal(t, tt.wantAuthenticated, authenticated)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+
+	// Clients without a certificate are left to the API key.
+	authenticated, err := auth.authorize(context.Background(), "/headscale.v1.HeadscaleService/ListNodes")
+	require.NoError(t, err)
+	assert.False(t, authenticated)
+
+	// A revocation list past its next update rejects all certificates.
+	writeCRL(2, time.Now().Add(-time.Minute))
+	require.NoError(t, os.Chtimes(crlPath, time.Now(), time.Now().Add(time.Minute)))
+	require.ErrorIs(t, tlsConfig.VerifyPeerCertificate(nil, [][]*x509.Certificate{{automation, ca}}), errCRLExpired)
+}
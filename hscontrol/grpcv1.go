@@ -2,10 +2,12 @@
 package hscontrol
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/netip"
 	"os"
 	"slices"
@@ -23,6 +25,7 @@ import (
 	"tailscale.com/net/tsaddr"
 	"tailscale.com/tailcfg"
 	"tailscale.com/types/key"
+	"tailscale.com/types/ptr"
 
 	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
 	"github.com/juanfont/headscale/hscontrol/db"
@@ -143,6 +146,129 @@ func (api headscaleV1APIServer) ListUsers(
 	return &v1.ListUsersResponse{Users: response}, nil
 }
 
+func (api headscaleV1APIServer) SuspendUser(
+	ctx context.Context,
+	request *v1.SuspendUserRequest,
+) (*v1.SuspendUserResponse, error) {
+	user, err := api.h.db.SuspendUser(types.UserID(request.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	err = api.h.userSuspensionChanged(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("updating resources using user: %w", err)
+	}
+
+	return &v1.SuspendUserResponse{User: user.Proto()}, nil
+}
+
+func (api headscaleV1APIServer) UnsuspendUser(
+	ctx context.Context,
+	request *v1.UnsuspendUserRequest,
+) (*v1.UnsuspendUserResponse, error) {
+	user, err := api.h.db.UnsuspendUser(types.UserID(request.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	err = api.h.userSuspensionChanged(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("updating resources using user: %w", err)
+	}
+
+	return &v1.UnsuspendUserResponse{User: user.Proto()}, nil
+}
+
+func (api headscaleV1APIServer) SetUserPassword(
+	ctx context.Context,
+	request *v1.SetUserPasswordRequest,
+) (*v1.SetUserPasswordResponse, error) {
+	if password := request.GetPassword(); password != "" {
+		if err := validateLocalPassword(api.h.cfg.LocalAuth, password); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	uid := types.UserID(request.GetId())
+	if err := api.h.db.SetUserPassword(uid, request.GetPassword()); err != nil {
+		return nil, err
+	}
+
+	user, err := api.h.db.GetUserByID(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.SetUserPasswordResponse{User: user.Proto()}, nil
+}
+
+func (api headscaleV1APIServer) SetUserTOTP(
+	ctx context.Context,
+	request *v1.SetUserTOTPRequest,
+) (*v1.SetUserTOTPResponse, error) {
+	user, err := api.h.db.GetUserByID(types.UserID(request.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	var secret, url string
+	if request.GetEnabled() {
+		secret, err = util.GenerateTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		url = util.TOTPURL(api.h.cfg.LocalAuth.TOTPIssuer, user.Name, secret)
+	}
+
+	err = api.h.db.SetUserTOTPSecret(types.UserID(user.ID), secret)
+	if errors.Is(err, db.ErrLocalCredentialNotFound) {
+		return nil, status.Errorf(codes.FailedPrecondition, "user %q has no password", user.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.SetUserTOTPResponse{User: user.Proto(), Secret: secret, Url: url}, nil
+}
+
+// userSuspensionChanged updates the routes served by the nodes of the user
+// and sends a full update to all nodes, so the nodes of a suspended user
+// disappear from all peer lists and filters, or reappear when reinstated.
+func (h *Headscale) userSuspensionChanged(ctx context.Context, user *types.User) error {
+	nodes, err := db.Read(h.db.DB, func(rx *gorm.DB) (types.Nodes, error) {
+		return db.ListNodesByUser(rx, types.UserID(user.ID))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if user.Suspended || !h.nodeNotifier.IsLikelyConnected(node.ID) {
+			h.primaryRoutes.SetRoutes(node.ID)
+		} else {
+			h.primaryRoutes.SetRoutes(node.ID, node.SubnetRoutes()...)
+		}
+	}
+
+	err = usersChangedHook(h.db, h.polMan, h.nodeNotifier)
+	if err != nil {
+		return err
+	}
+
+	updateSent, err := nodesChangedHook(h.db, h.polMan, h.nodeNotifier)
+	if err != nil {
+		return err
+	}
+
+	if !updateSent {
+		ctx = types.NotifyCtx(ctx, "cli-user-suspension", user.Name)
+		h.nodeNotifier.NotifyAll(ctx, types.UpdateFull())
+	}
+
+	return nil
+}
+
 func (api headscaleV1APIServer) CreatePreAuthKey(
 	ctx context.Context,
 	request *v1.CreatePreAuthKeyRequest,
@@ -161,6 +287,26 @@ func (api headscaleV1APIServer) CreatePreAuthKey(
 		}
 	}
 
+	approvedRoutes, err := parseApprovedRoutes(request.GetApprovedRoutes())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ipv4, ipv6, err := parseNodeIPs(request.GetIpv4(), request.GetIpv6())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	settings := &types.PreAuthKeyNodeSettings{
+		ApprovedRoutes:             approvedRoutes,
+		NodeExpiry:                 request.GetNodeExpiry().AsDuration(),
+		GivenName:                  request.GetGivenName(),
+		EphemeralInactivityTimeout: request.GetEphemeralInactivityTimeout().AsDuration(),
+		IPv4:                       ipv4,
+		IPv6:                       ipv6,
+		NameCollision:              types.NodeNameCollision(request.GetNameCollision()),
+	}
+
 	user, err := api.h.db.GetUserByID(types.UserID(request.GetUser()))
 	if err != nil {
 		return nil, err
@@ -172,7 +318,11 @@ func (api headscaleV1APIServer) CreatePreAuthKey(
 		request.GetEphemeral(),
 		&expiration,
 		request.AclTags,
+		settings,
 	)
+	if errors.Is(err, db.ErrPreAuthKeySettingsInvalid) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -243,9 +393,9 @@ func (api headscaleV1APIServer) RegisterNode(
 		return nil, err
 	}
 
-	ipv4, ipv6, err := api.h.ipAlloc.Next()
+	fixed4, fixed6, err := parseNodeIPs(request.GetIpv4(), request.GetIpv6())
 	if err != nil {
-		return nil, err
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	user, err := api.h.db.GetUserByName(request.GetUser())
@@ -253,15 +403,42 @@ func (api headscaleV1APIServer) RegisterNode(
 		return nil, fmt.Errorf("looking up user: %w", err)
 	}
 
-	node, _, err := api.h.db.HandleNodeFromAuthPath(
+	if user.Suspended || user.Deactivated {
+		return nil, status.Errorf(codes.FailedPrecondition, "user %q is suspended or deactivated", user.Name)
+	}
+
+	ipv4, ipv6, err := api.h.ipAlloc.NextFor(user, nil, fixed4, fixed6)
+	if err != nil {
+		return nil, err
+	}
+
+	// A node registered before keeps its addresses, unless others
+	// are requested. They are reserved with the registration, so a
+	// conflict does not leave the node behind.
+	var setIPs func(tx *gorm.DB, node *types.Node) error
+	if fixed4 != nil || fixed6 != nil {
+		setIPs = func(tx *gorm.DB, node *types.Node) error {
+			if err := db.SetNodeIPs(tx, api.h.ipAlloc, node.ID, fixed4, fixed6); err != nil {
+				return err
+			}
+
+			node.IPv4 = cmp.Or(fixed4, node.IPv4)
+			node.IPv6 = cmp.Or(fixed6, node.IPv6)
+
+			return nil
+		}
+	}
+
+	node, _, renamedID, err := api.h.db.HandleNodeFromAuthPath(
 		registrationId,
 		types.UserID(user.ID),
 		nil,
 		util.RegisterMethodCLI,
 		ipv4, ipv6,
+		setIPs,
 	)
 	if err != nil {
-		return nil, err
+		return nil, nodeIPsError(err)
 	}
 
 	updateSent, err := nodesChangedHook(api.h.db, api.h.polMan, api.h.nodeNotifier)
@@ -290,6 +467,8 @@ func (api headscaleV1APIServer) RegisterNode(
 		api.h.nodeNotifier.NotifyAll(ctx, types.UpdatePeerChanged(node.ID))
 	}
 
+	notifyNameTakenOver(api.h.nodeNotifier, renamedID, node.Hostname)
+
 	return &v1.RegisterNodeResponse{Node: node.Proto()}, nil
 }
 
@@ -347,27 +526,106 @@ func (api headscaleV1APIServer) SetTags(
 	return &v1.SetTagsResponse{Node: node.Proto()}, nil
 }
 
-func (api headscaleV1APIServer) SetApprovedRoutes(
+func (api headscaleV1APIServer) SetNodeLabels(
 	ctx context.Context,
-	request *v1.SetApprovedRoutesRequest,
-) (*v1.SetApprovedRoutesResponse, error) {
-	var routes []netip.Prefix
-	for _, route := range request.GetRoutes() {
-		prefix, err := netip.ParsePrefix(route)
+	request *v1.SetNodeLabelsRequest,
+) (*v1.SetNodeLabelsResponse, error) {
+	node, err := db.Write(api.h.db.DB, func(tx *gorm.DB) (*types.Node, error) {
+		err := db.SetNodeLabels(tx, types.NodeID(request.GetNodeId()), request.GetLabels())
 		if err != nil {
-			return nil, fmt.Errorf("parsing route: %w", err)
+			return nil, err
 		}
 
-		// If the prefix is an exit route, add both. The client expect both
-		// to annotate the node as an exit node.
-		if prefix == tsaddr.AllIPv4() || prefix == tsaddr.AllIPv6() {
-			routes = append(routes, tsaddr.AllIPv4(), tsaddr.AllIPv6())
-		} else {
-			routes = append(routes, prefix)
+		return db.GetNodeByID(tx, types.NodeID(request.GetNodeId()))
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
 		}
+
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.Trace().
+		Str("node", node.Hostname).
+		Interface("labels", request.GetLabels()).
+		Msg("Setting labels of node")
+
+	return &v1.SetNodeLabelsResponse{Node: node.Proto()}, nil
+}
+
+func (api headscaleV1APIServer) SetNodeAliases(
+	ctx context.Context,
+	request *v1.SetNodeAliasesRequest,
+) (*v1.SetNodeAliasesResponse, error) {
+	node, err := db.Write(api.h.db.DB, func(tx *gorm.DB) (*types.Node, error) {
+		err := db.SetNodeAliases(tx, types.NodeID(request.GetNodeId()), request.GetAliases())
+		if err != nil {
+			return nil, err
+		}
+
+		return db.GetNodeByID(tx, types.NodeID(request.GetNodeId()))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, db.ErrNodeAliasNotUnique):
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case errors.Is(err, db.ErrNodeAliasInvalid):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, err
+	}
+
+	ctx = types.NotifyCtx(ctx, "cli-setnodealiases", node.Hostname)
+	api.h.nodeNotifier.NotifyAll(ctx, types.UpdateFull())
+
+	log.Trace().
+		Str("node", node.Hostname).
+		Strs("aliases", node.Aliases).
+		Msg("Setting aliases of node")
+
+	return &v1.SetNodeAliasesResponse{Node: node.Proto()}, nil
+}
+
+func (api headscaleV1APIServer) DeleteNodeLabels(
+	ctx context.Context,
+	request *v1.DeleteNodeLabelsRequest,
+) (*v1.DeleteNodeLabelsResponse, error) {
+	node, err := db.Write(api.h.db.DB, func(tx *gorm.DB) (*types.Node, error) {
+		err := db.DeleteNodeLabels(tx, types.NodeID(request.GetNodeId()), request.GetKeys())
+		if err != nil {
+			return nil, err
+		}
+
+		return db.GetNodeByID(tx, types.NodeID(request.GetNodeId()))
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		return nil, err
+	}
+
+	log.Trace().
+		Str("node", node.Hostname).
+		Strs("keys", request.GetKeys()).
+		Msg("Deleting labels of node")
+
+	return &v1.DeleteNodeLabelsResponse{Node: node.Proto()}, nil
+}
+
+func (api headscaleV1APIServer) SetApprovedRoutes(
+	ctx context.Context,
+	request *v1.SetApprovedRoutesRequest,
+) (*v1.SetApprovedRoutesResponse, error) {
+	routes, err := parseApprovedRoutes(request.GetRoutes())
+	if err != nil {
+		return nil, err
 	}
-	tsaddr.SortPrefixes(routes)
-	routes = slices.Compact(routes)
 
 	node, err := db.Write(api.h.db.DB, func(tx *gorm.DB) (*types.Node, error) {
 		err := db.SetApprovedRoutes(tx, types.NodeID(request.GetNodeId()), routes)
@@ -395,6 +653,29 @@ func (api headscaleV1APIServer) SetApprovedRoutes(
 	return &v1.SetApprovedRoutesResponse{Node: proto}, nil
 }
 
+// parseApprovedRoutes parses a list of routes to approve on a node.
+func parseApprovedRoutes(routeStrs []string) ([]netip.Prefix, error) {
+	var routes []netip.Prefix
+	for _, route := range routeStrs {
+		prefix, err := netip.ParsePrefix(route)
+		if err != nil {
+			return nil, fmt.Errorf("parsing route: %w", err)
+		}
+
+		// If the prefix is an exit route, add both. The client expect both
+		// to annotate the node as an exit node.
+		if prefix == tsaddr.AllIPv4() || prefix == tsaddr.AllIPv6() {
+			routes = append(routes, tsaddr.AllIPv4(), tsaddr.AllIPv6())
+		} else {
+			routes = append(routes, prefix)
+		}
+	}
+	tsaddr.SortPrefixes(routes)
+	routes = slices.Compact(routes)
+
+	return routes, nil
+}
+
 func validateTag(tag string) error {
 	if strings.Index(tag, "tag:") != 0 {
 		return errors.New("tag must start with the string 'tag:'")
@@ -499,40 +780,18 @@ func (api headscaleV1APIServer) ListNodes(
 	ctx context.Context,
 	request *v1.ListNodesRequest,
 ) (*v1.ListNodesResponse, error) {
-	// TODO(kradalby): it looks like this can be simplified a lot,
-	// the filtering of nodes by user, vs nodes as a whole can
-	// probably be done once.
-	// TODO(kradalby): This should be done in one tx.
-
-	isLikelyConnected := api.h.nodeNotifier.LikelyConnectedMap()
-	if request.GetUser() != "" {
-		user, err := api.h.db.GetUserByName(request.GetUser())
-		if err != nil {
-			return nil, err
-		}
-
-		nodes, err := db.Read(api.h.db.DB, func(rx *gorm.DB) (types.Nodes, error) {
-			return db.ListNodesByUser(rx, types.UserID(user.ID))
-		})
-		if err != nil {
-			return nil, err
-		}
+	var nextPageToken string
+	nodes, err := db.Read(api.h.db.DB, func(rx *gorm.DB) ([]*v1.Node, error) {
+		nodes, next, err := api.selectNodes(rx, request)
+		nextPageToken = next
 
-		response := nodesToProto(api.h.polMan, isLikelyConnected, api.h.primaryRoutes, nodes)
-		return &v1.ListNodesResponse{Nodes: response}, nil
-	}
-
-	nodes, err := api.h.db.ListNodes()
+		return nodes, err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	sort.Slice(nodes, func(i, j int) bool {
-		return nodes[i].ID < nodes[j].ID
-	})
-
-	response := nodesToProto(api.h.polMan, isLikelyConnected, api.h.primaryRoutes, nodes)
-	return &v1.ListNodesResponse{Nodes: response}, nil
+	return &v1.ListNodesResponse{Nodes: nodes, NextPageToken: nextPageToken}, nil
 }
 
 func nodesToProto(polMan policy.PolicyManager, isLikelyConnected *xsync.MapOf[types.NodeID, bool], pr *routes.PrimaryRoutes, nodes types.Nodes) []*v1.Node {
@@ -592,6 +851,322 @@ func (api headscaleV1APIServer) MoveNode(
 	return &v1.MoveNodeResponse{Node: node.Proto()}, nil
 }
 
+// bulkNodes applies the change to the nodes selected by the selector in
+// one transaction, and returns the nodes after the change. Unless it is a
+// dry run, all nodes get a single update.
+func (api headscaleV1APIServer) bulkNodes(
+	ctx context.Context,
+	origin string,
+	selector string,
+	dryRun bool,
+	change func(tx *gorm.DB, ids []types.NodeID) error,
+	update func(ids []types.NodeID) types.StateUpdate,
+) ([]*v1.Node, error) {
+	var ids []types.NodeID
+	nodes, err := db.Write(api.h.db.DB, func(tx *gorm.DB) ([]*v1.Node, error) {
+		selected, err := api.selectedNodes(tx, selector)
+		if err != nil || dryRun || len(selected) == 0 {
+			return selected, err
+		}
+
+		for _, node := range selected {
+			ids = append(ids, types.NodeID(node.GetId()))
+		}
+
+		if err := change(tx, ids); err != nil {
+			return nil, err
+		}
+
+		after, err := db.ListNodes(tx, ids...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Deleted nodes are returned as they were selected.
+		if len(after) == 0 {
+			return selected, nil
+		}
+
+		slices.SortFunc(after, func(a, b *types.Node) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+
+		return nodesToProto(api.h.polMan, api.h.nodeNotifier.LikelyConnectedMap(), api.h.primaryRoutes, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+
+	log.Info().
+		Str("selector", selector).
+		Str("operation", origin).
+		Int("nodes", len(ids)).
+		Msg("Changed nodes in bulk")
+
+	ctx = types.NotifyCtx(ctx, "cli-"+origin, "bulk")
+	api.h.nodeNotifier.NotifyAll(ctx, update(ids))
+
+	return nodes, nil
+}
+
+func (api headscaleV1APIServer) ExpireNodes(
+	ctx context.Context,
+	request *v1.ExpireNodesRequest,
+) (*v1.ExpireNodesResponse, error) {
+	now := time.Now()
+	nodes, err := api.bulkNodes(ctx, "expirenodes", request.GetSelector(), request.GetDryRun(),
+		func(tx *gorm.DB, ids []types.NodeID) error {
+			for _, id := range ids {
+				if err := db.NodeSetExpiry(tx, id, now); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func([]types.NodeID) types.StateUpdate { return types.UpdateFull() },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.ExpireNodesResponse{Nodes: nodes}, nil
+}
+
+func (api headscaleV1APIServer) DeleteNodes(
+	ctx context.Context,
+	request *v1.DeleteNodesRequest,
+) (*v1.DeleteNodesResponse, error) {
+	nodes, err := api.bulkNodes(ctx, "deletenodes", request.GetSelector(), request.GetDryRun(),
+		func(tx *gorm.DB, ids []types.NodeID) error {
+			for _, id := range ids {
+				if err := db.DeleteNode(tx, &types.Node{ID: id}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func(ids []types.NodeID) types.StateUpdate { return types.UpdatePeerRemoved(ids...) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.DeleteNodesResponse{Nodes: nodes}, nil
+}
+
+func (api headscaleV1APIServer) SetNodesTags(
+	ctx context.Context,
+	request *v1.SetNodesTagsRequest,
+) (*v1.SetNodesTagsResponse, error) {
+	for _, tag := range request.GetTags() {
+		if err := validateTag(tag); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	nodes, err := api.bulkNodes(ctx, "setnodestags", request.GetSelector(), request.GetDryRun(),
+		func(tx *gorm.DB, ids []types.NodeID) error {
+			for _, id := range ids {
+				if err := db.SetTags(tx, id, request.GetTags()); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func([]types.NodeID) types.StateUpdate { return types.UpdateFull() },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.SetNodesTagsResponse{Nodes: nodes}, nil
+}
+
+func (api headscaleV1APIServer) MoveNodes(
+	ctx context.Context,
+	request *v1.MoveNodesRequest,
+) (*v1.MoveNodesResponse, error) {
+	nodes, err := api.bulkNodes(ctx, "movenodes", request.GetSelector(), request.GetDryRun(),
+		func(tx *gorm.DB, ids []types.NodeID) error {
+			nodes, err := db.ListNodes(tx, ids...)
+			if err != nil {
+				return err
+			}
+
+			for _, node := range nodes {
+				if err := db.AssignNodeToUser(tx, node, types.UserID(request.GetUser())); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		func([]types.NodeID) types.StateUpdate { return types.UpdateFull() },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.MoveNodesResponse{Nodes: nodes}, nil
+}
+
+func (api headscaleV1APIServer) ListStaleNodes(
+	ctx context.Context,
+	request *v1.ListStaleNodesRequest,
+) (*v1.ListStaleNodesResponse, error) {
+	stale, err := db.Read(api.h.db.DB, func(rx *gorm.DB) ([]staleNode, error) {
+		return api.h.staleNodes(rx, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(types.Nodes, len(stale))
+	for i, s := range stale {
+		nodes[i] = s.node
+	}
+	protos := nodesToProto(api.h.polMan, api.h.nodeNotifier.LikelyConnectedMap(), api.h.primaryRoutes, nodes)
+
+	response := &v1.ListStaleNodesResponse{
+		Enabled: api.h.cfg.NodeCleanup.Enabled,
+	}
+	for i, s := range stale {
+		response.Nodes = append(response.Nodes, &v1.StaleNode{
+			Node:   protos[i],
+			Action: s.action,
+			Due:    timestamppb.New(s.due),
+		})
+	}
+
+	return response, nil
+}
+
+func (api headscaleV1APIServer) ListEphemeralDeletions(
+	ctx context.Context,
+	request *v1.ListEphemeralDeletionsRequest,
+) (*v1.ListEphemeralDeletionsResponse, error) {
+	scheduled := api.h.ephemeralGC.Scheduled()
+	if len(scheduled) == 0 {
+		return &v1.ListEphemeralDeletionsResponse{}, nil
+	}
+
+	nodes, err := db.Read(api.h.db.DB, func(rx *gorm.DB) (types.Nodes, error) {
+		return db.ListNodes(rx, slices.Collect(maps.Keys(scheduled))...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(nodes, func(a, b *types.Node) int {
+		return cmp.Or(scheduled[a.ID].Compare(scheduled[b.ID]), cmp.Compare(a.ID, b.ID))
+	})
+	protos := nodesToProto(api.h.polMan, api.h.nodeNotifier.LikelyConnectedMap(), api.h.primaryRoutes, nodes)
+
+	response := &v1.ListEphemeralDeletionsResponse{}
+	for i, node := range nodes {
+		response.Deletions = append(response.Deletions, &v1.EphemeralDeletion{
+			Node: protos[i],
+			Due:  timestamppb.New(scheduled[node.ID]),
+		})
+	}
+
+	return response, nil
+}
+
+func (api headscaleV1APIServer) SetNodeIPs(
+	ctx context.Context,
+	request *v1.SetNodeIPsRequest,
+) (*v1.SetNodeIPsResponse, error) {
+	ipv4, ipv6, err := parseNodeIPs(request.GetIpv4(), request.GetIpv6())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if ipv4 == nil && ipv6 == nil {
+		return nil, status.Error(codes.InvalidArgument, "no IP address given")
+	}
+
+	node, err := db.Write(api.h.db.DB, func(tx *gorm.DB) (*types.Node, error) {
+		node, err := db.GetNodeByID(tx, types.NodeID(request.GetNodeId()))
+		if err != nil {
+			return nil, err
+		}
+
+		err = db.SetNodeIPs(tx, api.h.ipAlloc, node.ID, ipv4, ipv6)
+		if err != nil {
+			return nil, err
+		}
+
+		return db.GetNodeByID(tx, node.ID)
+	})
+	if err != nil {
+		return nil, nodeIPsError(err)
+	}
+
+	log.Info().
+		Uint64("node.id", node.ID.Uint64()).
+		Strs("ips", node.IPsAsString()).
+		Msg("node IPs changed")
+
+	// The policy is resolved to the addresses of the nodes.
+	updateSent, err := nodesChangedHook(api.h.db, api.h.polMan, api.h.nodeNotifier)
+	if err != nil {
+		return nil, fmt.Errorf("updating resources using node: %w", err)
+	}
+
+	if !updateSent {
+		ctx = types.NotifyCtx(ctx, "cli-setnodeips-self", node.Hostname)
+		api.h.nodeNotifier.NotifyByNodeID(ctx, types.UpdateSelf(node.ID), node.ID)
+		ctx = types.NotifyCtx(ctx, "cli-setnodeips", node.Hostname)
+		api.h.nodeNotifier.NotifyWithIgnore(ctx, types.UpdatePeerChanged(node.ID), node.ID)
+	}
+
+	return &v1.SetNodeIPsResponse{Node: node.Proto()}, nil
+}
+
+// parseNodeIPs parses the addresses to give a node, empty strings are
+// returned as nil.
+func parseNodeIPs(ipv4Str, ipv6Str string) (*netip.Addr, *netip.Addr, error) {
+	var ipv4, ipv6 *netip.Addr
+	if ipv4Str != "" {
+		addr, err := netip.ParseAddr(ipv4Str)
+		if err != nil || !addr.Is4() {
+			return nil, nil, fmt.Errorf("invalid IPv4 address %q", ipv4Str)
+		}
+		ipv4 = &addr
+	}
+
+	if ipv6Str != "" {
+		addr, err := netip.ParseAddr(ipv6Str)
+		if err != nil || !addr.Is6() || addr.Is4In6() {
+			return nil, nil, fmt.Errorf("invalid IPv6 address %q", ipv6Str)
+		}
+		ipv6 = &addr
+	}
+
+	return ipv4, ipv6, nil
+}
+
+// nodeIPsError returns the errors of assigning IP addresses with a
+// matching gRPC status code.
+func nodeIPsError(err error) error {
+	switch {
+	case errors.Is(err, db.ErrIPInUse):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, db.ErrIPNotInPrefix), errors.Is(err, db.ErrIPReserved):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return err
+}
+
 func (api headscaleV1APIServer) BackfillNodeIPs(
 	ctx context.Context,
 	request *v1.BackfillNodeIPsRequest,
@@ -610,6 +1185,91 @@ func (api headscaleV1APIServer) BackfillNodeIPs(
 	return &v1.BackfillNodeIPsResponse{Changes: changes}, nil
 }
 
+func (api headscaleV1APIServer) RenumberNodes(
+	ctx context.Context,
+	request *v1.RenumberNodesRequest,
+) (*v1.RenumberNodesResponse, error) {
+	var prefixes [2]*netip.Prefix
+	for index, prefixStr := range []string{request.GetPrefixV4(), request.GetPrefixV6()} {
+		if prefixStr == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(prefixStr)
+		if err != nil || prefix.Addr().Is4() != (index == 0) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid prefix %q", prefixStr)
+		}
+		prefix = prefix.Masked()
+		prefixes[index] = &prefix
+	}
+
+	mapping := make(map[netip.Addr]netip.Addr, len(request.GetMapping()))
+	for oldStr, newStr := range request.GetMapping() {
+		old, err := netip.ParseAddr(oldStr)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid address %q in mapping", oldStr)
+		}
+
+		addr, err := netip.ParseAddr(newStr)
+		if err != nil || addr.Is4() != old.Is4() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid address %q in mapping of %q", newStr, oldStr)
+		}
+
+		mapping[old] = addr
+	}
+
+	renumbered, changes, err := api.h.db.RenumberNodes(api.h.ipAlloc, prefixes[0], prefixes[1], mapping, request.GetDryRun())
+	switch {
+	case errors.Is(err, db.ErrRenumberMapping), errors.Is(err, db.ErrRenumberFamilyDisabled):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, db.ErrRenumberWithPools), errors.Is(err, db.ErrCouldNotAllocateIP):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, err
+	}
+
+	response := &v1.RenumberNodesResponse{Changes: changes}
+
+	// Hosts of the policy referring to old addresses are rewritten if
+	// the policy is in the database, otherwise they are only reported.
+	var pol string
+	if current, err := api.GetPolicy(ctx, &v1.GetPolicyRequest{}); err == nil {
+		pol = current.GetPolicy()
+	}
+
+	newPol, policyChanges, err := policy.RenumberHosts([]byte(pol), renumbered)
+	if err != nil {
+		return nil, err
+	}
+	response.PolicyChanges = policyChanges
+
+	if request.GetDryRun() || len(renumbered) == 0 {
+		return response, nil
+	}
+
+	log.Info().
+		Int("addresses", len(renumbered)).
+		Msg("nodes renumbered")
+
+	if _, err := nodesChangedHook(api.h.db, api.h.polMan, api.h.nodeNotifier); err != nil {
+		return nil, fmt.Errorf("updating resources using node: %w", err)
+	}
+
+	if api.h.cfg.Policy.Mode == types.PolicyModeDB && string(newPol) != pol {
+		if _, err := api.SetPolicy(ctx, &v1.SetPolicyRequest{Policy: string(newPol)}); err != nil {
+			return nil, fmt.Errorf("nodes are renumbered, but updating the hosts of the policy failed: %w", err)
+		}
+		response.PolicyUpdated = true
+	}
+
+	// Every node has to learn the new addresses of itself and its
+	// peers.
+	ctx = types.NotifyCtx(ctx, "cli-renumber", "all")
+	api.h.nodeNotifier.NotifyAll(ctx, types.UpdateFull())
+
+	return response, nil
+}
+
 func (api headscaleV1APIServer) CreateApiKey(
 	ctx context.Context,
 	request *v1.CreateApiKeyRequest,
@@ -691,6 +1351,73 @@ func (api headscaleV1APIServer) DeleteApiKey(
 	return &v1.DeleteApiKeyResponse{}, nil
 }
 
+func (api headscaleV1APIServer) CreateOAuthClient(
+	ctx context.Context,
+	request *v1.CreateOAuthClientRequest,
+) (*v1.CreateOAuthClientResponse, error) {
+	var expiration *time.Time
+	if request.GetExpiration() != nil {
+		expiration = ptr.To(request.GetExpiration().AsTime())
+	}
+
+	secret, client, err := api.h.db.CreateOAuthClient(
+		request.GetScopes(),
+		request.GetTags(),
+		request.GetDescription(),
+		expiration,
+		request.GetAllowNonEphemeral(),
+	)
+	if err != nil {
+		if errors.Is(err, db.ErrOAuthClientScopeInvalid) || errors.Is(err, db.ErrOAuthClientTagInvalid) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, err
+	}
+
+	return &v1.CreateOAuthClientResponse{
+		OauthClient:  client.Proto(),
+		ClientSecret: secret,
+	}, nil
+}
+
+func (api headscaleV1APIServer) ListOAuthClients(
+	ctx context.Context,
+	request *v1.ListOAuthClientsRequest,
+) (*v1.ListOAuthClientsResponse, error) {
+	clients, err := api.h.db.ListOAuthClients()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*v1.OAuthClient, len(clients))
+	for index, client := range clients {
+		response[index] = client.Proto()
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].Id < response[j].Id
+	})
+
+	return &v1.ListOAuthClientsResponse{OauthClients: response}, nil
+}
+
+func (api headscaleV1APIServer) DeleteOAuthClient(
+	ctx context.Context,
+	request *v1.DeleteOAuthClientRequest,
+) (*v1.DeleteOAuthClientResponse, error) {
+	client, err := api.h.db.GetOAuthClient(request.GetClientId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := api.h.db.DeleteOAuthClient(client); err != nil {
+		return nil, err
+	}
+
+	return &v1.DeleteOAuthClientResponse{}, nil
+}
+
 func (api headscaleV1APIServer) GetPolicy(
 	_ context.Context,
 	_ *v1.GetPolicyRequest,
@@ -827,6 +1554,7 @@ func (api headscaleV1APIServer) DebugCreateNode(
 			Hostinfo: &hostinfo,
 		},
 		Registered: make(chan *types.Node),
+		Naming:     api.h.nodeNaming(""),
 	}
 
 	log.Debug().
@@ -1,6 +1,21 @@
 package hscontrol
 
-import "testing"
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
+	"tailscale.com/types/ptr"
+)
 
 func Test_validateTag(t *testing.T) {
 	type args struct {
@@ -40,3 +55,182 @@ func Test_validateTag(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkNodes(t *testing.T) {
+	h := newTestHeadscale(t, nil)
+	api := newHeadscaleV1APIServer(h)
+	ctx := context.Background()
+
+	alice, err := h.db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+	bob, err := h.db.CreateUser(types.User{Name: "bob"})
+	require.NoError(t, err)
+
+	longAgo := time.Now().Add(-60 * 24 * time.Hour)
+	for _, node := range []types.Node{
+		{Hostname: "laptop-1", UserID: alice.ID, LastSeen: &longAgo, Hostinfo: &tailcfg.Hostinfo{OS: "windows"}},
+		{Hostname: "laptop-2", UserID: alice.ID, LastSeen: ptr.To(time.Now()), Hostinfo: &tailcfg.Hostinfo{OS: "windows"}},
+		{Hostname: "server", UserID: alice.ID, LastSeen: &longAgo, Hostinfo: &tailcfg.Hostinfo{OS: "linux"}},
+		{Hostname: "phone", UserID: bob.ID, LastSeen: &longAgo, Hostinfo: &tailcfg.Hostinfo{OS: "windows"}},
+		// Nodes which never connected count as offline since they were
+		// created.
+		{Hostname: "lab", UserID: bob.ID, CreatedAt: longAgo, Hostinfo: &tailcfg.Hostinfo{OS: "linux"}},
+		{Hostname: "new", UserID: bob.ID, Hostinfo: &tailcfg.Hostinfo{OS: "linux"}},
+	} {
+		node.MachineKey = key.NewMachine().Public()
+		node.NodeKey = key.NewNode().Public()
+		node.GivenName = node.Hostname
+		require.NoError(t, h.db.DB.Save(&node).Error)
+	}
+
+	hostnames := func(nodes []*v1.Node) []string {
+		var ret []string
+		for _, node := range nodes {
+			ret = append(ret, node.GetName())
+		}
+
+		return ret
+	}
+
+	_, err = api.ExpireNodes(ctx, &v1.ExpireNodesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = api.ExpireNodes(ctx, &v1.ExpireNodesRequest{Selector: "color=red"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	dryRun, err := api.ExpireNodes(ctx, &v1.ExpireNodesRequest{
+		Selector: "user=alice,os=windows,offline>30d",
+		DryRun:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"laptop-1"}, hostnames(dryRun.GetNodes()))
+	assert.Nil(t, dryRun.GetNodes()[0].GetExpiry())
+
+	expired, err := api.ExpireNodes(ctx, &v1.ExpireNodesRequest{Selector: "user=alice,os=windows,offline>30d"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"laptop-1"}, hostnames(expired.GetNodes()))
+	assert.NotNil(t, expired.GetNodes()[0].GetExpiry())
+
+	tagged, err := api.SetNodesTags(ctx, &v1.SetNodesTagsRequest{Selector: "os=windows", Tags: []string{"tag:laptop"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"laptop-1", "laptop-2", "phone"}, hostnames(tagged.GetNodes()))
+	for _, node := range tagged.GetNodes() {
+		assert.Equal(t, []string{"tag:laptop"}, node.GetForcedTags())
+	}
+
+	// A failing change leaves all nodes untouched.
+	_, err = api.MoveNodes(ctx, &v1.MoveNodesRequest{Selector: "user=alice", User: 4242})
+	require.Error(t, err)
+
+	moved, err := api.MoveNodes(ctx, &v1.MoveNodesRequest{Selector: "tag=tag:laptop,user=alice", User: uint64(bob.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"laptop-1", "laptop-2"}, hostnames(moved.GetNodes()))
+	assert.Equal(t, "bob", moved.GetNodes()[0].GetUser().GetName())
+
+	deleted, err := api.DeleteNodes(ctx, &v1.DeleteNodesRequest{Selector: "user=bob,offline>30d"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"laptop-1", "phone", "lab"}, hostnames(deleted.GetNodes()))
+
+	nodes, err := h.db.ListNodes()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 3)
+}
+
+func TestRegisterNodeFixedIPs(t *testing.T) {
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.PrefixV4 = ptr.To(netip.MustParsePrefix("100.64.0.0/10"))
+	})
+	api := newHeadscaleV1APIServer(h)
+	ctx := context.Background()
+
+	alice, err := h.db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+
+	taken := netip.MustParseAddr("100.64.0.10")
+	require.NoError(t, h.db.DB.Save(&types.Node{
+		MachineKey: key.NewMachine().Public(),
+		NodeKey:    key.NewNode().Public(),
+		Hostname:   "server",
+		GivenName:  "server",
+		UserID:     alice.ID,
+		IPv4:       &taken,
+	}).Error)
+
+	regID, err := types.NewRegistrationID()
+	require.NoError(t, err)
+	h.registrationCache.Set(regID, types.RegisterNode{
+		Node: types.Node{
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			Hostname:   "laptop",
+		},
+		Registered: make(chan *types.Node, 1),
+	})
+
+	register := func(ipv4 string) (*v1.RegisterNodeResponse, error) {
+		return api.RegisterNode(ctx, &v1.RegisterNodeRequest{
+			User: "alice",
+			Key:  regID.String(),
+			Ipv4: ipv4,
+		})
+	}
+
+	// Addresses of other nodes or outside of the prefix are rejected,
+	// without storing the node.
+	_, err = register(taken.String())
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = register("192.0.2.1")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	nodes, err := h.db.ListNodes()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+
+	// The registration is kept, so it can be retried.
+	resp, err := register("100.64.0.11")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100.64.0.11"}, resp.GetNode().GetIpAddresses())
+
+	nodes, err = h.db.ListNodes()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 2)
+}
+
+func TestRegisterNodeDisabledUser(t *testing.T) {
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.PrefixV4 = ptr.To(netip.MustParsePrefix("100.64.0.0/10"))
+	})
+	api := newHeadscaleV1APIServer(h)
+
+	suspended, err := h.db.CreateUser(types.User{Name: "suspended"})
+	require.NoError(t, err)
+	_, err = h.db.SuspendUser(types.UserID(suspended.ID))
+	require.NoError(t, err)
+
+	deactivated, err := h.db.CreateUser(types.User{Name: "deactivated", Deactivated: true})
+	require.NoError(t, err)
+
+	for _, user := range []string{suspended.Name, deactivated.Name} {
+		regID, err := types.NewRegistrationID()
+		require.NoError(t, err)
+		h.registrationCache.Set(regID, types.RegisterNode{
+			Node: types.Node{
+				MachineKey: key.NewMachine().Public(),
+				NodeKey:    key.NewNode().Public(),
+				Hostname:   "laptop",
+			},
+			Registered: make(chan *types.Node, 1),
+		})
+
+		_, err = api.RegisterNode(context.Background(), &v1.RegisterNodeRequest{
+			User: user,
+			Key:  regID.String(),
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), user)
+	}
+
+	nodes, err := h.db.ListNodes()
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+}
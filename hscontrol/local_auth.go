@@ -0,0 +1,268 @@
+package hscontrol
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chasefleming/elem-go"
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/notifier"
+	"github.com/juanfont/headscale/hscontrol/policy"
+	"github.com/juanfont/headscale/hscontrol/templates"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	csrfCookie = "headscale_csrf"
+
+	// bcrypt ignores everything after 72 bytes.
+	localPasswordMaxLength = 72
+)
+
+var (
+	errLocalPasswordTooShort = errors.New("password is too short")
+	errLocalPasswordTooLong  = errors.New("password must not be longer than 72 bytes")
+	errCSRFMismatch          = errors.New("invalid or expired form, reload the page and try again")
+	errLocalUserDisabled     = errors.New("user is suspended or deactivated")
+)
+
+// AuthProviderLocal registers nodes after the user logged in with the
+// password, and optionally the TOTP code, of a local user.
+type AuthProviderLocal struct {
+	serverURL string
+	cfg       types.LocalAuthConfig
+
+	authRegistrar
+}
+
+func NewAuthProviderLocal(
+	serverURL string,
+	cfg types.LocalAuthConfig,
+	db *db.HSDatabase,
+	notif *notifier.Notifier,
+	ipAlloc *db.IPAllocator,
+	polMan policy.PolicyManager,
+) *AuthProviderLocal {
+	return &AuthProviderLocal{
+		serverURL: serverURL,
+		cfg:       cfg,
+		authRegistrar: authRegistrar{
+			db:       db,
+			notifier: notif,
+			ipAlloc:  ipAlloc,
+			polMan:   polMan,
+		},
+	}
+}
+
+func (a *AuthProviderLocal) AuthURL(registrationID types.RegistrationID) string {
+	return fmt.Sprintf(
+		"%s/register/%s",
+		strings.TrimSuffix(a.serverURL, "/"),
+		registrationID.String())
+}
+
+// validateLocalPassword checks a new password against the configured
+// minimum length and the maximum length bcrypt supports.
+func validateLocalPassword(cfg types.LocalAuthConfig, password string) error {
+	if utf8.RuneCountInString(password) < cfg.MinPasswordLength {
+		return fmt.Errorf("%w, it must have at least %d characters", errLocalPasswordTooShort, cfg.MinPasswordLength)
+	}
+
+	if len(password) > localPasswordMaxLength {
+		return errLocalPasswordTooLong
+	}
+
+	return nil
+}
+
+// RegisterHandler shows the login form of local users and registers the
+// node once the user logged in.
+// Listens in /register/:registration_id.
+func (a *AuthProviderLocal) RegisterHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	registrationID, err := types.RegistrationIDFromString(mux.Vars(req)["registration_id"])
+	if err != nil {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "invalid registration id", err))
+		return
+	}
+
+	action := "/register/" + registrationID.String()
+
+	if req.Method != http.MethodPost {
+		renderCSRFForm(writer, req, http.StatusOK, func(csrf string) *elem.Element {
+			return templates.LocalLogin(action, csrf, "")
+		})
+
+		return
+	}
+
+	user, status, err := a.login(req)
+	if err != nil {
+		renderCSRFForm(writer, req, status, func(csrf string) *elem.Element {
+			return templates.LocalLogin(action, csrf, err.Error())
+		})
+
+		return
+	}
+
+	node, newNode, err := a.handleRegistration(
+		user,
+		registrationID,
+		nil,
+		util.RegisterMethodLocal,
+		nil,
+	)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	log.Info().
+		Str("user", user.Name).
+		Uint64("node.id", node.ID.Uint64()).
+		Msg("registered node with local login")
+
+	verb := "Reauthenticated"
+	if newNode {
+		verb = "Authenticated"
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	if _, err := writer.Write([]byte(templates.RegistrationSuccess(user.Display(), verb).Render())); err != nil {
+		util.LogErr(err, "Failed to write response")
+	}
+}
+
+// PasswordHandler lets local users change their password, they have to log
+// in with their current password and TOTP code.
+// Listens in /login/password.
+func (a *AuthProviderLocal) PasswordHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	if req.Method != http.MethodPost {
+		renderCSRFForm(writer, req, http.StatusOK, func(csrf string) *elem.Element {
+			return templates.LocalPasswordChange(csrf, "", false)
+		})
+
+		return
+	}
+
+	fail := func(status int, err error) {
+		renderCSRFForm(writer, req, status, func(csrf string) *elem.Element {
+			return templates.LocalPasswordChange(csrf, err.Error(), true)
+		})
+	}
+
+	newPassword := req.PostFormValue("new_password")
+	if newPassword != req.PostFormValue("new_password_repeat") {
+		fail(http.StatusBadRequest, errors.New("the new passwords do not match"))
+		return
+	}
+
+	if err := validateLocalPassword(a.cfg, newPassword); err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+
+	user, status, err := a.login(req)
+	if err != nil {
+		fail(status, err)
+		return
+	}
+
+	if err := a.db.SetUserPassword(types.UserID(user.ID), newPassword); err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	log.Info().Str("user", user.Name).Msg("local user changed their password")
+
+	renderCSRFForm(writer, req, http.StatusOK, func(csrf string) *elem.Element {
+		return templates.LocalPasswordChange(csrf, "Your password has been changed.", false)
+	})
+}
+
+// login checks the CSRF token and the credentials posted in the form. On
+// failure it returns the status code and an error to show to the user.
+func (a *AuthProviderLocal) login(req *http.Request) (*types.User, int, error) {
+	if !validCSRF(req) {
+		return nil, http.StatusBadRequest, errCSRFMismatch
+	}
+
+	user, err := a.db.LocalLogin(
+		req.PostFormValue("username"),
+		req.PostFormValue("password"),
+		req.PostFormValue("code"),
+		a.cfg.LockoutAttempts,
+		a.cfg.LockoutDuration,
+	)
+	switch {
+	case errors.Is(err, db.ErrLocalLoginInvalid):
+		return nil, http.StatusUnauthorized, err
+	case errors.Is(err, db.ErrLocalLoginLocked):
+		log.Warn().Str("user", req.PostFormValue("username")).Msg("local user is locked out after too many failed logins")
+		return nil, http.StatusTooManyRequests, err
+	case err != nil:
+		log.Error().Err(err).Msg("checking local login")
+		return nil, http.StatusInternalServerError, errors.New("internal error")
+	}
+
+	if user.Suspended || user.Deactivated {
+		return nil, http.StatusForbidden, errLocalUserDisabled
+	}
+
+	return user, http.StatusOK, nil
+}
+
+// validCSRF reports if the CSRF token posted in a form matches its cookie.
+func validCSRF(req *http.Request) bool {
+	cookie, err := req.Cookie(csrfCookie)
+
+	return err == nil && cookie.Value != "" &&
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(req.PostFormValue("csrf"))) == 1
+}
+
+// renderCSRFForm renders a form with a new CSRF token, which is also set
+// as cookie for validCSRF.
+func renderCSRFForm(
+	writer http.ResponseWriter,
+	req *http.Request,
+	status int,
+	form func(csrf string) *elem.Element,
+) {
+	csrf, err := util.GenerateRandomStringURLSafe(64)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	http.SetCookie(writer, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    csrf,
+		Path:     "/",
+		MaxAge:   int(time.Hour.Seconds()),
+		Secure:   req.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(status)
+	if _, err := writer.Write([]byte(form(csrf).Render())); err != nil {
+		util.LogErr(err, "Failed to write response")
+	}
+}
@@ -0,0 +1,119 @@
+package hscontrol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tailscale.com/types/key"
+)
+
+func TestAuthProviderLocal(t *testing.T) {
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.LocalAuth = types.LocalAuthConfig{
+			Enabled:           true,
+			MinPasswordLength: 8,
+			LockoutAttempts:   3,
+			LockoutDuration:   time.Minute,
+			TOTPIssuer:        "headscale",
+		}
+	})
+
+	provider, ok := h.authProvider.(*AuthProviderLocal)
+	require.True(t, ok)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/register/{registration_id}", provider.RegisterHandler)
+	router.HandleFunc("/login/password", provider.PasswordHandler)
+
+	user, err := h.db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+	require.NoError(t, h.db.SetUserPassword(types.UserID(user.ID), "correct horse"))
+
+	regID, err := types.NewRegistrationID()
+	require.NoError(t, err)
+	h.registrationCache.Set(regID, types.RegisterNode{
+		Node: types.Node{
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			Hostname:   "alice-laptop",
+		},
+		Registered: make(chan *types.Node, 1),
+	})
+
+	// post fetches the form for its CSRF cookie and submits it.
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		cookie := rec.Result().Cookies()[0]
+
+		form.Set("csrf", cookie.Value)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	registerPath := "/register/" + regID.String()
+
+	// Forms without the CSRF cookie are rejected.
+	req := httptest.NewRequest(http.MethodPost, registerPath, strings.NewReader("username=alice&password=correct+horse"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = post(registerPath, url.Values{"username": {"alice"}, "password": {"wrong"}})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid username, password or code")
+
+	rec = post(registerPath, url.Values{"username": {"alice"}, "password": {"correct horse"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Authenticated as alice")
+
+	nodes, err := h.db.ListNodes()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, user.ID, nodes[0].UserID)
+	assert.Equal(t, util.RegisterMethodLocal, nodes[0].RegisterMethod)
+
+	// The password can be changed with the current password.
+	rec = post("/login/password", url.Values{
+		"username":            {"alice"},
+		"password":            {"correct horse"},
+		"new_password":        {"short"},
+		"new_password_repeat": {"short"},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "password is too short")
+
+	rec = post("/login/password", url.Values{
+		"username":            {"alice"},
+		"password":            {"correct horse"},
+		"new_password":        {"battery staple"},
+		"new_password_repeat": {"battery staple"},
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Your password has been changed.")
+
+	_, err = h.db.LocalLogin("alice", "battery staple", "", 3, time.Minute)
+	require.NoError(t, err)
+
+	// Suspended users cannot log in.
+	_, err = h.db.SuspendUser(types.UserID(user.ID))
+	require.NoError(t, err)
+	rec = post(registerPath, url.Values{"username": {"alice"}, "password": {"battery staple"}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -126,6 +126,7 @@ func generateUserProfiles(
 func generateDNSConfig(
 	cfg *types.Config,
 	node *types.Node,
+	peers types.Nodes,
 ) *tailcfg.DNSConfig {
 	if cfg.TailcfgDNSConfig == nil {
 		return nil
@@ -134,10 +135,39 @@ func generateDNSConfig(
 	dnsConfig := cfg.TailcfgDNSConfig.Clone()
 
 	addNextDNSMetadata(dnsConfig.Resolvers, node)
+	dnsConfig.ExtraRecords = append(dnsConfig.ExtraRecords, aliasRecords(cfg.BaseDomain, append(types.Nodes{node}, peers...))...)
 
 	return dnsConfig
 }
 
+// aliasRecords returns the DNS records of the aliases of the nodes, which
+// resolve to the addresses of the node like its name.
+func aliasRecords(baseDomain string, nodes types.Nodes) []tailcfg.DNSRecord {
+	if baseDomain == "" {
+		return nil
+	}
+
+	var records []tailcfg.DNSRecord
+	for _, node := range nodes {
+		for _, alias := range node.Aliases {
+			for _, ip := range node.IPs() {
+				recordType := "A"
+				if ip.Is6() {
+					recordType = "AAAA"
+				}
+
+				records = append(records, tailcfg.DNSRecord{
+					Name:  alias + "." + baseDomain,
+					Type:  recordType,
+					Value: ip.String(),
+				})
+			}
+		}
+	}
+
+	return records
+}
+
 // If any nextdns DoH resolvers are present in the list of resolvers it will
 // take metadata from the node metadata and instruct tailscale to add it
 // to the requests. This makes it possible to identify from which device the
@@ -182,6 +212,9 @@ func (m *Mapper) fullMapResponse(
 		node,
 		capVer,
 		peers,
+		slices.DeleteFunc(slices.Clone(peers), func(peer *types.Node) bool {
+			return len(peer.Aliases) == 0
+		}),
 		m.cfg,
 	)
 	if err != nil {
@@ -278,6 +311,16 @@ func (m *Mapper) PeerChangedResponse(
 		}
 	}
 
+	// The DNS config replaces the one the node has, so it contains the
+	// aliases of all peers, not only of the changed ones.
+	aliased, err := m.db.ListNodesWithAliases()
+	if err != nil {
+		return nil, err
+	}
+	aliased = slices.DeleteFunc(aliased, func(peer *types.Node) bool {
+		return peer.ID == node.ID
+	})
+
 	err = appendPeerChanges(
 		&resp,
 		false, // partial change
@@ -286,6 +329,7 @@ func (m *Mapper) PeerChangedResponse(
 		node,
 		mapRequest.Version,
 		changedNodes,
+		aliased,
 		m.cfg,
 	)
 	if err != nil {
@@ -542,6 +586,7 @@ func appendPeerChanges(
 	node *types.Node,
 	capVer tailcfg.CapabilityVersion,
 	changed types.Nodes,
+	aliased types.Nodes,
 	cfg *types.Config,
 ) error {
 	filter, matchers := polMan.Filter()
@@ -551,15 +596,28 @@ func appendPeerChanges(
 		return err
 	}
 
+	// Nodes of suspended users are not visible to any peer and do
+	// not see any peers themselves.
+	if node.IsSuspended() {
+		changed = nil
+		aliased = nil
+		filter = nil
+		sshPolicy = nil
+	} else {
+		changed = changed.WithoutSuspended()
+		aliased = aliased.WithoutSuspended()
+	}
+
 	// If there are filter rules present, see if there are any nodes that cannot
 	// access each-other at all and remove them from the peers.
 	if len(filter) > 0 {
 		changed = policy.ReduceNodes(node, changed, matchers)
+		aliased = policy.ReduceNodes(node, aliased, matchers)
 	}
 
 	profiles := generateUserProfiles(node, changed)
 
-	dnsConfig := generateDNSConfig(cfg, node)
+	dnsConfig := generateDNSConfig(cfg, node, aliased)
 
 	tailPeers, err := tailNodes(
 		changed, capVer, polMan,
@@ -75,6 +75,7 @@ func TestDNSConfigMapResponse(t *testing.T) {
 					TailcfgDNSConfig: &dnsConfigOrig,
 				},
 				nodeInShared1,
+				nil,
 			)
 
 			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
@@ -84,6 +85,45 @@ func TestDNSConfigMapResponse(t *testing.T) {
 	}
 }
 
+func TestDNSConfigAliasRecords(t *testing.T) {
+	node := &types.Node{
+		ID:      1,
+		IPv4:    iap("100.64.0.1"),
+		IPv6:    iap("fd7a:115c:a1e0::1"),
+		Aliases: []string{"www"},
+	}
+	peer := &types.Node{
+		ID:      2,
+		IPv4:    iap("100.64.0.2"),
+		Aliases: []string{"db", "postgres"},
+	}
+
+	cfg := &types.Config{
+		BaseDomain: "example.ts.net",
+		TailcfgDNSConfig: &tailcfg.DNSConfig{
+			ExtraRecords: []tailcfg.DNSRecord{
+				{Name: "grafana.example.ts.net", Type: "A", Value: "100.64.0.3"},
+			},
+		},
+	}
+
+	got := generateDNSConfig(cfg, node, types.Nodes{peer})
+
+	want := []tailcfg.DNSRecord{
+		{Name: "grafana.example.ts.net", Type: "A", Value: "100.64.0.3"},
+		{Name: "www.example.ts.net", Type: "A", Value: "100.64.0.1"},
+		{Name: "www.example.ts.net", Type: "AAAA", Value: "fd7a:115c:a1e0::1"},
+		{Name: "db.example.ts.net", Type: "A", Value: "100.64.0.2"},
+		{Name: "postgres.example.ts.net", Type: "A", Value: "100.64.0.2"},
+	}
+	if diff := cmp.Diff(want, got.ExtraRecords); diff != "" {
+		t.Errorf("generateDNSConfig() unexpected extra records (-want +got):\n%s", diff)
+	}
+
+	// The records are not added to the shared configuration.
+	require.Len(t, cfg.TailcfgDNSConfig.ExtraRecords, 1)
+}
+
 func Test_fullMapResponse(t *testing.T) {
 	mustNK := func(str string) key.NodePublic {
 		var k key.NodePublic
@@ -449,3 +489,90 @@ func Test_fullMapResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestFullMapResponseSuspendedUser(t *testing.T) {
+	user1 := types.User{Model: gorm.Model{ID: 1}, Name: "user1"}
+	user2 := types.User{Model: gorm.Model{ID: 2}, Name: "user2"}
+
+	newNode := func(id types.NodeID, user types.User) *types.Node {
+		return &types.Node{
+			ID:         id,
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			DiscoKey:   key.NewDisco().Public(),
+			IPv4:       iap(fmt.Sprintf("100.64.0.%d", id)),
+			Hostname:   fmt.Sprintf("node%d", id),
+			GivenName:  fmt.Sprintf("node%d", id),
+			UserID:     user.ID,
+			User:       user,
+			Hostinfo:   &tailcfg.Hostinfo{},
+		}
+	}
+
+	pol := []byte(`
+		{
+			"acls": [
+				{
+					"action": "accept",
+					"src": ["*"],
+					"dst": ["*:*"],
+				},
+			],
+			"ssh": [
+				{
+					"action": "accept",
+					"src": ["user1@", "user2@"],
+					"dst": ["user1@", "user2@"],
+					"users": ["root"],
+				},
+			],
+		}
+	`)
+	cfg := &types.Config{
+		TailcfgDNSConfig: &tailcfg.DNSConfig{},
+	}
+
+	mapResponse := func(t *testing.T, node *types.Node, peers types.Nodes) *tailcfg.MapResponse {
+		t.Helper()
+
+		polMan, err := policy.NewPolicyManager(pol, []types.User{user1, user2}, append(peers, node))
+		require.NoError(t, err)
+
+		mappy := NewMapper(nil, cfg, &tailcfg.DERPMap{}, nil, polMan, routes.New())
+		resp, err := mappy.fullMapResponse(node, peers, 0)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	peerIDs := func(resp *tailcfg.MapResponse) []tailcfg.NodeID {
+		var ids []tailcfg.NodeID
+		for _, peer := range resp.Peers {
+			ids = append(ids, peer.ID)
+		}
+
+		return ids
+	}
+
+	// Before the suspension all nodes see each other.
+	node1, node2, node3 := newNode(1, user1), newNode(2, user2), newNode(3, user2)
+	resp := mapResponse(t, node2, types.Nodes{node1, node3})
+	require.Equal(t, []tailcfg.NodeID{1, 3}, peerIDs(resp))
+	require.NotEmpty(t, resp.PacketFilters["base"])
+	require.NotNil(t, resp.SSHPolicy)
+
+	user2.Suspended = true
+	node1, node2, node3 = newNode(1, user1), newNode(2, user2), newNode(3, user2)
+
+	// The nodes of a suspended user get an empty netmap.
+	resp = mapResponse(t, node2, types.Nodes{node1, node3})
+	require.False(t, resp.Node.MachineAuthorized)
+	require.Empty(t, resp.Peers)
+	require.Empty(t, resp.PacketFilters["base"])
+	require.Nil(t, resp.SSHPolicy)
+
+	// And they are removed from the peers of other nodes.
+	resp = mapResponse(t, node1, types.Nodes{node2, node3})
+	require.Empty(t, resp.Peers)
+	require.Len(t, resp.UserProfiles, 1)
+}
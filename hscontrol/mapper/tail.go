@@ -111,7 +111,7 @@ func tailNode(
 
 		Tags: tags,
 
-		MachineAuthorized: !node.IsExpired(),
+		MachineAuthorized: !node.IsExpired() && !node.IsSuspended(),
 		Expired:           node.IsExpired(),
 	}
 
@@ -0,0 +1,168 @@
+package hscontrol
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	staleNodeExpire = "expire"
+	staleNodeDelete = "delete"
+)
+
+var nodesCleanedUp = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: prometheusNamespace,
+	Name:      "nodes_cleaned_up_total",
+	Help:      "total count of stale nodes expired or deleted by the node cleanup",
+}, []string{"action"})
+
+// staleNode is a node the cleanup expires or deletes when it is due.
+type staleNode struct {
+	node   *types.Node
+	action string
+	due    time.Time
+}
+
+// planNodeCleanup returns the nodes that are due or will be due within
+// the grace period, sorted by due time. Connected and ephemeral nodes are
+// never stale, nodes that never connected count from their registration.
+func planNodeCleanup(
+	cfg types.NodeCleanupConfig,
+	nodes types.Nodes,
+	tagsOf func(*types.Node) []string,
+	online func(types.NodeID) bool,
+	now time.Time,
+) []staleNode {
+	var stale []staleNode
+	for _, node := range nodes {
+		if node.IsEphemeral() || online(node.ID) {
+			continue
+		}
+
+		retention, ok := cfg.Retention(node, tagsOf(node))
+		if !ok {
+			continue
+		}
+
+		seen := node.CreatedAt
+		if node.LastSeen != nil {
+			seen = *node.LastSeen
+		}
+
+		expireAt := seen.Add(retention.ExpireAfter)
+		deleteAt := seen.Add(retention.DeleteAfter)
+
+		switch {
+		case retention.DeleteAfter > 0 && !now.Before(deleteAt):
+			stale = append(stale, staleNode{node: node, action: staleNodeDelete, due: deleteAt})
+		case retention.ExpireAfter > 0 && !node.IsExpired() && !now.Before(expireAt.Add(-cfg.GracePeriod)):
+			stale = append(stale, staleNode{node: node, action: staleNodeExpire, due: expireAt})
+		case retention.DeleteAfter > 0 && !now.Before(deleteAt.Add(-cfg.GracePeriod)):
+			stale = append(stale, staleNode{node: node, action: staleNodeDelete, due: deleteAt})
+		}
+	}
+
+	slices.SortStableFunc(stale, func(a, b staleNode) int {
+		return a.due.Compare(b.due)
+	})
+
+	return stale
+}
+
+// staleNodes returns the nodes the cleanup expires or deletes now or
+// within the grace period.
+func (h *Headscale) staleNodes(tx *gorm.DB, now time.Time) ([]staleNode, error) {
+	nodes, err := db.ListNodes(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	isLikelyConnected := h.nodeNotifier.LikelyConnectedMap()
+
+	return planNodeCleanup(
+		h.cfg.NodeCleanup,
+		nodes,
+		func(node *types.Node) []string {
+			tags := node.Tags()
+			for _, tag := range node.RequestTags() {
+				if h.polMan.NodeCanHaveTag(node, tag) {
+					tags = append(tags, tag)
+				}
+			}
+
+			return tags
+		},
+		func(id types.NodeID) bool {
+			online, _ := isLikelyConnected.Load(id)
+			return online
+		},
+		now,
+	), nil
+}
+
+// cleanupStaleNodes expires and deletes the nodes that are due, and logs
+// the nodes that will be cleaned up within the grace period.
+func (h *Headscale) cleanupStaleNodes() {
+	now := time.Now()
+
+	var cleanedUp []string
+	err := h.db.Write(func(tx *gorm.DB) error {
+		stale, err := h.staleNodes(tx, now)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range stale {
+			logger := log.With().
+				Uint64("node.id", s.node.ID.Uint64()).
+				Str("node.name", s.node.Hostname).
+				Str("user", s.node.User.Username()).
+				Str("action", s.action).
+				Time("due", s.due).
+				Logger()
+
+			if s.due.After(now) {
+				logger.Warn().Msg("stale node will be cleaned up")
+
+				continue
+			}
+
+			switch s.action {
+			case staleNodeExpire:
+				err = db.NodeSetExpiry(tx, s.node.ID, now)
+			case staleNodeDelete:
+				err = db.DeleteNode(tx, s.node)
+			}
+			if err != nil {
+				return err
+			}
+
+			logger.Info().Msg("cleaned up stale node")
+			cleanedUp = append(cleanedUp, s.action)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("database error while cleaning up stale nodes")
+
+		return
+	}
+
+	for _, action := range cleanedUp {
+		nodesCleanedUp.WithLabelValues(action).Inc()
+	}
+
+	if len(cleanedUp) > 0 {
+		ctx := types.NotifyCtx(context.Background(), "cleanup-stale-nodes", "na")
+		h.nodeNotifier.NotifyAll(ctx, types.UpdateFull())
+	}
+}
@@ -0,0 +1,74 @@
+package hscontrol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/assert"
+	"tailscale.com/types/ptr"
+)
+
+func TestPlanNodeCleanup(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	seen := func(days int) *time.Time {
+		return ptr.To(now.Add(-time.Duration(days) * day))
+	}
+
+	cfg := types.NodeCleanupConfig{
+		GracePeriod:   7 * day,
+		NodeRetention: types.NodeRetention{ExpireAfter: 30 * day, DeleteAfter: 90 * day},
+		Rules: []types.NodeRetentionRule{
+			{Tags: []string{"tag:ci"}, NodeRetention: types.NodeRetention{DeleteAfter: day}},
+			{Users: []string{"lab"}, NodeRetention: types.NodeRetention{ExpireAfter: 2 * day}},
+		},
+		ExcludeTags:  []string{"tag:server"},
+		ExcludeLabel: "keep",
+	}
+
+	alice := types.User{Name: "alice"}
+	lab := types.User{Name: "lab"}
+	nodes := types.Nodes{
+		{ID: 1, Hostname: "recent", User: alice, LastSeen: seen(1)},
+		{ID: 2, Hostname: "expire-soon", User: alice, LastSeen: seen(25)},
+		{ID: 3, Hostname: "expire-now", User: alice, LastSeen: seen(31)},
+		{ID: 4, Hostname: "expired-delete-soon", User: alice, LastSeen: seen(85), Expiry: seen(55)},
+		{ID: 5, Hostname: "delete-now", User: alice, LastSeen: seen(100)},
+		{ID: 6, Hostname: "server", User: alice, LastSeen: seen(100), ForcedTags: []string{"tag:server"}},
+		{ID: 7, Hostname: "keep", User: alice, LastSeen: seen(100), Labels: map[string]string{"keep": ""}},
+		{ID: 8, Hostname: "online", User: alice, LastSeen: seen(100)},
+		{ID: 9, Hostname: "ephemeral", User: alice, LastSeen: seen(100), AuthKey: &types.PreAuthKey{Ephemeral: true}},
+		{ID: 10, Hostname: "ci", User: lab, LastSeen: seen(2), ForcedTags: []string{"tag:ci"}},
+		{ID: 11, Hostname: "lab", User: lab, LastSeen: seen(3)},
+		{ID: 12, Hostname: "never-seen", User: alice, CreatedAt: now.Add(-95 * day)},
+	}
+
+	stale := planNodeCleanup(
+		cfg,
+		nodes,
+		func(node *types.Node) []string { return node.Tags() },
+		func(id types.NodeID) bool { return id == 8 },
+		now,
+	)
+
+	type result struct {
+		Hostname string
+		Action   string
+		Due      time.Time
+	}
+	var got []result
+	for _, s := range stale {
+		got = append(got, result{s.node.Hostname, s.action, s.due})
+	}
+
+	assert.Equal(t, []result{
+		{"delete-now", staleNodeDelete, now.Add(-10 * day)},
+		{"never-seen", staleNodeDelete, now.Add(-5 * day)},
+		{"expire-now", staleNodeExpire, now.Add(-day)},
+		{"ci", staleNodeDelete, now.Add(-day)},
+		{"lab", staleNodeExpire, now.Add(-day)},
+		{"expire-soon", staleNodeExpire, now.Add(5 * day)},
+		{"expired-delete-soon", staleNodeDelete, now.Add(5 * day)},
+	}, got)
+}
@@ -0,0 +1,339 @@
+package hscontrol
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/policy"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/prometheus/common/model"
+	"github.com/puzpuzpuz/xsync/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"tailscale.com/types/ptr"
+)
+
+var (
+	errInvalidNodeSort      = errors.New("invalid sort_by")
+	errInvalidNodePageToken = errors.New("invalid page_token")
+	errInvalidNodeSelector  = errors.New("invalid node selector")
+	errEmptyNodeSelector    = errors.New("node selector is empty, it has to select nodes explicitly")
+)
+
+// nodePageToken is the position of the last node of a page in the sort
+// order. It is returned base64 encoded to clients.
+type nodePageToken struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Key        string `json:"k,omitempty"`
+	ID         uint64 `json:"i"`
+}
+
+func (t nodePageToken) String() string {
+	b, _ := json.Marshal(t)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func parseNodePageToken(s string) (*nodePageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidNodePageToken
+	}
+
+	var token nodePageToken
+	if err := json.Unmarshal(b, &token); err != nil {
+		return nil, errInvalidNodePageToken
+	}
+
+	return &token, nil
+}
+
+// nodeListQuery is a ListNodes request, split into the filters the
+// database applies and the ones applied to the loaded nodes.
+type nodeListQuery struct {
+	filter db.NodeFilter
+
+	labels        types.LabelSelector
+	tags          []string
+	online        *bool
+	expired       *bool
+	os            string
+	clientVersion string
+
+	pageSize int
+}
+
+func newNodeListQuery(request *v1.ListNodesRequest) (*nodeListQuery, error) {
+	labels, err := types.ParseLabelSelector(request.GetLabelSelector())
+	if err != nil {
+		return nil, err
+	}
+
+	q := &nodeListQuery{
+		filter: db.NodeFilter{
+			Name: request.GetName(),
+		},
+		labels:        labels,
+		tags:          request.GetTags(),
+		online:        request.Online,
+		expired:       request.Expired,
+		os:            request.GetOs(),
+		clientVersion: request.GetClientVersion(),
+		pageSize:      int(request.GetPageSize()),
+	}
+	q.filter.SortBy = cmp.Or(request.GetSortBy(), "id")
+	q.filter.Descending = request.GetDescending()
+
+	if request.GetLastSeenAfter() != nil {
+		after := request.GetLastSeenAfter().AsTime()
+		q.filter.LastSeenAfter = &after
+	}
+
+	if request.GetLastSeenBefore() != nil {
+		before := request.GetLastSeenBefore().AsTime()
+		q.filter.LastSeenBefore = &before
+	}
+
+	if !db.IsNodeSortKey(q.filter.SortBy) {
+		return nil, fmt.Errorf("%w: %q", errInvalidNodeSort, q.filter.SortBy)
+	}
+
+	if request.GetPageToken() != "" {
+		token, err := parseNodePageToken(request.GetPageToken())
+		if err != nil {
+			return nil, err
+		}
+
+		if token.SortBy != q.filter.SortBy || token.Descending != q.filter.Descending {
+			return nil, fmt.Errorf("%w: the sorting of the request changed", errInvalidNodePageToken)
+		}
+
+		q.filter.After = &db.NodeCursor{Key: token.Key, ID: types.NodeID(token.ID)}
+	}
+
+	return q, nil
+}
+
+// matchNode reports if the node matches the filters the database has not
+// applied.
+func (q *nodeListQuery) matchNode(
+	node *types.Node,
+	polMan policy.PolicyManager,
+	isLikelyConnected *xsync.MapOf[types.NodeID, bool],
+) bool {
+	if !q.labels.Matches(node.Labels) {
+		return false
+	}
+
+	if q.online != nil {
+		online, _ := isLikelyConnected.Load(node.ID)
+		if online != *q.online {
+			return false
+		}
+	}
+
+	if q.expired != nil && node.IsExpired() != *q.expired {
+		return false
+	}
+
+	if q.os != "" && (node.Hostinfo == nil || !strings.EqualFold(node.Hostinfo.OS, q.os)) {
+		return false
+	}
+
+	if q.clientVersion != "" && (node.Hostinfo == nil || !strings.HasPrefix(node.Hostinfo.IPNVersion, q.clientVersion)) {
+		return false
+	}
+
+	// The node has to have all requested tags, forced or valid.
+	for _, tag := range q.tags {
+		if slices.Contains(node.ForcedTags, tag) {
+			continue
+		}
+
+		if !slices.Contains(node.RequestTags(), tag) || !polMan.NodeCanHaveTag(node, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nextPageToken returns the token of the page after the node.
+func (q *nodeListQuery) nextPageToken(node *types.Node) string {
+	cursor := db.NodeSortCursor(node, q.filter.SortBy)
+
+	return nodePageToken{
+		SortBy:     q.filter.SortBy,
+		Descending: q.filter.Descending,
+		Key:        cursor.Key,
+		ID:         uint64(cursor.ID),
+	}.String()
+}
+
+// parseNodeSelector parses the selector of bulk node operations into the
+// equivalent ListNodes request. The selector consists of comma separated
+// terms, all of which have to match:
+//
+//	user=NAME, tag=TAG, os=OS, version=PREFIX, name=SUBSTRING
+//	online, offline, offline>DURATION, expired, !expired
+//	label.KEY=VALUE, label.KEY!=VALUE, label.KEY, !label.KEY
+func parseNodeSelector(selector string, now time.Time) (*v1.ListNodesRequest, error) {
+	request := &v1.ListNodesRequest{}
+	var labels []string
+
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		if label, ok := strings.CutPrefix(term, "label."); ok {
+			labels = append(labels, label)
+			continue
+		}
+		if label, ok := strings.CutPrefix(term, "!label."); ok {
+			labels = append(labels, "!"+label)
+			continue
+		}
+
+		switch term {
+		case "online":
+			request.Online = ptr.To(true)
+			continue
+		case "offline":
+			request.Online = ptr.To(false)
+			continue
+		case "expired":
+			request.Expired = ptr.To(true)
+			continue
+		case "!expired":
+			request.Expired = ptr.To(false)
+			continue
+		}
+
+		if value, ok := strings.CutPrefix(term, "offline>"); ok {
+			duration, err := model.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q: %w", errInvalidNodeSelector, term, err)
+			}
+
+			request.Online = ptr.To(false)
+			request.LastSeenBefore = timestamppb.New(now.Add(-time.Duration(duration)))
+			continue
+		}
+
+		key, value, ok := strings.Cut(term, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: unknown term %q", errInvalidNodeSelector, term)
+		}
+
+		switch key {
+		case "user":
+			request.User = value
+		case "tag":
+			request.Tags = append(request.Tags, value)
+		case "os":
+			request.Os = value
+		case "version":
+			request.ClientVersion = value
+		case "name":
+			request.Name = value
+		default:
+			return nil, fmt.Errorf("%w: unknown term %q", errInvalidNodeSelector, term)
+		}
+	}
+
+	if proto.Equal(request, &v1.ListNodesRequest{}) && len(labels) == 0 {
+		return nil, errEmptyNodeSelector
+	}
+
+	request.LabelSelector = strings.Join(labels, ",")
+
+	return request, nil
+}
+
+// selectNodes returns the nodes matching the ListNodes request, sorted and
+// paged as requested, and the token of the next page. The database sorts
+// and pages the nodes, the filters it cannot apply are applied to one page
+// of nodes at a time.
+func (api headscaleV1APIServer) selectNodes(
+	tx *gorm.DB,
+	request *v1.ListNodesRequest,
+) ([]*v1.Node, string, error) {
+	query, err := newNodeListQuery(request)
+	if err != nil {
+		return nil, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if request.GetUser() != "" {
+		user, err := db.GetUserByName(tx, request.GetUser())
+		if err != nil {
+			return nil, "", err
+		}
+
+		query.filter.UserID = ptr.To(types.UserID(user.ID))
+	}
+
+	if query.pageSize > 0 {
+		// One more node tells if there is a next page.
+		query.filter.Limit = query.pageSize + 1
+	}
+
+	isLikelyConnected := api.h.nodeNotifier.LikelyConnectedMap()
+	var selected types.Nodes
+	for {
+		nodes, err := db.ListNodesFiltered(tx, query.filter)
+		if errors.Is(err, db.ErrInvalidNodeCursor) {
+			return nil, "", status.Error(codes.InvalidArgument, errInvalidNodePageToken.Error())
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, node := range nodes {
+			if query.matchNode(node, api.h.polMan, isLikelyConnected) {
+				selected = append(selected, node)
+			}
+		}
+
+		if query.pageSize == 0 || len(selected) > query.pageSize || len(nodes) < query.filter.Limit {
+			break
+		}
+
+		cursor := db.NodeSortCursor(nodes[len(nodes)-1], query.filter.SortBy)
+		query.filter.After = &cursor
+	}
+
+	var nextPageToken string
+	if query.pageSize > 0 && len(selected) > query.pageSize {
+		selected = selected[:query.pageSize]
+		nextPageToken = query.nextPageToken(selected[len(selected)-1])
+	}
+
+	return nodesToProto(api.h.polMan, isLikelyConnected, api.h.primaryRoutes, selected), nextPageToken, nil
+}
+
+// selectedNodes returns the nodes selected by a bulk request, sorted by
+// ID.
+func (api headscaleV1APIServer) selectedNodes(tx *gorm.DB, selector string) ([]*v1.Node, error) {
+	request, err := parseNodeSelector(selector, time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	nodes, _, err := api.selectNodes(tx, request)
+
+	return nodes, err
+}
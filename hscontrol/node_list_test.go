@@ -0,0 +1,256 @@
+package hscontrol
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"github.com/juanfont/headscale/hscontrol/policy"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
+	"tailscale.com/types/ptr"
+)
+
+func TestSelectNodesPages(t *testing.T) {
+	h := newTestHeadscale(t, nil)
+	api := newHeadscaleV1APIServer(h)
+
+	alice, err := h.db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+
+	for i, os := range []string{"linux", "windows", "windows", "linux", "windows", "linux"} {
+		node := types.Node{
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			Hostname:   fmt.Sprintf("node-%d", i),
+			GivenName:  fmt.Sprintf("node-%d", 5-i),
+			UserID:     alice.ID,
+			Hostinfo:   &tailcfg.Hostinfo{OS: os},
+		}
+		if i%2 == 0 {
+			node.ForcedTags = []string{"tag:even"}
+		}
+		require.NoError(t, h.db.DB.Save(&node).Error)
+	}
+
+	tests := []struct {
+		name    string
+		request *v1.ListNodesRequest
+		want    [][]uint64
+	}{
+		{
+			name:    "all",
+			request: &v1.ListNodesRequest{},
+			want:    [][]uint64{{1, 2, 3, 4, 5, 6}},
+		},
+		{
+			name:    "pages",
+			request: &v1.ListNodesRequest{PageSize: 4},
+			want:    [][]uint64{{1, 2, 3, 4}, {5, 6}},
+		},
+		{
+			name:    "exact-pages",
+			request: &v1.ListNodesRequest{PageSize: 3},
+			want:    [][]uint64{{1, 2, 3}, {4, 5, 6}},
+		},
+		{
+			name:    "pages-skip-filtered-nodes",
+			request: &v1.ListNodesRequest{Os: "linux", PageSize: 1},
+			want:    [][]uint64{{1}, {4}, {6}},
+		},
+		{
+			name:    "pages-by-name-with-tags",
+			request: &v1.ListNodesRequest{Tags: []string{"tag:even"}, SortBy: "name", PageSize: 2},
+			want:    [][]uint64{{5, 3}, {1}},
+		},
+		{
+			name:    "pages-descending",
+			request: &v1.ListNodesRequest{Os: "windows", Descending: true, PageSize: 2},
+			want:    [][]uint64{{5, 3}, {2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]uint64
+			for {
+				resp, err := api.ListNodes(context.Background(), tt.request)
+				require.NoError(t, err)
+
+				var ids []uint64
+				for _, node := range resp.GetNodes() {
+					ids = append(ids, node.GetId())
+				}
+				got = append(got, ids)
+
+				if resp.GetNextPageToken() == "" {
+					break
+				}
+				tt.request.PageToken = resp.GetNextPageToken()
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNodeListQueryErrors(t *testing.T) {
+	_, err := newNodeListQuery(&v1.ListNodesRequest{SortBy: "ip"})
+	require.ErrorIs(t, err, errInvalidNodeSort)
+
+	_, err = newNodeListQuery(&v1.ListNodesRequest{PageToken: "not a token"})
+	require.ErrorIs(t, err, errInvalidNodePageToken)
+
+	next := nodePageToken{SortBy: "id", ID: 1}.String()
+	_, err = newNodeListQuery(&v1.ListNodesRequest{PageSize: 1, PageToken: next, SortBy: "name"})
+	require.ErrorIs(t, err, errInvalidNodePageToken)
+
+	_, err = newNodeListQuery(&v1.ListNodesRequest{LabelSelector: "a=b=c"})
+	require.ErrorIs(t, err, types.ErrInvalidLabelSelector)
+}
+
+func TestNodeListQueryMatch(t *testing.T) {
+	polMan, err := policy.NewPolicyManager(nil, nil, nil)
+	require.NoError(t, err)
+
+	connected := xsync.NewMapOf[types.NodeID, bool]()
+	connected.Store(1, true)
+
+	linux := &types.Node{
+		ID:         1,
+		Hostinfo:   &tailcfg.Hostinfo{OS: "linux", IPNVersion: "1.80.2-t123"},
+		Labels:     map[string]string{"env": "prod"},
+		ForcedTags: []string{"tag:a"},
+	}
+	windows := &types.Node{
+		ID:       2,
+		Hostinfo: &tailcfg.Hostinfo{OS: "windows", IPNVersion: "1.78.1"},
+		Expiry:   ptr.To(time.Now().Add(-time.Hour)),
+	}
+
+	tests := []struct {
+		name    string
+		request *v1.ListNodesRequest
+		want    []types.NodeID
+	}{
+		{
+			name:    "all",
+			request: &v1.ListNodesRequest{},
+			want:    []types.NodeID{1, 2},
+		},
+		{
+			name:    "online",
+			request: &v1.ListNodesRequest{Online: ptr.To(true)},
+			want:    []types.NodeID{1},
+		},
+		{
+			name:    "offline",
+			request: &v1.ListNodesRequest{Online: ptr.To(false)},
+			want:    []types.NodeID{2},
+		},
+		{
+			name:    "expired",
+			request: &v1.ListNodesRequest{Expired: ptr.To(true)},
+			want:    []types.NodeID{2},
+		},
+		{
+			name:    "os-ignores-case",
+			request: &v1.ListNodesRequest{Os: "Windows"},
+			want:    []types.NodeID{2},
+		},
+		{
+			name:    "client-version-prefix",
+			request: &v1.ListNodesRequest{ClientVersion: "1.80"},
+			want:    []types.NodeID{1},
+		},
+		{
+			name:    "labels",
+			request: &v1.ListNodesRequest{LabelSelector: "!env"},
+			want:    []types.NodeID{2},
+		},
+		{
+			name:    "forced-tags",
+			request: &v1.ListNodesRequest{Tags: []string{"tag:a"}},
+			want:    []types.NodeID{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := newNodeListQuery(tt.request)
+			require.NoError(t, err)
+
+			var got []types.NodeID
+			for _, node := range []*types.Node{linux, windows} {
+				if q.matchNode(node, polMan, connected) {
+					got = append(got, node.ID)
+				}
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseNodeSelector(t *testing.T) {
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		selector string
+		want     *v1.ListNodesRequest
+		wantErr  error
+	}{
+		{
+			selector: "user=alice,os=windows,offline>30d",
+			want: &v1.ListNodesRequest{
+				User:           "alice",
+				Os:             "windows",
+				Online:         ptr.To(false),
+				LastSeenBefore: timestamppb.New(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			selector: "tag=tag:a, tag=tag:b, !expired, version=1.80, name=web",
+			want: &v1.ListNodesRequest{
+				Tags:          []string{"tag:a", "tag:b"},
+				Expired:       ptr.To(false),
+				ClientVersion: "1.80",
+				Name:          "web",
+			},
+		},
+		{
+			selector: "label.env=prod,!label.owner,online",
+			want: &v1.ListNodesRequest{
+				LabelSelector: "env=prod,!owner",
+				Online:        ptr.To(true),
+			},
+		},
+		{selector: "", wantErr: errEmptyNodeSelector},
+		{selector: " , ", wantErr: errEmptyNodeSelector},
+		{selector: "color=red", wantErr: errInvalidNodeSelector},
+		{selector: "user=", wantErr: errInvalidNodeSelector},
+		{selector: "offline>soon", wantErr: errInvalidNodeSelector},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := parseNodeSelector(tt.selector, now)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.want, got), "got %v", got)
+		})
+	}
+}
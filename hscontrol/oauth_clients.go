@@ -0,0 +1,240 @@
+package hscontrol
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/prometheus/common/model"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// oauthTokenPath is the token endpoint of the OAuth 2.0 client
+	// credentials grant, it uses the same path as Tailscale so
+	// existing tooling can be pointed at headscale.
+	oauthTokenPath = "/api/v2/oauth/token"
+
+	oauthAccessTokenExpiry = time.Hour
+
+	// oauthAuthKeyMaxExpiry is the longest expiration of pre auth keys
+	// created by OAuth clients.
+	oauthAuthKeyMaxExpiry = 90 * 24 * time.Hour
+)
+
+// oauthTokenResponse is the successful response of the token endpoint
+// as described in RFC 6749, section 5.1.
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// oauthErrorResponse is the error response of the token endpoint
+// as described in RFC 6749, section 5.2.
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OAuthTokenHandler issues access tokens to OAuth clients using the
+// client credentials grant.
+// Listens in /api/v2/oauth/token.
+func (h *Headscale) OAuthTokenHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	respondError := func(code int, oauthErr string, description string) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set("Cache-Control", "no-store")
+		writer.WriteHeader(code)
+		json.NewEncoder(writer).Encode(oauthErrorResponse{
+			Error:            oauthErr,
+			ErrorDescription: description,
+		})
+	}
+
+	if err := req.ParseForm(); err != nil {
+		respondError(http.StatusBadRequest, "invalid_request", "malformed form body")
+		return
+	}
+
+	if grantType := req.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		respondError(http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	// Clients can authenticate with either HTTP basic authentication
+	// or the request body, see RFC 6749, section 2.3.1.
+	clientID, clientSecret, ok := req.BasicAuth()
+	if !ok {
+		clientID = req.PostForm.Get("client_id")
+		clientSecret = req.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" || clientSecret == "" {
+		respondError(http.StatusUnauthorized, "invalid_client", "client credentials are missing")
+		return
+	}
+
+	scopes := strings.Fields(req.PostForm.Get("scope"))
+
+	tokenStr, token, err := h.db.CreateOAuthAccessToken(clientID, clientSecret, scopes, oauthAccessTokenExpiry)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrOAuthClientInvalid):
+			log.Info().
+				Str("client_address", req.RemoteAddr).
+				Str("client_id", clientID).
+				Msg("invalid OAuth client credentials")
+			respondError(http.StatusUnauthorized, "invalid_client", "")
+		case errors.Is(err, db.ErrOAuthScopeNotGranted):
+			respondError(http.StatusBadRequest, "invalid_scope", err.Error())
+		default:
+			log.Error().
+				Caller().
+				Err(err).
+				Str("client_id", clientID).
+				Msg("failed to issue OAuth access token")
+			respondError(http.StatusInternalServerError, "server_error", "")
+		}
+
+		return
+	}
+
+	log.Debug().
+		Str("client_id", clientID).
+		Str("scope", token.ScopeString()).
+		Msg("issued OAuth access token")
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(writer).Encode(oauthTokenResponse{
+		AccessToken: tokenStr,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(oauthAccessTokenExpiry.Seconds()),
+		Scope:       token.ScopeString(),
+	})
+}
+
+// validateAPIToken reports if the given bearer token is a valid API key
+// or OAuth access token.
+func (h *Headscale) validateAPIToken(token string) (bool, error) {
+	if !strings.HasPrefix(token, types.OAuthAccessTokenPrefix) {
+		return h.db.ValidateAPIKey(token, h.cfg.CLILogin.IsAdmin)
+	}
+
+	_, err := h.db.ValidateOAuthAccessToken(token)
+	if errors.Is(err, db.ErrOAuthAccessTokenInvalid) || errors.Is(err, db.ErrOAuthAccessTokenFailedParse) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// authorizeOAuthAccessToken validates the access token and checks that its
+// scopes allow calling the given gRPC method with the given request.
+func (h *Headscale) authorizeOAuthAccessToken(tokenStr string, fullMethod string, req any) error {
+	token, err := h.db.ValidateOAuthAccessToken(tokenStr)
+	if errors.Is(err, db.ErrOAuthAccessTokenInvalid) || errors.Is(err, db.ErrOAuthAccessTokenFailedParse) {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if err != nil {
+		return status.Error(codes.Internal, "failed to validate token")
+	}
+
+	return authorizeOAuthScopes(token, fullMethod, req)
+}
+
+// authorizeOAuthScopes checks that the scopes of the access token allow
+// calling the given gRPC method with the given request.
+func authorizeOAuthScopes(token *types.OAuthAccessToken, fullMethod string, req any) error {
+	if token.HasScope(types.OAuthScopeAll) {
+		return nil
+	}
+
+	if token.HasScope(types.OAuthScopeAllRead) && isReadOnlyMethod(fullMethod) {
+		return nil
+	}
+
+	if token.HasScope(types.OAuthScopeAuthKeys) && fullMethod == v1.HeadscaleService_CreatePreAuthKey_FullMethodName {
+		request, ok := req.(*v1.CreatePreAuthKeyRequest)
+		if !ok {
+			return status.Error(codes.Internal, "unexpected request type")
+		}
+
+		if len(request.GetAclTags()) == 0 {
+			return status.Error(codes.PermissionDenied, "pre auth keys created by an OAuth client must be tagged")
+		}
+
+		for _, tag := range request.GetAclTags() {
+			if !slices.Contains(token.OAuthClient.Tags, tag) {
+				return status.Errorf(codes.PermissionDenied, "OAuth client is not allowed to use tag %q", tag)
+			}
+		}
+
+		return authorizeOAuthPreAuthKey(&token.OAuthClient, request, time.Now())
+	}
+
+	return status.Errorf(codes.PermissionDenied, "access token is not allowed to call %s", fullMethod)
+}
+
+// authorizeOAuthPreAuthKey limits the pre auth keys an OAuth client can
+// create. Routes and addresses bypass the auto approvers and IP allocation
+// of the policy, so they are left to administrators.
+func authorizeOAuthPreAuthKey(client *types.OAuthClient, request *v1.CreatePreAuthKeyRequest, now time.Time) error {
+	if len(request.GetApprovedRoutes()) > 0 {
+		return status.Error(codes.PermissionDenied, "pre auth keys created by an OAuth client cannot approve routes")
+	}
+
+	if request.GetIpv4() != "" || request.GetIpv6() != "" {
+		return status.Error(codes.PermissionDenied, "pre auth keys created by an OAuth client cannot set IP addresses")
+	}
+
+	if !request.GetEphemeral() && !client.AllowNonEphemeral {
+		return status.Error(codes.PermissionDenied, "pre auth keys created by an OAuth client must be ephemeral")
+	}
+
+	if request.GetExpiration() == nil || request.GetExpiration().AsTime().After(now.Add(oauthAuthKeyMaxExpiry)) {
+		return status.Errorf(
+			codes.PermissionDenied,
+			"pre auth keys created by an OAuth client must expire within %s",
+			model.Duration(oauthAuthKeyMaxExpiry),
+		)
+	}
+
+	return nil
+}
+
+// authorizeAPIKeyScopes checks that the scopes of the API key allow calling
+// the given gRPC method.
+func authorizeAPIKeyScopes(key *types.APIKey, fullMethod string) error {
+	if key.HasScope(types.OAuthScopeAll) {
+		return nil
+	}
+
+	if key.HasScope(types.OAuthScopeAllRead) && isReadOnlyMethod(fullMethod) {
+		return nil
+	}
+
+	return status.Errorf(codes.PermissionDenied, "API key is not allowed to call %s", fullMethod)
+}
+
+// isReadOnlyMethod reports if the gRPC method only reads state.
+func isReadOnlyMethod(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+
+	return strings.HasPrefix(name, "List") || strings.HasPrefix(name, "Get")
+}
@@ -0,0 +1,154 @@
+package hscontrol
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAuthorizeOAuthScopes(t *testing.T) {
+	token := func(scopes ...string) *types.OAuthAccessToken {
+		return &types.OAuthAccessToken{
+			Scopes: scopes,
+			OAuthClient: types.OAuthClient{
+				Tags: []string{"tag:ci"},
+			},
+		}
+	}
+
+	inAnHour := timestamppb.New(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		token  *types.OAuthAccessToken
+		method string
+		req    any
+		want   codes.Code
+	}{
+		{
+			name:   "all-can-delete-node",
+			token:  token(types.OAuthScopeAll),
+			method: v1.HeadscaleService_DeleteNode_FullMethodName,
+			req:    &v1.DeleteNodeRequest{},
+			want:   codes.OK,
+		},
+		{
+			name:   "read-can-list-nodes",
+			token:  token(types.OAuthScopeAllRead),
+			method: v1.HeadscaleService_ListNodes_FullMethodName,
+			req:    &v1.ListNodesRequest{},
+			want:   codes.OK,
+		},
+		{
+			name:   "read-cannot-delete-node",
+			token:  token(types.OAuthScopeAllRead),
+			method: v1.HeadscaleService_DeleteNode_FullMethodName,
+			req:    &v1.DeleteNodeRequest{},
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "auth-keys-can-create-tagged-key",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req:    &v1.CreatePreAuthKeyRequest{AclTags: []string{"tag:ci"}, Ephemeral: true, Expiration: inAnHour},
+			want:   codes.OK,
+		},
+		{
+			name:   "auth-keys-cannot-create-persistent-key",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req:    &v1.CreatePreAuthKeyRequest{AclTags: []string{"tag:ci"}, Expiration: inAnHour},
+			want:   codes.PermissionDenied,
+		},
+		{
+			name: "auth-keys-can-create-persistent-key-if-allowed",
+			token: &types.OAuthAccessToken{
+				Scopes: []string{types.OAuthScopeAuthKeys},
+				OAuthClient: types.OAuthClient{
+					Tags:              []string{"tag:ci"},
+					AllowNonEphemeral: true,
+				},
+			},
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req:    &v1.CreatePreAuthKeyRequest{AclTags: []string{"tag:ci"}, Reusable: true, Expiration: inAnHour},
+			want:   codes.OK,
+		},
+		{
+			name:   "auth-keys-cannot-create-key-without-expiration",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req:    &v1.CreatePreAuthKeyRequest{AclTags: []string{"tag:ci"}, Ephemeral: true},
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "auth-keys-cannot-create-long-lived-key",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req: &v1.CreatePreAuthKeyRequest{
+				AclTags:    []string{"tag:ci"},
+				Ephemeral:  true,
+				Expiration: timestamppb.New(time.Now().Add(365 * 24 * time.Hour)),
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name:   "auth-keys-cannot-approve-routes",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req: &v1.CreatePreAuthKeyRequest{
+				AclTags:        []string{"tag:ci"},
+				Ephemeral:      true,
+				Expiration:     inAnHour,
+				ApprovedRoutes: []string{"0.0.0.0/0"},
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name:   "auth-keys-cannot-set-ips",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req: &v1.CreatePreAuthKeyRequest{
+				AclTags:    []string{"tag:ci"},
+				Ephemeral:  true,
+				Expiration: inAnHour,
+				Ipv4:       "100.64.0.1",
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name:   "auth-keys-cannot-create-untagged-key",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req:    &v1.CreatePreAuthKeyRequest{},
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "auth-keys-cannot-use-other-tag",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_CreatePreAuthKey_FullMethodName,
+			req:    &v1.CreatePreAuthKeyRequest{AclTags: []string{"tag:ci", "tag:prod"}},
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "auth-keys-cannot-list-nodes",
+			token:  token(types.OAuthScopeAuthKeys),
+			method: v1.HeadscaleService_ListNodes_FullMethodName,
+			req:    &v1.ListNodesRequest{},
+			want:   codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizeOAuthScopes(tt.token, tt.method, tt.req)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("authorizeOAuthScopes() = %s, want %s (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
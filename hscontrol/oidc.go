@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
@@ -18,6 +20,7 @@ import (
 	"github.com/juanfont/headscale/hscontrol/db"
 	"github.com/juanfont/headscale/hscontrol/notifier"
 	"github.com/juanfont/headscale/hscontrol/policy"
+	"github.com/juanfont/headscale/hscontrol/templates"
 	"github.com/juanfont/headscale/hscontrol/types"
 	"github.com/juanfont/headscale/hscontrol/util"
 	"github.com/rs/zerolog/log"
@@ -44,53 +47,74 @@ var (
 	errOIDCInvalidNodeState = errors.New(
 		"requested node state key expired before authorisation completed",
 	)
-	errOIDCNodeKeyMissing = errors.New("could not get node key from cache")
+	errOIDCNodeKeyMissing      = errors.New("could not get node key from cache")
+	errOIDCNoProviderAvailable = errors.New("no OIDC provider is available")
+	errOIDCProviderNotFound    = errors.New("OIDC provider not found")
+	errOIDCProviderMismatch    = errors.New("OIDC callback does not belong to the provider the registration was started with")
+	errOIDCUserDeactivated     = errors.New("authenticated principal belongs to a deactivated user")
+	errOIDCUserSuspended       = errors.New("authenticated principal belongs to a suspended user")
 )
 
 // RegistrationInfo contains both machine key and verifier information for OIDC validation.
 type RegistrationInfo struct {
 	RegistrationID types.RegistrationID
 	Verifier       *string
+	// Provider is the name of the OIDC provider the registration
+	// was started with.
+	Provider string
+}
+
+// oidcProvider is a single configured OIDC provider.
+type oidcProvider struct {
+	cfg          *types.OIDCConfig
+	oidcProvider *oidc.Provider
+	oauth2Config *oauth2.Config
 }
 
 type AuthProviderOIDC struct {
 	serverURL         string
-	cfg               *types.OIDCConfig
-	db                *db.HSDatabase
+	providers         []*oidcProvider
 	registrationCache *zcache.Cache[string, RegistrationInfo]
-	notifier          *notifier.Notifier
-	ipAlloc           *db.IPAllocator
-	polMan            policy.PolicyManager
-
-	oidcProvider *oidc.Provider
-	oauth2Config *oauth2.Config
+	// logoutTokenCache holds the IDs of processed logout tokens to
+	// reject replays.
+	logoutTokenCache *zcache.Cache[string, struct{}]
+	// refreshing is set while RefreshNodeExpiries runs, a check is
+	// skipped if the previous one has not finished yet.
+	refreshing atomic.Bool
+
+	authRegistrar
 }
 
+// NewAuthProviderOIDC sets up all given OIDC providers. A provider which
+// is not reachable is skipped unless it is required to be available, if
+// no provider is left errOIDCNoProviderAvailable is returned.
 func NewAuthProviderOIDC(
 	ctx context.Context,
 	serverURL string,
-	cfg *types.OIDCConfig,
+	cfgs []*types.OIDCConfig,
 	db *db.HSDatabase,
 	notif *notifier.Notifier,
 	ipAlloc *db.IPAllocator,
 	polMan policy.PolicyManager,
 ) (*AuthProviderOIDC, error) {
-	var err error
-	// grab oidc config if it hasn't been already
-	oidcProvider, err := oidc.NewProvider(context.Background(), cfg.Issuer)
-	if err != nil {
-		return nil, fmt.Errorf("creating OIDC provider from issuer config: %w", err)
+	providers := make([]*oidcProvider, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		provider, err := newOIDCProvider(serverURL, cfg)
+		if err != nil {
+			if cfg.OnlyStartIfOIDCIsAvailable {
+				return nil, fmt.Errorf("OIDC provider %q: %w", cfg.Name, err)
+			}
+
+			log.Warn().Err(err).Str("provider", cfg.Name).Msg("failed to set up OIDC provider, skipping it")
+
+			continue
+		}
+
+		providers = append(providers, provider)
 	}
 
-	oauth2Config := &oauth2.Config{
-		ClientID:     cfg.ClientID,
-		ClientSecret: cfg.ClientSecret,
-		Endpoint:     oidcProvider.Endpoint(),
-		RedirectURL: fmt.Sprintf(
-			"%s/oidc/callback",
-			strings.TrimSuffix(serverURL, "/"),
-		),
-		Scopes: cfg.Scope,
+	if len(providers) == 0 {
+		return nil, errOIDCNoProviderAvailable
 	}
 
 	registrationCache := zcache.New[string, RegistrationInfo](
@@ -100,18 +124,70 @@ func NewAuthProviderOIDC(
 
 	return &AuthProviderOIDC{
 		serverURL:         serverURL,
-		cfg:               cfg,
-		db:                db,
+		providers:         providers,
 		registrationCache: registrationCache,
-		notifier:          notif,
-		ipAlloc:           ipAlloc,
-		polMan:            polMan,
+		logoutTokenCache: zcache.New[string, struct{}](
+			registerCacheExpiration,
+			registerCacheCleanup,
+		),
+		authRegistrar: authRegistrar{
+			db:       db,
+			notifier: notif,
+			ipAlloc:  ipAlloc,
+			polMan:   polMan,
+		},
+	}, nil
+}
+
+func newOIDCProvider(serverURL string, cfg *types.OIDCConfig) (*oidcProvider, error) {
+	// grab oidc config if it hasn't been already
+	provider, err := oidc.NewProvider(context.Background(), cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("creating OIDC provider from issuer config: %w", err)
+	}
+
+	scopes := slices.Clone(cfg.Scope)
+	if cfg.RefreshTokens.Enabled && !slices.Contains(scopes, oidc.ScopeOfflineAccess) {
+		scopes = append(scopes, oidc.ScopeOfflineAccess)
+	}
+
+	oauth2Config := &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  strings.TrimSuffix(serverURL, "/") + cfg.CallbackPath,
+		Scopes:       scopes,
+	}
 
-		oidcProvider: oidcProvider,
+	return &oidcProvider{
+		cfg:          cfg,
+		oidcProvider: provider,
 		oauth2Config: oauth2Config,
 	}, nil
 }
 
+// providerByName returns the provider with the given name.
+func (a *AuthProviderOIDC) providerByName(name string) (*oidcProvider, bool) {
+	for _, provider := range a.providers {
+		if provider.cfg.Name == name {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
+
+// providerByCallbackPath returns the provider the callback path belongs to.
+func (a *AuthProviderOIDC) providerByCallbackPath(path string) (*oidcProvider, bool) {
+	for _, provider := range a.providers {
+		if provider.cfg.CallbackPath == path {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
+
 func (a *AuthProviderOIDC) AuthURL(registrationID types.RegistrationID) string {
 	return fmt.Sprintf(
 		"%s/register/%s",
@@ -119,16 +195,18 @@ func (a *AuthProviderOIDC) AuthURL(registrationID types.RegistrationID) string {
 		registrationID.String())
 }
 
-func (a *AuthProviderOIDC) determineNodeExpiry(idTokenExpiration time.Time) time.Time {
-	if a.cfg.UseExpiryFromToken {
+func (p *oidcProvider) determineNodeExpiry(idTokenExpiration time.Time) time.Time {
+	if p.cfg.UseExpiryFromToken {
 		return idTokenExpiration
 	}
 
-	return time.Now().Add(a.cfg.Expiry)
+	return time.Now().Add(p.cfg.Expiry)
 }
 
 // RegisterOIDC redirects to the OIDC provider for authentication
 // Puts NodeKey in cache so the callback can retrieve it using the oidc state param
+// If multiple providers are configured and none is chosen with the provider
+// query parameter, a page to choose one is shown.
 // Listens in /register/:registration_id.
 func (a *AuthProviderOIDC) RegisterHandler(
 	writer http.ResponseWriter,
@@ -146,6 +224,34 @@ func (a *AuthProviderOIDC) RegisterHandler(
 		return
 	}
 
+	var provider *oidcProvider
+	if name := req.URL.Query().Get("provider"); name != "" {
+		var ok bool
+		provider, ok = a.providerByName(name)
+		if !ok {
+			httpError(writer, NewHTTPError(http.StatusNotFound, "unknown provider", errOIDCProviderNotFound))
+			return
+		}
+	} else if len(a.providers) == 1 {
+		provider = a.providers[0]
+	} else {
+		links := make([]templates.OIDCProviderLink, 0, len(a.providers))
+		for _, p := range a.providers {
+			links = append(links, templates.OIDCProviderLink{
+				DisplayName: cmp.Or(p.cfg.DisplayName, p.cfg.Name),
+				URL:         fmt.Sprintf("/register/%s?provider=%s", registrationId, url.QueryEscape(p.cfg.Name)),
+			})
+		}
+
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.WriteHeader(http.StatusOK)
+		if _, err := writer.Write([]byte(templates.OIDCProviderChooser(links).Render())); err != nil {
+			util.LogErr(err, "Failed to write response")
+		}
+
+		return
+	}
+
 	// Set the state and nonce cookies to protect against CSRF attacks
 	state, err := setCSRFCookie(writer, req, "state")
 	if err != nil {
@@ -163,17 +269,18 @@ func (a *AuthProviderOIDC) RegisterHandler(
 	// Initialize registration info with machine key
 	registrationInfo := RegistrationInfo{
 		RegistrationID: registrationId,
+		Provider:       provider.cfg.Name,
 	}
 
-	extras := make([]oauth2.AuthCodeOption, 0, len(a.cfg.ExtraParams)+defaultOAuthOptionsCount)
+	extras := make([]oauth2.AuthCodeOption, 0, len(provider.cfg.ExtraParams)+defaultOAuthOptionsCount)
 	// Add PKCE verification if enabled
-	if a.cfg.PKCE.Enabled {
+	if provider.cfg.PKCE.Enabled {
 		verifier := oauth2.GenerateVerifier()
 		registrationInfo.Verifier = &verifier
 
 		extras = append(extras, oauth2.AccessTypeOffline)
 
-		switch a.cfg.PKCE.Method {
+		switch provider.cfg.PKCE.Method {
 		case types.PKCEMethodS256:
 			extras = append(extras, oauth2.S256ChallengeOption(verifier))
 		case types.PKCEMethodPlain:
@@ -182,8 +289,13 @@ func (a *AuthProviderOIDC) RegisterHandler(
 		}
 	}
 
+	// Ask for a refresh token to extend the node expiry with
+	if provider.cfg.RefreshTokens.Enabled {
+		extras = append(extras, oauth2.AccessTypeOffline)
+	}
+
 	// Add any extra parameters from configuration
-	for k, v := range a.cfg.ExtraParams {
+	for k, v := range provider.cfg.ExtraParams {
 		extras = append(extras, oauth2.SetAuthURLParam(k, v))
 	}
 	extras = append(extras, oidc.Nonce(nonce))
@@ -191,7 +303,7 @@ func (a *AuthProviderOIDC) RegisterHandler(
 	// Cache the registration info
 	a.registrationCache.Set(state, registrationInfo)
 
-	authURL := a.oauth2Config.AuthCodeURL(state, extras...)
+	authURL := provider.oauth2Config.AuthCodeURL(state, extras...)
 	log.Debug().Msgf("Redirecting to %s for authentication", authURL)
 
 	http.Redirect(writer, req, authURL, http.StatusFound)
@@ -213,11 +325,17 @@ var oidcCallbackTemplate = template.Must(
 // Retrieves the nkey from the state cache and adds the node to the users email user
 // TODO: A confirmation page for new nodes should be added to avoid phishing vulnerabilities
 // TODO: Add groups information from OIDC tokens into node HostInfo
-// Listens in /oidc/callback.
+// Listens in /oidc/callback and /oidc/callback/:provider.
 func (a *AuthProviderOIDC) OIDCCallbackHandler(
 	writer http.ResponseWriter,
 	req *http.Request,
 ) {
+	provider, ok := a.providerByCallbackPath(req.URL.Path)
+	if !ok {
+		httpError(writer, NewHTTPError(http.StatusNotFound, "unknown provider", errOIDCProviderNotFound))
+		return
+	}
+
 	code, state, err := extractCodeAndStateParamFromRequest(req)
 	if err != nil {
 		httpError(writer, err)
@@ -235,14 +353,19 @@ func (a *AuthProviderOIDC) OIDCCallbackHandler(
 		return
 	}
 
-	oauth2Token, err := a.getOauth2Token(req.Context(), code, state)
+	if regInfo, ok := a.registrationCache.Get(state); ok && regInfo.Provider != provider.cfg.Name {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "provider did not match", errOIDCProviderMismatch))
+		return
+	}
+
+	oauth2Token, err := a.getOauth2Token(req.Context(), provider, code, state)
 
 	if err != nil {
 		httpError(writer, err)
 		return
 	}
 
-	idToken, err := a.extractIDToken(req.Context(), oauth2Token)
+	idToken, err := extractIDToken(req.Context(), provider, oauth2Token)
 	if err != nil {
 		httpError(writer, err)
 		return
@@ -258,7 +381,7 @@ func (a *AuthProviderOIDC) OIDCCallbackHandler(
 		return
 	}
 
-	nodeExpiry := a.determineNodeExpiry(idToken.Expiry)
+	nodeExpiry := provider.determineNodeExpiry(idToken.Expiry)
 
 	var claims types.OIDCClaims
 	if err := idToken.Claims(&claims); err != nil {
@@ -266,23 +389,23 @@ func (a *AuthProviderOIDC) OIDCCallbackHandler(
 		return
 	}
 
-	if err := validateOIDCAllowedDomains(a.cfg.AllowedDomains, &claims); err != nil {
+	if err := validateOIDCAllowedDomains(provider.cfg.AllowedDomains, &claims); err != nil {
 		httpError(writer, err)
 		return
 	}
 
-	if err := validateOIDCAllowedGroups(a.cfg.AllowedGroups, &claims); err != nil {
+	if err := validateOIDCAllowedGroups(provider.cfg.AllowedGroups, &claims); err != nil {
 		httpError(writer, err)
 		return
 	}
 
-	if err := validateOIDCAllowedUsers(a.cfg.AllowedUsers, &claims); err != nil {
+	if err := validateOIDCAllowedUsers(provider.cfg.AllowedUsers, &claims); err != nil {
 		httpError(writer, err)
 		return
 	}
 
 	var userinfo *oidc.UserInfo
-	userinfo, err = a.oidcProvider.UserInfo(req.Context(), oauth2.StaticTokenSource(oauth2Token))
+	userinfo, err = provider.oidcProvider.UserInfo(req.Context(), oauth2.StaticTokenSource(oauth2Token))
 	if err != nil {
 		util.LogErr(err, "could not get userinfo; only checking claim")
 	}
@@ -305,7 +428,14 @@ func (a *AuthProviderOIDC) OIDCCallbackHandler(
 		}
 	}
 
-	user, err := a.createOrUpdateUserFromClaim(&claims)
+	rawClaims, err := rawOIDCClaims(idToken, userinfo)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+	mapping := mapOIDCClaims(provider.cfg.Claims, rawClaims)
+
+	user, err := a.createOrUpdateUserFromClaim(provider.cfg.Groups, &claims, mapping)
 	if err != nil {
 		httpError(writer, err)
 		return
@@ -320,12 +450,22 @@ func (a *AuthProviderOIDC) OIDCCallbackHandler(
 	// Register the node if it does not exist.
 	if registrationId != nil {
 		verb := "Reauthenticated"
-		newNode, err := a.handleRegistration(user, *registrationId, nodeExpiry)
+		node, newNode, err := a.handleRegistration(
+			user,
+			*registrationId,
+			&nodeExpiry,
+			util.RegisterMethodOIDC,
+			mapping.Tags,
+		)
 		if err != nil {
 			httpError(writer, err)
 			return
 		}
 
+		if err := a.storeOIDCSession(provider, node, idToken, oauth2Token); err != nil {
+			util.LogErr(err, "could not store OIDC session")
+		}
+
 		if newNode {
 			verb = "Authenticated"
 		}
@@ -368,12 +508,13 @@ func extractCodeAndStateParamFromRequest(
 // getOauth2Token exchanges the code from the callback for an oauth2 token.
 func (a *AuthProviderOIDC) getOauth2Token(
 	ctx context.Context,
+	provider *oidcProvider,
 	code string,
 	state string,
 ) (*oauth2.Token, error) {
 	var exchangeOpts []oauth2.AuthCodeOption
 
-	if a.cfg.PKCE.Enabled {
+	if provider.cfg.PKCE.Enabled {
 		regInfo, ok := a.registrationCache.Get(state)
 		if !ok {
 			return nil, NewHTTPError(http.StatusNotFound, "registration not found", errNoOIDCRegistrationInfo)
@@ -383,7 +524,7 @@ func (a *AuthProviderOIDC) getOauth2Token(
 		}
 	}
 
-	oauth2Token, err := a.oauth2Config.Exchange(ctx, code, exchangeOpts...)
+	oauth2Token, err := provider.oauth2Config.Exchange(ctx, code, exchangeOpts...)
 	if err != nil {
 		return nil, NewHTTPError(http.StatusForbidden, "invalid code", fmt.Errorf("could not exchange code for token: %w", err))
 	}
@@ -391,8 +532,9 @@ func (a *AuthProviderOIDC) getOauth2Token(
 }
 
 // extractIDToken extracts the ID token from the oauth2 token.
-func (a *AuthProviderOIDC) extractIDToken(
+func extractIDToken(
 	ctx context.Context,
+	provider *oidcProvider,
 	oauth2Token *oauth2.Token,
 ) (*oidc.IDToken, error) {
 	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
@@ -400,7 +542,7 @@ func (a *AuthProviderOIDC) extractIDToken(
 		return nil, NewHTTPError(http.StatusBadRequest, "no id_token", errNoOIDCIDToken)
 	}
 
-	verifier := a.oidcProvider.Verifier(&oidc.Config{ClientID: a.cfg.ClientID})
+	verifier := provider.oidcProvider.Verifier(&oidc.Config{ClientID: provider.cfg.ClientID})
 	idToken, err := verifier.Verify(ctx, rawIDToken)
 	if err != nil {
 		return nil, NewHTTPError(http.StatusForbidden, "failed to verify id_token", fmt.Errorf("failed to verify ID token: %w", err))
@@ -460,6 +602,29 @@ func validateOIDCAllowedUsers(
 	return nil
 }
 
+// policyGroupsFromClaims returns the groups claim as policy groups, named
+// by the configured prefix. Groups are only kept if syncing them is enabled,
+// so disabling it clears the groups on the next login.
+func policyGroupsFromClaims(
+	cfg types.OIDCGroupsConfig,
+	claims *types.OIDCClaims,
+) []string {
+	if !cfg.Sync {
+		return nil
+	}
+
+	groups := make([]string, 0, len(claims.Groups))
+	for _, group := range claims.Groups {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, cfg.Prefix+group)
+		}
+	}
+
+	slices.Sort(groups)
+
+	return slices.Compact(groups)
+}
+
 // getRegistrationIDFromState retrieves the registration ID from the state.
 func (a *AuthProviderOIDC) getRegistrationIDFromState(state string) *types.RegistrationID {
 	regInfo, ok := a.registrationCache.Get(state)
@@ -471,7 +636,9 @@ func (a *AuthProviderOIDC) getRegistrationIDFromState(state string) *types.Regis
 }
 
 func (a *AuthProviderOIDC) createOrUpdateUserFromClaim(
+	groupsCfg types.OIDCGroupsConfig,
 	claims *types.OIDCClaims,
+	mapping oidcClaimMapping,
 ) (*types.User, error) {
 	var user *types.User
 	var err error
@@ -480,12 +647,35 @@ func (a *AuthProviderOIDC) createOrUpdateUserFromClaim(
 		return nil, fmt.Errorf("creating or updating user: %w", err)
 	}
 
+	// Users provisioned via SCIM are linked to their OIDC identity
+	// on their first login.
+	if user == nil && claims.EmailVerified && claims.Email != "" {
+		user, err = a.db.GetUnlinkedSCIMUserByEmail(claims.Email)
+		if err != nil && !errors.Is(err, db.ErrUserNotFound) {
+			return nil, fmt.Errorf("creating or updating user: %w", err)
+		}
+	}
+
+	if user != nil && user.Deactivated {
+		return nil, NewHTTPError(http.StatusForbidden, "user is deactivated", errOIDCUserDeactivated)
+	}
+
+	if user != nil && user.Suspended {
+		return nil, NewHTTPError(http.StatusForbidden, "user is suspended", errOIDCUserSuspended)
+	}
+
 	// if the user is still not found, create a new empty user.
 	if user == nil {
 		user = &types.User{}
 	}
 
 	user.FromClaim(claims)
+	mapping.apply(user)
+
+	groups := append(policyGroupsFromClaims(groupsCfg, claims), mapping.Groups...)
+	slices.Sort(groups)
+	user.Groups = slices.Compact(groups)
+
 	err = a.db.DB.Save(user).Error
 	if err != nil {
 		return nil, fmt.Errorf("creating or updating user: %w", err)
@@ -499,66 +689,6 @@ func (a *AuthProviderOIDC) createOrUpdateUserFromClaim(
 	return user, nil
 }
 
-func (a *AuthProviderOIDC) handleRegistration(
-	user *types.User,
-	registrationID types.RegistrationID,
-	expiry time.Time,
-) (bool, error) {
-	ipv4, ipv6, err := a.ipAlloc.Next()
-	if err != nil {
-		return false, err
-	}
-
-	node, newNode, err := a.db.HandleNodeFromAuthPath(
-		registrationID,
-		types.UserID(user.ID),
-		&expiry,
-		util.RegisterMethodOIDC,
-		ipv4, ipv6,
-	)
-	if err != nil {
-		return false, fmt.Errorf("could not register node: %w", err)
-	}
-
-	// Send an update to all nodes if this is a new node that they need to know
-	// about.
-	// If this is a refresh, just send new expiry updates.
-	updateSent, err := nodesChangedHook(a.db, a.polMan, a.notifier)
-	if err != nil {
-		return false, fmt.Errorf("updating resources using node: %w", err)
-	}
-
-	// This is a bit of a back and forth, but we have a bit of a chicken and egg
-	// dependency here.
-	// Because the way the policy manager works, we need to have the node
-	// in the database, then add it to the policy manager and then we can
-	// approve the route. This means we get this dance where the node is
-	// first added to the database, then we add it to the policy manager via
-	// nodesChangedHook and then we can auto approve the routes.
-	// As that only approves the struct object, we need to save it again and
-	// ensure we send an update.
-	// This works, but might be another good candidate for doing some sort of
-	// eventbus.
-	routesChanged := policy.AutoApproveRoutes(a.polMan, node)
-	if err := a.db.DB.Save(node).Error; err != nil {
-		return false, fmt.Errorf("saving auto approved routes to node: %w", err)
-	}
-
-	if !updateSent || routesChanged {
-		ctx := types.NotifyCtx(context.Background(), "oidc-expiry-self", node.Hostname)
-		a.notifier.NotifyByNodeID(
-			ctx,
-			types.UpdateSelf(node.ID),
-			node.ID,
-		)
-
-		ctx = types.NotifyCtx(context.Background(), "oidc-expiry-peers", node.Hostname)
-		a.notifier.NotifyWithIgnore(ctx, types.UpdatePeerChanged(node.ID), node.ID)
-	}
-
-	return newNode, nil
-}
-
 // TODO(kradalby):
 // Rewrite in elem-go.
 func renderOIDCCallbackTemplate(
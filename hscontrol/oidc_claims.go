@@ -0,0 +1,142 @@
+package hscontrol
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+)
+
+// oidcClaimMapping is the result of applying the claim mapping of a
+// provider to the claims of a user.
+type oidcClaimMapping struct {
+	Username    string
+	DisplayName string
+
+	// Tags are the forced tags of the node logging in, nil if no rule
+	// of the provider sets tags, so the tags of the node are kept.
+	Tags   []string
+	Groups []string
+}
+
+// rawOIDCClaims returns all claims of the ID token, extended with the
+// claims of the userinfo if it belongs to the same subject.
+func rawOIDCClaims(idToken *oidc.IDToken, userinfo *oidc.UserInfo) (map[string]any, error) {
+	claims := make(map[string]any)
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("decoding ID token claims: %w", err)
+	}
+
+	if userinfo == nil || userinfo.Subject != idToken.Subject {
+		return claims, nil
+	}
+
+	var userinfoClaims map[string]any
+	if err := userinfo.Claims(&userinfoClaims); err != nil {
+		return nil, fmt.Errorf("decoding userinfo claims: %w", err)
+	}
+
+	for name, value := range userinfoClaims {
+		if _, ok := claims[name]; !ok {
+			claims[name] = value
+		}
+	}
+
+	return claims, nil
+}
+
+// mapOIDCClaims applies the claim mapping to the claims of a user. Templates
+// which fail to execute, e.g. because a claim is missing, are logged and
+// result in an empty value, so the default claims are used.
+func mapOIDCClaims(cfg types.OIDCClaimsConfig, claims map[string]any) oidcClaimMapping {
+	mapping := oidcClaimMapping{
+		Username:    executeClaimTemplate(cfg.Username, claims),
+		DisplayName: executeClaimTemplate(cfg.DisplayName, claims),
+	}
+
+	for _, rule := range cfg.Rules {
+		if len(rule.Tags) > 0 && mapping.Tags == nil {
+			mapping.Tags = []string{}
+		}
+
+		if !slices.ContainsFunc(claimValues(claims, rule.Claim), rule.Match.MatchString) {
+			continue
+		}
+
+		mapping.Tags = append(mapping.Tags, rule.Tags...)
+		mapping.Groups = append(mapping.Groups, rule.Groups...)
+	}
+
+	slices.Sort(mapping.Tags)
+	mapping.Tags = slices.Compact(mapping.Tags)
+
+	return mapping
+}
+
+// apply sets the mapped username and display name on the user. A mapped
+// username which is not a valid username is ignored.
+func (m oidcClaimMapping) apply(user *types.User) {
+	if m.Username != "" {
+		if err := util.ValidateUsername(m.Username); err != nil {
+			log.Warn().
+				Err(err).
+				Str("username", m.Username).
+				Msg("mapped OIDC username is invalid, using the preferred_username claim")
+		} else {
+			user.Name = m.Username
+		}
+	}
+
+	if m.DisplayName != "" {
+		user.DisplayName = m.DisplayName
+	}
+}
+
+func executeClaimTemplate(tmpl *template.Template, claims map[string]any) string {
+	if tmpl == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, claims); err != nil {
+		log.Warn().Err(err).Str("template", tmpl.Name()).Msg("failed to map OIDC claims")
+		return ""
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// claimValues returns the values of a claim as strings, nested claims are
+// separated by dots. Lists return all of their values.
+func claimValues(claims map[string]any, name string) []string {
+	var value any = claims
+	for _, part := range strings.Split(name, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		if value, ok = obj[part]; !ok {
+			return nil
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, elem := range v {
+			values = append(values, fmt.Sprint(elem))
+		}
+
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,220 @@
+package hscontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// oidcBackChannelLogoutEvent is the event a logout token must contain.
+const oidcBackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+var (
+	errOIDCLogoutTokenMissing  = errors.New("missing logout_token parameter")
+	errOIDCLogoutTokenInvalid  = errors.New("invalid logout token")
+	errOIDCLogoutTokenReplayed = errors.New("logout token has already been used")
+)
+
+// oidcLogoutClaims are the claims of a logout token next to the
+// registered claims, see
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+type oidcLogoutClaims struct {
+	SessionID string                     `json:"sid"`
+	Events    map[string]json.RawMessage `json:"events"`
+	Nonce     json.RawMessage            `json:"nonce"`
+	JTI       string                     `json:"jti"`
+}
+
+// providerByLogoutPath returns the provider the logout path belongs to.
+func (a *AuthProviderOIDC) providerByLogoutPath(path string) (*oidcProvider, bool) {
+	for _, provider := range a.providers {
+		if provider.cfg.LogoutPath == path {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
+
+// verifyLogoutToken validates the signature, issuer, audience and expiry of
+// a logout token against the provider and checks the logout specific claims.
+func (a *AuthProviderOIDC) verifyLogoutToken(
+	ctx context.Context,
+	provider *oidcProvider,
+	rawToken string,
+) (*oidc.IDToken, *oidcLogoutClaims, error) {
+	verifier := provider.oidcProvider.Verifier(&oidc.Config{ClientID: provider.cfg.ClientID})
+	token, err := verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errOIDCLogoutTokenInvalid, err)
+	}
+
+	var claims oidcLogoutClaims
+	if err := token.Claims(&claims); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errOIDCLogoutTokenInvalid, err)
+	}
+
+	if _, ok := claims.Events[oidcBackChannelLogoutEvent]; !ok {
+		return nil, nil, fmt.Errorf("%w: missing back-channel logout event", errOIDCLogoutTokenInvalid)
+	}
+
+	if token.Subject == "" && claims.SessionID == "" {
+		return nil, nil, fmt.Errorf("%w: neither sub nor sid is set", errOIDCLogoutTokenInvalid)
+	}
+
+	// A nonce is forbidden to prevent ID tokens from being used as
+	// logout tokens.
+	if claims.Nonce != nil {
+		return nil, nil, fmt.Errorf("%w: nonce must not be set", errOIDCLogoutTokenInvalid)
+	}
+
+	if _, ok := a.logoutTokenCache.Get(claims.JTI); ok && claims.JTI != "" {
+		return nil, nil, errOIDCLogoutTokenReplayed
+	}
+
+	return token, &claims, nil
+}
+
+// logoutUserIDs returns the users the logout token applies to. The subject
+// is resolved to the user with the OIDC identity, the sid to the users of
+// the nodes which logged in with that session.
+func (a *AuthProviderOIDC) logoutUserIDs(
+	provider *oidcProvider,
+	token *oidc.IDToken,
+	claims *oidcLogoutClaims,
+) ([]types.UserID, error) {
+	if token.Subject != "" {
+		identity := types.OIDCClaims{Iss: token.Issuer, Sub: token.Subject}
+		user, err := a.db.GetUserByOIDCIdentifier(identity.Identifier())
+		if errors.Is(err, db.ErrUserNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return []types.UserID{types.UserID(user.ID)}, nil
+	}
+
+	sessions, err := a.db.ListOIDCSessionsBySessionID(provider.cfg.Name, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []types.UserID
+	for _, session := range sessions {
+		uid := types.UserID(session.Node.UserID)
+		if !slices.Contains(uids, uid) {
+			uids = append(uids, uid)
+		}
+	}
+
+	return uids, nil
+}
+
+// OIDCBackChannelLogoutHandler implements OpenID Connect Back-Channel
+// Logout. The identity provider posts a signed logout token when a session
+// is terminated, all nodes of the user are expired or, depending on the
+// configuration, the user is suspended.
+// Listens in /oidc/logout and /oidc/logout/:provider.
+func (h *Headscale) OIDCBackChannelLogoutHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	a, ok := h.authProvider.(*AuthProviderOIDC)
+	if !ok {
+		httpError(writer, NewHTTPError(http.StatusNotFound, "not found", nil))
+		return
+	}
+
+	provider, ok := a.providerByLogoutPath(req.URL.Path)
+	if !ok || !provider.cfg.BackChannelLogout.Enabled {
+		httpError(writer, NewHTTPError(http.StatusNotFound, "unknown provider", errOIDCProviderNotFound))
+		return
+	}
+
+	writer.Header().Set("Cache-Control", "no-store")
+
+	rawToken := req.PostFormValue("logout_token")
+	if rawToken == "" {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "missing logout token", errOIDCLogoutTokenMissing))
+		return
+	}
+
+	token, claims, err := a.verifyLogoutToken(req.Context(), provider, rawToken)
+	if err != nil {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "invalid logout token", err))
+		return
+	}
+
+	uids, err := a.logoutUserIDs(provider, token, claims)
+	if err != nil {
+		httpError(writer, fmt.Errorf("resolving users of logout token: %w", err))
+		return
+	}
+
+	for _, uid := range uids {
+		if err := h.logoutUser(uid, provider.cfg.BackChannelLogout.Action); err != nil {
+			httpError(writer, err)
+			return
+		}
+	}
+
+	// Only remember processed tokens, so the identity provider can
+	// retry a logout which failed.
+	if claims.JTI != "" {
+		a.logoutTokenCache.Set(claims.JTI, struct{}{})
+	}
+
+	log.Info().
+		Str("provider", provider.cfg.Name).
+		Str("sub", token.Subject).
+		Str("sid", claims.SessionID).
+		Int("users", len(uids)).
+		Msg("processed OIDC back-channel logout")
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+// logoutUser cuts off the nodes of a user logged out by the identity
+// provider.
+func (h *Headscale) logoutUser(uid types.UserID, action types.OIDCLogoutAction) error {
+	if action == types.OIDCLogoutActionSuspend {
+		user, err := db.Write(h.db.DB, func(tx *gorm.DB) (*types.User, error) {
+			return db.SetUserSuspended(tx, uid, true)
+		})
+		if err != nil {
+			return fmt.Errorf("suspending user: %w", err)
+		}
+
+		return h.userSuspensionChanged(context.Background(), user)
+	}
+
+	now := time.Now()
+	nodes, err := db.Write(h.db.DB, func(tx *gorm.DB) (types.Nodes, error) {
+		return db.ExpireUserNodes(tx, uid, now)
+	})
+	if err != nil {
+		return fmt.Errorf("expiring nodes of user: %w", err)
+	}
+
+	for _, node := range nodes {
+		ctx := types.NotifyCtx(context.Background(), "oidc-logout-self", node.Hostname)
+		h.nodeNotifier.NotifyByNodeID(ctx, types.UpdateSelf(node.ID), node.ID)
+
+		ctx = types.NotifyCtx(ctx, "oidc-logout-peers", node.Hostname)
+		h.nodeNotifier.NotifyWithIgnore(ctx, types.UpdateExpire(node.ID, now), node.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package hscontrol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/oauth2-proxy/mockoidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tailscale.com/types/key"
+)
+
+func TestOIDCBackChannelLogout(t *testing.T) {
+	mock, err := mockoidc.Run()
+	require.NoError(t, err)
+	defer mock.Shutdown()
+
+	h := newTestHeadscale(t, nil)
+
+	cfg := &types.OIDCConfig{
+		Name:         "default",
+		CallbackPath: "/oidc/callback",
+		LogoutPath:   "/oidc/logout",
+		Issuer:       mock.Issuer(),
+		ClientID:     mock.ClientID,
+		ClientSecret: mock.ClientSecret,
+		Expiry:       time.Hour,
+		BackChannelLogout: types.OIDCBackChannelLogoutConfig{
+			Enabled: true,
+			Action:  types.OIDCLogoutActionExpire,
+		},
+	}
+
+	a, err := NewAuthProviderOIDC(
+		context.Background(),
+		h.cfg.ServerURL,
+		[]*types.OIDCConfig{cfg},
+		h.db,
+		h.nodeNotifier,
+		h.ipAlloc,
+		h.polMan,
+	)
+	require.NoError(t, err)
+	h.authProvider = a
+
+	router := mux.NewRouter()
+	router.HandleFunc("/oidc/logout", h.OIDCBackChannelLogoutHandler).Methods(http.MethodPost)
+
+	createUser := func(sub string) (*types.User, *types.Node) {
+		user := types.User{}
+		user.FromClaim(&types.OIDCClaims{Iss: mock.Issuer(), Sub: sub, Username: sub})
+		require.NoError(t, h.db.DB.Save(&user).Error)
+
+		expiry := time.Now().Add(time.Hour)
+		node := types.Node{
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			Hostname:   sub + "-laptop",
+			UserID:     user.ID,
+			Expiry:     &expiry,
+		}
+		require.NoError(t, h.db.DB.Save(&node).Error)
+
+		return &user, &node
+	}
+
+	logout := func(claims jwt.MapClaims) int {
+		now := time.Now()
+		token := jwt.MapClaims{
+			"iss":    mock.Issuer(),
+			"aud":    mock.ClientID,
+			"iat":    now.Unix(),
+			"exp":    now.Add(time.Minute).Unix(),
+			"events": map[string]any{oidcBackChannelLogoutEvent: map[string]any{}},
+		}
+		for k, v := range claims {
+			token[k] = v
+		}
+
+		signed, err := mock.Keypair.SignJWT(token)
+		require.NoError(t, err)
+
+		form := url.Values{"logout_token": {signed}}
+		req := httptest.NewRequest(http.MethodPost, "/oidc/logout", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	isExpired := func(node *types.Node) bool {
+		dbNode, err := h.db.GetNodeByID(node.ID)
+		require.NoError(t, err)
+
+		return dbNode.IsExpired()
+	}
+
+	_, aliceNode := createUser("alice")
+	_, bobNode := createUser("bob")
+
+	// Tokens which are not logout tokens are rejected.
+	assert.Equal(t, http.StatusBadRequest, logout(jwt.MapClaims{"sub": "alice", "events": nil}))
+	assert.Equal(t, http.StatusBadRequest, logout(jwt.MapClaims{"sub": "alice", "nonce": "abc"}))
+	assert.Equal(t, http.StatusBadRequest, logout(jwt.MapClaims{"sub": "alice", "aud": "other-client"}))
+	assert.False(t, isExpired(aliceNode))
+
+	assert.Equal(t, http.StatusOK, logout(jwt.MapClaims{"sub": "alice", "jti": "logout-1"}))
+	assert.True(t, isExpired(aliceNode))
+	assert.False(t, isExpired(bobNode))
+
+	assert.Equal(t, http.StatusBadRequest, logout(jwt.MapClaims{"sub": "alice", "jti": "logout-1"}))
+
+	// Unknown subjects are accepted, there is nothing to log out.
+	assert.Equal(t, http.StatusOK, logout(jwt.MapClaims{"sub": "mallory"}))
+
+	// The sid is resolved via the session the node logged in with,
+	// with the suspend action the user is suspended.
+	cfg.BackChannelLogout.Action = types.OIDCLogoutActionSuspend
+	require.NoError(t, h.db.SetOIDCSession(&types.OIDCSession{
+		NodeID:    bobNode.ID,
+		Provider:  "default",
+		Subject:   "bob",
+		SessionID: "session-bob",
+	}))
+
+	assert.Equal(t, http.StatusOK, logout(jwt.MapClaims{"sid": "session-bob"}))
+	bob, err := h.db.GetUserByOIDCIdentifier((&types.OIDCClaims{Iss: mock.Issuer(), Sub: "bob"}).Identifier())
+	require.NoError(t, err)
+	assert.True(t, bob.Suspended)
+}
@@ -0,0 +1,281 @@
+package hscontrol
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// oidcRefreshCheckInterval is how often the OIDC sessions are checked
+	// for a refresh, if the configured refresh interval is not shorter.
+	oidcRefreshCheckInterval = time.Minute
+
+	// oidcRefreshTimeout limits a single refresh, so an identity provider
+	// which does not respond only delays the sessions after it.
+	oidcRefreshTimeout = 10 * time.Second
+)
+
+var (
+	errOIDCRefreshTokenInvalid    = errors.New("stored refresh token could not be decrypted")
+	errOIDCRefreshSubjectMismatch = errors.New("refreshed ID token belongs to a different subject")
+)
+
+// storeOIDCSession stores the login of a node, so it can be found when the
+// identity provider logs out the session. If refresh tokens are enabled,
+// the refresh token is stored to extend the expiry of the node while the
+// identity provider keeps issuing tokens for the subject.
+func (a *AuthProviderOIDC) storeOIDCSession(
+	provider *oidcProvider,
+	node *types.Node,
+	idToken *oidc.IDToken,
+	token *oauth2.Token,
+) error {
+	var sid struct {
+		SessionID string `json:"sid"`
+	}
+	if err := idToken.Claims(&sid); err != nil {
+		return fmt.Errorf("decoding ID token claims: %w", err)
+	}
+
+	session := &types.OIDCSession{
+		NodeID:        node.ID,
+		Provider:      provider.cfg.Name,
+		Subject:       idToken.Subject,
+		SessionID:     sid.SessionID,
+		LastRefreshed: time.Now(),
+	}
+
+	if provider.cfg.RefreshTokens.Enabled {
+		if token.RefreshToken == "" {
+			log.Warn().
+				Str("provider", provider.cfg.Name).
+				Uint64("node.id", node.ID.Uint64()).
+				Msg("identity provider did not issue a refresh token, node expiry will not be extended")
+		} else {
+			var err error
+			session.RefreshToken, err = encryptRefreshToken(provider.cfg.RefreshTokens.EncryptionKey, token.RefreshToken)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return a.db.SetOIDCSession(session)
+}
+
+// refreshCheckInterval returns how often the OIDC sessions need to be
+// checked, or zero if no provider has refresh tokens enabled.
+func (a *AuthProviderOIDC) refreshCheckInterval() time.Duration {
+	var interval time.Duration
+	for _, provider := range a.providers {
+		if !provider.cfg.RefreshTokens.Enabled {
+			continue
+		}
+
+		providerInterval := min(provider.cfg.RefreshTokens.Interval, oidcRefreshCheckInterval)
+		if interval == 0 || providerInterval < interval {
+			interval = providerInterval
+		}
+	}
+
+	return interval
+}
+
+// RefreshNodeExpiries refreshes the OIDC sessions which are due and extends
+// the expiry of their nodes. A node is expired right away if the identity
+// provider rejects the refresh token, e.g. because the user was disabled.
+// Sessions of expired nodes are removed, those nodes have to log in again.
+// Sessions without a refresh token are kept for back-channel logout. The
+// expiry of nodes of suspended or deactivated users is not extended.
+func (a *AuthProviderOIDC) RefreshNodeExpiries(ctx context.Context) {
+	if !a.refreshing.CompareAndSwap(false, true) {
+		log.Warn().Msg("previous refresh of OIDC sessions has not finished, skipping")
+		return
+	}
+	defer a.refreshing.Store(false)
+
+	sessions, err := a.db.ListOIDCSessions()
+	if err != nil {
+		log.Error().Err(err).Msg("listing OIDC sessions")
+		return
+	}
+
+	for _, session := range sessions {
+		provider, ok := a.providerByName(session.Provider)
+		if !ok || session.Node.IsExpired() {
+			if err := a.db.DeleteOIDCSession(session.NodeID); err != nil {
+				log.Error().Err(err).Msg("deleting OIDC session")
+			}
+
+			continue
+		}
+
+		if !provider.cfg.RefreshTokens.Enabled || len(session.RefreshToken) == 0 ||
+			time.Since(session.LastRefreshed) < provider.cfg.RefreshTokens.Interval {
+			continue
+		}
+
+		if session.Node.User.Suspended || session.Node.User.Deactivated {
+			continue
+		}
+
+		refreshCtx, cancel := context.WithTimeout(ctx, oidcRefreshTimeout)
+		expiry, err := a.refreshOIDCSession(refreshCtx, provider, &session)
+		cancel()
+
+		var retrieveErr *oauth2.RetrieveError
+		switch {
+		case err == nil:
+		case errors.Is(err, errOIDCRefreshTokenInvalid):
+			// The encryption key was changed, the node keeps its
+			// expiry and has to log in again when it is reached.
+			log.Warn().
+				Err(err).
+				Str("provider", provider.cfg.Name).
+				Uint64("node.id", session.NodeID.Uint64()).
+				Msg("dropping refresh token, node expiry will not be extended")
+
+			session.RefreshToken = nil
+			if err := a.db.SetOIDCSession(&session); err != nil {
+				log.Error().Err(err).Msg("storing OIDC session")
+			}
+
+			continue
+		case errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant",
+			errors.Is(err, errOIDCRefreshSubjectMismatch):
+			log.Info().
+				Err(err).
+				Str("provider", provider.cfg.Name).
+				Uint64("node.id", session.NodeID.Uint64()).
+				Msg("OIDC session was revoked, expiring node")
+
+			expiry = time.Now()
+			if err := a.db.DeleteOIDCSession(session.NodeID); err != nil {
+				log.Error().Err(err).Msg("deleting OIDC session")
+			}
+		default:
+			log.Warn().
+				Err(err).
+				Str("provider", provider.cfg.Name).
+				Uint64("node.id", session.NodeID.Uint64()).
+				Msg("failed to refresh OIDC session, retrying later")
+
+			continue
+		}
+
+		if err := a.db.NodeSetExpiry(session.NodeID, expiry); err != nil {
+			log.Error().Err(err).Uint64("node.id", session.NodeID.Uint64()).Msg("setting node expiry")
+			continue
+		}
+
+		ctx := types.NotifyCtx(context.Background(), "oidc-refresh-self", session.Node.Hostname)
+		a.notifier.NotifyByNodeID(ctx, types.UpdateSelf(session.NodeID), session.NodeID)
+
+		ctx = types.NotifyCtx(context.Background(), "oidc-refresh-peers", session.Node.Hostname)
+		a.notifier.NotifyWithIgnore(ctx, types.UpdateExpire(session.NodeID, expiry), session.NodeID)
+	}
+}
+
+// refreshOIDCSession exchanges the refresh token of the session for new
+// tokens and returns the new expiry of the node.
+func (a *AuthProviderOIDC) refreshOIDCSession(
+	ctx context.Context,
+	provider *oidcProvider,
+	session *types.OIDCSession,
+) (time.Time, error) {
+	refreshToken, err := decryptRefreshToken(provider.cfg.RefreshTokens.EncryptionKey, session.RefreshToken)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	token, err := provider.oauth2Config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("refreshing token: %w", err)
+	}
+
+	tokenExpiry := token.Expiry
+
+	// The ID token is optional in a refresh response, if it is
+	// returned it must belong to the same subject.
+	if _, ok := token.Extra("id_token").(string); ok {
+		idToken, err := extractIDToken(ctx, provider, token)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if idToken.Subject != session.Subject {
+			return time.Time{}, errOIDCRefreshSubjectMismatch
+		}
+
+		tokenExpiry = idToken.Expiry
+	}
+
+	// Identity providers may rotate the refresh token.
+	if token.RefreshToken != "" && token.RefreshToken != refreshToken {
+		session.RefreshToken, err = encryptRefreshToken(provider.cfg.RefreshTokens.EncryptionKey, token.RefreshToken)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	session.LastRefreshed = time.Now()
+	if err := a.db.SetOIDCSession(session); err != nil {
+		return time.Time{}, err
+	}
+
+	return provider.determineNodeExpiry(tokenExpiry), nil
+}
+
+// encryptRefreshToken encrypts the token with AES-GCM, the nonce is
+// prepended to the ciphertext.
+func encryptRefreshToken(key []byte, token string) ([]byte, error) {
+	gcm, err := newRefreshTokenCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, []byte(token), nil), nil
+}
+
+func decryptRefreshToken(key []byte, ciphertext []byte) (string, error) {
+	gcm, err := newRefreshTokenCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", errOIDCRefreshTokenInvalid
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	token, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errOIDCRefreshTokenInvalid, err)
+	}
+
+	return string(token), nil
+}
+
+func newRefreshTokenCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating refresh token cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,180 @@
+package hscontrol
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/oauth2-proxy/mockoidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"tailscale.com/types/key"
+)
+
+func TestRefreshTokenEncryption(t *testing.T) {
+	key := make([]byte, 32)
+
+	encrypted, err := encryptRefreshToken(key, "refresh-token")
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "refresh-token")
+
+	token, err := decryptRefreshToken(key, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-token", token)
+
+	otherKey := make([]byte, 32)
+	otherKey[0] = 1
+	_, err = decryptRefreshToken(otherKey, encrypted)
+	require.ErrorIs(t, err, errOIDCRefreshTokenInvalid)
+}
+
+// loginWithMockOIDC runs the authorization code flow against the mock
+// provider and returns the tokens.
+func loginWithMockOIDC(t *testing.T, provider *oidcProvider) *oauth2.Token {
+	t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(provider.oauth2Config.AuthCodeURL("state"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	token, err := provider.oauth2Config.Exchange(context.Background(), location.Query().Get("code"))
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestRefreshNodeExpiries(t *testing.T) {
+	if !slices.Contains(mockoidc.ScopesSupported, oidc.ScopeOfflineAccess) {
+		mockoidc.ScopesSupported = append(mockoidc.ScopesSupported, oidc.ScopeOfflineAccess)
+	}
+
+	mock, err := mockoidc.Run()
+	require.NoError(t, err)
+	defer mock.Shutdown()
+
+	h := newTestHeadscale(t, nil)
+
+	cfg := &types.OIDCConfig{
+		Name:         "default",
+		CallbackPath: "/oidc/callback",
+		Issuer:       mock.Issuer(),
+		ClientID:     mock.ClientID,
+		ClientSecret: mock.ClientSecret,
+		Scope:        []string{"openid", "profile", "email"},
+		Expiry:       time.Hour,
+		RefreshTokens: types.OIDCRefreshTokensConfig{
+			Enabled:       true,
+			EncryptionKey: make([]byte, 32),
+			Interval:      time.Minute,
+		},
+	}
+
+	a, err := NewAuthProviderOIDC(
+		context.Background(),
+		h.cfg.ServerURL,
+		[]*types.OIDCConfig{cfg},
+		h.db,
+		h.nodeNotifier,
+		h.ipAlloc,
+		h.polMan,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, a.refreshCheckInterval())
+
+	provider := a.providers[0]
+	assert.Contains(t, provider.oauth2Config.Scopes, "offline_access")
+
+	token := loginWithMockOIDC(t, provider)
+	idToken, err := extractIDToken(context.Background(), provider, token)
+	require.NoError(t, err)
+
+	user, err := h.db.CreateUser(types.User{Name: "alice"})
+	require.NoError(t, err)
+
+	expiry := time.Now().Add(time.Minute)
+	node := types.Node{
+		MachineKey: key.NewMachine().Public(),
+		NodeKey:    key.NewNode().Public(),
+		Hostname:   "alice-laptop",
+		UserID:     user.ID,
+		Expiry:     &expiry,
+	}
+	require.NoError(t, h.db.DB.Save(&node).Error)
+
+	require.NoError(t, a.storeOIDCSession(provider, &node, idToken, token))
+
+	// The session is not due for a refresh yet.
+	a.RefreshNodeExpiries(context.Background())
+	dbNode, err := h.db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, expiry, *dbNode.Expiry, time.Second)
+
+	sessions, err := h.db.ListOIDCSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	sessions[0].LastRefreshed = time.Now().Add(-time.Hour)
+	require.NoError(t, h.db.SetOIDCSession(&sessions[0]))
+
+	a.RefreshNodeExpiries(context.Background())
+	dbNode, err = h.db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *dbNode.Expiry, time.Minute)
+
+	// The expiry of nodes of suspended users is not extended.
+	_, err = h.db.SuspendUser(types.UserID(user.ID))
+	require.NoError(t, err)
+
+	sessions, err = h.db.ListOIDCSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	sessions[0].LastRefreshed = time.Now().Add(-time.Hour)
+	require.NoError(t, h.db.SetOIDCSession(&sessions[0]))
+
+	expiry = time.Now().Add(time.Minute)
+	require.NoError(t, h.db.NodeSetExpiry(node.ID, expiry))
+
+	a.RefreshNodeExpiries(context.Background())
+	dbNode, err = h.db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, expiry, *dbNode.Expiry, time.Second)
+
+	_, err = h.db.UnsuspendUser(types.UserID(user.ID))
+	require.NoError(t, err)
+
+	// A revoked refresh token expires the node right away.
+	sessions, err = h.db.ListOIDCSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	sessions[0].LastRefreshed = time.Now().Add(-time.Hour)
+	require.NoError(t, h.db.SetOIDCSession(&sessions[0]))
+
+	mock.QueueError(&mockoidc.ServerError{
+		Code:  http.StatusBadRequest,
+		Error: mockoidc.InvalidGrant,
+	})
+
+	a.RefreshNodeExpiries(context.Background())
+	dbNode, err = h.db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	assert.True(t, dbNode.IsExpired())
+
+	sessions, err = h.db.ListOIDCSessions()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
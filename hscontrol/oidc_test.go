@@ -0,0 +1,148 @@
+package hscontrol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"zgo.at/zcache/v2"
+)
+
+func newTestOIDCProvider(name, authURL string) *oidcProvider {
+	return &oidcProvider{
+		cfg: &types.OIDCConfig{
+			Name:         name,
+			DisplayName:  "Login with " + name,
+			CallbackPath: "/oidc/callback/" + name,
+		},
+		oauth2Config: &oauth2.Config{
+			ClientID:    name,
+			Endpoint:    oauth2.Endpoint{AuthURL: authURL},
+			RedirectURL: "https://headscale.example.com/oidc/callback/" + name,
+		},
+	}
+}
+
+func TestOIDCRegisterHandlerProviders(t *testing.T) {
+	a := &AuthProviderOIDC{
+		serverURL: "https://headscale.example.com",
+		providers: []*oidcProvider{
+			newTestOIDCProvider("corp", "https://sso.corp.example.com/authorize"),
+			newTestOIDCProvider("partners", "https://sso.partners.example.org/authorize"),
+		},
+		registrationCache: zcache.New[string, RegistrationInfo](time.Minute, time.Minute),
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/register/{registration_id}", a.RegisterHandler)
+	router.HandleFunc("/oidc/callback/{provider}", a.OIDCCallbackHandler)
+
+	regID, err := types.NewRegistrationID()
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return rec
+	}
+
+	// Without a chosen provider, all providers are offered.
+	rec := get("/register/" + regID.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/register/"+regID.String()+"?provider=corp")
+	assert.Contains(t, rec.Body.String(), "Login with partners")
+
+	rec = get("/register/" + regID.String() + "?provider=partners")
+	require.Equal(t, http.StatusFound, rec.Code)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "sso.partners.example.org", location.Host)
+	assert.Equal(t, "https://headscale.example.com/oidc/callback/partners", location.Query().Get("redirect_uri"))
+
+	regInfo, ok := a.registrationCache.Get(location.Query().Get("state"))
+	require.True(t, ok)
+	assert.Equal(t, "partners", regInfo.Provider)
+	assert.Equal(t, regID, regInfo.RegistrationID)
+
+	rec = get("/register/" + regID.String() + "?provider=unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = get("/oidc/callback/unknown?code=code&state=state")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// A callback for a different provider than the registration was
+	// started with is rejected.
+	state := location.Query().Get("state")
+	req := httptest.NewRequest(http.MethodGet, "/oidc/callback/corp?code=code&state="+state, nil)
+	req.AddCookie(&http.Cookie{Name: "state", Value: state})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// With a single provider, the user is redirected right away.
+	a.providers = a.providers[:1]
+	rec = get("/register/" + regID.String())
+	require.Equal(t, http.StatusFound, rec.Code)
+	assert.Contains(t, rec.Header().Get("Location"), "sso.corp.example.com")
+}
+
+func TestMapOIDCClaims(t *testing.T) {
+	username := template.Must(template.New("username").
+		Option("missingkey=error").
+		Parse("{{ .login_name }}"))
+
+	cfg := types.OIDCClaimsConfig{
+		Username: username,
+		Rules: []types.OIDCClaimRule{
+			{
+				Claim:  "department",
+				Match:  regexp.MustCompile("^Engineering$"),
+				Tags:   []string{"tag:engineering"},
+				Groups: []string{"group:engineering"},
+			},
+			{
+				Claim:  "realm_access.roles",
+				Match:  regexp.MustCompile("^admin$"),
+				Groups: []string{"group:admins"},
+			},
+		},
+	}
+
+	mapping := mapOIDCClaims(cfg, map[string]any{
+		"login_name": "jdoe",
+		"department": "Engineering",
+		"realm_access": map[string]any{
+			"roles": []any{"user", "admin"},
+		},
+	})
+	assert.Equal(t, oidcClaimMapping{
+		Username: "jdoe",
+		Tags:     []string{"tag:engineering"},
+		Groups:   []string{"group:engineering", "group:admins"},
+	}, mapping)
+
+	user := types.User{Name: "jane.doe@example.com", DisplayName: "Jane Doe"}
+	mapping.apply(&user)
+	assert.Equal(t, "jdoe", user.Name)
+	assert.Equal(t, "Jane Doe", user.DisplayName)
+
+	// Without a matching rule the tags are cleared, a missing claim
+	// falls back to the default username.
+	mapping = mapOIDCClaims(cfg, map[string]any{"department": "Sales"})
+	assert.Equal(t, oidcClaimMapping{Tags: []string{}}, mapping)
+
+	// Invalid usernames are ignored.
+	user = types.User{Name: "jane"}
+	oidcClaimMapping{Username: "Jane Doe!"}.apply(&user)
+	assert.Equal(t, "jane", user.Name)
+}
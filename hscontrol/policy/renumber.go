@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/tailscale/hujson"
+)
+
+// RenumberHosts rewrites the hosts of the policy which are addresses of
+// renumbered nodes, keeping comments and formatting. Hosts with prefixes
+// containing renumbered addresses cannot be rewritten, they are reported
+// with the changes.
+func RenumberHosts(pol []byte, renumbered map[netip.Addr]netip.Addr) ([]byte, []string, error) {
+	if len(pol) == 0 || len(renumbered) == 0 {
+		return pol, nil, nil
+	}
+
+	ast, err := hujson.Parse(pol)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing policy: %w", err)
+	}
+
+	obj, ok := ast.Value.(*hujson.Object)
+	if !ok {
+		return pol, nil, nil
+	}
+
+	var changes []string
+	for _, member := range obj.Members {
+		if name, ok := member.Name.Value.(hujson.Literal); !ok || name.String() != "hosts" {
+			continue
+		}
+
+		hosts, ok := member.Value.Value.(*hujson.Object)
+		if !ok {
+			continue
+		}
+
+		for i := range hosts.Members {
+			host := &hosts.Members[i]
+			name, _ := host.Name.Value.(hujson.Literal)
+			value, ok := host.Value.Value.(hujson.Literal)
+			if !ok || value.Kind() != '"' {
+				continue
+			}
+
+			prefix, err := parseHost(value.String())
+			if err != nil {
+				continue
+			}
+
+			if prefix.IsSingleIP() {
+				addr, ok := renumbered[prefix.Addr()]
+				if !ok {
+					continue
+				}
+
+				newValue := addr.String()
+				if strings.Contains(value.String(), "/") {
+					newValue = netip.PrefixFrom(addr, addr.BitLen()).String()
+				}
+
+				host.Value.Value = hujson.String(newValue)
+				changes = append(changes, fmt.Sprintf("host %q: %s -> %s", name.String(), value.String(), newValue))
+
+				continue
+			}
+
+			for old := range renumbered {
+				if prefix.Contains(old) {
+					changes = append(changes, fmt.Sprintf("host %q: %s contains renumbered addresses and has to be changed manually", name.String(), value.String()))
+
+					break
+				}
+			}
+		}
+	}
+
+	return ast.Pack(), changes, nil
+}
+
+func parseHost(host string) (netip.Prefix, error) {
+	if strings.Contains(host, "/") {
+		return netip.ParsePrefix(host)
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,56 @@
+package policy
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenumberHosts(t *testing.T) {
+	pol := `{
+  // Hosts referenced by firewall rules.
+  "hosts": {
+    "db":      "100.64.0.5",
+    "backup":  "100.64.0.6/32",
+    "office":  "100.64.0.0/24",
+    "printer": "192.168.1.10",
+  },
+  "acls": [
+    {"action": "accept", "src": ["*"], "dst": ["db:5432"]},
+  ],
+}`
+
+	renumbered := map[netip.Addr]netip.Addr{
+		netip.MustParseAddr("100.64.0.5"): netip.MustParseAddr("100.80.0.5"),
+		netip.MustParseAddr("100.64.0.6"): netip.MustParseAddr("100.80.0.6"),
+	}
+
+	got, changes, err := RenumberHosts([]byte(pol), renumbered)
+	require.NoError(t, err)
+
+	want := `{
+  // Hosts referenced by firewall rules.
+  "hosts": {
+    "db":      "100.80.0.5",
+    "backup":  "100.80.0.6/32",
+    "office":  "100.64.0.0/24",
+    "printer": "192.168.1.10",
+  },
+  "acls": [
+    {"action": "accept", "src": ["*"], "dst": ["db:5432"]},
+  ],
+}`
+	assert.Equal(t, want, string(got))
+	assert.Equal(t, []string{
+		`host "db": 100.64.0.5 -> 100.80.0.5`,
+		`host "backup": 100.64.0.6/32 -> 100.80.0.6/32`,
+		`host "office": 100.64.0.0/24 contains renumbered addresses and has to be changed manually`,
+	}, changes)
+
+	got, changes, err = RenumberHosts(nil, renumbered)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.Empty(t, changes)
+}
@@ -179,6 +179,24 @@ func (g Group) Resolve(p *Policy, users types.Users, nodes types.Nodes) (*netipx
 		ips.AddSet(uips)
 	}
 
+	// Members of the group can also come from the identity
+	// provider, via OIDC at login or via SCIM.
+	for _, user := range users {
+		if !user.InGroup(string(g)) {
+			continue
+		}
+
+		for _, node := range nodes {
+			if node.IsTagged() {
+				continue
+			}
+
+			if node.User.ID == user.ID {
+				node.AppendToIPSet(&ips)
+			}
+		}
+	}
+
 	return buildIPSetMultiErr(&ips, errs)
 }
 
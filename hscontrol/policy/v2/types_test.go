@@ -752,6 +752,7 @@ func TestResolvePolicy(t *testing.T) {
 		"groupuser1": {Model: gorm.Model{ID: 3}, Name: "groupuser1"},
 		"groupuser2": {Model: gorm.Model{ID: 4}, Name: "groupuser2"},
 		"notme":      {Model: gorm.Model{ID: 5}, Name: "notme"},
+		"oidcuser":   {Model: gorm.Model{ID: 6}, Name: "oidcuser", Groups: []string{"group:oidc-eng"}},
 	}
 	tests := []struct {
 		name      string
@@ -850,6 +851,37 @@ func TestResolvePolicy(t *testing.T) {
 			},
 			want: []netip.Prefix{mp("100.100.101.203/32"), mp("100.100.101.204/32")},
 		},
+		{
+			name:      "group-from-identity-provider",
+			toResolve: ptr.To(Group("group:oidc-eng")),
+			nodes: types.Nodes{
+				// Not matching other user
+				{
+					User: users["notme"],
+					IPv4: ap("100.100.101.7"),
+				},
+				// Not matching forced tags
+				{
+					User:       users["oidcuser"],
+					ForcedTags: []string{"tag:anything"},
+					IPv4:       ap("100.100.101.8"),
+				},
+				{
+					User: users["oidcuser"],
+					IPv4: ap("100.100.101.205"),
+				},
+				{
+					User: users["groupuser"],
+					IPv4: ap("100.100.101.206"),
+				},
+			},
+			pol: &Policy{
+				Groups: Groups{
+					"group:oidc-eng": Usernames{"groupuser"},
+				},
+			},
+			want: []netip.Prefix{mp("100.100.101.205/32"), mp("100.100.101.206/32")},
+		},
 		{
 			name:      "tag",
 			toResolve: tp("tag:test"),
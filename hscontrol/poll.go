@@ -133,13 +133,13 @@ func (m *mapSession) resetKeepAlive() {
 
 func (m *mapSession) beforeServeLongPoll() {
 	if m.node.IsEphemeral() {
-		m.h.ephemeralGC.Cancel(m.node.ID)
+		m.h.cancelEphemeralDeletion(m.node)
 	}
 }
 
 func (m *mapSession) afterServeLongPoll() {
 	if m.node.IsEphemeral() {
-		m.h.ephemeralGC.Schedule(m.node.ID, m.h.cfg.EphemeralNodeInactivityTimeout)
+		m.h.scheduleEphemeralDeletion(m.node, time.Now().Add(m.node.EphemeralInactivityTimeout(m.h.cfg.EphemeralNodeInactivityTimeout)))
 	}
 }
 
@@ -440,6 +440,7 @@ func (m *mapSession) handleEndpointUpdate() {
 		m.req.Hostinfo.NetInfo = m.node.Hostinfo.NetInfo
 	}
 	m.node.Hostinfo = m.req.Hostinfo
+	m.node.CapVer = m.req.Version
 
 	logTracePeerChange(m.node.Hostname, sendUpdate, &change)
 
@@ -0,0 +1,463 @@
+package hscontrol
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/notifier"
+	"github.com/juanfont/headscale/hscontrol/policy"
+	"github.com/juanfont/headscale/hscontrol/templates"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	xrv "github.com/mattermost/xml-roundtrip-validator"
+	"github.com/rs/zerolog/log"
+	dsig "github.com/russellhaering/goxmldsig"
+	"zgo.at/zcache/v2"
+)
+
+const (
+	samlMetadataPath = "/saml/metadata"
+	samlACSPath      = "/saml/acs"
+)
+
+var (
+	errSAMLNoIDPDescriptor     = errors.New("IdP metadata has no IDPSSODescriptor")
+	errSAMLMetadataFetch       = errors.New("unexpected status code fetching IdP metadata")
+	errSAMLKeyNotSigner        = errors.New("private key of saml.key_path cannot sign")
+	errSAMLRequestNotFound     = errors.New("SAML login request not found or expired")
+	errSAMLNameIDMissing       = errors.New("SAML assertion has no NameID")
+	errSAMLUserDeactivated     = errors.New("user is deactivated")
+	errSAMLUserSuspended       = errors.New("user is suspended")
+	errSAMLResponseNotAccepted = errors.New("SAML response not accepted")
+)
+
+// samlRequest is an authentication request sent to the identity provider,
+// waiting for its response.
+type samlRequest struct {
+	RegistrationID types.RegistrationID
+	// RequestID is the ID of the AuthnRequest, the response must
+	// refer to it.
+	RequestID string
+}
+
+// AuthProviderSAML registers nodes after the user logged in at a SAML 2.0
+// identity provider, with headscale as service provider.
+type AuthProviderSAML struct {
+	serverURL    string
+	cfg          types.SAMLConfig
+	sp           *saml.ServiceProvider
+	requestCache *zcache.Cache[string, samlRequest]
+
+	authRegistrar
+}
+
+func NewAuthProviderSAML(
+	ctx context.Context,
+	serverURL string,
+	cfg types.SAMLConfig,
+	db *db.HSDatabase,
+	notif *notifier.Notifier,
+	ipAlloc *db.IPAllocator,
+	polMan policy.PolicyManager,
+) (*AuthProviderSAML, error) {
+	idpMetadata, err := loadSAMLIDPMetadata(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("loading SAML IdP metadata: %w", err)
+	}
+
+	baseURL := strings.TrimSuffix(serverURL, "/")
+	metadataURL, err := url.Parse(baseURL + samlMetadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing server_url: %w", err)
+	}
+	acsURL, err := url.Parse(baseURL + samlACSPath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing server_url: %w", err)
+	}
+
+	sp := &saml.ServiceProvider{
+		EntityID:          cmp.Or(cfg.EntityID, metadataURL.String()),
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+	}
+
+	if cfg.CertificatePath != "" {
+		key, cert, err := loadSAMLKeyPair(cfg.CertificatePath, cfg.KeyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		sp.Key = key
+		sp.Certificate = cert
+
+		if cfg.SignRequests {
+			sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+			if _, ok := key.(*ecdsa.PrivateKey); ok {
+				sp.SignatureMethod = dsig.ECDSASHA256SignatureMethod
+			}
+		}
+	}
+
+	return &AuthProviderSAML{
+		serverURL: serverURL,
+		cfg:       cfg,
+		sp:        sp,
+		requestCache: zcache.New[string, samlRequest](
+			registerCacheExpiration,
+			registerCacheCleanup,
+		),
+		authRegistrar: authRegistrar{
+			db:       db,
+			notifier: notif,
+			ipAlloc:  ipAlloc,
+			polMan:   polMan,
+		},
+	}, nil
+}
+
+// loadSAMLIDPMetadata reads the metadata of the identity provider from the
+// configured file or URL.
+func loadSAMLIDPMetadata(ctx context.Context, cfg types.SAMLConfig) (*saml.EntityDescriptor, error) {
+	if cfg.IDPMetadataPath != "" {
+		data, err := os.ReadFile(cfg.IDPMetadataPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return parseSAMLMetadata(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.IDPMetadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", errSAMLMetadataFetch, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSAMLMetadata(data)
+}
+
+// parseSAMLMetadata parses the metadata of an identity provider, which is
+// either its EntityDescriptor or an EntitiesDescriptor containing it.
+func parseSAMLMetadata(data []byte) (*saml.EntityDescriptor, error) {
+	if err := xrv.Validate(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	var entity saml.EntityDescriptor
+	entityErr := xml.Unmarshal(data, &entity)
+	if entityErr == nil {
+		if len(entity.IDPSSODescriptors) == 0 {
+			return nil, errSAMLNoIDPDescriptor
+		}
+
+		return &entity, nil
+	}
+
+	var entities saml.EntitiesDescriptor
+	if err := xml.Unmarshal(data, &entities); err != nil {
+		return nil, entityErr
+	}
+
+	for i, e := range entities.EntityDescriptors {
+		if len(e.IDPSSODescriptors) > 0 {
+			return &entities.EntityDescriptors[i], nil
+		}
+	}
+
+	return nil, errSAMLNoIDPDescriptor
+}
+
+func loadSAMLKeyPair(certPath, keyPath string) (crypto.Signer, *x509.Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading SAML key pair: %w", err)
+	}
+
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errSAMLKeyNotSigner
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing SAML certificate: %w", err)
+	}
+
+	return key, cert, nil
+}
+
+func (a *AuthProviderSAML) AuthURL(registrationID types.RegistrationID) string {
+	return fmt.Sprintf(
+		"%s/register/%s",
+		strings.TrimSuffix(a.serverURL, "/"),
+		registrationID.String())
+}
+
+// RegisterHandler sends the user to the identity provider with a new
+// authentication request.
+// Listens in /register/:registration_id.
+func (a *AuthProviderSAML) RegisterHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	registrationID, err := types.RegistrationIDFromString(mux.Vars(req)["registration_id"])
+	if err != nil {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "invalid registration id", err))
+		return
+	}
+
+	authnRequest, err := a.sp.MakeAuthenticationRequest(
+		a.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding),
+		saml.HTTPRedirectBinding,
+		saml.HTTPPostBinding,
+	)
+	if err != nil {
+		httpError(writer, fmt.Errorf("creating SAML authentication request: %w", err))
+		return
+	}
+
+	relayState, err := util.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	redirectURL, err := authnRequest.Redirect(relayState, a.sp)
+	if err != nil {
+		httpError(writer, fmt.Errorf("creating SAML redirect: %w", err))
+		return
+	}
+
+	a.requestCache.Set(relayState, samlRequest{
+		RegistrationID: registrationID,
+		RequestID:      authnRequest.ID,
+	})
+
+	http.Redirect(writer, req, redirectURL.String(), http.StatusFound)
+}
+
+// MetadataHandler serves the service provider metadata of headscale, to
+// be registered at the identity provider.
+// Listens in /saml/metadata.
+func (a *AuthProviderSAML) MetadataHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	metadata, err := xml.MarshalIndent(a.sp.Metadata(), "", "  ")
+	if err != nil {
+		httpError(writer, fmt.Errorf("marshalling SAML metadata: %w", err))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/samlmetadata+xml")
+	writer.WriteHeader(http.StatusOK)
+	if _, err := writer.Write(metadata); err != nil {
+		util.LogErr(err, "Failed to write response")
+	}
+}
+
+// ACSHandler is the assertion consumer service receiving the response of
+// the identity provider. It validates the signed assertion, creates or
+// updates the user and registers the node.
+// Listens in /saml/acs.
+func (a *AuthProviderSAML) ACSHandler(
+	writer http.ResponseWriter,
+	req *http.Request,
+) {
+	if err := req.ParseForm(); err != nil {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "invalid form", err))
+		return
+	}
+
+	// Only responses to requests started in RegisterHandler are
+	// accepted, IdP initiated logins have no node to register.
+	relayState := req.PostForm.Get("RelayState")
+	request, ok := a.requestCache.Get(relayState)
+	if !ok {
+		httpError(writer, NewHTTPError(http.StatusBadRequest, "login session not found or expired, try again", errSAMLRequestNotFound))
+		return
+	}
+	a.requestCache.Delete(relayState)
+
+	assertion, err := a.sp.ParseResponse(req, []string{request.RequestID})
+	if err != nil {
+		var invalidErr *saml.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			err = fmt.Errorf("%w: %w", errSAMLResponseNotAccepted, invalidErr.PrivateErr)
+		}
+		httpError(writer, NewHTTPError(http.StatusForbidden, "invalid SAML response", err))
+
+		return
+	}
+
+	identity, err := samlIdentityFromAssertion(a.cfg, assertion)
+	if err != nil {
+		httpError(writer, NewHTTPError(http.StatusForbidden, "invalid SAML assertion", err))
+		return
+	}
+
+	user, err := a.createOrUpdateUserFromSAML(identity)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	nodeExpiry := time.Now().Add(a.cfg.Expiry)
+	node, newNode, err := a.handleRegistration(
+		user,
+		request.RegistrationID,
+		&nodeExpiry,
+		util.RegisterMethodSAML,
+		nil,
+	)
+	if err != nil {
+		httpError(writer, err)
+		return
+	}
+
+	log.Info().
+		Str("user", user.Name).
+		Uint64("node.id", node.ID.Uint64()).
+		Msg("registered node with SAML login")
+
+	verb := "Reauthenticated"
+	if newNode {
+		verb = "Authenticated"
+	}
+
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+	if _, err := writer.Write([]byte(templates.RegistrationSuccess(user.Display(), verb).Render())); err != nil {
+		util.LogErr(err, "Failed to write response")
+	}
+}
+
+// samlIdentityFromAssertion maps the NameID and the configured attributes
+// of an assertion to the identity of the user.
+func samlIdentityFromAssertion(cfg types.SAMLConfig, assertion *saml.Assertion) (*types.SAMLIdentity, error) {
+	if assertion.Subject == nil || assertion.Subject.NameID == nil ||
+		assertion.Subject.NameID.Value == "" {
+		return nil, errSAMLNameIDMissing
+	}
+
+	identity := &types.SAMLIdentity{
+		Issuer:      assertion.Issuer.Value,
+		NameID:      assertion.Subject.NameID.Value,
+		Username:    cmp.Or(samlAttributeValue(assertion, cfg.Attributes.Username), assertion.Subject.NameID.Value),
+		DisplayName: samlAttributeValue(assertion, cfg.Attributes.DisplayName),
+		Email:       samlAttributeValue(assertion, cfg.Attributes.Email),
+	}
+
+	if cfg.Attributes.Groups != "" {
+		identity.Groups = []string{}
+		for _, group := range samlAttributeValues(assertion, cfg.Attributes.Groups) {
+			identity.Groups = append(identity.Groups, cfg.GroupsPrefix+group)
+		}
+		slices.Sort(identity.Groups)
+		identity.Groups = slices.Compact(identity.Groups)
+	}
+
+	return identity, nil
+}
+
+// samlAttributeValues returns the non-empty values of the attributes with
+// the given name or friendly name.
+func samlAttributeValues(assertion *saml.Assertion, name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	var values []string
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			if attr.Name != name && attr.FriendlyName != name {
+				continue
+			}
+
+			for _, value := range attr.Values {
+				if v := strings.TrimSpace(value.Value); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+	}
+
+	return values
+}
+
+// samlAttributeValue returns the first value of an attribute.
+func samlAttributeValue(assertion *saml.Assertion, name string) string {
+	if values := samlAttributeValues(assertion, name); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (a *AuthProviderSAML) createOrUpdateUserFromSAML(identity *types.SAMLIdentity) (*types.User, error) {
+	user, err := a.db.GetUserByOIDCIdentifier(identity.Identifier())
+	if err != nil && !errors.Is(err, db.ErrUserNotFound) {
+		return nil, fmt.Errorf("creating or updating user: %w", err)
+	}
+
+	if user != nil && user.Deactivated {
+		return nil, NewHTTPError(http.StatusForbidden, "user is deactivated", errSAMLUserDeactivated)
+	}
+
+	if user != nil && user.Suspended {
+		return nil, NewHTTPError(http.StatusForbidden, "user is suspended", errSAMLUserSuspended)
+	}
+
+	if user == nil {
+		user = &types.User{}
+	}
+
+	user.FromSAML(identity)
+	if identity.Groups != nil {
+		user.Groups = identity.Groups
+	}
+
+	if err := a.db.DB.Save(user).Error; err != nil {
+		return nil, fmt.Errorf("creating or updating user: %w", err)
+	}
+
+	if err := usersChangedHook(a.db, a.polMan, a.notifier); err != nil {
+		return nil, fmt.Errorf("updating resources using user: %w", err)
+	}
+
+	return user, nil
+}
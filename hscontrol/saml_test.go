@@ -0,0 +1,226 @@
+package hscontrol
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/xml"
+	"html"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/logger"
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tailscale.com/types/key"
+)
+
+// testSAMLSessions logs every request in as the same user.
+type testSAMLSessions struct {
+	session *saml.Session
+}
+
+func (s testSAMLSessions) GetSession(http.ResponseWriter, *http.Request, *saml.IdpAuthnRequest) *saml.Session {
+	return s.session
+}
+
+// testSAMLServiceProviders knows headscale as the only service provider.
+type testSAMLServiceProviders struct {
+	sp **AuthProviderSAML
+}
+
+func (s testSAMLServiceProviders) GetServiceProvider(*http.Request, string) (*saml.EntityDescriptor, error) {
+	return (*s.sp).sp.Metadata(), nil
+}
+
+func newTestSAMLIdentityProvider(t *testing.T) *saml.IdentityProvider {
+	t.Helper()
+
+	idpKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &idpKey.PublicKey, idpKey)
+	require.NoError(t, err)
+	idpCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	metadataURL, _ := url.Parse("https://idp.example.com/metadata")
+	ssoURL, _ := url.Parse("https://idp.example.com/sso")
+
+	return &saml.IdentityProvider{
+		Key:         idpKey,
+		Signer:      idpKey,
+		Certificate: idpCert,
+		Logger:      logger.DefaultLogger,
+		MetadataURL: *metadataURL,
+		SSOURL:      *ssoURL,
+	}
+}
+
+var samlFormValue = regexp.MustCompile(`name="(SAMLResponse|RelayState)" value="([^"]*)"`)
+
+func TestAuthProviderSAML(t *testing.T) {
+	idp := newTestSAMLIdentityProvider(t)
+
+	metadata, err := xml.Marshal(idp.Metadata())
+	require.NoError(t, err)
+	metadataPath := filepath.Join(t.TempDir(), "idp-metadata.xml")
+	require.NoError(t, os.WriteFile(metadataPath, metadata, 0o600))
+
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.SAML = types.SAMLConfig{
+			Enabled:         true,
+			IDPMetadataPath: metadataPath,
+			Expiry:          time.Hour,
+			Attributes: types.SAMLAttributesConfig{
+				Username:    "uid",
+				DisplayName: "displayName",
+				Email:       "mail",
+				Groups:      "eduPersonAffiliation",
+			},
+			GroupsPrefix: "group:saml-",
+		}
+	})
+
+	provider, ok := h.authProvider.(*AuthProviderSAML)
+	require.True(t, ok)
+
+	idp.ServiceProviderProvider = testSAMLServiceProviders{sp: &provider}
+	idp.SessionProvider = testSAMLSessions{session: &saml.Session{
+		ID:         "session",
+		CreateTime: time.Now(),
+		ExpireTime: time.Now().Add(time.Hour),
+		NameID:     "alice-id",
+		UserName:   "alice",
+		UserEmail:  "alice@example.com",
+		Groups:     []string{"admins", "dev", "admins"},
+		CustomAttributes: []saml.Attribute{{
+			Name:   "displayName",
+			Values: []saml.AttributeValue{{Type: "xs:string", Value: "Alice Example"}},
+		}},
+	}}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/register/{registration_id}", provider.RegisterHandler)
+	router.HandleFunc(samlMetadataPath, provider.MetadataHandler)
+	router.HandleFunc(samlACSPath, provider.ACSHandler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, samlMetadataPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `entityID="https://headscale.example.com/saml/metadata"`)
+	assert.Contains(t, rec.Body.String(), `Location="https://headscale.example.com/saml/acs"`)
+
+	// login starts a registration at the identity provider and returns
+	// the form it posts back to headscale.
+	login := func(regID types.RegistrationID) url.Values {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/register/"+regID.String(), nil))
+		require.Equal(t, http.StatusFound, rec.Code)
+
+		location := rec.Header().Get("Location")
+		require.True(t, strings.HasPrefix(location, "https://idp.example.com/sso?"))
+
+		rec = httptest.NewRecorder()
+		idp.ServeSSO(rec, httptest.NewRequest(http.MethodGet, location, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		form := url.Values{}
+		for _, match := range samlFormValue.FindAllStringSubmatch(rec.Body.String(), -1) {
+			form.Set(match[1], html.UnescapeString(match[2]))
+		}
+		require.NotEmpty(t, form.Get("SAMLResponse"))
+		require.NotEmpty(t, form.Get("RelayState"))
+
+		return form
+	}
+
+	acs := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "https://headscale.example.com"+samlACSPath, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	regID, err := types.NewRegistrationID()
+	require.NoError(t, err)
+	h.registrationCache.Set(regID, types.RegisterNode{
+		Node: types.Node{
+			MachineKey: key.NewMachine().Public(),
+			NodeKey:    key.NewNode().Public(),
+			Hostname:   "alice-laptop",
+		},
+		Registered: make(chan *types.Node, 1),
+	})
+
+	form := login(regID)
+
+	// Responses to unknown requests are rejected.
+	tampered := url.Values{"SAMLResponse": form["SAMLResponse"], "RelayState": {"unknown"}}
+	assert.Equal(t, http.StatusBadRequest, acs(tampered).Code)
+
+	rec = acs(form)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "Authenticated as Alice Example")
+
+	// The response can only be used once.
+	assert.Equal(t, http.StatusBadRequest, acs(form).Code)
+
+	user, err := h.db.GetUserByOIDCIdentifier("https://idp.example.com/metadata/alice-id")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, "Alice Example", user.DisplayName)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.Equal(t, util.RegisterMethodSAML, user.Provider)
+	assert.Equal(t, []string{"group:saml-admins", "group:saml-dev"}, user.Groups)
+
+	nodes, err := h.db.ListNodes()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, user.ID, nodes[0].UserID)
+	assert.Equal(t, util.RegisterMethodSAML, nodes[0].RegisterMethod)
+	require.NotNil(t, nodes[0].Expiry)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *nodes[0].Expiry, time.Minute)
+
+	// Responses with a broken signature are rejected.
+	regID, err = types.NewRegistrationID()
+	require.NoError(t, err)
+	form = login(regID)
+	form.Set("SAMLResponse", tamperSAMLResponse(t, form.Get("SAMLResponse")))
+	assert.Equal(t, http.StatusForbidden, acs(form).Code)
+}
+
+// tamperSAMLResponse changes the NameID of a signed response.
+func tamperSAMLResponse(t *testing.T, response string) string {
+	t.Helper()
+
+	decoded, err := base64.StdEncoding.DecodeString(response)
+	require.NoError(t, err)
+
+	tampered := strings.Replace(string(decoded), "alice-id", "mallory", 1)
+	require.NotEqual(t, string(decoded), tampered)
+
+	return base64.StdEncoding.EncodeToString([]byte(tampered))
+}
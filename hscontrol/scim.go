@@ -0,0 +1,1222 @@
+package hscontrol
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/db"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	scimBasePath    = "/scim/v2"
+	scimContentType = "application/scim+json"
+
+	scimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// scimFilterRegex matches the only filter expression supported, an
+// equality comparison of an attribute with a string, e.g.
+// userName eq "alice@example.com".
+var scimFilterRegex = regexp.MustCompile(`(?i)^\s*([a-z.]+)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// scimMemberFilterRegex matches the value filter of a member path,
+// e.g. members[value eq "2"].
+var scimMemberFilterRegex = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+type scimMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type scimMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// scimUser is the SCIM representation of a types.User, see RFC 7643,
+// section 4.1.
+type scimUser struct {
+	Schemas     []string               `json:"schemas"`
+	ID          string                 `json:"id,omitempty"`
+	ExternalID  string                 `json:"externalId,omitempty"`
+	UserName    string                 `json:"userName"`
+	Name        *scimName              `json:"name,omitempty"`
+	DisplayName string                 `json:"displayName,omitempty"`
+	Emails      []scimMultiValue       `json:"emails,omitempty"`
+	Active      *types.FlexibleBoolean `json:"active,omitempty"`
+	Groups      []scimMultiValue       `json:"groups,omitempty"`
+	Meta        *scimMeta              `json:"meta,omitempty"`
+}
+
+// scimGroup is the SCIM representation of a types.SCIMGroup, see
+// RFC 7643, section 4.2.
+type scimGroup struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	ExternalID  string           `json:"externalId,omitempty"`
+	DisplayName string           `json:"displayName"`
+	Members     []scimMultiValue `json:"members"`
+	Meta        *scimMeta        `json:"meta,omitempty"`
+}
+
+type scimListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type scimErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// scimError is an error which is returned to the SCIM client as described
+// in RFC 7644, section 3.12.
+type scimError struct {
+	Code     int
+	ScimType string
+	Detail   string
+}
+
+func (e scimError) Error() string {
+	return fmt.Sprintf("scim error[%d]: %s %s", e.Code, e.ScimType, e.Detail)
+}
+
+func newSCIMError(code int, scimType string, detail string) scimError {
+	return scimError{Code: code, ScimType: scimType, Detail: detail}
+}
+
+var errSCIMNotFound = newSCIMError(http.StatusNotFound, "", "resource not found")
+
+// scimHandlerFunc handles a SCIM request and returns the status code and
+// the resource to respond with, if any.
+type scimHandlerFunc func(req *http.Request) (int, any, error)
+
+// registerSCIMRoutes adds the SCIM 2.0 endpoints, authenticated with the
+// configured bearer token, to the router.
+func (h *Headscale) registerSCIMRoutes(router *mux.Router) {
+	scimRouter := router.PathPrefix(scimBasePath).Subrouter()
+	scimRouter.Use(h.scimAuthenticationMiddleware)
+
+	scimRouter.HandleFunc("/ServiceProviderConfig", h.scimHandler(h.scimServiceProviderConfig)).
+		Methods(http.MethodGet)
+
+	scimRouter.HandleFunc("/Users", h.scimHandler(h.scimListUsers)).Methods(http.MethodGet)
+	scimRouter.HandleFunc("/Users", h.scimHandler(h.scimCreateUser)).Methods(http.MethodPost)
+	scimRouter.HandleFunc("/Users/{id}", h.scimHandler(h.scimGetUser)).Methods(http.MethodGet)
+	scimRouter.HandleFunc("/Users/{id}", h.scimHandler(h.scimReplaceUser)).Methods(http.MethodPut)
+	scimRouter.HandleFunc("/Users/{id}", h.scimHandler(h.scimPatchUser)).Methods(http.MethodPatch)
+	scimRouter.HandleFunc("/Users/{id}", h.scimHandler(h.scimDeleteUser)).Methods(http.MethodDelete)
+
+	scimRouter.HandleFunc("/Groups", h.scimHandler(h.scimListGroups)).Methods(http.MethodGet)
+	scimRouter.HandleFunc("/Groups", h.scimHandler(h.scimCreateGroup)).Methods(http.MethodPost)
+	scimRouter.HandleFunc("/Groups/{id}", h.scimHandler(h.scimGetGroup)).Methods(http.MethodGet)
+	scimRouter.HandleFunc("/Groups/{id}", h.scimHandler(h.scimReplaceGroup)).Methods(http.MethodPut)
+	scimRouter.HandleFunc("/Groups/{id}", h.scimHandler(h.scimPatchGroup)).Methods(http.MethodPatch)
+	scimRouter.HandleFunc("/Groups/{id}", h.scimHandler(h.scimDeleteGroup)).Methods(http.MethodDelete)
+}
+
+func (h *Headscale) scimAuthenticationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), AuthPrefix)
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.SCIM.Token)) != 1 {
+			log.Info().
+				Str("client_address", req.RemoteAddr).
+				Msg("invalid SCIM token")
+			writeSCIMError(writer, newSCIMError(http.StatusUnauthorized, "", "unauthorized"))
+
+			return
+		}
+
+		next.ServeHTTP(writer, req)
+	})
+}
+
+func (h *Headscale) scimHandler(handler scimHandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		code, resource, err := handler(req)
+		if err != nil {
+			writeSCIMError(writer, err)
+			return
+		}
+
+		writer.Header().Set("Content-Type", scimContentType)
+		if code == http.StatusCreated {
+			switch r := resource.(type) {
+			case *scimUser:
+				writer.Header().Set("Location", r.Meta.Location)
+			case *scimGroup:
+				writer.Header().Set("Location", r.Meta.Location)
+			}
+		}
+		writer.WriteHeader(code)
+
+		if resource != nil {
+			if err := json.NewEncoder(writer).Encode(resource); err != nil {
+				log.Error().Caller().Err(err).Msg("failed to write SCIM response")
+			}
+		}
+	}
+}
+
+func writeSCIMError(writer http.ResponseWriter, err error) {
+	var serr scimError
+	if !errors.As(err, &serr) {
+		log.Error().Caller().Err(err).Msg("SCIM request failed")
+		serr = newSCIMError(http.StatusInternalServerError, "", "internal server error")
+	}
+
+	writer.Header().Set("Content-Type", scimContentType)
+	writer.WriteHeader(serr.Code)
+	json.NewEncoder(writer).Encode(scimErrorResponse{
+		Schemas:  []string{scimSchemaError},
+		Status:   strconv.Itoa(serr.Code),
+		ScimType: serr.ScimType,
+		Detail:   serr.Detail,
+	})
+}
+
+func decodeSCIMBody(req *http.Request, v any) error {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidSyntax", fmt.Sprintf("invalid request body: %s", err))
+	}
+
+	return nil
+}
+
+func scimResourceID(req *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(req)["id"], util.Base10, 64)
+	if err != nil {
+		return 0, errSCIMNotFound
+	}
+
+	return uint(id), nil
+}
+
+func (h *Headscale) scimLocation(resourceType string, id uint) string {
+	return fmt.Sprintf("%s%s/%s/%d", strings.TrimSuffix(h.cfg.ServerURL, "/"), scimBasePath, resourceType, id)
+}
+
+func (h *Headscale) scimServiceProviderConfig(req *http.Request) (int, any, error) {
+	return http.StatusOK, map[string]any{
+		"schemas":        []string{scimSchemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": 0},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]any{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "Bearer token",
+				"description": "Authentication with the token configured in scim.token",
+				"primary":     true,
+			},
+		},
+	}, nil
+}
+
+// scimListResponseFromRequest filters the resources with the filter of the
+// request and paginates them according to startIndex and count.
+func scimListResponseFromRequest[T any](
+	req *http.Request,
+	resources []T,
+	attribute func(resource T, attr string) []string,
+) (*scimListResponse, error) {
+	query := req.URL.Query()
+
+	if filter := query.Get("filter"); filter != "" {
+		match := scimFilterRegex.FindStringSubmatch(filter)
+		if match == nil {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidFilter", "only filters of the form 'attribute eq \"value\"' are supported")
+		}
+		attr, value := strings.ToLower(match[1]), strings.ReplaceAll(match[2], `\"`, `"`)
+
+		resources = slices.DeleteFunc(resources, func(resource T) bool {
+			return !slices.ContainsFunc(attribute(resource, attr), func(v string) bool {
+				return strings.EqualFold(v, value)
+			})
+		})
+	}
+
+	startIndex := 1
+	if s := query.Get("startIndex"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid startIndex")
+		}
+		startIndex = max(i, 1)
+	}
+
+	count := len(resources)
+	if s := query.Get("count"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid count")
+		}
+		count = max(i, 0)
+	}
+
+	resp := &scimListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		Resources:    []any{},
+	}
+
+	for _, resource := range resources[min(startIndex-1, len(resources)):] {
+		if len(resp.Resources) >= count {
+			break
+		}
+		resp.Resources = append(resp.Resources, resource)
+	}
+	resp.ItemsPerPage = len(resp.Resources)
+
+	return resp, nil
+}
+
+// scimUserFromUser converts the user to its SCIM representation, groups are
+// all SCIM groups and used to list the groups of the user.
+func (h *Headscale) scimUserFromUser(user *types.User, groups []types.SCIMGroup) *scimUser {
+	active := types.FlexibleBoolean(!user.Deactivated)
+	resource := &scimUser{
+		Schemas:     []string{scimSchemaUser},
+		ID:          user.StringID(),
+		ExternalID:  user.SCIMExternalID,
+		UserName:    user.Name,
+		DisplayName: user.DisplayName,
+		Active:      &active,
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     h.scimLocation("Users", user.ID),
+		},
+	}
+
+	if user.DisplayName != "" {
+		resource.Name = &scimName{Formatted: user.DisplayName}
+	}
+
+	if user.Email != "" {
+		resource.Emails = []scimMultiValue{{Value: user.Email, Type: "work", Primary: true}}
+	}
+
+	for _, group := range groups {
+		if slices.Contains(user.SCIMGroups, group.PolicyName) {
+			resource.Groups = append(resource.Groups, scimMultiValue{
+				Value:   strconv.FormatUint(uint64(group.ID), util.Base10),
+				Display: group.DisplayName,
+				Ref:     h.scimLocation("Groups", group.ID),
+			})
+		}
+	}
+
+	return resource
+}
+
+// applyToUser sets the attributes of the user from the SCIM resource,
+// the active attribute is handled by the caller.
+func (r *scimUser) applyToUser(user *types.User) error {
+	if r.UserName == "" {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	if err := util.ValidateUsername(r.UserName); err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid userName: %s", err))
+	}
+
+	user.Name = r.UserName
+	user.SCIMExternalID = r.ExternalID
+
+	user.DisplayName = r.DisplayName
+	if user.DisplayName == "" && r.Name != nil {
+		user.DisplayName = r.Name.Formatted
+		if user.DisplayName == "" {
+			user.DisplayName = strings.TrimSpace(r.Name.GivenName + " " + r.Name.FamilyName)
+		}
+	}
+
+	user.Email = ""
+	for _, email := range r.Emails {
+		if user.Email == "" || email.Primary {
+			user.Email = email.Value
+		}
+	}
+
+	return nil
+}
+
+// applyPatch applies a SCIM patch operation to the resource, see RFC 7644,
+// section 3.5.2. Operations on unsupported attributes are ignored.
+func (r *scimUser) applyPatch(op scimPatchOperation) error {
+	if op.Path == "" {
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", "patch operation without path requires an object value")
+		}
+
+		for path, value := range values {
+			if err := r.applyPatch(scimPatchOperation{Op: op.Op, Path: path, Value: value}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	remove := strings.EqualFold(op.Op, "remove")
+	path := strings.ToLower(op.Path)
+
+	var target any
+	switch {
+	case path == "active":
+		if remove {
+			return nil
+		}
+		target = &r.Active
+	case path == "username":
+		target = &r.UserName
+	case path == "displayname":
+		target = &r.DisplayName
+	case path == "externalid":
+		target = &r.ExternalID
+	case path == "name":
+		target = &r.Name
+	case strings.HasPrefix(path, "name."):
+		if r.Name == nil {
+			r.Name = &scimName{}
+		}
+		switch path {
+		case "name.formatted":
+			target = &r.Name.Formatted
+		case "name.givenname":
+			target = &r.Name.GivenName
+		case "name.familyname":
+			target = &r.Name.FamilyName
+		}
+	case path == "emails":
+		target = &r.Emails
+	case strings.HasPrefix(path, "emails"):
+		// Sub attribute of an email, e.g. emails[type eq "work"].value,
+		// which replaces the email of the user.
+		if remove {
+			r.Emails = nil
+			return nil
+		}
+
+		var email string
+		if err := json.Unmarshal(op.Value, &email); err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid value for %s", op.Path))
+		}
+		r.Emails = []scimMultiValue{{Value: email, Primary: true}}
+
+		return nil
+	}
+
+	if target == nil {
+		log.Debug().Str("path", op.Path).Msg("ignoring SCIM patch operation on unsupported attribute")
+		return nil
+	}
+
+	if remove {
+		switch t := target.(type) {
+		case *string:
+			*t = ""
+		case **scimName:
+			*t = nil
+		case *[]scimMultiValue:
+			*t = nil
+		}
+
+		return nil
+	}
+
+	if err := json.Unmarshal(op.Value, target); err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid value for %s", op.Path))
+	}
+
+	return nil
+}
+
+func scimUserAttribute(resource *scimUser, attr string) []string {
+	switch attr {
+	case "id":
+		return []string{resource.ID}
+	case "username":
+		return []string{resource.UserName}
+	case "externalid":
+		return []string{resource.ExternalID}
+	case "displayname":
+		return []string{resource.DisplayName}
+	case "emails", "emails.value":
+		var emails []string
+		for _, email := range resource.Emails {
+			emails = append(emails, email.Value)
+		}
+
+		return emails
+	}
+
+	return nil
+}
+
+func (h *Headscale) scimListUsers(req *http.Request) (int, any, error) {
+	users, err := h.db.ListUsers()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	groups, err := db.Read(h.db.DB, db.ListSCIMGroups)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resources := make([]*scimUser, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, h.scimUserFromUser(&user, groups))
+	}
+
+	resp, err := scimListResponseFromRequest(req, resources, scimUserAttribute)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, resp, nil
+}
+
+func (h *Headscale) scimGetUser(req *http.Request) (int, any, error) {
+	id, err := scimResourceID(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	user, groups, err := h.scimUserWithGroups(id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, h.scimUserFromUser(user, groups), nil
+}
+
+func (h *Headscale) scimUserWithGroups(id uint) (*types.User, []types.SCIMGroup, error) {
+	user, err := h.db.GetUserByID(types.UserID(id))
+	if errors.Is(err, db.ErrUserNotFound) {
+		return nil, nil, errSCIMNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups, err := db.Read(h.db.DB, db.ListSCIMGroups)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, groups, nil
+}
+
+// scimCreateUser creates a user, or takes over an existing user which has
+// not been provisioned via SCIM yet. These are users which logged in with
+// OIDC before with the same email, or users which have been deleted via
+// SCIM but kept because of their nodes.
+func (h *Headscale) scimCreateUser(req *http.Request) (int, any, error) {
+	var resource scimUser
+	if err := decodeSCIMBody(req, &resource); err != nil {
+		return 0, nil, err
+	}
+
+	deprovisioned, err := db.Write(h.db.DB, func(tx *gorm.DB) (types.Nodes, error) {
+		user := types.User{Provider: types.SCIMProvider}
+		if err := resource.applyToUser(&user); err != nil {
+			return nil, err
+		}
+
+		existing, err := scimAdoptableUser(tx, &user)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing != nil {
+			scimMergeUser(existing, &user)
+			user = *existing
+		}
+
+		if err := scimCheckUserName(tx, &user); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Save(&user).Error; err != nil {
+			return nil, fmt.Errorf("saving SCIM user: %w", err)
+		}
+		resource.ID = user.StringID()
+
+		return h.scimSetUserActive(tx, &user, resource.Active == nil || bool(*resource.Active))
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	h.scimNotifyUsersChanged(req.Context(), deprovisioned)
+
+	id, _ := strconv.ParseUint(resource.ID, util.Base10, 64)
+	user, groups, err := h.scimUserWithGroups(uint(id))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, h.scimUserFromUser(user, groups), nil
+}
+
+// scimMergeUser copies the attributes of the SCIM user to the existing
+// user. The name and email of users of other providers, e.g. OIDC, are
+// left alone, their provider sets them again on every login.
+func scimMergeUser(existing *types.User, user *types.User) {
+	existing.SCIMExternalID = user.SCIMExternalID
+	existing.DisplayName = user.DisplayName
+	if existing.Provider == types.SCIMProvider {
+		existing.Name = user.Name
+		existing.Email = user.Email
+	}
+}
+
+// scimAdoptableUser returns the existing user which is taken over by a
+// SCIM user which is about to be created, if any.
+func scimAdoptableUser(tx *gorm.DB, user *types.User) (*types.User, error) {
+	var candidates []types.User
+	query := tx.Where("scim_external_id = '' OR scim_external_id IS NULL")
+	if user.Email != "" {
+		query = query.Where(
+			tx.Where("email = ?", user.Email).
+				Or("name = ? AND provider = ?", user.Name, types.SCIMProvider),
+		)
+	} else {
+		query = query.Where("name = ? AND provider = ?", user.Name, types.SCIMProvider)
+	}
+
+	if err := query.Order("id").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	return &candidates[0], nil
+}
+
+// scimCheckUserName returns a uniqueness error if the name of the user
+// is taken, see the unique index idx_name_no_provider_identifier.
+func scimCheckUserName(tx *gorm.DB, user *types.User) error {
+	if user.ProviderIdentifier.Valid {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&types.User{}).
+		Where("name = ? AND provider_identifier IS NULL AND id <> ?", user.Name, user.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return newSCIMError(http.StatusConflict, "uniqueness", fmt.Sprintf("user %q already exists", user.Name))
+	}
+
+	return nil
+}
+
+// scimSetUserActive deactivates or reactivates the user if its state
+// changes and returns the nodes deprovisioned by a deactivation.
+func (h *Headscale) scimSetUserActive(tx *gorm.DB, user *types.User, active bool) (types.Nodes, error) {
+	switch {
+	case active && user.Deactivated:
+		return nil, db.ReactivateUser(tx, types.UserID(user.ID))
+	case !active && !user.Deactivated:
+		return db.DeactivateUser(tx, types.UserID(user.ID), h.cfg.SCIM.DeprovisionAction)
+	}
+
+	return nil, nil
+}
+
+func (h *Headscale) scimReplaceUser(req *http.Request) (int, any, error) {
+	var resource scimUser
+	if err := decodeSCIMBody(req, &resource); err != nil {
+		return 0, nil, err
+	}
+
+	return h.scimUpdateUser(req, func(*scimUser) (*scimUser, error) {
+		return &resource, nil
+	})
+}
+
+func (h *Headscale) scimPatchUser(req *http.Request) (int, any, error) {
+	var patch scimPatchRequest
+	if err := decodeSCIMBody(req, &patch); err != nil {
+		return 0, nil, err
+	}
+
+	return h.scimUpdateUser(req, func(resource *scimUser) (*scimUser, error) {
+		for _, op := range patch.Operations {
+			if err := resource.applyPatch(op); err != nil {
+				return nil, err
+			}
+		}
+
+		return resource, nil
+	})
+}
+
+// scimUpdateUser updates the user with the resource returned by update,
+// which is given the current SCIM representation of the user.
+func (h *Headscale) scimUpdateUser(
+	req *http.Request,
+	update func(resource *scimUser) (*scimUser, error),
+) (int, any, error) {
+	id, err := scimResourceID(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	deprovisioned, err := db.Write(h.db.DB, func(tx *gorm.DB) (types.Nodes, error) {
+		user, err := db.GetUserByID(tx, types.UserID(id))
+		if errors.Is(err, db.ErrUserNotFound) {
+			return nil, errSCIMNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resource, err := update(h.scimUserFromUser(user, nil))
+		if err != nil {
+			return nil, err
+		}
+
+		var updated types.User
+		if err := resource.applyToUser(&updated); err != nil {
+			return nil, err
+		}
+		scimMergeUser(user, &updated)
+
+		if err := scimCheckUserName(tx, user); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Save(user).Error; err != nil {
+			return nil, fmt.Errorf("saving SCIM user: %w", err)
+		}
+
+		return h.scimSetUserActive(tx, user, resource.Active == nil || bool(*resource.Active))
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	h.scimNotifyUsersChanged(req.Context(), deprovisioned)
+
+	user, groups, err := h.scimUserWithGroups(id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, h.scimUserFromUser(user, groups), nil
+}
+
+// scimDeleteUser deprovisions the user. With the delete action the user is
+// deleted along with its nodes, with the expire action the user is kept
+// deactivated so its expired nodes remain visible until an administrator
+// removes them.
+func (h *Headscale) scimDeleteUser(req *http.Request) (int, any, error) {
+	id, err := scimResourceID(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	deprovisioned, err := db.Write(h.db.DB, func(tx *gorm.DB) (types.Nodes, error) {
+		user, err := db.GetUserByID(tx, types.UserID(id))
+		if errors.Is(err, db.ErrUserNotFound) {
+			return nil, errSCIMNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		nodes, err := db.DeactivateUser(tx, types.UserID(user.ID), h.cfg.SCIM.DeprovisionAction)
+		if err != nil {
+			return nil, err
+		}
+
+		if h.cfg.SCIM.DeprovisionAction == types.SCIMDeprovisionActionDelete {
+			return nodes, db.DestroyUser(tx, types.UserID(user.ID))
+		}
+
+		if err := tx.Model(user).Updates(map[string]any{
+			"scim_external_id": "",
+			"scim_groups":      "[]",
+		}).Error; err != nil {
+			return nil, fmt.Errorf("unlinking SCIM user: %w", err)
+		}
+
+		return nodes, nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	h.scimNotifyUsersChanged(req.Context(), deprovisioned)
+
+	return http.StatusNoContent, nil, nil
+}
+
+// scimNotifyUsersChanged updates the policy after users changed and notifies
+// about the nodes which have been expired or deleted by a deprovisioning.
+func (h *Headscale) scimNotifyUsersChanged(ctx context.Context, deprovisioned types.Nodes) {
+	if len(deprovisioned) > 0 {
+		if h.cfg.SCIM.DeprovisionAction == types.SCIMDeprovisionActionDelete {
+			ctx := types.NotifyCtx(ctx, "scim-deprovision-delete", "all")
+			h.nodeNotifier.NotifyAll(ctx, types.UpdatePeerRemoved(deprovisioned.IDs()...))
+		} else {
+			for _, node := range deprovisioned {
+				if node.Expiry == nil {
+					continue
+				}
+
+				ctx := types.NotifyCtx(ctx, "scim-deprovision-expire-self", node.Hostname)
+				h.nodeNotifier.NotifyByNodeID(ctx, types.UpdateSelf(node.ID), node.ID)
+
+				ctx = types.NotifyCtx(ctx, "scim-deprovision-expire-peers", node.Hostname)
+				h.nodeNotifier.NotifyWithIgnore(ctx, types.UpdateExpire(node.ID, *node.Expiry), node.ID)
+			}
+		}
+	}
+
+	if err := usersChangedHook(h.db, h.polMan, h.nodeNotifier); err != nil {
+		log.Error().Caller().Err(err).Msg("failed to update policy after SCIM change")
+	}
+
+	if _, err := nodesChangedHook(h.db, h.polMan, h.nodeNotifier); err != nil {
+		log.Error().Caller().Err(err).Msg("failed to update nodes after SCIM change")
+	}
+}
+
+func (h *Headscale) scimGroupFromGroup(group *types.SCIMGroup, members types.Users) *scimGroup {
+	resource := &scimGroup{
+		Schemas:     []string{scimSchemaGroup},
+		ID:          strconv.FormatUint(uint64(group.ID), util.Base10),
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Members:     []scimMultiValue{},
+		Meta: &scimMeta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+			Location:     h.scimLocation("Groups", group.ID),
+		},
+	}
+
+	for _, member := range members {
+		resource.Members = append(resource.Members, scimMultiValue{
+			Value:   member.StringID(),
+			Display: member.Name,
+			Ref:     h.scimLocation("Users", member.ID),
+		})
+	}
+
+	return resource
+}
+
+func scimGroupAttribute(resource *scimGroup, attr string) []string {
+	switch attr {
+	case "id":
+		return []string{resource.ID}
+	case "displayname":
+		return []string{resource.DisplayName}
+	case "externalid":
+		return []string{resource.ExternalID}
+	case "members", "members.value":
+		var members []string
+		for _, member := range resource.Members {
+			members = append(members, member.Value)
+		}
+
+		return members
+	}
+
+	return nil
+}
+
+func (h *Headscale) scimListGroups(req *http.Request) (int, any, error) {
+	groups, err := db.Read(h.db.DB, db.ListSCIMGroups)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	users, err := h.db.ListUsers()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Members are omitted if requested, the groups of large
+	// organisations can have many members.
+	excludeMembers := strings.Contains(strings.ToLower(req.URL.Query().Get("excludedAttributes")), "members")
+
+	resources := make([]*scimGroup, 0, len(groups))
+	for _, group := range groups {
+		var members types.Users
+		if !excludeMembers {
+			for _, user := range users {
+				if slices.Contains(user.SCIMGroups, group.PolicyName) {
+					members = append(members, user)
+				}
+			}
+		}
+
+		resources = append(resources, h.scimGroupFromGroup(&group, members))
+	}
+
+	resp, err := scimListResponseFromRequest(req, resources, scimGroupAttribute)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, resp, nil
+}
+
+func (h *Headscale) scimGetGroup(req *http.Request) (int, any, error) {
+	id, err := scimResourceID(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return h.scimGroupResponse(http.StatusOK, id)
+}
+
+func (h *Headscale) scimGroupResponse(code int, id uint) (int, any, error) {
+	type groupWithMembers struct {
+		group   *types.SCIMGroup
+		members types.Users
+	}
+
+	result, err := db.Read(h.db.DB, func(rx *gorm.DB) (*groupWithMembers, error) {
+		group, err := db.GetSCIMGroup(rx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		members, err := db.ListSCIMGroupMembers(rx, group)
+		if err != nil {
+			return nil, err
+		}
+
+		return &groupWithMembers{group: group, members: members}, nil
+	})
+	if errors.Is(err, db.ErrSCIMGroupNotFound) {
+		return 0, nil, errSCIMNotFound
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return code, h.scimGroupFromGroup(result.group, result.members), nil
+}
+
+// scimMemberIDs returns the IDs of the given members, all of them
+// must be existing users.
+func scimMemberIDs(tx *gorm.DB, members []scimMultiValue) ([]types.UserID, error) {
+	ids := make([]types.UserID, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member.Value, util.Base10, 64)
+		if err != nil {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid member %q", member.Value))
+		}
+
+		if _, err := db.GetUserByID(tx, types.UserID(id)); err != nil {
+			if errors.Is(err, db.ErrUserNotFound) {
+				return nil, newSCIMError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("member %q does not exist", member.Value))
+			}
+
+			return nil, err
+		}
+
+		ids = append(ids, types.UserID(id))
+	}
+
+	return ids, nil
+}
+
+func scimGroupError(err error) error {
+	if errors.Is(err, db.ErrSCIMGroupExists) {
+		return newSCIMError(http.StatusConflict, "uniqueness", err.Error())
+	}
+	if errors.Is(err, db.ErrSCIMGroupNotFound) {
+		return errSCIMNotFound
+	}
+
+	return err
+}
+
+func (h *Headscale) scimCreateGroup(req *http.Request) (int, any, error) {
+	var resource scimGroup
+	if err := decodeSCIMBody(req, &resource); err != nil {
+		return 0, nil, err
+	}
+
+	if resource.DisplayName == "" {
+		return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	group, err := db.Write(h.db.DB, func(tx *gorm.DB) (*types.SCIMGroup, error) {
+		ids, err := scimMemberIDs(tx, resource.Members)
+		if err != nil {
+			return nil, err
+		}
+
+		group, err := db.CreateSCIMGroup(tx, types.SCIMGroup{
+			DisplayName: resource.DisplayName,
+			ExternalID:  resource.ExternalID,
+		}, h.cfg.SCIM.GroupsPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		return group, db.SetSCIMGroupMembers(tx, group, ids)
+	})
+	if err != nil {
+		return 0, nil, scimGroupError(err)
+	}
+
+	h.scimNotifyUsersChanged(req.Context(), nil)
+
+	return h.scimGroupResponse(http.StatusCreated, group.ID)
+}
+
+func (h *Headscale) scimReplaceGroup(req *http.Request) (int, any, error) {
+	var resource scimGroup
+	if err := decodeSCIMBody(req, &resource); err != nil {
+		return 0, nil, err
+	}
+
+	if resource.DisplayName == "" {
+		return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	id, err := scimResourceID(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = h.db.Write(func(tx *gorm.DB) error {
+		group, err := db.GetSCIMGroup(tx, id)
+		if err != nil {
+			return err
+		}
+
+		ids, err := scimMemberIDs(tx, resource.Members)
+		if err != nil {
+			return err
+		}
+
+		group.DisplayName = resource.DisplayName
+		group.ExternalID = resource.ExternalID
+		if err := db.UpdateSCIMGroup(tx, group, h.cfg.SCIM.GroupsPrefix); err != nil {
+			return err
+		}
+
+		return db.SetSCIMGroupMembers(tx, group, ids)
+	})
+	if err != nil {
+		return 0, nil, scimGroupError(err)
+	}
+
+	h.scimNotifyUsersChanged(req.Context(), nil)
+
+	return h.scimGroupResponse(http.StatusOK, id)
+}
+
+func (h *Headscale) scimPatchGroup(req *http.Request) (int, any, error) {
+	var patch scimPatchRequest
+	if err := decodeSCIMBody(req, &patch); err != nil {
+		return 0, nil, err
+	}
+
+	id, err := scimResourceID(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = h.db.Write(func(tx *gorm.DB) error {
+		group, err := db.GetSCIMGroup(tx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, op := range patch.Operations {
+			if err := applySCIMGroupPatch(tx, group, op, h.cfg.SCIM.GroupsPrefix); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, nil, scimGroupError(err)
+	}
+
+	h.scimNotifyUsersChanged(req.Context(), nil)
+
+	// Clients are allowed to not expect a body, but most of them
+	// handle the updated resource better than no content.
+	return h.scimGroupResponse(http.StatusOK, id)
+}
+
+// applySCIMGroupPatch applies a SCIM patch operation to the group and its
+// members, see RFC 7644, section 3.5.2. Operations on unsupported attributes
+// are ignored.
+func applySCIMGroupPatch(tx *gorm.DB, group *types.SCIMGroup, op scimPatchOperation, prefix string) error {
+	if op.Path == "" {
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", "patch operation without path requires an object value")
+		}
+
+		for path, value := range values {
+			if err := applySCIMGroupPatch(tx, group, scimPatchOperation{Op: op.Op, Path: path, Value: value}, prefix); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	opName := strings.ToLower(op.Op)
+	path := strings.ToLower(op.Path)
+
+	switch {
+	case path == "displayname" || path == "externalid":
+		var value string
+		if opName != "remove" {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return newSCIMError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid value for %s", op.Path))
+			}
+		}
+
+		if path == "displayname" {
+			if value == "" {
+				return newSCIMError(http.StatusBadRequest, "invalidValue", "displayName is required")
+			}
+			group.DisplayName = value
+		} else {
+			group.ExternalID = value
+		}
+
+		return db.UpdateSCIMGroup(tx, group, prefix)
+
+	case path == "members":
+		var members []scimMultiValue
+		if len(op.Value) > 0 && string(op.Value) != "null" {
+			if err := json.Unmarshal(op.Value, &members); err != nil {
+				return newSCIMError(http.StatusBadRequest, "invalidValue", "invalid value for members")
+			}
+		}
+
+		if opName == "remove" && len(members) == 0 {
+			return db.SetSCIMGroupMembers(tx, group, nil)
+		}
+
+		ids, err := scimMemberIDs(tx, members)
+		if err != nil {
+			return err
+		}
+
+		switch opName {
+		case "add":
+			return db.AddSCIMGroupMembers(tx, group, ids)
+		case "remove":
+			return db.RemoveSCIMGroupMembers(tx, group, ids)
+		default:
+			return db.SetSCIMGroupMembers(tx, group, ids)
+		}
+
+	case scimMemberFilterRegex.MatchString(op.Path):
+		if opName != "remove" {
+			return newSCIMError(http.StatusBadRequest, "invalidPath", fmt.Sprintf("unsupported operation %s on %s", op.Op, op.Path))
+		}
+
+		id, err := strconv.ParseUint(scimMemberFilterRegex.FindStringSubmatch(op.Path)[1], util.Base10, 64)
+		if err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("invalid member in %s", op.Path))
+		}
+
+		return db.RemoveSCIMGroupMembers(tx, group, []types.UserID{types.UserID(id)})
+	}
+
+	log.Debug().Str("path", op.Path).Msg("ignoring SCIM patch operation on unsupported attribute")
+
+	return nil
+}
+
+func (h *Headscale) scimDeleteGroup(req *http.Request) (int, any, error) {
+	id, err := scimResourceID(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = h.db.Write(func(tx *gorm.DB) error {
+		group, err := db.GetSCIMGroup(tx, id)
+		if err != nil {
+			return err
+		}
+
+		return db.DeleteSCIMGroup(tx, group)
+	})
+	if err != nil {
+		return 0, nil, scimGroupError(err)
+	}
+
+	h.scimNotifyUsersChanged(req.Context(), nil)
+
+	return http.StatusNoContent, nil, nil
+}
@@ -0,0 +1,259 @@
+package hscontrol
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tailscale.com/types/key"
+)
+
+func newSCIMTestServer(t *testing.T, action types.SCIMDeprovisionAction) (*Headscale, *mux.Router) {
+	t.Helper()
+
+	h := newTestHeadscale(t, func(cfg *types.Config) {
+		cfg.SCIM = types.SCIMConfig{
+			Enabled:           true,
+			Token:             "scim-secret",
+			GroupsPrefix:      "group:scim-",
+			DeprovisionAction: action,
+		}
+	})
+
+	router := mux.NewRouter()
+	h.registerSCIMRoutes(router)
+
+	return h, router
+}
+
+func scimRequest(t *testing.T, router *mux.Router, method, path, body string) (int, map[string]any) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer scim-secret")
+	req.Header.Set("Content-Type", scimContentType)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp map[string]any
+	if rec.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+
+	return rec.Code, resp
+}
+
+func TestSCIMAuthentication(t *testing.T) {
+	_, router := newSCIMTestServer(t, types.SCIMDeprovisionActionExpire)
+
+	req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	code, _ := scimRequest(t, router, http.MethodGet, "/scim/v2/Users", "")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestSCIMUserLifecycle(t *testing.T) {
+	h, router := newSCIMTestServer(t, types.SCIMDeprovisionActionExpire)
+
+	code, user := scimRequest(t, router, http.MethodPost, "/scim/v2/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "alice@example.com",
+		"externalId": "00u1",
+		"name": {"formatted": "Alice Example"},
+		"emails": [{"value": "alice@example.com", "primary": true}],
+		"active": true
+	}`)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "alice@example.com", user["userName"])
+	assert.Equal(t, "Alice Example", user["displayName"])
+	assert.Equal(t, true, user["active"])
+	id := user["id"].(string)
+
+	code, _ = scimRequest(t, router, http.MethodPost, "/scim/v2/Users", `{"userName": "alice@example.com", "externalId": "00u2"}`)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, list := scimRequest(t, router, http.MethodGet, `/scim/v2/Users?filter=userName%20eq%20%22ALICE@example.com%22`, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.InDelta(t, 1, list["totalResults"], 0)
+
+	dbUser, err := h.db.GetUserByName("alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, types.SCIMProvider, dbUser.Provider)
+
+	node := types.Node{
+		MachineKey: key.NewMachine().Public(),
+		NodeKey:    key.NewNode().Public(),
+		Hostname:   "alice-laptop",
+		UserID:     dbUser.ID,
+	}
+	require.NoError(t, h.db.DB.Save(&node).Error)
+
+	code, user = scimRequest(t, router, http.MethodPatch, "/scim/v2/Users/"+id, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "Replace", "path": "active", "value": "False"}]
+	}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, user["active"])
+
+	dbNode, err := h.db.GetNodeByID(node.ID)
+	require.NoError(t, err)
+	require.NotNil(t, dbNode.Expiry)
+	assert.True(t, dbNode.Expiry.Before(time.Now().Add(time.Second)))
+
+	code, user = scimRequest(t, router, http.MethodPatch, "/scim/v2/Users/"+id, `{
+		"Operations": [{"op": "replace", "value": {"active": true, "displayName": "Alice"}}]
+	}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, user["active"])
+	assert.Equal(t, "Alice", user["displayName"])
+
+	code, _ = scimRequest(t, router, http.MethodDelete, "/scim/v2/Users/"+id, "")
+	require.Equal(t, http.StatusNoContent, code)
+
+	// With the expire action the user is kept deactivated with its
+	// nodes, and taken over when it is provisioned again.
+	dbUser, err = h.db.GetUserByName("alice@example.com")
+	require.NoError(t, err)
+	assert.True(t, dbUser.Deactivated)
+	assert.Empty(t, dbUser.SCIMExternalID)
+
+	code, user = scimRequest(t, router, http.MethodPost, "/scim/v2/Users", `{"userName": "alice@example.com", "externalId": "00u3"}`)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, id, user["id"])
+	assert.Equal(t, true, user["active"])
+}
+
+func TestSCIMUpdateKeepsNameOfOIDCUser(t *testing.T) {
+	h, router := newSCIMTestServer(t, types.SCIMDeprovisionActionExpire)
+
+	oidcUser, err := h.db.CreateUser(types.User{
+		Name:     "carol",
+		Email:    "carol@example.com",
+		Provider: util.RegisterMethodOIDC,
+		ProviderIdentifier: sql.NullString{
+			String: "https://sso.example.com/carol",
+			Valid:  true,
+		},
+	})
+	require.NoError(t, err)
+
+	// The OIDC user is adopted by its email.
+	code, user := scimRequest(t, router, http.MethodPost, "/scim/v2/Users", `{
+		"userName": "carol.s",
+		"externalId": "00u4",
+		"emails": [{"value": "carol@example.com", "primary": true}]
+	}`)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, oidcUser.StringID(), user["id"])
+
+	code, user = scimRequest(t, router, http.MethodPut, "/scim/v2/Users/"+oidcUser.StringID(), `{
+		"userName": "carol.smith",
+		"externalId": "00u4",
+		"displayName": "Carol Smith",
+		"emails": [{"value": "carol.smith@example.com", "primary": true}]
+	}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Carol Smith", user["displayName"])
+
+	// The name and email are set by the OIDC provider on login.
+	dbUser, err := h.db.GetUserByID(types.UserID(oidcUser.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "carol", dbUser.Name)
+	assert.Equal(t, "carol@example.com", dbUser.Email)
+	assert.Equal(t, "Carol Smith", dbUser.DisplayName)
+	assert.Equal(t, "00u4", dbUser.SCIMExternalID)
+}
+
+func TestSCIMDeleteUserWithDeleteAction(t *testing.T) {
+	h, router := newSCIMTestServer(t, types.SCIMDeprovisionActionDelete)
+
+	code, user := scimRequest(t, router, http.MethodPost, "/scim/v2/Users", `{"userName": "bob"}`)
+	require.Equal(t, http.StatusCreated, code)
+
+	dbUser, err := h.db.GetUserByName("bob")
+	require.NoError(t, err)
+
+	node := types.Node{
+		MachineKey: key.NewMachine().Public(),
+		NodeKey:    key.NewNode().Public(),
+		Hostname:   "bob-laptop",
+		UserID:     dbUser.ID,
+	}
+	require.NoError(t, h.db.DB.Save(&node).Error)
+
+	code, _ = scimRequest(t, router, http.MethodDelete, "/scim/v2/Users/"+user["id"].(string), "")
+	require.Equal(t, http.StatusNoContent, code)
+
+	_, err = h.db.GetNodeByID(node.ID)
+	require.Error(t, err)
+
+	code, _ = scimRequest(t, router, http.MethodGet, "/scim/v2/Users/"+user["id"].(string), "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestSCIMGroupMembership(t *testing.T) {
+	h, router := newSCIMTestServer(t, types.SCIMDeprovisionActionExpire)
+
+	_, alice := scimRequest(t, router, http.MethodPost, "/scim/v2/Users", `{"userName": "alice"}`)
+	_, bob := scimRequest(t, router, http.MethodPost, "/scim/v2/Users", `{"userName": "bob"}`)
+	aliceID, bobID := alice["id"].(string), bob["id"].(string)
+
+	code, group := scimRequest(t, router, http.MethodPost, "/scim/v2/Groups", `{
+		"displayName": "engineering",
+		"members": [{"value": "`+aliceID+`"}]
+	}`)
+	require.Equal(t, http.StatusCreated, code)
+	groupID := group["id"].(string)
+
+	code, _ = scimRequest(t, router, http.MethodPost, "/scim/v2/Groups", `{"displayName": "engineering"}`)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, _ = scimRequest(t, router, http.MethodPatch, "/scim/v2/Groups/"+groupID, `{
+		"Operations": [{"op": "add", "path": "members", "value": [{"value": "`+bobID+`"}]}]
+	}`)
+	require.Equal(t, http.StatusOK, code)
+
+	bobUser, err := h.db.GetUserByName("bob")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"group:scim-engineering"}, bobUser.SCIMGroups)
+	assert.True(t, bobUser.InGroup("group:scim-engineering"))
+
+	code, _ = scimRequest(t, router, http.MethodPatch, "/scim/v2/Groups/"+groupID, `{
+		"Operations": [
+			{"op": "remove", "path": "members[value eq \"`+aliceID+`\"]"},
+			{"op": "replace", "path": "displayName", "value": "platform"}
+		]
+	}`)
+	require.Equal(t, http.StatusOK, code)
+
+	aliceUser, err := h.db.GetUserByName("alice")
+	require.NoError(t, err)
+	assert.Empty(t, aliceUser.SCIMGroups)
+
+	bobUser, err = h.db.GetUserByName("bob")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"group:scim-platform"}, bobUser.SCIMGroups)
+
+	_, bob = scimRequest(t, router, http.MethodGet, "/scim/v2/Users/"+bobID, "")
+	require.Len(t, bob["groups"], 1)
+
+	code, _ = scimRequest(t, router, http.MethodDelete, "/scim/v2/Groups/"+groupID, "")
+	require.Equal(t, http.StatusNoContent, code)
+
+	bobUser, err = h.db.GetUserByName("bob")
+	require.NoError(t, err)
+	assert.Empty(t, bobUser.SCIMGroups)
+}
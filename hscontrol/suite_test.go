@@ -3,8 +3,10 @@ package hscontrol
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/check.v1"
 )
 
@@ -54,3 +56,36 @@ func (s *Suite) ResetDB(c *check.C) {
 		c.Fatal(err)
 	}
 }
+
+// newTestHeadscale creates a headscale instance with a SQLite database in
+// a temporary directory, modify can adjust the configuration.
+func newTestHeadscale(t *testing.T, modify func(cfg *types.Config)) *Headscale {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	cfg := types.Config{
+		ServerURL:           "https://headscale.example.com",
+		NoisePrivateKeyPath: tmpDir + "/noise_private.key",
+		Database: types.DatabaseConfig{
+			Type: "sqlite3",
+			Sqlite: types.SqliteConfig{
+				Path: tmpDir + "/headscale_test.db",
+			},
+		},
+		Policy: types.PolicyConfig{
+			Mode: types.PolicyModeDB,
+		},
+		Tuning: types.Tuning{
+			BatchChangeDelay:    time.Second,
+			NotifierSendTimeout: time.Second,
+		},
+	}
+	if modify != nil {
+		modify(&cfg)
+	}
+
+	h, err := NewHeadscale(&cfg)
+	require.NoError(t, err)
+
+	return h
+}
@@ -0,0 +1,135 @@
+package templates
+
+import (
+	"html"
+
+	"github.com/chasefleming/elem-go"
+	"github.com/chasefleming/elem-go/attrs"
+	"github.com/chasefleming/elem-go/styles"
+)
+
+var localFormFieldStyle = styles.Props{
+	styles.Display:      "block",
+	styles.MarginBottom: "10px",
+}
+
+var localFormErrorStyle = styles.Props{
+	styles.Color: "#b00",
+}
+
+// localFormField renders a labeled input of a local login form.
+func localFormField(label, name, inputType, autocomplete string, required bool) elem.Node {
+	props := attrs.Props{
+		attrs.ID:           name,
+		attrs.Name:         name,
+		attrs.Type:         inputType,
+		attrs.Autocomplete: autocomplete,
+	}
+	if required {
+		props[attrs.Required] = "true"
+	}
+
+	return elem.Div(attrs.Props{attrs.Style: localFormFieldStyle.ToInline()},
+		elem.Label(attrs.Props{attrs.For: name}, elem.Text(label)),
+		elem.Br(nil),
+		elem.Input(props),
+	)
+}
+
+// localFormMessage renders the error or success message of a form, if any.
+func localFormMessage(message string, isError bool) elem.Node {
+	if message == "" {
+		return elem.None()
+	}
+
+	var props attrs.Props
+	if isError {
+		props = attrs.Props{attrs.Style: localFormErrorStyle.ToInline()}
+	}
+
+	return elem.P(props, elem.Text(html.EscapeString(message)))
+}
+
+// LocalLogin is the login form of local users, registering the machine
+// the registration was started from.
+func LocalLogin(action, csrf, errMsg string) *elem.Element {
+	return HtmlStructure(
+		elem.Title(nil, elem.Text("Registration - Headscale")),
+		elem.Body(attrs.Props{
+			attrs.Style: bodyStyle.ToInline(),
+		},
+			headerOne("headscale"),
+			headerTwo("Machine registration"),
+			elem.P(nil, elem.Text("Log in to add this machine to your network:")),
+			localFormMessage(errMsg, true),
+			elem.Form(attrs.Props{attrs.Method: "POST", attrs.Action: action},
+				elem.Input(attrs.Props{attrs.Type: "hidden", attrs.Name: "csrf", attrs.Value: csrf}),
+				localFormField("Username", "username", "text", "username", true),
+				localFormField("Password", "password", "password", "current-password", true),
+				localFormField("Code from your authenticator app, if enabled", "code", "text", "one-time-code", false),
+				elem.Button(attrs.Props{attrs.Type: "submit"}, elem.Text("Log in")),
+			),
+			elem.P(nil,
+				elem.A(attrs.Props{attrs.Href: "/login/password"}, elem.Text("Change your password")),
+			),
+		),
+	)
+}
+
+// LocalPasswordChange is the form local users change their password with.
+func LocalPasswordChange(csrf, message string, isError bool) *elem.Element {
+	return HtmlStructure(
+		elem.Title(nil, elem.Text("Change password - Headscale")),
+		elem.Body(attrs.Props{
+			attrs.Style: bodyStyle.ToInline(),
+		},
+			headerOne("headscale"),
+			headerTwo("Change password"),
+			localFormMessage(message, isError),
+			elem.Form(attrs.Props{attrs.Method: "POST", attrs.Action: "/login/password"},
+				elem.Input(attrs.Props{attrs.Type: "hidden", attrs.Name: "csrf", attrs.Value: csrf}),
+				localFormField("Username", "username", "text", "username", true),
+				localFormField("Current password", "password", "password", "current-password", true),
+				localFormField("Code from your authenticator app, if enabled", "code", "text", "one-time-code", false),
+				localFormField("New password", "new_password", "password", "new-password", true),
+				localFormField("Repeat new password", "new_password_repeat", "password", "new-password", true),
+				elem.Button(attrs.Props{attrs.Type: "submit"}, elem.Text("Change password")),
+			),
+		),
+	)
+}
+
+// RegistrationSuccess is shown once the machine has been registered after
+// a login with a local password, a SAML identity provider or a forward-auth
+// proxy.
+func RegistrationSuccess(user, verb string) *elem.Element {
+	return HtmlStructure(
+		elem.Title(nil, elem.Text("Registration - Headscale")),
+		elem.Body(attrs.Props{
+			attrs.Style: bodyStyle.ToInline(),
+		},
+			headerOne("headscale"),
+			headerTwo("Machine registration"),
+			elem.P(nil, elem.Text(html.EscapeString(verb+" as "+user+", you can now close this window."))),
+		),
+	)
+}
+
+// ForwardAuthConfirm asks the user logged in by the forward-auth proxy to
+// confirm the registration of the machine.
+func ForwardAuthConfirm(action, csrf, user string) *elem.Element {
+	return HtmlStructure(
+		elem.Title(nil, elem.Text("Registration - Headscale")),
+		elem.Body(attrs.Props{
+			attrs.Style: bodyStyle.ToInline(),
+		},
+			headerOne("headscale"),
+			headerTwo("Machine registration"),
+			elem.P(nil, elem.Text(html.EscapeString("Add this machine to the network of "+user+"?"))),
+			elem.Form(attrs.Props{attrs.Method: "POST", attrs.Action: action},
+				elem.Input(attrs.Props{attrs.Type: "hidden", attrs.Name: "csrf", attrs.Value: csrf}),
+				elem.Button(attrs.Props{attrs.Type: "submit"}, elem.Text("Register")),
+			),
+		),
+	)
+}
@@ -0,0 +1,41 @@
+package templates
+
+import (
+	"html"
+
+	"github.com/chasefleming/elem-go"
+	"github.com/chasefleming/elem-go/attrs"
+)
+
+// OIDCProviderLink is an identity provider offered on the registration page.
+type OIDCProviderLink struct {
+	DisplayName string
+	URL         string
+}
+
+// OIDCProviderChooser lets the user pick the identity provider to log in
+// with, if multiple OIDC providers are configured.
+func OIDCProviderChooser(links []OIDCProviderLink) *elem.Element {
+	return HtmlStructure(
+		elem.Title(nil, elem.Text("Registration - Headscale")),
+		elem.Body(attrs.Props{
+			attrs.Style: bodyStyle.ToInline(),
+		},
+			headerOne("headscale"),
+			headerTwo("Machine registration"),
+			elem.P(nil, elem.Text("Log in with your identity provider to add this machine to your network:")),
+			elem.Ul(nil,
+				elem.TransformEach(links, func(link OIDCProviderLink) elem.Node {
+					return elem.Li(nil,
+						elem.A(
+							attrs.Props{
+								attrs.Href: link.URL,
+							},
+							elem.Text(html.EscapeString(link.DisplayName)),
+						),
+					)
+				})...,
+			),
+		),
+	)
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"slices"
 	"time"
 
 	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
@@ -14,11 +15,25 @@ type APIKey struct {
 	Prefix string `gorm:"uniqueIndex"`
 	Hash   []byte
 
+	// Scopes limit the key like the scopes of OAuth access tokens,
+	// keys without scopes have full access.
+	Scopes []string `gorm:"serializer:json"`
+	// ProviderIdentifier is the identity of the admin the key was
+	// issued to by headscale login, the key is no longer valid once
+	// the user with this identity is suspended or deactivated.
+	ProviderIdentifier string
+
 	CreatedAt  *time.Time
 	Expiration *time.Time
 	LastSeen   *time.Time
 }
 
+// HasScope reports if the key has full access or the given scope.
+func (key *APIKey) HasScope(scope string) bool {
+	return len(key.Scopes) == 0 || slices.Contains(key.Scopes, OAuthScopeAll) ||
+		slices.Contains(key.Scopes, scope)
+}
+
 func (key *APIKey) Proto() *v1.ApiKey {
 	protoKey := v1.ApiKey{
 		Id:     key.ID,
@@ -0,0 +1,45 @@
+package types
+
+import "time"
+
+const (
+	// CLILoginDevicePath starts a headscale login with the device
+	// authorization flow of the OIDC provider.
+	CLILoginDevicePath = "/cli/login/device"
+	// CLILoginTokenPath is polled for the API key once the admin
+	// logged in.
+	CLILoginTokenPath = "/cli/login/token"
+
+	// CLILoginPending is the error of the token endpoint while the
+	// admin has not logged in yet, as in RFC 8628, section 3.5.
+	CLILoginPending = "authorization_pending"
+)
+
+// CLILoginDevice is the response of the device endpoint, telling the admin
+// where to log in.
+type CLILoginDevice struct {
+	// LoginID identifies the login when polling the token endpoint.
+	LoginID                 string    `json:"login_id"`
+	UserCode                string    `json:"user_code"`
+	VerificationURI         string    `json:"verification_uri"`
+	VerificationURIComplete string    `json:"verification_uri_complete,omitempty"`
+	Expiry                  time.Time `json:"expiry"`
+	// Interval is the number of seconds to wait between polls.
+	Interval int64 `json:"interval"`
+}
+
+// CLILoginTokenRequest polls the token endpoint for the API key.
+type CLILoginTokenRequest struct {
+	LoginID string `json:"login_id"`
+}
+
+// CLILoginToken is the response of the token endpoint, either the API key
+// issued to the admin or an error.
+type CLILoginToken struct {
+	APIKey     string    `json:"api_key,omitempty"`
+	Expiration time.Time `json:"expiration,omitzero"`
+	Scope      string    `json:"scope,omitempty"`
+
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
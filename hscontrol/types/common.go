@@ -195,4 +195,8 @@ func (r RegistrationID) String() string {
 type RegisterNode struct {
 	Node       Node
 	Registered chan *Node
+
+	// Naming decides the given name of the node if its hostname is
+	// already used when it is registered.
+	Naming NodeNaming
 }
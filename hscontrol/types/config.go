@@ -1,13 +1,20 @@
 package types
 
 import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io/fs"
 	"net/netip"
 	"net/url"
 	"os"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
@@ -24,17 +31,47 @@ import (
 )
 
 const (
-	defaultOIDCExpiryTime               = 180 * 24 * time.Hour // 180 Days
-	maxDuration           time.Duration = 1<<63 - 1
-	PKCEMethodPlain       string        = "plain"
-	PKCEMethodS256        string        = "S256"
+	defaultOIDCLogoutPath                 = "/oidc/logout"
+	defaultOIDCCallbackPath               = "/oidc/callback"
+	defaultOIDCExpiryTime                 = 180 * 24 * time.Hour // 180 Days
+	maxDuration             time.Duration = 1<<63 - 1
+	PKCEMethodPlain         string        = "plain"
+	PKCEMethodS256          string        = "S256"
 )
 
 var (
-	errOidcMutuallyExclusive = errors.New("oidc_client_secret and oidc_client_secret_path are mutually exclusive")
-	errServerURLSuffix       = errors.New("server_url cannot be part of base_domain in a way that could make the DERP and headscale server unreachable")
-	errServerURLSame         = errors.New("server_url cannot use the same domain as base_domain in a way that could make the DERP and headscale server unreachable")
-	errInvalidPKCEMethod     = errors.New("pkce.method must be either 'plain' or 'S256'")
+	errOidcMutuallyExclusive      = errors.New("oidc_client_secret and oidc_client_secret_path are mutually exclusive")
+	errInvalidOIDCGroupsPrefix    = errors.New(`oidc.groups.prefix must start with "group:"`)
+	errInvalidOIDCProviderName    = errors.New("OIDC provider name must only contain lowercase letters, digits and dashes")
+	errDuplicateOIDCProviderName  = errors.New("OIDC provider name must be unique")
+	errOIDCProviderIncomplete     = errors.New("OIDC provider requires an issuer and a client_id")
+	errOIDCRefreshTokensKey       = errors.New("oidc.refresh_tokens.encryption_key_path is required when refresh tokens are enabled")
+	errInvalidOIDCLogoutAction    = errors.New("oidc.backchannel_logout.action must be either 'expire' or 'suspend'")
+	errInvalidOIDCClaimRule       = errors.New("invalid oidc.claims.rules entry")
+	errCLILoginNoOIDC             = errors.New("oidc.cli_login requires an OIDC provider")
+	errCLILoginProviderNotFound   = errors.New("oidc.cli_login.provider is not a configured OIDC provider")
+	errCLILoginNoAdmins           = errors.New("oidc.cli_login requires admin_users or admin_groups")
+	errCLILoginGroupsNotSynced    = errors.New("oidc.cli_login.admin_groups requires the groups of the provider to be synced")
+	errInvalidCLILoginScope       = errors.New(`oidc.cli_login.scope must be either "all" or "all:read"`)
+	errSCIMTokenMutuallyExclusive = errors.New("scim.token and scim.token_path are mutually exclusive")
+	errInvalidSCIMGroupsPrefix    = errors.New(`scim.groups.prefix must start with "group:"`)
+	errSCIMTokenMissing           = errors.New("scim.token or scim.token_path is required when SCIM is enabled")
+	errLocalAuthWithOIDC          = errors.New("local_auth and oidc cannot be enabled at the same time")
+	errSAMLWithOtherAuth          = errors.New("saml cannot be enabled together with oidc or local_auth")
+	errSAMLIDPMetadataMissing     = errors.New("saml.idp_metadata_url or saml.idp_metadata_path is required when SAML is enabled")
+	errSAMLKeyPairIncomplete      = errors.New("saml.certificate_path and saml.key_path must be set together")
+	errInvalidSAMLGroupsPrefix    = errors.New(`saml.groups_prefix must start with "group:"`)
+	errSAMLSignRequestsNoKey      = errors.New("saml.sign_requests requires saml.certificate_path and saml.key_path")
+	errForwardAuthWithOtherAuth   = errors.New("forward_auth cannot be enabled together with oidc, local_auth or saml")
+	errForwardAuthNoProxies       = errors.New("forward_auth.trusted_proxies is required when forward_auth is enabled")
+	errInvalidForwardAuthPrefix   = errors.New(`forward_auth.groups_prefix must start with "group:"`)
+	errUnixSocketPeersUnsupported = errors.New("unix_socket_peers is only supported on Linux")
+	errGRPCClientAuthNoCA         = errors.New("grpc_client_auth.ca_path is required when grpc_client_auth is enabled")
+	errInvalidIPPool              = errors.New("invalid prefixes.pools entry")
+	errInvalidNodeCleanup         = errors.New("invalid node_cleanup")
+	errServerURLSuffix            = errors.New("server_url cannot be part of base_domain in a way that could make the DERP and headscale server unreachable")
+	errServerURLSame              = errors.New("server_url cannot use the same domain as base_domain in a way that could make the DERP and headscale server unreachable")
+	errInvalidPKCEMethod          = errors.New("pkce.method must be either 'plain' or 'S256'")
 )
 
 type IPAllocationStrategy string
@@ -44,6 +81,59 @@ const (
 	IPAllocationStrategyRandom     IPAllocationStrategy = "random"
 )
 
+// IPPool is a part of the tailnet prefixes the nodes of some users or
+// with some tags get their addresses from. Nodes matching no pool get
+// addresses outside of all pools.
+type IPPool struct {
+	Name     string
+	PrefixV4 *netip.Prefix
+	PrefixV6 *netip.Prefix
+
+	// Users are the names or emails of users, * matches every user.
+	Users []string
+
+	// Tags take precedence over Users, a node with one of the tags
+	// gets its addresses from the pool whatever its user is.
+	Tags []string
+}
+
+// NodeNameCollision decides what happens when the hostname of a new node
+// is already the name of another node.
+type NodeNameCollision string
+
+const (
+	// NodeNameCollisionSuffix appends a random suffix to the name.
+	NodeNameCollisionSuffix NodeNameCollision = "suffix"
+	// NodeNameCollisionReject rejects the registration.
+	NodeNameCollisionReject NodeNameCollision = "reject"
+	// NodeNameCollisionTakeover takes the name from the other node if it
+	// has the same hostname and user and is offline or expired, the
+	// other node gets a suffix instead.
+	NodeNameCollisionTakeover NodeNameCollision = "takeover"
+)
+
+// NodeNaming decides the given name of a new node whose hostname is
+// already the name of another node.
+type NodeNaming struct {
+	Collision NodeNameCollision
+
+	// IsOnline reports if a node is connected, the name of a
+	// connected node is only taken over if it is expired.
+	IsOnline func(NodeID) bool
+}
+
+var ErrInvalidNodeNameCollision = errors.New("invalid node name collision strategy, allowed options: suffix, reject, takeover")
+
+// ParseNodeNameCollision parses a strategy, empty is returned as is.
+func ParseNodeNameCollision(s string) (NodeNameCollision, error) {
+	switch collision := NodeNameCollision(s); collision {
+	case "", NodeNameCollisionSuffix, NodeNameCollisionReject, NodeNameCollisionTakeover:
+		return collision, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrInvalidNodeNameCollision, s)
+}
+
 type PolicyMode string
 
 const (
@@ -58,10 +148,14 @@ type Config struct {
 	MetricsAddr                    string
 	GRPCAddr                       string
 	GRPCAllowInsecure              bool
+	GRPCClientAuth                 GRPCClientAuthConfig
 	EphemeralNodeInactivityTimeout time.Duration
+	NodeCleanup                    NodeCleanupConfig
+	NodeNameCollision              NodeNameCollision
 	PrefixV4                       *netip.Prefix
 	PrefixV6                       *netip.Prefix
 	IPAllocation                   IPAllocationStrategy
+	IPPools                        []IPPool
 	NoisePrivateKeyPath            string
 	BaseDomain                     string
 	Log                            LogConfig
@@ -87,9 +181,24 @@ type Config struct {
 
 	UnixSocket           string
 	UnixSocketPermission fs.FileMode
+	UnixSocketPeers      UnixSocketPeersConfig
 
 	OIDC OIDCConfig
 
+	// OIDCProviders are additional OIDC providers users can choose
+	// from when registering a node, next to OIDC.
+	OIDCProviders []OIDCConfig
+
+	CLILogin CLILoginConfig
+
+	SCIM SCIMConfig
+
+	LocalAuth LocalAuthConfig
+
+	SAML SAMLConfig
+
+	ForwardAuth ForwardAuthConfig
+
 	LogTail             LogTailConfig
 	RandomizeClientPort bool
 
@@ -160,6 +269,90 @@ type TLSConfig struct {
 	LetsEncrypt LetsEncryptConfig
 }
 
+// GRPCClientAuthConfig configures the authentication of remote gRPC
+// clients with TLS client certificates, next to API keys.
+type GRPCClientAuthConfig struct {
+	Enabled bool
+	// CAPath is a PEM bundle of the CAs issuing client certificates.
+	CAPath string
+	// CRLPath is an optional certificate revocation list of the CAs,
+	// it is read again when it changes.
+	CRLPath string
+	// Admin and ReadOnly match the subject common name or a DNS, URI or
+	// email SAN of client certificates, a trailing * matches any suffix.
+	Admin    []string
+	ReadOnly []string
+}
+
+// NodeCleanupConfig configures the expiry and deletion of nodes that have
+// not been seen for a long time. Ephemeral nodes are left to the ephemeral
+// garbage collector.
+type NodeCleanupConfig struct {
+	// Enabled runs the cleanup every Interval, the report of stale
+	// nodes is available either way.
+	Enabled  bool
+	Interval time.Duration
+
+	// GracePeriod is how long before nodes are expired or deleted they
+	// are reported as stale.
+	GracePeriod time.Duration
+
+	NodeRetention
+
+	// Rules override the retention for the nodes of some users or with
+	// some tags, the first matching rule applies.
+	Rules []NodeRetentionRule
+
+	// Nodes with one of the ExcludeTags or with the ExcludeLabel are
+	// never cleaned up.
+	ExcludeTags  []string
+	ExcludeLabel string
+}
+
+// NodeRetention is how long after they were last seen nodes are expired
+// and deleted, zero never does.
+type NodeRetention struct {
+	ExpireAfter time.Duration
+	DeleteAfter time.Duration
+}
+
+type NodeRetentionRule struct {
+	// Users are the names or emails of users.
+	Users []string
+	// Tags take precedence over Users like for IP pools.
+	Tags []string
+
+	NodeRetention
+}
+
+// Retention returns the retention of the node with the given tags, and
+// false if the node is excluded from the cleanup.
+func (c NodeCleanupConfig) Retention(node *Node, tags []string) (NodeRetention, bool) {
+	if slices.ContainsFunc(c.ExcludeTags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+		return NodeRetention{}, false
+	}
+
+	if _, ok := node.Labels[c.ExcludeLabel]; ok && c.ExcludeLabel != "" {
+		return NodeRetention{}, false
+	}
+
+	for _, rule := range c.Rules {
+		if slices.ContainsFunc(rule.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			return rule.NodeRetention, true
+		}
+	}
+
+	for _, rule := range c.Rules {
+		if slices.ContainsFunc(rule.Users, func(name string) bool {
+			return name == node.User.Name || name == node.User.Email
+		}) {
+			return rule.NodeRetention, true
+		}
+	}
+
+	return c.NodeRetention, true
+}
+
 type LetsEncryptConfig struct {
 	Listen        string
 	Hostname      string
@@ -172,7 +365,73 @@ type PKCEConfig struct {
 	Method  string
 }
 
+// OIDCGroupsConfig configures if and how the groups claim of a user
+// is stored to be used as groups in the policy.
+type OIDCGroupsConfig struct {
+	Sync   bool
+	Prefix string
+}
+
+// OIDCRefreshTokensConfig configures if the refresh tokens of OIDC logins
+// are stored to extend the expiry of nodes while the identity provider
+// still issues tokens for the user.
+type OIDCRefreshTokensConfig struct {
+	Enabled bool
+	// EncryptionKey is the key the stored refresh tokens are
+	// encrypted with.
+	EncryptionKey []byte
+	// Interval is how often the tokens are refreshed.
+	Interval time.Duration
+}
+
+// OIDCLogoutAction is what happens to the nodes of a user which is
+// logged out by the identity provider.
+type OIDCLogoutAction string
+
+const (
+	OIDCLogoutActionExpire  OIDCLogoutAction = "expire"
+	OIDCLogoutActionSuspend OIDCLogoutAction = "suspend"
+)
+
+// OIDCBackChannelLogoutConfig configures OpenID Connect Back-Channel
+// Logout, which allows the identity provider to cut off the nodes of a
+// user when its session is terminated.
+type OIDCBackChannelLogoutConfig struct {
+	Enabled bool
+	Action  OIDCLogoutAction
+}
+
+// OIDCClaimRule adds tags and groups to users which have a claim with a
+// value matching the expression. If the claim is a list, any of its values
+// has to match.
+type OIDCClaimRule struct {
+	// Claim is the name of the claim, nested claims are separated
+	// by dots, e.g. "realm_access.roles".
+	Claim  string
+	Match  *regexp.Regexp
+	Tags   []string
+	Groups []string
+}
+
+// OIDCClaimsConfig configures how the claims of a user are mapped to the
+// user and its nodes.
+type OIDCClaimsConfig struct {
+	// Username and DisplayName are templates executed with the claims
+	// of the user, if set they replace the preferred_username and name
+	// claims.
+	Username    *template.Template
+	DisplayName *template.Template
+	Rules       []OIDCClaimRule
+}
+
 type OIDCConfig struct {
+	// Name identifies the provider in the registration URL and
+	// its callback path.
+	Name string
+	// DisplayName is shown to the user when choosing a provider.
+	DisplayName                string
+	CallbackPath               string
+	LogoutPath                 string
 	OnlyStartIfOIDCIsAvailable bool
 	Issuer                     string
 	ClientID                   string
@@ -185,6 +444,142 @@ type OIDCConfig struct {
 	Expiry                     time.Duration
 	UseExpiryFromToken         bool
 	PKCE                       PKCEConfig
+	Groups                     OIDCGroupsConfig
+	RefreshTokens              OIDCRefreshTokensConfig
+	BackChannelLogout          OIDCBackChannelLogoutConfig
+	Claims                     OIDCClaimsConfig
+}
+
+// CLILoginConfig configures headscale login, which issues short-lived API
+// keys to admins logging in with the OAuth 2.0 device authorization flow
+// of an OIDC provider.
+type CLILoginConfig struct {
+	Enabled bool
+	// Provider is the name of the OIDC provider admins log in with.
+	Provider string
+	// AdminUsers are the emails and AdminGroups the groups claims of the
+	// admins allowed to log in.
+	AdminUsers  []string
+	AdminGroups []string
+	// GroupsPrefix is the prefix of the policy groups synced from the
+	// groups claim of the provider.
+	GroupsPrefix string
+	// Expiry is how long the issued API keys are valid.
+	Expiry time.Duration
+	// Scope limits the issued API keys, see OAuthScopeAll and
+	// OAuthScopeAllRead.
+	Scope string
+}
+
+// IsAdmin reports if the verified email of the user or one of its policy
+// groups synced from the provider is in the admin lists.
+func (c *CLILoginConfig) IsAdmin(user *User) bool {
+	if !c.Enabled {
+		return false
+	}
+
+	if user.Email != "" && slices.Contains(c.AdminUsers, user.Email) {
+		return true
+	}
+
+	return slices.ContainsFunc(c.AdminGroups, func(group string) bool {
+		return slices.Contains(user.Groups, c.GroupsPrefix+group)
+	})
+}
+
+// SCIMConfig configures the SCIM 2.0 provisioning endpoint.
+type SCIMConfig struct {
+	Enabled           bool
+	Token             string
+	GroupsPrefix      string
+	DeprovisionAction SCIMDeprovisionAction
+}
+
+// LocalAuthConfig configures the login of nodes with the password and
+// optional TOTP code of a local user.
+type LocalAuthConfig struct {
+	Enabled           bool
+	MinPasswordLength int
+	// LockoutAttempts is the number of failed logins after which a user
+	// is locked out for LockoutDuration, zero disables the lockout.
+	LockoutAttempts int
+	LockoutDuration time.Duration
+	// TOTPIssuer is the issuer shown in authenticator apps.
+	TOTPIssuer string
+}
+
+// SAMLConfig configures SAML 2.0 authentication, with headscale as service
+// provider.
+type SAMLConfig struct {
+	Enabled bool
+	// EntityID of headscale, defaults to the URL of the metadata.
+	EntityID string
+
+	// The metadata of the identity provider is read from the URL or
+	// the file.
+	IDPMetadataURL  string
+	IDPMetadataPath string
+
+	// CertificatePath and KeyPath are the optional key pair of
+	// headscale, used to sign authentication requests and decrypt
+	// encrypted assertions.
+	CertificatePath string
+	KeyPath         string
+	SignRequests    bool
+
+	Expiry     time.Duration
+	Attributes SAMLAttributesConfig
+	// GroupsPrefix is prepended to the groups of the user, if the
+	// groups attribute is set.
+	GroupsPrefix string
+}
+
+// SAMLAttributesConfig names the attributes of an assertion the user is
+// updated from. The username falls back to the NameID, groups are only
+// stored if the attribute is set.
+type SAMLAttributesConfig struct {
+	Username    string
+	DisplayName string
+	Email       string
+	Groups      string
+}
+
+// ForwardAuthConfig configures the login of nodes with the identity a
+// trusted reverse proxy, like oauth2-proxy or Authelia, sets in headers.
+type ForwardAuthConfig struct {
+	Enabled bool
+	// TrustedProxies are the source addresses the identity headers
+	// are accepted from.
+	TrustedProxies []netip.Prefix
+	Headers        ForwardAuthHeadersConfig
+	// GroupsPrefix is prepended to the groups of the user, if the
+	// groups header is set.
+	GroupsPrefix string
+}
+
+// ForwardAuthHeadersConfig names the headers carrying the identity of the
+// user. Groups are a comma separated list, and only stored if the header
+// is set.
+type ForwardAuthHeadersConfig struct {
+	User        string
+	Email       string
+	DisplayName string
+	Groups      string
+}
+
+// UnixSocketPeersConfig maps the local users and groups of the processes
+// calling the gRPC API on the unix socket to roles. Callers matching
+// neither role are denied.
+type UnixSocketPeersConfig struct {
+	Enabled  bool
+	Admin    UnixSocketRoleConfig
+	ReadOnly UnixSocketRoleConfig
+}
+
+// UnixSocketRoleConfig lists local users and groups by name or numeric ID.
+type UnixSocketRoleConfig struct {
+	Users  []string
+	Groups []string
 }
 
 type DERPConfig struct {
@@ -209,10 +604,12 @@ type LogTailConfig struct {
 }
 
 type CLIConfig struct {
-	Address  string
-	APIKey   string
-	Timeout  time.Duration
-	Insecure bool
+	Address        string
+	APIKey         string
+	Timeout        time.Duration
+	Insecure       bool
+	ClientCertPath string
+	ClientKeyPath  string
 }
 
 type PolicyConfig struct {
@@ -235,6 +632,21 @@ type Tuning struct {
 	NodeMapSessionBufferedChanSize int
 }
 
+// OIDCConfigs returns all configured OIDC providers, starting with the
+// top-level provider if it is configured.
+func (c *Config) OIDCConfigs() []*OIDCConfig {
+	var cfgs []*OIDCConfig
+	if c.OIDC.Issuer != "" {
+		cfgs = append(cfgs, &c.OIDC)
+	}
+
+	for i := range c.OIDCProviders {
+		cfgs = append(cfgs, &c.OIDCProviders[i])
+	}
+
+	return cfgs
+}
+
 func validatePKCEMethod(method string) error {
 	if method != PKCEMethodPlain && method != PKCEMethodS256 {
 		return errInvalidPKCEMethod
@@ -300,6 +712,13 @@ func LoadConfig(path string, isFile bool) error {
 
 	viper.SetDefault("unix_socket", "/var/run/headscale/headscale.sock")
 	viper.SetDefault("unix_socket_permission", "0o770")
+	viper.SetDefault("unix_socket_peers.enabled", false)
+
+	viper.SetDefault("grpc_client_auth.enabled", false)
+
+	viper.SetDefault("node_cleanup.enabled", false)
+	viper.SetDefault("node_cleanup.interval", "1h")
+	viper.SetDefault("node_cleanup.grace_period", "7d")
 
 	viper.SetDefault("grpc_listen_addr", ":50443")
 	viper.SetDefault("grpc_allow_insecure", false)
@@ -315,12 +734,49 @@ func LoadConfig(path string, isFile bool) error {
 	viper.SetDefault("database.sqlite.write_ahead_log", true)
 	viper.SetDefault("database.sqlite.wal_autocheckpoint", 1000) // SQLite default
 
+	viper.SetDefault("oidc.name", "default")
 	viper.SetDefault("oidc.scope", []string{oidc.ScopeOpenID, "profile", "email"})
 	viper.SetDefault("oidc.only_start_if_oidc_is_available", true)
 	viper.SetDefault("oidc.expiry", "180d")
 	viper.SetDefault("oidc.use_expiry_from_token", false)
 	viper.SetDefault("oidc.pkce.enabled", false)
 	viper.SetDefault("oidc.pkce.method", "S256")
+	viper.SetDefault("oidc.groups.sync", false)
+	viper.SetDefault("oidc.groups.prefix", "group:")
+	viper.SetDefault("oidc.refresh_tokens.enabled", false)
+	viper.SetDefault("oidc.refresh_tokens.interval", "1h")
+	viper.SetDefault("oidc.backchannel_logout.enabled", false)
+	viper.SetDefault("oidc.backchannel_logout.action", string(OIDCLogoutActionExpire))
+
+	viper.SetDefault("oidc.cli_login.enabled", false)
+	viper.SetDefault("oidc.cli_login.expiry", "1h")
+	viper.SetDefault("oidc.cli_login.scope", OAuthScopeAll)
+
+	viper.SetDefault("scim.enabled", false)
+	viper.SetDefault("scim.groups.prefix", "group:scim-")
+	viper.SetDefault("scim.deprovision_action", string(SCIMDeprovisionActionExpire))
+
+	viper.SetDefault("local_auth.enabled", false)
+	viper.SetDefault("local_auth.min_password_length", 12)
+	viper.SetDefault("local_auth.lockout.attempts", 5)
+	viper.SetDefault("local_auth.lockout.duration", "15m")
+	viper.SetDefault("local_auth.totp_issuer", "headscale")
+
+	viper.SetDefault("saml.enabled", false)
+	viper.SetDefault("saml.sign_requests", false)
+	viper.SetDefault("saml.expiry", "180d")
+	viper.SetDefault("saml.attributes.username", "uid")
+	viper.SetDefault("saml.attributes.display_name", "displayName")
+	viper.SetDefault("saml.attributes.email", "mail")
+	viper.SetDefault("saml.attributes.groups", "")
+	viper.SetDefault("saml.groups_prefix", "group:saml-")
+
+	viper.SetDefault("forward_auth.enabled", false)
+	viper.SetDefault("forward_auth.headers.user", "X-Forwarded-User")
+	viper.SetDefault("forward_auth.headers.email", "X-Forwarded-Email")
+	viper.SetDefault("forward_auth.headers.display_name", "")
+	viper.SetDefault("forward_auth.headers.groups", "")
+	viper.SetDefault("forward_auth.groups_prefix", "group:forward-auth-")
 
 	viper.SetDefault("logtail.enabled", false)
 	viper.SetDefault("randomize_client_port", false)
@@ -333,6 +789,8 @@ func LoadConfig(path string, isFile bool) error {
 
 	viper.SetDefault("prefixes.allocation", string(IPAllocationStrategySequential))
 
+	viper.SetDefault("node_name_collision", string(NodeNameCollisionSuffix))
+
 	if err := viper.ReadInConfig(); err != nil {
 		return fmt.Errorf("fatal error reading config file: %w", err)
 	}
@@ -372,6 +830,53 @@ func validateServerConfig() error {
 		if err := validatePKCEMethod(viper.GetString("oidc.pkce.method")); err != nil {
 			return err
 		}
+
+		if viper.GetBool("oidc.groups.sync") && !strings.HasPrefix(viper.GetString("oidc.groups.prefix"), "group:") {
+			return errInvalidOIDCGroupsPrefix
+		}
+	}
+
+	if viper.GetBool("scim.enabled") {
+		if !strings.HasPrefix(viper.GetString("scim.groups.prefix"), "group:") {
+			return errInvalidSCIMGroupsPrefix
+		}
+
+		switch SCIMDeprovisionAction(viper.GetString("scim.deprovision_action")) {
+		case SCIMDeprovisionActionExpire, SCIMDeprovisionActionDelete:
+		default:
+			return fmt.Errorf(
+				"scim.deprovision_action must be either %q or %q",
+				SCIMDeprovisionActionExpire,
+				SCIMDeprovisionActionDelete,
+			)
+		}
+	}
+
+	if viper.GetBool("local_auth.enabled") &&
+		(viper.GetString("oidc.issuer") != "" || viper.IsSet("oidc.providers")) {
+		return errLocalAuthWithOIDC
+	}
+
+	if viper.GetBool("saml.enabled") {
+		if viper.GetBool("local_auth.enabled") ||
+			viper.GetString("oidc.issuer") != "" || viper.IsSet("oidc.providers") {
+			return errSAMLWithOtherAuth
+		}
+
+		if !strings.HasPrefix(viper.GetString("saml.groups_prefix"), "group:") {
+			return errInvalidSAMLGroupsPrefix
+		}
+	}
+
+	if viper.GetBool("forward_auth.enabled") {
+		if viper.GetBool("local_auth.enabled") || viper.GetBool("saml.enabled") ||
+			viper.GetString("oidc.issuer") != "" || viper.IsSet("oidc.providers") {
+			return errForwardAuthWithOtherAuth
+		}
+
+		if !strings.HasPrefix(viper.GetString("forward_auth.groups_prefix"), "group:") {
+			return errInvalidForwardAuthPrefix
+		}
 	}
 
 	depr.Log()
@@ -532,6 +1037,624 @@ func policyConfig() PolicyConfig {
 	}
 }
 
+var oidcProviderNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// oidcConfigs reads the top-level OIDC provider and the providers listed
+// in oidc.providers. Listed providers inherit the scope, expiry, PKCE and
+// groups settings of the top-level provider unless they set them.
+func oidcConfigs() (OIDCConfig, []OIDCConfig, error) {
+	cfg, err := readOIDCConfig(viper.GetViper(), "oidc.")
+	if err != nil {
+		return OIDCConfig{}, nil, err
+	}
+	cfg.Name = viper.GetString("oidc.name")
+	cfg.DisplayName = cmp.Or(viper.GetString("oidc.display_name"), cfg.Name)
+	cfg.CallbackPath = defaultOIDCCallbackPath
+	cfg.LogoutPath = defaultOIDCLogoutPath
+	if cfg.Issuer != "" && !oidcProviderNameRegex.MatchString(cfg.Name) {
+		return OIDCConfig{}, nil, fmt.Errorf("%w: %q", errInvalidOIDCProviderName, cfg.Name)
+	}
+
+	var entries []map[string]any
+	if err := viper.UnmarshalKey("oidc.providers", &entries); err != nil {
+		return OIDCConfig{}, nil, fmt.Errorf("reading oidc.providers: %w", err)
+	}
+
+	names := set.SetOf([]string{cfg.Name})
+	providers := make([]OIDCConfig, 0, len(entries))
+	for _, entry := range entries {
+		v := viper.New()
+		if err := v.MergeConfigMap(entry); err != nil {
+			return OIDCConfig{}, nil, fmt.Errorf("reading oidc.providers: %w", err)
+		}
+
+		for _, key := range []string{
+			"only_start_if_oidc_is_available",
+			"scope",
+			"expiry",
+			"use_expiry_from_token",
+			"pkce.enabled",
+			"pkce.method",
+			"groups.sync",
+			"groups.prefix",
+			"refresh_tokens.enabled",
+			"refresh_tokens.encryption_key_path",
+			"refresh_tokens.interval",
+			"backchannel_logout.enabled",
+			"backchannel_logout.action",
+			"claims.username",
+			"claims.display_name",
+			"claims.rules",
+		} {
+			v.SetDefault(key, viper.Get("oidc."+key))
+		}
+
+		provider, err := readOIDCConfig(v, "")
+		if err != nil {
+			return OIDCConfig{}, nil, err
+		}
+
+		provider.Name = v.GetString("name")
+		provider.DisplayName = cmp.Or(v.GetString("display_name"), provider.Name)
+		provider.CallbackPath = defaultOIDCCallbackPath + "/" + provider.Name
+		provider.LogoutPath = defaultOIDCLogoutPath + "/" + provider.Name
+
+		if !oidcProviderNameRegex.MatchString(provider.Name) {
+			return OIDCConfig{}, nil, fmt.Errorf("%w: %q", errInvalidOIDCProviderName, provider.Name)
+		}
+		if names.Contains(provider.Name) {
+			return OIDCConfig{}, nil, fmt.Errorf("%w: %q", errDuplicateOIDCProviderName, provider.Name)
+		}
+		names.Add(provider.Name)
+
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return OIDCConfig{}, nil, fmt.Errorf("%w: %q", errOIDCProviderIncomplete, provider.Name)
+		}
+		if err := validatePKCEMethod(provider.PKCE.Method); err != nil {
+			return OIDCConfig{}, nil, fmt.Errorf("OIDC provider %q: %w", provider.Name, err)
+		}
+		if provider.Groups.Sync && !strings.HasPrefix(provider.Groups.Prefix, "group:") {
+			return OIDCConfig{}, nil, fmt.Errorf("OIDC provider %q: %w", provider.Name, errInvalidOIDCGroupsPrefix)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return cfg, providers, nil
+}
+
+// readOIDCConfig reads the settings of a single OIDC provider from v,
+// with all keys prefixed by prefix.
+func readOIDCConfig(v *viper.Viper, prefix string) (OIDCConfig, error) {
+	clientSecret := v.GetString(prefix + "client_secret")
+	clientSecretPath := v.GetString(prefix + "client_secret_path")
+	if clientSecretPath != "" && clientSecret != "" {
+		return OIDCConfig{}, errOidcMutuallyExclusive
+	}
+	if clientSecretPath != "" {
+		secretBytes, err := os.ReadFile(os.ExpandEnv(clientSecretPath))
+		if err != nil {
+			return OIDCConfig{}, err
+		}
+		clientSecret = strings.TrimSpace(string(secretBytes))
+	}
+
+	refreshTokens := OIDCRefreshTokensConfig{
+		Enabled:  v.GetBool(prefix + "refresh_tokens.enabled"),
+		Interval: v.GetDuration(prefix + "refresh_tokens.interval"),
+	}
+	if refreshTokens.Enabled {
+		keyPath := v.GetString(prefix + "refresh_tokens.encryption_key_path")
+		if keyPath == "" {
+			return OIDCConfig{}, errOIDCRefreshTokensKey
+		}
+		keyBytes, err := os.ReadFile(os.ExpandEnv(keyPath))
+		if err != nil {
+			return OIDCConfig{}, err
+		}
+		// Any secret can be used as key, it is hashed to the
+		// size of an AES-256 key.
+		key := sha256.Sum256(bytes.TrimSpace(keyBytes))
+		refreshTokens.EncryptionKey = key[:]
+	}
+
+	claims, err := readOIDCClaimsConfig(v, prefix)
+	if err != nil {
+		return OIDCConfig{}, err
+	}
+
+	logoutAction := OIDCLogoutAction(v.GetString(prefix + "backchannel_logout.action"))
+	switch logoutAction {
+	case OIDCLogoutActionExpire, OIDCLogoutActionSuspend:
+	default:
+		return OIDCConfig{}, errInvalidOIDCLogoutAction
+	}
+
+	return OIDCConfig{
+		OnlyStartIfOIDCIsAvailable: v.GetBool(
+			prefix + "only_start_if_oidc_is_available",
+		),
+		Issuer:         v.GetString(prefix + "issuer"),
+		ClientID:       v.GetString(prefix + "client_id"),
+		ClientSecret:   clientSecret,
+		Scope:          v.GetStringSlice(prefix + "scope"),
+		ExtraParams:    v.GetStringMapString(prefix + "extra_params"),
+		AllowedDomains: v.GetStringSlice(prefix + "allowed_domains"),
+		AllowedUsers:   v.GetStringSlice(prefix + "allowed_users"),
+		AllowedGroups:  v.GetStringSlice(prefix + "allowed_groups"),
+		Expiry: func() time.Duration {
+			// if set to 0, we assume no expiry
+			if value := v.GetString(prefix + "expiry"); value == "0" {
+				return maxDuration
+			} else {
+				expiry, err := model.ParseDuration(value)
+				if err != nil {
+					log.Warn().Msgf("failed to parse %sexpiry, defaulting back to 180 days", prefix)
+
+					return defaultOIDCExpiryTime
+				}
+
+				return time.Duration(expiry)
+			}
+		}(),
+		UseExpiryFromToken: v.GetBool(prefix + "use_expiry_from_token"),
+		PKCE: PKCEConfig{
+			Enabled: v.GetBool(prefix + "pkce.enabled"),
+			Method:  v.GetString(prefix + "pkce.method"),
+		},
+		Groups: OIDCGroupsConfig{
+			Sync:   v.GetBool(prefix + "groups.sync"),
+			Prefix: v.GetString(prefix + "groups.prefix"),
+		},
+		RefreshTokens: refreshTokens,
+		BackChannelLogout: OIDCBackChannelLogoutConfig{
+			Enabled: v.GetBool(prefix + "backchannel_logout.enabled"),
+			Action:  logoutAction,
+		},
+		Claims: claims,
+	}, nil
+}
+
+// oidcClaimsTemplateFuncs are the functions available in the templates of
+// oidc.claims.
+var oidcClaimsTemplateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace":    strings.ReplaceAll,
+}
+
+// readOIDCClaimsConfig reads and compiles the claim mapping of an OIDC
+// provider.
+func readOIDCClaimsConfig(v *viper.Viper, prefix string) (OIDCClaimsConfig, error) {
+	var cfg OIDCClaimsConfig
+
+	parse := func(key string) (*template.Template, error) {
+		text := v.GetString(prefix + key)
+		if text == "" {
+			return nil, nil
+		}
+
+		tmpl, err := template.New(key).
+			Funcs(oidcClaimsTemplateFuncs).
+			Option("missingkey=error").
+			Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s%s: %w", prefix, key, err)
+		}
+
+		return tmpl, nil
+	}
+
+	var err error
+	if cfg.Username, err = parse("claims.username"); err != nil {
+		return OIDCClaimsConfig{}, err
+	}
+	if cfg.DisplayName, err = parse("claims.display_name"); err != nil {
+		return OIDCClaimsConfig{}, err
+	}
+
+	var rules []struct {
+		Claim  string
+		Match  string
+		Tags   []string
+		Groups []string
+	}
+	if err := v.UnmarshalKey(prefix+"claims.rules", &rules); err != nil {
+		return OIDCClaimsConfig{}, fmt.Errorf("%w: %w", errInvalidOIDCClaimRule, err)
+	}
+
+	for _, rule := range rules {
+		if rule.Claim == "" {
+			return OIDCClaimsConfig{}, fmt.Errorf("%w: claim is required", errInvalidOIDCClaimRule)
+		}
+
+		match, err := regexp.Compile(cmp.Or(rule.Match, ".*"))
+		if err != nil {
+			return OIDCClaimsConfig{}, fmt.Errorf("%w: claim %q: %w", errInvalidOIDCClaimRule, rule.Claim, err)
+		}
+
+		for _, tag := range rule.Tags {
+			if !strings.HasPrefix(tag, "tag:") {
+				return OIDCClaimsConfig{}, fmt.Errorf("%w: claim %q: tag %q must start with \"tag:\"", errInvalidOIDCClaimRule, rule.Claim, tag)
+			}
+		}
+		for _, group := range rule.Groups {
+			if !strings.HasPrefix(group, "group:") {
+				return OIDCClaimsConfig{}, fmt.Errorf("%w: claim %q: group %q must start with \"group:\"", errInvalidOIDCClaimRule, rule.Claim, group)
+			}
+		}
+
+		cfg.Rules = append(cfg.Rules, OIDCClaimRule{
+			Claim:  rule.Claim,
+			Match:  match,
+			Tags:   rule.Tags,
+			Groups: rule.Groups,
+		})
+	}
+
+	return cfg, nil
+}
+
+// cliLoginConfig reads the settings of headscale login, the provider must
+// be one of the configured OIDC providers.
+func cliLoginConfig(oidc OIDCConfig, providers []OIDCConfig) (CLILoginConfig, error) {
+	if !viper.GetBool("oidc.cli_login.enabled") {
+		return CLILoginConfig{}, nil
+	}
+
+	cfg := CLILoginConfig{
+		Enabled:     true,
+		Provider:    viper.GetString("oidc.cli_login.provider"),
+		AdminUsers:  viper.GetStringSlice("oidc.cli_login.admin_users"),
+		AdminGroups: viper.GetStringSlice("oidc.cli_login.admin_groups"),
+		Expiry:      viper.GetDuration("oidc.cli_login.expiry"),
+		Scope:       viper.GetString("oidc.cli_login.scope"),
+	}
+
+	if oidc.Issuer != "" {
+		providers = append([]OIDCConfig{oidc}, providers...)
+	}
+
+	if len(providers) == 0 {
+		return CLILoginConfig{}, errCLILoginNoOIDC
+	}
+
+	cfg.Provider = cmp.Or(cfg.Provider, providers[0].Name)
+	index := slices.IndexFunc(providers, func(p OIDCConfig) bool { return p.Name == cfg.Provider })
+	if index < 0 {
+		return CLILoginConfig{}, fmt.Errorf("%w: %q", errCLILoginProviderNotFound, cfg.Provider)
+	}
+	provider := providers[index]
+
+	if len(cfg.AdminUsers) == 0 && len(cfg.AdminGroups) == 0 {
+		return CLILoginConfig{}, errCLILoginNoAdmins
+	}
+
+	// The groups of admins are rechecked with the groups stored for
+	// their user.
+	if len(cfg.AdminGroups) > 0 && !provider.Groups.Sync {
+		return CLILoginConfig{}, errCLILoginGroupsNotSynced
+	}
+	cfg.GroupsPrefix = provider.Groups.Prefix
+
+	if cfg.Scope != OAuthScopeAll && cfg.Scope != OAuthScopeAllRead {
+		return CLILoginConfig{}, errInvalidCLILoginScope
+	}
+
+	return cfg, nil
+}
+
+func scimConfig() (SCIMConfig, error) {
+	if !viper.GetBool("scim.enabled") {
+		return SCIMConfig{}, nil
+	}
+
+	token := viper.GetString("scim.token")
+	tokenPath := viper.GetString("scim.token_path")
+	if tokenPath != "" && token != "" {
+		return SCIMConfig{}, errSCIMTokenMutuallyExclusive
+	}
+	if tokenPath != "" {
+		tokenBytes, err := os.ReadFile(os.ExpandEnv(tokenPath))
+		if err != nil {
+			return SCIMConfig{}, err
+		}
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+	if token == "" {
+		return SCIMConfig{}, errSCIMTokenMissing
+	}
+
+	return SCIMConfig{
+		Enabled:           true,
+		Token:             token,
+		GroupsPrefix:      viper.GetString("scim.groups.prefix"),
+		DeprovisionAction: SCIMDeprovisionAction(viper.GetString("scim.deprovision_action")),
+	}, nil
+}
+
+func localAuthConfig() LocalAuthConfig {
+	return LocalAuthConfig{
+		Enabled:           viper.GetBool("local_auth.enabled"),
+		MinPasswordLength: viper.GetInt("local_auth.min_password_length"),
+		LockoutAttempts:   viper.GetInt("local_auth.lockout.attempts"),
+		LockoutDuration:   viper.GetDuration("local_auth.lockout.duration"),
+		TOTPIssuer:        viper.GetString("local_auth.totp_issuer"),
+	}
+}
+
+func forwardAuthConfig() (ForwardAuthConfig, error) {
+	if !viper.GetBool("forward_auth.enabled") {
+		return ForwardAuthConfig{}, nil
+	}
+
+	cfg := ForwardAuthConfig{
+		Enabled: true,
+		Headers: ForwardAuthHeadersConfig{
+			User:        viper.GetString("forward_auth.headers.user"),
+			Email:       viper.GetString("forward_auth.headers.email"),
+			DisplayName: viper.GetString("forward_auth.headers.display_name"),
+			Groups:      viper.GetString("forward_auth.headers.groups"),
+		},
+		GroupsPrefix: viper.GetString("forward_auth.groups_prefix"),
+	}
+
+	for _, proxy := range viper.GetStringSlice("forward_auth.trusted_proxies") {
+		prefix, err := parsePrefixOrAddr(proxy)
+		if err != nil {
+			return ForwardAuthConfig{}, fmt.Errorf("parsing forward_auth.trusted_proxies: %w", err)
+		}
+
+		cfg.TrustedProxies = append(cfg.TrustedProxies, prefix)
+	}
+
+	if len(cfg.TrustedProxies) == 0 {
+		return ForwardAuthConfig{}, errForwardAuthNoProxies
+	}
+
+	return cfg, nil
+}
+
+func grpcClientAuthConfig() (GRPCClientAuthConfig, error) {
+	if !viper.GetBool("grpc_client_auth.enabled") {
+		return GRPCClientAuthConfig{}, nil
+	}
+
+	cfg := GRPCClientAuthConfig{
+		Enabled:  true,
+		CAPath:   util.AbsolutePathFromConfigPath(viper.GetString("grpc_client_auth.ca_path")),
+		CRLPath:  util.AbsolutePathFromConfigPath(viper.GetString("grpc_client_auth.crl_path")),
+		Admin:    viper.GetStringSlice("grpc_client_auth.admin"),
+		ReadOnly: viper.GetStringSlice("grpc_client_auth.read_only"),
+	}
+
+	if cfg.CAPath == "" {
+		return GRPCClientAuthConfig{}, errGRPCClientAuthNoCA
+	}
+
+	return cfg, nil
+}
+
+func nodeCleanupConfig() (NodeCleanupConfig, error) {
+	duration := func(key string) (time.Duration, error) {
+		value := viper.GetString(key)
+		if value == "" || value == "0" {
+			return 0, nil
+		}
+
+		d, err := model.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s: %w", errInvalidNodeCleanup, key, err)
+		}
+
+		return time.Duration(d), nil
+	}
+
+	retention := func(name string, r NodeRetention) error {
+		if r.ExpireAfter == 0 && r.DeleteAfter == 0 {
+			return fmt.Errorf("%w: %s requires expire_after or delete_after", errInvalidNodeCleanup, name)
+		}
+
+		if r.ExpireAfter != 0 && r.DeleteAfter != 0 && r.DeleteAfter <= r.ExpireAfter {
+			return fmt.Errorf("%w: %s: delete_after must be longer than expire_after", errInvalidNodeCleanup, name)
+		}
+
+		return nil
+	}
+
+	var cfg NodeCleanupConfig
+	var err error
+	cfg.Enabled = viper.GetBool("node_cleanup.enabled")
+	cfg.ExcludeTags = viper.GetStringSlice("node_cleanup.exclude_tags")
+	cfg.ExcludeLabel = viper.GetString("node_cleanup.exclude_label")
+
+	if cfg.Interval, err = duration("node_cleanup.interval"); err != nil {
+		return NodeCleanupConfig{}, err
+	}
+	if cfg.GracePeriod, err = duration("node_cleanup.grace_period"); err != nil {
+		return NodeCleanupConfig{}, err
+	}
+	if cfg.ExpireAfter, err = duration("node_cleanup.expire_after"); err != nil {
+		return NodeCleanupConfig{}, err
+	}
+	if cfg.DeleteAfter, err = duration("node_cleanup.delete_after"); err != nil {
+		return NodeCleanupConfig{}, err
+	}
+
+	var rules []struct {
+		Users       []string
+		Tags        []string
+		ExpireAfter string `mapstructure:"expire_after"`
+		DeleteAfter string `mapstructure:"delete_after"`
+	}
+	if err := viper.UnmarshalKey("node_cleanup.rules", &rules); err != nil {
+		return NodeCleanupConfig{}, fmt.Errorf("%w: rules: %w", errInvalidNodeCleanup, err)
+	}
+
+	for i, entry := range rules {
+		name := fmt.Sprintf("rules[%d]", i)
+		rule := NodeRetentionRule{
+			Users: entry.Users,
+			Tags:  entry.Tags,
+		}
+
+		for _, field := range []struct {
+			value string
+			to    *time.Duration
+		}{
+			{entry.ExpireAfter, &rule.ExpireAfter},
+			{entry.DeleteAfter, &rule.DeleteAfter},
+		} {
+			if field.value == "" || field.value == "0" {
+				continue
+			}
+
+			d, err := model.ParseDuration(field.value)
+			if err != nil {
+				return NodeCleanupConfig{}, fmt.Errorf("%w: %s: %w", errInvalidNodeCleanup, name, err)
+			}
+			*field.to = time.Duration(d)
+		}
+
+		if len(rule.Users) == 0 && len(rule.Tags) == 0 {
+			return NodeCleanupConfig{}, fmt.Errorf("%w: %s requires users or tags", errInvalidNodeCleanup, name)
+		}
+
+		if err := retention(name, rule.NodeRetention); err != nil {
+			return NodeCleanupConfig{}, err
+		}
+
+		cfg.Rules = append(cfg.Rules, rule)
+	}
+
+	for _, tag := range append(cfg.ExcludeTags, ruleTags(cfg.Rules)...) {
+		if !strings.HasPrefix(tag, "tag:") {
+			return NodeCleanupConfig{}, fmt.Errorf("%w: tag %q must start with \"tag:\"", errInvalidNodeCleanup, tag)
+		}
+	}
+
+	if cfg.ExcludeLabel != "" {
+		if err := ValidateLabel(cfg.ExcludeLabel, ""); err != nil {
+			return NodeCleanupConfig{}, fmt.Errorf("%w: exclude_label: %w", errInvalidNodeCleanup, err)
+		}
+	}
+
+	if cfg.ExpireAfter != 0 || cfg.DeleteAfter != 0 {
+		if err := retention("node_cleanup", cfg.NodeRetention); err != nil {
+			return NodeCleanupConfig{}, err
+		}
+	} else if cfg.Enabled && len(cfg.Rules) == 0 {
+		return NodeCleanupConfig{}, fmt.Errorf("%w: requires expire_after, delete_after or rules", errInvalidNodeCleanup)
+	}
+
+	if cfg.Enabled && cfg.Interval <= 0 {
+		return NodeCleanupConfig{}, fmt.Errorf("%w: interval must be positive", errInvalidNodeCleanup)
+	}
+
+	return cfg, nil
+}
+
+func ruleTags(rules []NodeRetentionRule) []string {
+	var tags []string
+	for _, rule := range rules {
+		tags = append(tags, rule.Tags...)
+	}
+
+	return tags
+}
+
+func unixSocketPeersConfig() (UnixSocketPeersConfig, error) {
+	if !viper.GetBool("unix_socket_peers.enabled") {
+		return UnixSocketPeersConfig{}, nil
+	}
+
+	if runtime.GOOS != "linux" {
+		return UnixSocketPeersConfig{}, errUnixSocketPeersUnsupported
+	}
+
+	return UnixSocketPeersConfig{
+		Enabled: true,
+		Admin: UnixSocketRoleConfig{
+			Users:  viper.GetStringSlice("unix_socket_peers.admin.users"),
+			Groups: viper.GetStringSlice("unix_socket_peers.admin.groups"),
+		},
+		ReadOnly: UnixSocketRoleConfig{
+			Users:  viper.GetStringSlice("unix_socket_peers.read_only.users"),
+			Groups: viper.GetStringSlice("unix_socket_peers.read_only.groups"),
+		},
+	}, nil
+}
+
+// parsePrefixOrAddr parses a CIDR prefix, a single address is a prefix
+// of its full length.
+func parsePrefixOrAddr(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func samlConfig() (SAMLConfig, error) {
+	if !viper.GetBool("saml.enabled") {
+		return SAMLConfig{}, nil
+	}
+
+	cfg := SAMLConfig{
+		Enabled:         true,
+		EntityID:        viper.GetString("saml.entity_id"),
+		IDPMetadataURL:  viper.GetString("saml.idp_metadata_url"),
+		IDPMetadataPath: viper.GetString("saml.idp_metadata_path"),
+		CertificatePath: viper.GetString("saml.certificate_path"),
+		KeyPath:         viper.GetString("saml.key_path"),
+		SignRequests:    viper.GetBool("saml.sign_requests"),
+		Expiry:          defaultOIDCExpiryTime,
+		Attributes: SAMLAttributesConfig{
+			Username:    viper.GetString("saml.attributes.username"),
+			DisplayName: viper.GetString("saml.attributes.display_name"),
+			Email:       viper.GetString("saml.attributes.email"),
+			Groups:      viper.GetString("saml.attributes.groups"),
+		},
+		GroupsPrefix: viper.GetString("saml.groups_prefix"),
+	}
+
+	if cfg.IDPMetadataURL == "" && cfg.IDPMetadataPath == "" {
+		return SAMLConfig{}, errSAMLIDPMetadataMissing
+	}
+
+	if (cfg.CertificatePath == "") != (cfg.KeyPath == "") {
+		return SAMLConfig{}, errSAMLKeyPairIncomplete
+	}
+
+	if cfg.SignRequests && cfg.KeyPath == "" {
+		return SAMLConfig{}, errSAMLSignRequestsNoKey
+	}
+
+	// if set to 0, we assume no expiry
+	if value := viper.GetString("saml.expiry"); value == "0" {
+		cfg.Expiry = maxDuration
+	} else if expiry, err := model.ParseDuration(value); err != nil {
+		log.Warn().Msg("failed to parse saml.expiry, defaulting back to 180 days")
+	} else {
+		cfg.Expiry = time.Duration(expiry)
+	}
+
+	return cfg, nil
+}
+
 func logConfig() LogConfig {
 	logLevelStr := viper.GetString("log.level")
 	logLevel, err := zerolog.ParseLevel(logLevelStr)
@@ -796,6 +1919,81 @@ func prefixV6() (*netip.Prefix, error) {
 	return &prefixV6, nil
 }
 
+// ipPoolsConfig reads prefixes.pools, the prefixes of the pools have to be
+// within the prefixes of the tailnet.
+func ipPoolsConfig(prefix4, prefix6 *netip.Prefix) ([]IPPool, error) {
+	var entries []struct {
+		Name  string
+		V4    string
+		V6    string
+		Users []string
+		Tags  []string
+	}
+	if err := viper.UnmarshalKey("prefixes.pools", &entries); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidIPPool, err)
+	}
+
+	parse := func(name, str string, within *netip.Prefix) (*netip.Prefix, error) {
+		if str == "" {
+			return nil, nil
+		}
+
+		prefix, err := netip.ParsePrefix(str)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pool %q: %w", errInvalidIPPool, name, err)
+		}
+
+		prefix = prefix.Masked()
+		if within == nil || !within.Contains(prefix.Addr()) || prefix.Bits() < within.Bits() {
+			return nil, fmt.Errorf("%w: pool %q: %s is not within the prefixes of the tailnet", errInvalidIPPool, name, prefix)
+		}
+
+		return &prefix, nil
+	}
+
+	names := make(set.Set[string])
+	pools := make([]IPPool, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name == "" || names.Contains(entry.Name) {
+			return nil, fmt.Errorf("%w: pool name %q must be set and unique", errInvalidIPPool, entry.Name)
+		}
+		names.Add(entry.Name)
+
+		pool := IPPool{
+			Name:  entry.Name,
+			Users: entry.Users,
+			Tags:  entry.Tags,
+		}
+
+		var err error
+		if pool.PrefixV4, err = parse(entry.Name, entry.V4, prefix4); err != nil {
+			return nil, err
+		}
+
+		if pool.PrefixV6, err = parse(entry.Name, entry.V6, prefix6); err != nil {
+			return nil, err
+		}
+
+		if pool.PrefixV4 == nil && pool.PrefixV6 == nil {
+			return nil, fmt.Errorf("%w: pool %q requires v4 or v6", errInvalidIPPool, entry.Name)
+		}
+
+		if len(pool.Users) == 0 && len(pool.Tags) == 0 {
+			return nil, fmt.Errorf("%w: pool %q requires users or tags", errInvalidIPPool, entry.Name)
+		}
+
+		for _, tag := range pool.Tags {
+			if !strings.HasPrefix(tag, "tag:") {
+				return nil, fmt.Errorf("%w: pool %q: tag %q must start with \"tag:\"", errInvalidIPPool, entry.Name, tag)
+			}
+		}
+
+		pools = append(pools, pool)
+	}
+
+	return pools, nil
+}
+
 // LoadCLIConfig returns the needed configuration for the CLI client
 // of Headscale to connect to a Headscale server.
 func LoadCLIConfig() (*Config, error) {
@@ -810,6 +2008,9 @@ func LoadCLIConfig() (*Config, error) {
 			APIKey:   viper.GetString("cli.api_key"),
 			Timeout:  viper.GetDuration("cli.timeout"),
 			Insecure: viper.GetBool("cli.insecure"),
+
+			ClientCertPath: util.AbsolutePathFromConfigPath(viper.GetString("cli.client_cert_path")),
+			ClientKeyPath:  util.AbsolutePathFromConfigPath(viper.GetString("cli.client_key_path")),
 		},
 		Log: logConfig,
 	}, nil
@@ -855,6 +2056,11 @@ func LoadServerConfig() (*Config, error) {
 		)
 	}
 
+	ipPools, err := ipPoolsConfig(prefix4, prefix6)
+	if err != nil {
+		return nil, err
+	}
+
 	dnsConfig, err := dns()
 	if err != nil {
 		return nil, err
@@ -864,17 +2070,49 @@ func LoadServerConfig() (*Config, error) {
 	logTailConfig := logtailConfig()
 	randomizeClientPort := viper.GetBool("randomize_client_port")
 
-	oidcClientSecret := viper.GetString("oidc.client_secret")
-	oidcClientSecretPath := viper.GetString("oidc.client_secret_path")
-	if oidcClientSecretPath != "" && oidcClientSecret != "" {
-		return nil, errOidcMutuallyExclusive
+	oidcConfig, oidcProviders, err := oidcConfigs()
+	if err != nil {
+		return nil, err
 	}
-	if oidcClientSecretPath != "" {
-		secretBytes, err := os.ReadFile(os.ExpandEnv(oidcClientSecretPath))
-		if err != nil {
-			return nil, err
-		}
-		oidcClientSecret = strings.TrimSpace(string(secretBytes))
+
+	cliLogin, err := cliLoginConfig(oidcConfig, oidcProviders)
+	if err != nil {
+		return nil, err
+	}
+
+	scim, err := scimConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	saml, err := samlConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	forwardAuth, err := forwardAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	unixSocketPeers, err := unixSocketPeersConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	grpcClientAuth, err := grpcClientAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeCleanup, err := nodeCleanupConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeNameCollision, err := ParseNodeNameCollision(viper.GetString("node_name_collision"))
+	if err != nil {
+		return nil, fmt.Errorf("node_name_collision: %w", err)
 	}
 
 	serverURL := viper.GetString("server_url")
@@ -898,11 +2136,13 @@ func LoadServerConfig() (*Config, error) {
 		MetricsAddr:        viper.GetString("metrics_listen_addr"),
 		GRPCAddr:           viper.GetString("grpc_listen_addr"),
 		GRPCAllowInsecure:  viper.GetBool("grpc_allow_insecure"),
+		GRPCClientAuth:     grpcClientAuth,
 		DisableUpdateCheck: false,
 
 		PrefixV4:     prefix4,
 		PrefixV6:     prefix6,
 		IPAllocation: IPAllocationStrategy(alloc),
+		IPPools:      ipPools,
 
 		NoisePrivateKeyPath: util.AbsolutePathFromConfigPath(
 			viper.GetString("noise.private_key_path"),
@@ -914,6 +2154,8 @@ func LoadServerConfig() (*Config, error) {
 		EphemeralNodeInactivityTimeout: viper.GetDuration(
 			"ephemeral_node_inactivity_timeout",
 		),
+		NodeCleanup:       nodeCleanup,
+		NodeNameCollision: nodeNameCollision,
 
 		Database: databaseConfig(),
 
@@ -927,40 +2169,20 @@ func LoadServerConfig() (*Config, error) {
 
 		UnixSocket:           viper.GetString("unix_socket"),
 		UnixSocketPermission: util.GetFileMode("unix_socket_permission"),
+		UnixSocketPeers:      unixSocketPeers,
 
-		OIDC: OIDCConfig{
-			OnlyStartIfOIDCIsAvailable: viper.GetBool(
-				"oidc.only_start_if_oidc_is_available",
-			),
-			Issuer:         viper.GetString("oidc.issuer"),
-			ClientID:       viper.GetString("oidc.client_id"),
-			ClientSecret:   oidcClientSecret,
-			Scope:          viper.GetStringSlice("oidc.scope"),
-			ExtraParams:    viper.GetStringMapString("oidc.extra_params"),
-			AllowedDomains: viper.GetStringSlice("oidc.allowed_domains"),
-			AllowedUsers:   viper.GetStringSlice("oidc.allowed_users"),
-			AllowedGroups:  viper.GetStringSlice("oidc.allowed_groups"),
-			Expiry: func() time.Duration {
-				// if set to 0, we assume no expiry
-				if value := viper.GetString("oidc.expiry"); value == "0" {
-					return maxDuration
-				} else {
-					expiry, err := model.ParseDuration(value)
-					if err != nil {
-						log.Warn().Msg("failed to parse oidc.expiry, defaulting back to 180 days")
-
-						return defaultOIDCExpiryTime
-					}
-
-					return time.Duration(expiry)
-				}
-			}(),
-			UseExpiryFromToken: viper.GetBool("oidc.use_expiry_from_token"),
-			PKCE: PKCEConfig{
-				Enabled: viper.GetBool("oidc.pkce.enabled"),
-				Method:  viper.GetString("oidc.pkce.method"),
-			},
-		},
+		OIDC:          oidcConfig,
+		OIDCProviders: oidcProviders,
+
+		CLILogin: cliLogin,
+
+		SCIM: scim,
+
+		LocalAuth: localAuthConfig(),
+
+		SAML: saml,
+
+		ForwardAuth: forwardAuth,
 
 		LogTail:             logTailConfig,
 		RandomizeClientPort: randomizeClientPort,
@@ -972,6 +2194,9 @@ func LoadServerConfig() (*Config, error) {
 			APIKey:   viper.GetString("cli.api_key"),
 			Timeout:  viper.GetDuration("cli.timeout"),
 			Insecure: viper.GetBool("cli.insecure"),
+
+			ClientCertPath: util.AbsolutePathFromConfigPath(viper.GetString("cli.client_cert_path")),
+			ClientKeyPath:  util.AbsolutePathFromConfigPath(viper.GetString("cli.client_key_path")),
 		},
 
 		Log: logConfig,
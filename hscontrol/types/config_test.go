@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -237,6 +239,289 @@ func TestReadConfig(t *testing.T) {
 				"policy.path": "/etc/policy.hujson",
 			},
 		},
+		{
+			name:       "oidc-providers",
+			configPath: "testdata/oidc-providers.yaml",
+			setup: func(t *testing.T) (any, error) {
+				cfg, err := LoadServerConfig()
+				if err != nil {
+					return nil, err
+				}
+
+				var got []map[string]any
+				for _, oidcCfg := range cfg.OIDCConfigs() {
+					got = append(got, map[string]any{
+						"name":            oidcCfg.Name,
+						"display_name":    oidcCfg.DisplayName,
+						"callback_path":   oidcCfg.CallbackPath,
+						"issuer":          oidcCfg.Issuer,
+						"client_secret":   oidcCfg.ClientSecret,
+						"allowed_domains": oidcCfg.AllowedDomains,
+						"expiry":          oidcCfg.Expiry,
+						"pkce":            oidcCfg.PKCE.Enabled,
+						"scope":           oidcCfg.Scope,
+					})
+				}
+
+				return got, nil
+			},
+			want: []map[string]any{
+				{
+					"name":            "default",
+					"display_name":    "Example SSO",
+					"callback_path":   "/oidc/callback",
+					"issuer":          "https://sso.example.com",
+					"client_secret":   "secret",
+					"allowed_domains": []string(nil),
+					"expiry":          30 * 24 * time.Hour,
+					"pkce":            true,
+					"scope":           []string{"openid", "profile", "email"},
+				},
+				{
+					"name":            "partners",
+					"display_name":    "Partner login",
+					"callback_path":   "/oidc/callback/partners",
+					"issuer":          "https://accounts.partner.example.org",
+					"client_secret":   "partner-secret",
+					"allowed_domains": []string{"partner.example.org"},
+					"expiry":          30 * 24 * time.Hour,
+					"pkce":            true,
+					"scope":           []string{"openid", "profile", "email"},
+				},
+				{
+					"name":            "contractors",
+					"display_name":    "contractors",
+					"callback_path":   "/oidc/callback/contractors",
+					"issuer":          "https://login.contractors.example.net",
+					"client_secret":   "",
+					"allowed_domains": []string(nil),
+					"expiry":          24 * time.Hour,
+					"pkce":            false,
+					"scope":           []string{"openid", "profile", "email"},
+				},
+			},
+		},
+		{
+			name:       "oidc-providers-duplicate-name",
+			configPath: "testdata/oidc-providers-duplicate-name.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: `OIDC provider name must be unique: "default"`,
+		},
+		{
+			name:       "oidc-claims",
+			configPath: "testdata/oidc-claims.yaml",
+			setup: func(t *testing.T) (any, error) {
+				cfg, err := LoadServerConfig()
+				if err != nil {
+					return nil, err
+				}
+
+				var got []map[string]any
+				for _, oidcCfg := range cfg.OIDCConfigs() {
+					var username, displayName strings.Builder
+					claims := map[string]any{
+						"login_name":  "JDoe",
+						"given_name":  "Jane",
+						"family_name": "Doe",
+					}
+					if err := oidcCfg.Claims.Username.Execute(&username, claims); err != nil {
+						return nil, err
+					}
+					if err := oidcCfg.Claims.DisplayName.Execute(&displayName, claims); err != nil {
+						return nil, err
+					}
+
+					var rules []map[string]any
+					for _, rule := range oidcCfg.Claims.Rules {
+						rules = append(rules, map[string]any{
+							"claim":  rule.Claim,
+							"match":  rule.Match.String(),
+							"tags":   rule.Tags,
+							"groups": rule.Groups,
+						})
+					}
+
+					got = append(got, map[string]any{
+						"name":         oidcCfg.Name,
+						"username":     username.String(),
+						"display_name": displayName.String(),
+						"rules":        rules,
+					})
+				}
+
+				return got, nil
+			},
+			want: func() []map[string]any {
+				rules := []map[string]any{
+					{
+						"claim":  "department",
+						"match":  "^Engineering$",
+						"tags":   []string{"tag:engineering"},
+						"groups": []string{"group:engineering"},
+					},
+				}
+
+				return []map[string]any{
+					{"name": "default", "username": "jdoe", "display_name": "Jane Doe", "rules": rules},
+					{"name": "partners", "username": "jdoe", "display_name": "Jane Doe", "rules": rules},
+				}
+			}(),
+		},
+		{
+			name:       "oidc-claims-invalid-tag",
+			configPath: "testdata/oidc-claims-invalid-tag.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: `invalid oidc.claims.rules entry: claim "department": tag "engineering" must start with "tag:"`,
+		},
+		{
+			name:       "local-auth-with-oidc",
+			configPath: "testdata/local-auth-with-oidc.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "local_auth and oidc cannot be enabled at the same time",
+		},
+		{
+			name:       "saml-with-oidc",
+			configPath: "testdata/saml-with-oidc.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "saml cannot be enabled together with oidc or local_auth",
+		},
+		{
+			name:       "saml-sign-requests-without-key",
+			configPath: "testdata/saml-sign-requests-without-key.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "saml.sign_requests requires saml.certificate_path and saml.key_path",
+		},
+		{
+			name:       "forward-auth-with-saml",
+			configPath: "testdata/forward-auth-with-saml.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "forward_auth cannot be enabled together with oidc, local_auth or saml",
+		},
+		{
+			name:       "forward-auth-without-proxies",
+			configPath: "testdata/forward-auth-without-proxies.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "forward_auth.trusted_proxies is required when forward_auth is enabled",
+		},
+		{
+			name:       "cli-login-without-admins",
+			configPath: "testdata/cli-login-without-admins.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "oidc.cli_login requires admin_users or admin_groups",
+		},
+		{
+			name:       "cli-login-groups-not-synced",
+			configPath: "testdata/cli-login-groups-not-synced.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "oidc.cli_login.admin_groups requires the groups of the provider to be synced",
+		},
+		{
+			name:       "grpc-client-auth-without-ca",
+			configPath: "testdata/grpc-client-auth-without-ca.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "grpc_client_auth.ca_path is required when grpc_client_auth is enabled",
+		},
+		{
+			name:       "ip-pools",
+			configPath: "testdata/ip-pools.yaml",
+			setup: func(t *testing.T) (any, error) {
+				cfg, err := LoadServerConfig()
+				if err != nil {
+					return nil, err
+				}
+
+				var pools []string
+				for _, pool := range cfg.IPPools {
+					pools = append(pools, fmt.Sprintf("%s %v %v %v %v", pool.Name, pool.PrefixV4, pool.PrefixV6, pool.Users, pool.Tags))
+				}
+
+				return pools, nil
+			},
+			want: []string{
+				"servers 100.64.10.0/24 <nil> [] [tag:server]",
+				"users 100.64.128.0/17 fd7a:115c:a1e0:8000::/64 [*] []",
+			},
+		},
+		{
+			name:       "ip-pool-outside-prefix",
+			configPath: "testdata/ip-pool-outside-prefix.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: `invalid prefixes.pools entry: pool "servers": 10.0.0.0/24 is not within the prefixes of the tailnet`,
+		},
+		{
+			name:       "node-cleanup",
+			configPath: "testdata/node-cleanup.yaml",
+			setup: func(t *testing.T) (any, error) {
+				cfg, err := LoadServerConfig()
+				if err != nil {
+					return nil, err
+				}
+
+				return cfg.NodeCleanup, nil
+			},
+			want: NodeCleanupConfig{
+				Enabled:     true,
+				Interval:    time.Hour,
+				GracePeriod: 7 * 24 * time.Hour,
+				NodeRetention: NodeRetention{
+					ExpireAfter: 30 * 24 * time.Hour,
+					DeleteAfter: 90 * 24 * time.Hour,
+				},
+				Rules: []NodeRetentionRule{
+					{
+						Tags:          []string{"tag:ci"},
+						NodeRetention: NodeRetention{DeleteAfter: 24 * time.Hour},
+					},
+					{
+						Users: []string{"lab"},
+						NodeRetention: NodeRetention{
+							ExpireAfter: 7 * 24 * time.Hour,
+							DeleteAfter: 14 * 24 * time.Hour,
+						},
+					},
+				},
+				ExcludeTags:  []string{"tag:server"},
+				ExcludeLabel: "keep",
+			},
+		},
+		{
+			name:       "node-cleanup-invalid-rule",
+			configPath: "testdata/node-cleanup-invalid-rule.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: "invalid node_cleanup: rules[0]: delete_after must be longer than expire_after",
+		},
+		{
+			name:       "node-name-collision-invalid",
+			configPath: "testdata/node-name-collision-invalid.yaml",
+			setup: func(t *testing.T) (any, error) {
+				return LoadServerConfig()
+			},
+			wantErr: `node_name_collision: invalid node name collision strategy, allowed options: suffix, reject, takeover: "replace"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"database/sql"
+	"net/mail"
+
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+)
+
+// forwardAuthIssuer is the issuer part of the provider identifier of users
+// logged in by a forward-auth proxy.
+const forwardAuthIssuer = "forward-auth"
+
+// ForwardAuthIdentity is the identity of a user, as set in the headers by
+// a trusted reverse proxy.
+type ForwardAuthIdentity struct {
+	Username    string
+	DisplayName string
+	Email       string
+	Groups      []string
+}
+
+// Identifier returns the provider identifier of the user, in the same
+// format as the identifier of OIDC users.
+func (i *ForwardAuthIdentity) Identifier() string {
+	return (&OIDCClaims{Iss: forwardAuthIssuer, Sub: i.Username}).Identifier()
+}
+
+// FromForwardAuth overrides a User from a forward-auth identity.
+// All fields will be updated, except for the ID and the groups.
+func (u *User) FromForwardAuth(identity *ForwardAuthIdentity) {
+	if err := util.ValidateUsername(identity.Username); err == nil {
+		u.Name = identity.Username
+	} else {
+		log.Debug().Err(err).Msgf("Username %s is not valid", identity.Username)
+	}
+
+	if _, err := mail.ParseAddress(identity.Email); err == nil {
+		u.Email = identity.Email
+	}
+
+	u.ProviderIdentifier = sql.NullString{String: identity.Identifier(), Valid: true}
+	u.DisplayName = identity.DisplayName
+	u.Provider = util.RegisterMethodForwardAuth
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxLabelValueLength = 255
+
+var (
+	ErrInvalidLabel         = errors.New("invalid label")
+	ErrInvalidLabelSelector = errors.New("invalid label selector")
+
+	labelKeyRegex   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]{0,61}[a-zA-Z0-9])?$`)
+	labelValueRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9._:/@-]*[a-zA-Z0-9])?)?$`)
+)
+
+// ValidateLabel checks the key and value of a node label. Keys are up to
+// 63 letters, digits, dots, dashes, underscores and slashes, values up to
+// 255 of those and colons and at signs, both start and end with a letter
+// or digit. Values can be empty.
+func ValidateLabel(key, value string) error {
+	if !labelKeyRegex.MatchString(key) {
+		return fmt.Errorf("%w: key %q", ErrInvalidLabel, key)
+	}
+
+	if len(value) > maxLabelValueLength || !labelValueRegex.MatchString(value) {
+		return fmt.Errorf("%w: value %q of %q", ErrInvalidLabel, value, key)
+	}
+
+	return nil
+}
+
+type labelOperator int
+
+const (
+	labelExists labelOperator = iota
+	labelNotExists
+	labelEquals
+	labelNotEquals
+)
+
+type labelRequirement struct {
+	key      string
+	operator labelOperator
+	value    string
+}
+
+// LabelSelector selects nodes by their labels, all of its requirements
+// have to match.
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector parses comma separated requirements of the forms
+// key=value, key==value, key!=value, key and !key.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var ret LabelSelector
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var req labelRequirement
+		switch {
+		case strings.HasPrefix(part, "!"):
+			req = labelRequirement{key: strings.TrimSpace(part[1:]), operator: labelNotExists}
+		case strings.Contains(part, "!="):
+			key, value, _ := strings.Cut(part, "!=")
+			req = labelRequirement{key: key, operator: labelNotEquals, value: value}
+		case strings.Contains(part, "=="):
+			key, value, _ := strings.Cut(part, "==")
+			req = labelRequirement{key: key, operator: labelEquals, value: value}
+		case strings.Contains(part, "="):
+			key, value, _ := strings.Cut(part, "=")
+			req = labelRequirement{key: key, operator: labelEquals, value: value}
+		default:
+			req = labelRequirement{key: part, operator: labelExists}
+		}
+
+		req.key = strings.TrimSpace(req.key)
+		req.value = strings.TrimSpace(req.value)
+		if err := ValidateLabel(req.key, req.value); err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLabelSelector, part)
+		}
+
+		ret = append(ret, req)
+	}
+
+	return ret, nil
+}
+
+// Matches reports if the labels match all requirements of the selector.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.key]
+
+		switch req.operator {
+		case labelExists:
+			if !ok {
+				return false
+			}
+		case labelNotExists:
+			if ok {
+				return false
+			}
+		case labelEquals:
+			if !ok || value != req.value {
+				return false
+			}
+		case labelNotEquals:
+			if ok && value == req.value {
+				return false
+			}
+		}
+	}
+
+	return true
+}
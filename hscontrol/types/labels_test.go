@@ -0,0 +1,84 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateLabel(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{key: "env", value: "prod"},
+		{key: "owner", value: "alice@example.com"},
+		{key: "team/infra", value: ""},
+		{key: "rack.id", value: "eu-1:r12"},
+		{key: "", value: "prod", wantErr: true},
+		{key: "-env", value: "prod", wantErr: true},
+		{key: "env", value: "prod ", wantErr: true},
+		{key: "env=prod", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			err := ValidateLabel(tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateLabel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidLabel) {
+				t.Errorf("ValidateLabel() error = %v, want ErrInvalidLabel", err)
+			}
+		})
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{
+		"env":   "prod",
+		"owner": "alice",
+	}
+
+	tests := []struct {
+		selector string
+		want     bool
+		wantErr  bool
+	}{
+		{selector: "", want: true},
+		{selector: "env=prod", want: true},
+		{selector: "env==prod", want: true},
+		{selector: "env=dev", want: false},
+		{selector: "env!=dev", want: true},
+		{selector: "env!=prod", want: false},
+		{selector: "team!=infra", want: true},
+		{selector: "owner", want: true},
+		{selector: "team", want: false},
+		{selector: "!team", want: true},
+		{selector: "!owner", want: false},
+		{selector: "env=prod, owner, !team", want: true},
+		{selector: "env=prod,team", want: false},
+		{selector: "env=prod=dev", wantErr: true},
+		{selector: "!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLabelSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidLabelSelector) {
+					t.Errorf("ParseLabelSelector() error = %v, want ErrInvalidLabelSelector", err)
+				}
+
+				return
+			}
+
+			if got := selector.Matches(labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// LocalCredential is the password, and optionally the TOTP secret, a local
+// user logs in with when local authentication is enabled.
+type LocalCredential struct {
+	ID     uint64 `gorm:"primary_key"`
+	UserID uint   `gorm:"uniqueIndex"`
+	User   User   `gorm:"constraint:OnDelete:CASCADE;"`
+
+	// PasswordHash is the bcrypt hash of the password.
+	PasswordHash []byte
+	// TOTPSecret is the base32 encoded TOTP secret, if a second factor
+	// is required.
+	TOTPSecret string
+	// LastTOTPStep is the time step of the last accepted TOTP code,
+	// every code can only be used once.
+	LastTOTPStep int64
+
+	// FailedAttempts counts the failed logins since the last successful
+	// one, the user is locked out until LockedUntil once it reaches the
+	// configured limit.
+	FailedAttempts int
+	LockedUntil    *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -18,6 +18,7 @@ import (
 	"tailscale.com/net/tsaddr"
 	"tailscale.com/tailcfg"
 	"tailscale.com/types/key"
+	"tailscale.com/types/opt"
 )
 
 var (
@@ -100,12 +101,28 @@ type Node struct {
 	// headscale. It is best effort and not persisted.
 	LastSeen *time.Time `gorm:"column:last_seen"`
 
+	// EphemeralDeletionDue is when the disconnected ephemeral node is
+	// deleted. It is kept so that the deletion survives restarts.
+	EphemeralDeletionDue *time.Time `gorm:"column:ephemeral_deletion_due"`
+
 	// ApprovedRoutes is a list of routes that the node is allowed to announce
 	// as a subnet router. They are not necessarily the routes that the node
 	// announces at the moment.
 	// See [Node.Hostinfo]
 	ApprovedRoutes []netip.Prefix `gorm:"column:approved_routes;serializer:json"`
 
+	// Labels are free-form metadata set by CLI/API, e.g. the owner of
+	// the node. Unlike tags, they are not used by the policy.
+	Labels map[string]string `gorm:"column:labels;serializer:json"`
+
+	// Aliases are extra DNS names of the node that MagicDNS resolves
+	// to its addresses, in addition to the GivenName.
+	Aliases []string `gorm:"column:aliases;serializer:json"`
+
+	// CapVer is the capability version the client reported in its
+	// last endpoint update.
+	CapVer tailcfg.CapabilityVersion `gorm:"column:capver"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt *time.Time
@@ -132,12 +149,29 @@ func (node Node) IsExpired() bool {
 	return time.Since(*node.Expiry) > 0
 }
 
+// IsSuspended returns whether the user of the node is suspended,
+// nodes of suspended users are unauthorized and hidden from peers.
+func (node *Node) IsSuspended() bool {
+	return node.User.Suspended
+}
+
 // IsEphemeral returns if the node is registered as an Ephemeral node.
 // https://tailscale.com/kb/1111/ephemeral-nodes/
 func (node *Node) IsEphemeral() bool {
 	return node.AuthKey != nil && node.AuthKey.Ephemeral
 }
 
+// EphemeralInactivityTimeout returns how long an ephemeral node can be
+// disconnected before it is deleted. The PreAuthKey the node registered
+// with can override the given server default.
+func (node *Node) EphemeralInactivityTimeout(defaultTimeout time.Duration) time.Duration {
+	if node.AuthKey != nil && node.AuthKey.NodeSettings.EphemeralInactivityTimeout > 0 {
+		return node.AuthKey.NodeSettings.EphemeralInactivityTimeout
+	}
+
+	return defaultTimeout
+}
+
 func (node *Node) IPs() []netip.Addr {
 	var ret []netip.Addr
 
@@ -350,6 +384,9 @@ func (node *Node) Proto() *v1.Node {
 		AvailableRoutes: util.PrefixesToString(node.AnnouncedRoutes()),
 
 		RegisterMethod: node.RegisterMethodToV1Enum(),
+		Labels:         node.Labels,
+		Aliases:        node.Aliases,
+		Device:         node.DeviceProto(),
 
 		CreatedAt: timestamppb.New(node.CreatedAt),
 	}
@@ -406,7 +443,12 @@ func (node *Node) AnnouncedRoutes() []netip.Prefix {
 }
 
 // SubnetRoutes returns the list of routes that the node announces and are approved.
+// Nodes of suspended users do not serve any routes.
 func (node *Node) SubnetRoutes() []netip.Prefix {
+	if node.IsSuspended() {
+		return nil
+	}
+
 	var routes []netip.Prefix
 
 	for _, route := range node.AnnouncedRoutes() {
@@ -473,6 +515,63 @@ func (node *Node) PeerChangeFromMapRequest(req tailcfg.MapRequest) tailcfg.PeerC
 	return ret
 }
 
+// DeviceProto returns the details the client reported about its device in
+// the Hostinfo, its capability version and its current endpoints.
+func (node *Node) DeviceProto() *v1.NodeDevice {
+	device := &v1.NodeDevice{
+		CapabilityVersion: int32(node.CapVer),
+	}
+
+	for _, ep := range node.Endpoints {
+		device.Endpoints = append(device.Endpoints, ep.String())
+	}
+
+	hi := node.Hostinfo
+	if hi == nil {
+		return device
+	}
+
+	device.Os = hi.OS
+	device.OsVersion = hi.OSVersion
+	device.ClientVersion = hi.IPNVersion
+	device.Distro = hi.Distro
+	device.DistroVersion = hi.DistroVersion
+	device.DeviceModel = hi.DeviceModel
+	device.Machine = hi.Machine
+	device.Package = hi.Package
+
+	for _, service := range hi.Services {
+		device.Services = append(device.Services, &v1.NodeService{
+			Proto:       string(service.Proto),
+			Port:        uint32(service.Port),
+			Description: service.Description,
+		})
+	}
+
+	if ni := hi.NetInfo; ni != nil {
+		device.NetInfo = &v1.NodeNetInfo{
+			PreferredDerp:         int32(ni.PreferredDERP),
+			WorkingUdp:            optBool(ni.WorkingUDP),
+			WorkingIpv6:           optBool(ni.WorkingIPv6),
+			MappingVariesByDestIp: optBool(ni.MappingVariesByDestIP),
+			HairPinning:           optBool(ni.HairPinning),
+			LinkType:              ni.LinkType,
+		}
+	}
+
+	return device
+}
+
+// optBool returns nil if the value is unset.
+func optBool(b opt.Bool) *bool {
+	v, ok := b.Get()
+	if !ok {
+		return nil
+	}
+
+	return &v
+}
+
 func (node *Node) RegisterMethodToV1Enum() v1.RegisterMethod {
 	switch node.RegisterMethod {
 	case "authkey":
@@ -481,6 +580,12 @@ func (node *Node) RegisterMethodToV1Enum() v1.RegisterMethod {
 		return v1.RegisterMethod_REGISTER_METHOD_OIDC
 	case "cli":
 		return v1.RegisterMethod_REGISTER_METHOD_CLI
+	case "local":
+		return v1.RegisterMethod_REGISTER_METHOD_LOCAL
+	case "saml":
+		return v1.RegisterMethod_REGISTER_METHOD_SAML
+	case "forward-auth":
+		return v1.RegisterMethod_REGISTER_METHOD_FORWARD_AUTH
 	default:
 		return v1.RegisterMethod_REGISTER_METHOD_UNSPECIFIED
 	}
@@ -560,6 +665,29 @@ func (nodes Nodes) IDMap() map[NodeID]*Node {
 	return ret
 }
 
+func (nodes Nodes) IDs() []NodeID {
+	ret := make([]NodeID, 0, len(nodes))
+
+	for _, node := range nodes {
+		ret = append(ret, node.ID)
+	}
+
+	return ret
+}
+
+// WithoutSuspended returns the nodes which do not belong to a suspended user.
+func (nodes Nodes) WithoutSuspended() Nodes {
+	ret := make(Nodes, 0, len(nodes))
+
+	for _, node := range nodes {
+		if !node.IsSuspended() {
+			ret = append(ret, node)
+		}
+	}
+
+	return ret
+}
+
 func (nodes Nodes) DebugString() string {
 	var sb strings.Builder
 	sb.WriteString("Nodes:\n")
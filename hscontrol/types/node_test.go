@@ -11,8 +11,10 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
 	"github.com/juanfont/headscale/hscontrol/util"
+	"google.golang.org/protobuf/testing/protocmp"
 	"tailscale.com/tailcfg"
 	"tailscale.com/types/key"
+	"tailscale.com/types/ptr"
 )
 
 func Test_NodeCanAccess(t *testing.T) {
@@ -536,6 +538,14 @@ func TestNodeRegisterMethodToV1Enum(t *testing.T) {
 			},
 			want: v1.RegisterMethod_REGISTER_METHOD_CLI,
 		},
+		{
+			name: "forward-auth",
+			node: Node{
+				ID:             1,
+				RegisterMethod: util.RegisterMethodForwardAuth,
+			},
+			want: v1.RegisterMethod_REGISTER_METHOD_FORWARD_AUTH,
+		},
 		{
 			name: "unknown",
 			node: Node{
@@ -555,3 +565,80 @@ func TestNodeRegisterMethodToV1Enum(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeDeviceProto(t *testing.T) {
+	tests := []struct {
+		name string
+		node Node
+		want *v1.NodeDevice
+	}{
+		{
+			name: "no-hostinfo",
+			node: Node{
+				CapVer:    106,
+				Endpoints: []netip.AddrPort{netip.MustParseAddrPort("192.0.2.1:41641")},
+			},
+			want: &v1.NodeDevice{
+				CapabilityVersion: 106,
+				Endpoints:         []string{"192.0.2.1:41641"},
+			},
+		},
+		{
+			name: "full-hostinfo",
+			node: Node{
+				CapVer: 113,
+				Hostinfo: &tailcfg.Hostinfo{
+					OS:            "linux",
+					OSVersion:     "6.8.0",
+					IPNVersion:    "1.80.0-t1234",
+					Distro:        "ubuntu",
+					DistroVersion: "24.04",
+					DeviceModel:   "ThinkPad X1",
+					Machine:       "x86_64",
+					Package:       "deb",
+					Services: []tailcfg.Service{
+						{Proto: tailcfg.PeerAPI4, Port: 12345},
+						{Proto: tailcfg.TCP, Port: 22, Description: "sshd"},
+					},
+					NetInfo: &tailcfg.NetInfo{
+						PreferredDERP:         2,
+						WorkingUDP:            "true",
+						MappingVariesByDestIP: "false",
+						LinkType:              "wired",
+					},
+				},
+			},
+			want: &v1.NodeDevice{
+				Os:            "linux",
+				OsVersion:     "6.8.0",
+				ClientVersion: "1.80.0-t1234",
+				Distro:        "ubuntu",
+				DistroVersion: "24.04",
+				DeviceModel:   "ThinkPad X1",
+				Machine:       "x86_64",
+				Package:       "deb",
+				NetInfo: &v1.NodeNetInfo{
+					PreferredDerp:         2,
+					WorkingUdp:            ptr.To(true),
+					MappingVariesByDestIp: ptr.To(false),
+					LinkType:              "wired",
+				},
+				Services: []*v1.NodeService{
+					{Proto: "peerapi4", Port: 12345},
+					{Proto: "tcp", Port: 22, Description: "sshd"},
+				},
+				CapabilityVersion: 113,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.node.DeviceProto()
+
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("DeviceProto() unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// OAuthScopeAll grants full access to the API, like an API key.
+	OAuthScopeAll = "all"
+	// OAuthScopeAllRead grants read-only access to the API.
+	OAuthScopeAllRead = "all:read"
+	// OAuthScopeAuthKeys grants access to pre auth keys, limited to
+	// the tags of the client.
+	OAuthScopeAuthKeys = "auth_keys"
+)
+
+// OAuthAccessTokenPrefix is prepended to access tokens issued to OAuth
+// clients, it separates them from API keys.
+const OAuthAccessTokenPrefix = "hsoauth-"
+
+// OAuthScopes is the list of scopes an OAuth client can be granted.
+var OAuthScopes = []string{
+	OAuthScopeAll,
+	OAuthScopeAllRead,
+	OAuthScopeAuthKeys,
+}
+
+// OAuthClient describes the datamodel for OAuth 2.0 clients using the
+// client credentials grant to obtain short-lived access tokens for
+// the API.
+type OAuthClient struct {
+	ID       uint64 `gorm:"primary_key"`
+	ClientID string `gorm:"uniqueIndex"`
+	Hash     []byte
+
+	Scopes      []string `gorm:"serializer:json"`
+	Tags        []string `gorm:"serializer:json"`
+	Description string
+
+	// AllowNonEphemeral allows the client to create pre auth keys
+	// that are not ephemeral.
+	AllowNonEphemeral bool
+
+	CreatedAt  *time.Time
+	Expiration *time.Time
+	LastSeen   *time.Time
+}
+
+// IsExpired reports if the client has expired.
+func (c *OAuthClient) IsExpired() bool {
+	return c.Expiration != nil && c.Expiration.Before(time.Now())
+}
+
+func (c *OAuthClient) Proto() *v1.OAuthClient {
+	protoClient := v1.OAuthClient{
+		Id:          c.ID,
+		ClientId:    c.ClientID,
+		Scopes:      c.Scopes,
+		Tags:        c.Tags,
+		Description: c.Description,
+
+		AllowNonEphemeral: c.AllowNonEphemeral,
+	}
+
+	if c.Expiration != nil {
+		protoClient.Expiration = timestamppb.New(*c.Expiration)
+	}
+
+	if c.CreatedAt != nil {
+		protoClient.CreatedAt = timestamppb.New(*c.CreatedAt)
+	}
+
+	if c.LastSeen != nil {
+		protoClient.LastSeen = timestamppb.New(*c.LastSeen)
+	}
+
+	return &protoClient
+}
+
+// OAuthAccessToken is a short-lived token issued to an OAuth client,
+// its scopes are a subset of the scopes of the client.
+type OAuthAccessToken struct {
+	ID     uint64 `gorm:"primary_key"`
+	Prefix string `gorm:"uniqueIndex"`
+	Hash   []byte
+
+	OAuthClientID uint64
+	OAuthClient   OAuthClient `gorm:"constraint:OnDelete:CASCADE;"`
+
+	Scopes []string `gorm:"serializer:json"`
+
+	CreatedAt  *time.Time
+	Expiration *time.Time
+}
+
+// HasScope reports if the token was granted the given scope.
+func (t *OAuthAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// ScopeString returns the scopes of the token in the space separated
+// form used by OAuth 2.0.
+func (t *OAuthAccessToken) ScopeString() string {
+	return strings.Join(t.Scopes, " ")
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// OIDCSession is the OIDC login which registered a node. It is used to
+// find the nodes of a session logged out by the identity provider, and its
+// refresh token to extend the expiry of the node while the identity
+// provider keeps issuing tokens for the subject.
+type OIDCSession struct {
+	ID     uint64 `gorm:"primary_key"`
+	NodeID NodeID `gorm:"uniqueIndex"`
+	Node   Node   `gorm:"constraint:OnDelete:CASCADE;"`
+
+	// Provider is the name of the OIDC provider the node logged in with.
+	Provider string
+	// Subject is the subject of the ID token of the login.
+	Subject string
+	// SessionID is the sid claim of the ID token, if the identity
+	// provider issues one.
+	SessionID string `gorm:"index"`
+	// RefreshToken is the refresh token, encrypted with the key
+	// configured for the provider. It is only stored if refresh
+	// tokens are enabled.
+	RefreshToken []byte
+
+	LastRefreshed time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -1,9 +1,12 @@
 package types
 
 import (
+	"net/netip"
 	"time"
 
 	v1 "github.com/juanfont/headscale/gen/go/headscale/v1"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -23,10 +26,48 @@ type PreAuthKey struct {
 	// and ignored after.
 	Tags []string `gorm:"serializer:json"`
 
+	// NodeSettings are applied to a node when it registers
+	// with the key.
+	NodeSettings PreAuthKeyNodeSettings `gorm:"embedded"`
+
 	CreatedAt  *time.Time
 	Expiration *time.Time
 }
 
+// PreAuthKeyNodeSettings holds the optional settings a PreAuthKey
+// applies to the nodes registering with it. They make it possible
+// to provision a node that is fully configured on its first login,
+// without any follow-up calls to the API.
+// Like Tags, they are only applied at registration.
+type PreAuthKeyNodeSettings struct {
+	// ApprovedRoutes are copied to the ApprovedRoutes of the node.
+	ApprovedRoutes []netip.Prefix `gorm:"column:approved_routes;serializer:json"`
+
+	// NodeExpiry overrides the key expiry requested by the client,
+	// the node will expire NodeExpiry after it registered.
+	// Zero means the client decides.
+	NodeExpiry time.Duration `gorm:"column:node_expiry"`
+
+	// GivenName is used as the given name of the node instead of
+	// one derived from its hostname.
+	GivenName string `gorm:"column:given_name"`
+
+	// EphemeralInactivityTimeout overrides the server wide
+	// ephemeral_node_inactivity_timeout for nodes registered with
+	// the key. Zero means the server default is used.
+	EphemeralInactivityTimeout time.Duration `gorm:"column:ephemeral_inactivity_timeout"`
+
+	// IPv4 and IPv6 are given to the node instead of allocated
+	// addresses, e.g. to keep the address of a re-created node.
+	IPv4 *netip.Addr `gorm:"column:ipv4;serializer:text"`
+	IPv6 *netip.Addr `gorm:"column:ipv6;serializer:text"`
+
+	// NameCollision overrides the server wide node_name_collision for
+	// nodes registered with the key. Empty means the server default is
+	// used.
+	NameCollision NodeNameCollision `gorm:"column:name_collision"`
+}
+
 func (key *PreAuthKey) Proto() *v1.PreAuthKey {
 	protoKey := v1.PreAuthKey{
 		User:      key.User.Proto(),
@@ -36,6 +77,26 @@ func (key *PreAuthKey) Proto() *v1.PreAuthKey {
 		Reusable:  key.Reusable,
 		Used:      key.Used,
 		AclTags:   key.Tags,
+
+		ApprovedRoutes: util.PrefixesToString(key.NodeSettings.ApprovedRoutes),
+		GivenName:      key.NodeSettings.GivenName,
+		NameCollision:  string(key.NodeSettings.NameCollision),
+	}
+
+	if key.NodeSettings.NodeExpiry != 0 {
+		protoKey.NodeExpiry = durationpb.New(key.NodeSettings.NodeExpiry)
+	}
+
+	if key.NodeSettings.IPv4 != nil {
+		protoKey.Ipv4 = key.NodeSettings.IPv4.String()
+	}
+
+	if key.NodeSettings.IPv6 != nil {
+		protoKey.Ipv6 = key.NodeSettings.IPv6.String()
+	}
+
+	if key.NodeSettings.EphemeralInactivityTimeout != 0 {
+		protoKey.EphemeralInactivityTimeout = durationpb.New(key.NodeSettings.EphemeralInactivityTimeout)
 	}
 
 	if key.Expiration != nil {
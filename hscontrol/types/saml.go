@@ -0,0 +1,47 @@
+package types
+
+import (
+	"database/sql"
+	"net/mail"
+
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+)
+
+// SAMLIdentity is the identity of a user asserted by a SAML identity
+// provider, with the attributes mapped by the configuration.
+type SAMLIdentity struct {
+	// Issuer is the entity ID of the identity provider.
+	Issuer string
+	// NameID is the identifier of the user at the identity provider.
+	NameID string
+
+	Username    string
+	DisplayName string
+	Email       string
+	Groups      []string
+}
+
+// Identifier returns the provider identifier of the user, in the same
+// format as the identifier of OIDC users.
+func (i *SAMLIdentity) Identifier() string {
+	return (&OIDCClaims{Iss: i.Issuer, Sub: i.NameID}).Identifier()
+}
+
+// FromSAML overrides a User from a SAML identity.
+// All fields will be updated, except for the ID and the groups.
+func (u *User) FromSAML(identity *SAMLIdentity) {
+	if err := util.ValidateUsername(identity.Username); err == nil {
+		u.Name = identity.Username
+	} else {
+		log.Debug().Err(err).Msgf("Username %s is not valid", identity.Username)
+	}
+
+	if _, err := mail.ParseAddress(identity.Email); err == nil {
+		u.Email = identity.Email
+	}
+
+	u.ProviderIdentifier = sql.NullString{String: identity.Identifier(), Valid: true}
+	u.DisplayName = identity.DisplayName
+	u.Provider = util.RegisterMethodSAML
+}
@@ -0,0 +1,31 @@
+package types
+
+import "time"
+
+// SCIMProvider is the provider of users created via SCIM, until
+// they log in with OIDC.
+const SCIMProvider = "scim"
+
+// SCIMDeprovisionAction is what happens to the nodes of a user which is
+// deactivated or deleted by the identity provider via SCIM.
+type SCIMDeprovisionAction string
+
+const (
+	SCIMDeprovisionActionExpire SCIMDeprovisionAction = "expire"
+	SCIMDeprovisionActionDelete SCIMDeprovisionAction = "delete"
+)
+
+// SCIMGroup is a group provisioned by the identity provider via SCIM.
+// The members of the group carry its PolicyName in User.SCIMGroups.
+type SCIMGroup struct {
+	ID          uint   `gorm:"primary_key"`
+	DisplayName string `gorm:"uniqueIndex"`
+	ExternalID  string
+
+	// PolicyName is the name of the group in the policy, it is
+	// the display name with the configured prefix.
+	PolicyName string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/mail"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -68,6 +69,33 @@ type User struct {
 	Provider string
 
 	ProfilePicURL string
+
+	// Groups are the policy groups of the user, taken from the
+	// groups claim of the last OIDC login, if enabled.
+	Groups []string `gorm:"serializer:json"`
+
+	// SCIMExternalID is the identifier of the user at the identity
+	// provider, if the user is provisioned via SCIM.
+	SCIMExternalID string
+
+	// SCIMGroups are the policy groups of the user, maintained by
+	// the identity provider via SCIM.
+	SCIMGroups []string `gorm:"serializer:json"`
+
+	// Deactivated users have been deprovisioned by the identity
+	// provider, they cannot log in or register new nodes.
+	Deactivated bool
+
+	// Suspended users have been suspended by an administrator, their
+	// nodes are unauthorized and removed from all peer lists and
+	// filters, but kept with their IPs so they can be reinstated.
+	Suspended bool
+}
+
+// InGroup reports if the identity provider made the user a member of
+// the given policy group, either via OIDC or SCIM.
+func (u *User) InGroup(group string) bool {
+	return slices.Contains(u.Groups, group) || slices.Contains(u.SCIMGroups, group)
 }
 
 func (u *User) StringID() string {
@@ -146,6 +174,9 @@ func (u *User) Proto() *v1.User {
 		ProviderId:    u.ProviderIdentifier.String,
 		Provider:      u.Provider,
 		ProfilePicUrl: u.ProfilePicURL,
+		Groups:        u.Groups,
+		Deactivated:   u.Deactivated,
+		Suspended:     u.Suspended,
 	}
 }
 
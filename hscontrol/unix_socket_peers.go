@@ -0,0 +1,246 @@
+package hscontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const socketPeerAuthType = "unix-peer"
+
+var errSocketPeerClientHandshake = errors.New("unix socket peer credentials are only used by the server")
+
+// apiRole is the role of a gRPC caller authenticated by its unix socket
+// peer credentials or its TLS client certificate.
+type apiRole int
+
+const (
+	apiRoleNone apiRole = iota
+	apiRoleReadOnly
+	apiRoleAdmin
+)
+
+// socketPeerAuthInfo carries the credentials of the process on the other
+// end of a unix socket connection.
+type socketPeerAuthInfo struct {
+	credentials.CommonAuthInfo
+	util.PeerCredentials
+}
+
+func (socketPeerAuthInfo) AuthType() string {
+	return socketPeerAuthType
+}
+
+// socketPeerCredentials are gRPC transport credentials reading the
+// credentials of the peer process of unix socket connections. Like
+// insecure credentials, the connection is not encrypted.
+type socketPeerCredentials struct{}
+
+func (socketPeerCredentials) ClientHandshake(
+	context.Context,
+	string,
+	net.Conn,
+) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errSocketPeerClientHandshake
+}
+
+func (socketPeerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := util.UnixPeerCredentials(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, socketPeerAuthInfo{
+		CommonAuthInfo:  credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		PeerCredentials: *creds,
+	}, nil
+}
+
+func (socketPeerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: socketPeerAuthType}
+}
+
+func (c socketPeerCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+func (socketPeerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// socketPeerAuthorizer maps the local users and groups of processes
+// calling the gRPC API on the unix socket to roles.
+type socketPeerAuthorizer struct {
+	// serverUID is the user headscale runs as, it can read the
+	// database anyway and is always an admin.
+	serverUID uint32
+	// serverPID is the headscale process, which connects to the socket
+	// with the gRPC gateway of the HTTP API.
+	serverPID int32
+
+	users  map[uint32]apiRole
+	groups map[uint32]apiRole
+}
+
+func newSocketPeerAuthorizer(cfg types.UnixSocketPeersConfig) (*socketPeerAuthorizer, error) {
+	authorizer := &socketPeerAuthorizer{
+		serverUID: uint32(os.Getuid()),
+		serverPID: int32(os.Getpid()),
+		users:     make(map[uint32]apiRole),
+		groups:    make(map[uint32]apiRole),
+	}
+
+	for _, role := range []struct {
+		role apiRole
+		cfg  types.UnixSocketRoleConfig
+	}{
+		{apiRoleReadOnly, cfg.ReadOnly},
+		{apiRoleAdmin, cfg.Admin},
+	} {
+		for _, name := range role.cfg.Users {
+			uid, err := lookupLocalID(name, func(name string) (string, error) {
+				u, err := user.Lookup(name)
+				if err != nil {
+					return "", err
+				}
+
+				return u.Uid, nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("unix_socket_peers: user %q: %w", name, err)
+			}
+
+			authorizer.users[uid] = role.role
+		}
+
+		for _, name := range role.cfg.Groups {
+			gid, err := lookupLocalID(name, func(name string) (string, error) {
+				g, err := user.LookupGroup(name)
+				if err != nil {
+					return "", err
+				}
+
+				return g.Gid, nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("unix_socket_peers: group %q: %w", name, err)
+			}
+
+			authorizer.groups[gid] = role.role
+		}
+	}
+
+	return authorizer, nil
+}
+
+// lookupLocalID returns a numeric ID as is, and looks up names.
+func lookupLocalID(name string, lookup func(string) (string, error)) (uint32, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+
+	id, err := lookup(name)
+	if err != nil {
+		return 0, err
+	}
+
+	parsed, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint32(parsed), nil
+}
+
+// role returns the role of the peer process, the highest of its user and
+// its primary and supplementary groups, and the name of its user.
+func (a *socketPeerAuthorizer) role(creds util.PeerCredentials) (apiRole, string) {
+	uid := strconv.FormatUint(uint64(creds.UID), 10)
+	name := uid
+	gids := []uint32{creds.GID}
+
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+
+		groupIDs, err := u.GroupIds()
+		if err == nil {
+			for _, groupID := range groupIDs {
+				if gid, err := strconv.ParseUint(groupID, 10, 32); err == nil {
+					gids = append(gids, uint32(gid))
+				}
+			}
+		}
+	}
+
+	if creds.UID == a.serverUID {
+		return apiRoleAdmin, name
+	}
+
+	role := a.users[creds.UID]
+	for _, gid := range gids {
+		role = max(role, a.groups[gid])
+	}
+
+	return role, name
+}
+
+// authorize allows admins to call any method and read-only callers to call
+// the List and Get methods.
+func (a *socketPeerAuthorizer) authorize(ctx context.Context, fullMethod string) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.PermissionDenied, "unknown caller on unix socket")
+	}
+
+	info, ok := p.AuthInfo.(socketPeerAuthInfo)
+	if !ok {
+		return status.Error(codes.PermissionDenied, "unknown caller on unix socket")
+	}
+
+	// The HTTP API has already authenticated the requests of the
+	// gRPC gateway.
+	if info.PID == a.serverPID {
+		return nil
+	}
+
+	role, name := a.role(info.PeerCredentials)
+	logger := log.With().
+		Str("local_user", name).
+		Uint32("uid", info.UID).
+		Int32("pid", info.PID).
+		Str("method", fullMethod).
+		Logger()
+
+	switch {
+	case role == apiRoleAdmin:
+	case role == apiRoleReadOnly && isReadOnlyMethod(fullMethod):
+	case role == apiRoleReadOnly:
+		logger.Info().Msg("unix socket call denied, local user is read-only")
+
+		return status.Errorf(codes.PermissionDenied, "local user %q is only allowed to read", name)
+	default:
+		logger.Info().Msg("unix socket call denied, local user is not allowed")
+
+		return status.Errorf(codes.PermissionDenied, "local user %q is not allowed to use the unix socket", name)
+	}
+
+	if isReadOnlyMethod(fullMethod) {
+		logger.Debug().Msg("unix socket call")
+	} else {
+		logger.Info().Msg("unix socket call")
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package hscontrol
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/juanfont/headscale/hscontrol/types"
+	"github.com/juanfont/headscale/hscontrol/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestSocketPeerCredentials(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on Linux")
+	}
+
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "headscale.sock"))
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := net.Dial("unix", listener.Addr().String())
+		if err == nil {
+			defer conn.Close()
+		}
+	}()
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, authInfo, err := socketPeerCredentials{}.ServerHandshake(conn)
+	require.NoError(t, err)
+
+	info, ok := authInfo.(socketPeerAuthInfo)
+	require.True(t, ok)
+	assert.Equal(t, uint32(os.Getuid()), info.UID)
+	assert.Equal(t, int32(os.Getpid()), info.PID)
+}
+
+func TestSocketPeerAuthorizer(t *testing.T) {
+	_, err := newSocketPeerAuthorizer(types.UnixSocketPeersConfig{
+		Enabled: true,
+		Admin:   types.UnixSocketRoleConfig{Users: []string{"headscale-no-such-user"}},
+	})
+	require.Error(t, err)
+
+	authorizer, err := newSocketPeerAuthorizer(types.UnixSocketPeersConfig{
+		Enabled: true,
+		Admin: types.UnixSocketRoleConfig{
+			Groups: []string{"4343"},
+		},
+		ReadOnly: types.UnixSocketRoleConfig{
+			Users:  []string{"4242"},
+			Groups: []string{"4343", "4444"},
+		},
+	})
+	require.NoError(t, err)
+	authorizer.serverUID = 4000
+	authorizer.serverPID = 1
+
+	call := func(creds util.PeerCredentials, method string) codes.Code {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: socketPeerAuthInfo{PeerCredentials: creds},
+		})
+
+		return status.Code(authorizer.authorize(ctx, "/headscale.v1.HeadscaleService/"+method))
+	}
+
+	tests := []struct {
+		name   string
+		creds  util.PeerCredentials
+		method string
+		want   codes.Code
+	}{
+		{
+			name:   "gateway",
+			creds:  util.PeerCredentials{UID: 5000, GID: 5000, PID: 1},
+			method: "DeleteNode",
+			want:   codes.OK,
+		},
+		{
+			name:   "server-user",
+			creds:  util.PeerCredentials{UID: 4000, GID: 4000, PID: 2},
+			method: "DeleteNode",
+			want:   codes.OK,
+		},
+		{
+			name:   "read-only-user-lists",
+			creds:  util.PeerCredentials{UID: 4242, GID: 5000, PID: 2},
+			method: "ListNodes",
+			want:   codes.OK,
+		},
+		{
+			name:   "read-only-user-deletes",
+			creds:  util.PeerCredentials{UID: 4242, GID: 5000, PID: 2},
+			method: "DeleteNode",
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "read-only-group-deletes",
+			creds:  util.PeerCredentials{UID: 5000, GID: 4444, PID: 2},
+			method: "DeleteNode",
+			want:   codes.PermissionDenied,
+		},
+		{
+			name:   "admin-group-wins",
+			creds:  util.PeerCredentials{UID: 4242, GID: 4343, PID: 2},
+			method: "DeleteNode",
+			want:   codes.OK,
+		},
+		{
+			name:   "unknown-user",
+			creds:  util.PeerCredentials{UID: 5000, GID: 5000, PID: 2},
+			method: "ListNodes",
+			want:   codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, call(tt.creds, tt.method))
+		})
+	}
+
+	// Connections without peer credentials are denied.
+	err = authorizer.authorize(context.Background(), "/headscale.v1.HeadscaleService/ListNodes")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
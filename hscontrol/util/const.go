@@ -1,7 +1,10 @@
 package util
 
 const (
-	RegisterMethodAuthKey = "authkey"
-	RegisterMethodOIDC    = "oidc"
-	RegisterMethodCLI     = "cli"
+	RegisterMethodAuthKey     = "authkey"
+	RegisterMethodOIDC        = "oidc"
+	RegisterMethodCLI         = "cli"
+	RegisterMethodLocal       = "local"
+	RegisterMethodSAML        = "saml"
+	RegisterMethodForwardAuth = "forward-auth"
 )
@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"errors"
 	"net"
 	"net/netip"
 	"sync"
@@ -16,6 +17,16 @@ func GrpcSocketDialer(ctx context.Context, addr string) (net.Conn, error) {
 	return d.DialContext(ctx, "unix", addr)
 }
 
+var ErrNotUnixSocket = errors.New("connection is not a unix socket")
+
+// PeerCredentials are the credentials of the process on the other end of a
+// unix socket connection.
+type PeerCredentials struct {
+	UID uint32
+	GID uint32
+	PID int32
+}
+
 func PrefixesToString(prefixes []netip.Prefix) []string {
 	ret := make([]string, 0, len(prefixes))
 	for _, prefix := range prefixes {
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package util
+
+import (
+	"errors"
+	"net"
+)
+
+var errPeerCredentialsUnsupported = errors.New("peer credentials of unix sockets are only supported on Linux")
+
+// UnixPeerCredentials is only supported on Linux.
+func UnixPeerCredentials(conn net.Conn) (*PeerCredentials, error) {
+	return nil, errPeerCredentialsUnsupported
+}